@@ -0,0 +1,216 @@
+// Package arc implements Anonymous Rate-Limited Credentials (ARC).
+//
+// ARC is a keyed-verification anonymous credential: the issuer and the
+// verifier of credentials are the same party, the server, which holds the
+// private key. Credentials are algebraic MACs in a prime-order group, so
+// no pairings are needed.
+//
+// A client requests a credential bound to a request context, such as an
+// epoch, and the server issues it blindly: it does not learn the client's
+// secret. The client can then present the credential up to a limit of
+// times per presentation context, such as an origin. Presentations of the
+// same credential cannot be linked to each other nor to the issuance, but
+// each of them carries a tag that is unique for the credential, the
+// presentation context and a nonce below the limit. The server rejects
+// tags it has already seen to enforce the limit.
+//
+// The issuance and presentation messages carry proofs of linear relations
+// between group elements, which are built with package zk/sigma.
+//
+// Here the presentation nonce is sent in the clear, so the server learns
+// how many times a credential was presented in a context.
+//
+// References:
+//   - draft-yun-cfrg-arc: https://datatracker.ietf.org/doc/draft-yun-cfrg-arc/
+//   - Chase, Meiklejohn, Zaverucha: https://eprint.iacr.org/2013/516
+package arc
+
+import (
+	"errors"
+	"io"
+
+	"github.com/cloudflare/circl/group"
+)
+
+var (
+	// ErrInvalidSuite is returned when a suite is not supported.
+	ErrInvalidSuite = errors.New("arc: invalid suite")
+	// ErrInvalidKey is returned when a key cannot be decoded.
+	ErrInvalidKey = errors.New("arc: invalid key")
+	// ErrInvalidMessage is returned when a message is malformed.
+	ErrInvalidMessage = errors.New("arc: invalid message")
+	// ErrInvalidProof is returned when the proof of a message does not
+	// verify.
+	ErrInvalidProof = errors.New("arc: invalid proof")
+	// ErrInvalidPresentation is returned when a presentation is not valid
+	// for the credential key and the contexts.
+	ErrInvalidPresentation = errors.New("arc: invalid presentation")
+	// ErrLimitExceeded is returned when a credential was presented as many
+	// times as allowed in a presentation context.
+	ErrLimitExceeded = errors.New("arc: presentation limit exceeded")
+)
+
+// Suite is an ARC ciphersuite.
+type Suite interface {
+	Identifier() string
+	Group() group.Group
+	cannotBeImplementedExternally()
+}
+
+var (
+	// SuiteRistretto255 represents ARC with ristretto255.
+	SuiteRistretto255 Suite = params{identifier: "ristretto255", group: group.Ristretto255}
+	// SuiteP256 represents ARC with P-256.
+	SuiteP256 Suite = params{identifier: "P256", group: group.P256}
+)
+
+type params struct {
+	identifier string
+	group      group.Group
+}
+
+func (p params) cannotBeImplementedExternally() {}
+
+func (p params) String() string     { return p.Identifier() }
+func (p params) Group() group.Group { return p.group }
+func (p params) Identifier() string { return p.identifier }
+
+func getParams(s Suite) params {
+	p, ok := s.(params)
+	if !ok {
+		panic(ErrInvalidSuite)
+	}
+	return p
+}
+
+// dst returns a domain separation tag for the suite.
+func (p params) dst(label string) []byte { return []byte("ARCV1-" + p.identifier + "-" + label) }
+
+// generatorH returns the second generator H, whose discrete logarithm to
+// the base G is unknown.
+func (p params) generatorH() group.Element {
+	return p.group.HashToElement(appendElements(nil, p.group.Generator()), p.dst("generatorH"))
+}
+
+func (p params) readScalars(data []byte, n int) ([]group.Scalar, error) {
+	size := int(p.group.Params().ScalarLength)
+	if len(data) != n*size {
+		return nil, ErrInvalidMessage
+	}
+	scalars := make([]group.Scalar, n)
+	for i := range scalars {
+		scalars[i] = p.group.NewScalar()
+		if scalars[i].UnmarshalBinary(data[i*size:(i+1)*size]) != nil {
+			return nil, ErrInvalidMessage
+		}
+	}
+	return scalars, nil
+}
+
+// readElements decodes n compressed elements other than the identity from
+// the start of data, and returns the rest of data.
+func (p params) readElements(data []byte, n int) ([]group.Element, []byte, error) {
+	size := int(p.group.Params().CompressedElementLength)
+	if len(data) < n*size {
+		return nil, nil, ErrInvalidMessage
+	}
+	elements := make([]group.Element, n)
+	for i := range elements {
+		elements[i] = p.group.NewElement()
+		if elements[i].UnmarshalBinary(data[i*size:(i+1)*size]) != nil || elements[i].IsIdentity() {
+			return nil, nil, ErrInvalidMessage
+		}
+	}
+	return elements, data[n*size:], nil
+}
+
+func appendScalars(b []byte, scalars ...group.Scalar) []byte {
+	for _, s := range scalars {
+		enc, _ := s.MarshalBinary()
+		b = append(b, enc...)
+	}
+	return b
+}
+
+func appendElements(b []byte, elements ...group.Element) []byte {
+	for _, e := range elements {
+		enc, _ := e.MarshalBinaryCompress()
+		b = append(b, enc...)
+	}
+	return b
+}
+
+// PrivateKey is the private key of a server, made of the MAC key
+// (x0, x1, x2) and the blinding x0Blinding of the commitment to x0.
+type PrivateKey struct {
+	p                    params
+	x0, x1, x2, blinding group.Scalar
+	pub                  *PublicKey
+}
+
+// PublicKey is the public key of a server, made of the commitments
+// X0 = x0*G + x0Blinding*H, X1 = x1*H and X2 = x2*H.
+type PublicKey struct {
+	p          params
+	x0, x1, x2 group.Element
+}
+
+// GenerateKey generates a private key for the suite.
+func GenerateKey(s Suite, rnd io.Reader) (*PrivateKey, error) {
+	if rnd == nil {
+		return nil, io.ErrNoProgress
+	}
+	p := getParams(s)
+	g := p.group
+	return &PrivateKey{
+		p:        p,
+		x0:       g.RandomScalar(rnd),
+		x1:       g.RandomNonZeroScalar(rnd),
+		x2:       g.RandomScalar(rnd),
+		blinding: g.RandomScalar(rnd),
+	}, nil
+}
+
+func (k *PrivateKey) Public() *PublicKey {
+	if k.pub == nil {
+		g, h := k.p.group, k.p.generatorH()
+		x0 := g.NewElement().MulGen(k.x0)
+		x0.Add(x0, g.NewElement().Mul(h, k.blinding))
+		k.pub = &PublicKey{
+			p:  k.p,
+			x0: x0,
+			x1: g.NewElement().Mul(h, k.x1),
+			x2: g.NewElement().Mul(h, k.x2),
+		}
+	}
+
+	return k.pub
+}
+
+func (k *PrivateKey) MarshalBinary() ([]byte, error) {
+	return appendScalars(nil, k.x0, k.x1, k.x2, k.blinding), nil
+}
+
+func (k *PublicKey) MarshalBinary() ([]byte, error) {
+	return appendElements(nil, k.x0, k.x1, k.x2), nil
+}
+
+func (k *PrivateKey) UnmarshalBinary(s Suite, data []byte) error {
+	p := getParams(s)
+	x, err := p.readScalars(data, 4)
+	if err != nil || x[1].IsZero() {
+		return ErrInvalidKey
+	}
+	*k = PrivateKey{p: p, x0: x[0], x1: x[1], x2: x[2], blinding: x[3]}
+	return nil
+}
+
+func (k *PublicKey) UnmarshalBinary(s Suite, data []byte) error {
+	p := getParams(s)
+	x, rest, err := p.readElements(data, 3)
+	if err != nil || len(rest) != 0 {
+		return ErrInvalidKey
+	}
+	*k = PublicKey{p: p, x0: x[0], x1: x[1], x2: x[2]}
+	return nil
+}
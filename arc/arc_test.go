@@ -0,0 +1,133 @@
+package arc
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/cloudflare/circl/internal/test"
+)
+
+func issue(t *testing.T, s Suite, server Server, requestContext []byte) *Credential {
+	t.Helper()
+	client := NewClient(s, server.key.Public())
+	req, state, err := client.CreateRequest(rand.Reader, requestContext)
+	test.CheckNoErr(t, err, "create request")
+
+	enc, err := req.MarshalBinary()
+	test.CheckNoErr(t, err, "marshal request")
+	req = new(CredentialRequest)
+	test.CheckNoErr(t, req.UnmarshalBinary(s, enc), "unmarshal request")
+
+	resp, err := server.Issue(rand.Reader, requestContext, req)
+	test.CheckNoErr(t, err, "issue")
+
+	enc, err = resp.MarshalBinary()
+	test.CheckNoErr(t, err, "marshal response")
+	resp = new(CredentialResponse)
+	test.CheckNoErr(t, resp.UnmarshalBinary(s, enc), "unmarshal response")
+
+	cred, err := state.Finalize(resp)
+	test.CheckNoErr(t, err, "finalize")
+	return cred
+}
+
+func TestARC(t *testing.T) {
+	for _, s := range []Suite{SuiteRistretto255, SuiteP256} {
+		t.Run(s.Identifier(), func(t *testing.T) {
+			key, err := GenerateKey(s, rand.Reader)
+			test.CheckNoErr(t, err, "key generation")
+
+			enc, err := key.MarshalBinary()
+			test.CheckNoErr(t, err, "marshal private key")
+			key = new(PrivateKey)
+			test.CheckNoErr(t, key.UnmarshalBinary(s, enc), "unmarshal private key")
+			enc, err = key.Public().MarshalBinary()
+			test.CheckNoErr(t, err, "marshal public key")
+			pk := new(PublicKey)
+			test.CheckNoErr(t, pk.UnmarshalBinary(s, enc), "unmarshal public key")
+
+			server := NewServer(s, key)
+			requestContext := []byte("epoch 1")
+			presentationContext := []byte("origin.example")
+			const limit = 3
+
+			cred := issue(t, s, server, requestContext)
+			state := cred.NewPresentationState(presentationContext, limit)
+			tags := make(map[string]bool)
+			for i := 0; i < limit; i++ {
+				pres, err := state.Present(rand.Reader)
+				test.CheckNoErr(t, err, "present")
+
+				enc, err := pres.MarshalBinary()
+				test.CheckNoErr(t, err, "marshal presentation")
+				pres = new(Presentation)
+				test.CheckNoErr(t, pres.UnmarshalBinary(s, enc), "unmarshal presentation")
+
+				err = server.VerifyPresentation(requestContext, presentationContext, limit, pres)
+				test.CheckNoErr(t, err, "verify presentation")
+				if tags[string(pres.Tag())] {
+					test.ReportError(t, pres.Nonce(), "unique tag")
+				}
+				tags[string(pres.Tag())] = true
+
+				err = server.VerifyPresentation([]byte("epoch 2"), presentationContext, limit, pres)
+				test.CheckIsErr(t, err, "other request context must fail")
+				err = server.VerifyPresentation(requestContext, []byte("other"), limit, pres)
+				test.CheckIsErr(t, err, "other presentation context must fail")
+				err = server.VerifyPresentation(requestContext, presentationContext, pres.Nonce(), pres)
+				test.CheckIsErr(t, err, "nonce above limit must fail")
+
+				enc[len(enc)-1] ^= 1
+				if pres.UnmarshalBinary(s, enc) == nil {
+					err = server.VerifyPresentation(requestContext, presentationContext, limit, pres)
+					test.CheckIsErr(t, err, "tampered proof must fail")
+				}
+			}
+			_, err = state.Present(rand.Reader)
+			if err != ErrLimitExceeded {
+				test.ReportError(t, err, ErrLimitExceeded)
+			}
+
+			// Tags depend on the presentation context.
+			pres, err := cred.NewPresentationState([]byte("other"), limit).Present(rand.Reader)
+			test.CheckNoErr(t, err, "present")
+			if tags[string(pres.Tag())] {
+				test.ReportError(t, pres.Tag(), "unique tag")
+			}
+
+			other, err := GenerateKey(s, rand.Reader)
+			test.CheckNoErr(t, err, "key generation")
+			err = NewServer(s, other).VerifyPresentation(requestContext, []byte("other"), limit, pres)
+			test.CheckIsErr(t, err, "other key must fail")
+		})
+	}
+}
+
+func TestInvalidIssuance(t *testing.T) {
+	s := SuiteRistretto255
+	key, err := GenerateKey(s, rand.Reader)
+	test.CheckNoErr(t, err, "key generation")
+	other, err := GenerateKey(s, rand.Reader)
+	test.CheckNoErr(t, err, "key generation")
+
+	req, state, err := NewClient(s, key.Public()).CreateRequest(rand.Reader, []byte("epoch"))
+	test.CheckNoErr(t, err, "create request")
+	_, err = NewServer(s, key).Issue(rand.Reader, []byte("other"), req)
+	if err != ErrInvalidProof {
+		test.ReportError(t, err, ErrInvalidProof)
+	}
+
+	// The client rejects a response made with another key.
+	resp, err := NewServer(s, other).Issue(rand.Reader, []byte("epoch"), req)
+	test.CheckNoErr(t, err, "issue")
+	_, err = state.Finalize(resp)
+	if err != ErrInvalidProof {
+		test.ReportError(t, err, ErrInvalidProof)
+	}
+
+	enc, err := req.MarshalBinary()
+	test.CheckNoErr(t, err, "marshal request")
+	if err = new(CredentialRequest).UnmarshalBinary(s, enc[1:]); err != ErrInvalidMessage {
+		test.ReportError(t, err, ErrInvalidMessage)
+	}
+}
@@ -0,0 +1,226 @@
+package arc
+
+import (
+	"io"
+
+	"github.com/cloudflare/circl/group"
+	"github.com/cloudflare/circl/zk/sigma"
+)
+
+// requestScalar returns the scalar m2 of a request context.
+func (p params) requestScalar(requestContext []byte) group.Scalar {
+	return p.group.HashToScalar(requestContext, p.dst("requestContext"))
+}
+
+// CredentialRequest is sent by a client to request a credential. It holds
+// a commitment m1Enc = m1*G + r1*H to the client secret m1.
+type CredentialRequest struct {
+	m1Enc group.Element
+	proof *sigma.Proof
+}
+
+// requestRelation proves knowledge of (m1, r1).
+func (p params) requestRelation(m1Enc group.Element, requestContext []byte) *statement {
+	r := p.newStatement("CredentialRequest", 2)
+	r.context = requestContext
+	r.add(m1Enc, term(0, p.group.Generator()), term(1, p.generatorH()))
+	return r
+}
+
+func (r *CredentialRequest) MarshalBinary() ([]byte, error) {
+	pr, err := marshalProof(r.proof)
+	if err != nil {
+		return nil, err
+	}
+	return append(appendElements(nil, r.m1Enc), pr...), nil
+}
+
+func (r *CredentialRequest) UnmarshalBinary(s Suite, data []byte) error {
+	p := getParams(s)
+	e, rest, err := p.readElements(data, 1)
+	if err != nil {
+		return err
+	}
+	pr, err := p.unmarshalProof(rest, 2)
+	if err != nil {
+		return err
+	}
+	r.m1Enc, r.proof = e[0], pr
+	return nil
+}
+
+// CredentialResponse is sent by the server to issue a credential. For a
+// random b, it holds U = b*G and the encryption of U' = (x0 + x1*m1 +
+// x2*m2)*U under the blinding of the request, with the auxiliary elements
+// needed to remove the blinding.
+type CredentialResponse struct {
+	u, encUPrime, x0Aux, x1Aux, x2Aux, hAux group.Element
+	proof                                   *sigma.Proof
+}
+
+const responseElements = 6
+
+// responseRelation proves that the response was computed with the private
+// key of pk. The witnesses are (x0, x1, x2, x0Blinding, b, b*x1, b*x2).
+func (p params) responseRelation(pk *PublicKey, m1Enc group.Element, m2 group.Scalar, resp *CredentialResponse) *statement {
+	g, h := p.group.Generator(), p.generatorH()
+	r := p.newStatement("CredentialResponse", 7)
+	r.add(pk.x0, term(0, g), term(3, h))
+	r.add(pk.x1, term(1, h))
+	r.add(pk.x2, term(2, h))
+	r.add(resp.u, term(4, g))
+	r.add(resp.hAux, term(4, h))
+	r.add(resp.x0Aux, term(3, resp.hAux))
+	r.add(resp.x1Aux, term(4, pk.x1))
+	r.add(resp.x1Aux, term(5, h))
+	r.add(resp.x2Aux, term(4, pk.x2))
+	r.add(resp.x2Aux, term(6, h))
+	r.add(resp.encUPrime, term(4, pk.x0), term(5, m1Enc), term(6, p.group.NewElement().MulGen(m2)))
+	return r
+}
+
+func (r *CredentialResponse) MarshalBinary() ([]byte, error) {
+	b := appendElements(nil, r.u, r.encUPrime, r.x0Aux, r.x1Aux, r.x2Aux, r.hAux)
+	pr, err := marshalProof(r.proof)
+	if err != nil {
+		return nil, err
+	}
+	return append(b, pr...), nil
+}
+
+func (r *CredentialResponse) UnmarshalBinary(s Suite, data []byte) error {
+	p := getParams(s)
+	e, rest, err := p.readElements(data, responseElements)
+	if err != nil {
+		return err
+	}
+	pr, err := p.unmarshalProof(rest, 7)
+	if err != nil {
+		return err
+	}
+	*r = CredentialResponse{e[0], e[1], e[2], e[3], e[4], e[5], pr}
+	return nil
+}
+
+// Client requests credentials from a server.
+type Client struct {
+	p  params
+	pk *PublicKey
+}
+
+// NewClient returns a client for the server with the public key.
+func NewClient(s Suite, pk *PublicKey) Client { return Client{getParams(s), pk} }
+
+// CreateRequest returns a request for a credential bound to the request
+// context, and the state to finalize the credential with the response of
+// the server.
+func (c Client) CreateRequest(rnd io.Reader, requestContext []byte) (*CredentialRequest, *RequestState, error) {
+	if rnd == nil {
+		return nil, nil, io.ErrNoProgress
+	}
+	g := c.p.group
+	m1, r1 := g.RandomNonZeroScalar(rnd), g.RandomScalar(rnd)
+	m1Enc := g.NewElement().MulGen(m1)
+	m1Enc.Add(m1Enc, g.NewElement().Mul(c.p.generatorH(), r1))
+
+	pr, err := c.p.requestRelation(m1Enc, requestContext).prove([]group.Scalar{m1, r1}, rnd)
+	if err != nil {
+		return nil, nil, err
+	}
+	req := &CredentialRequest{m1Enc, pr}
+	state := &RequestState{
+		client:         c,
+		requestContext: append([]byte{}, requestContext...),
+		m1:             m1,
+		r1:             r1,
+		m1Enc:          m1Enc,
+	}
+	return req, state, nil
+}
+
+// RequestState holds the secrets of a credential request.
+type RequestState struct {
+	client         Client
+	requestContext []byte
+	m1, r1         group.Scalar
+	m1Enc          group.Element
+}
+
+// Finalize verifies the response of the server and returns the credential.
+func (s *RequestState) Finalize(resp *CredentialResponse) (*Credential, error) {
+	p, pk := s.client.p, s.client.pk
+	m2 := p.requestScalar(s.requestContext)
+	if !p.responseRelation(pk, s.m1Enc, m2, resp).verify(resp.proof) {
+		return nil, ErrInvalidProof
+	}
+
+	// U' = encUPrime - X0Aux - r1*X1Aux
+	g := p.group
+	uPrime := g.NewElement().Mul(resp.x1Aux, s.r1)
+	uPrime.Add(uPrime, resp.x0Aux)
+	uPrime.Neg(uPrime)
+	uPrime.Add(uPrime, resp.encUPrime)
+
+	return &Credential{
+		p:              p,
+		requestContext: s.requestContext,
+		m1:             s.m1,
+		u:              resp.u.Copy(),
+		uPrime:         uPrime,
+		x1:             pk.x1,
+	}, nil
+}
+
+// Server issues credentials and verifies their presentations.
+type Server struct {
+	p   params
+	key *PrivateKey
+}
+
+// NewServer returns a server with the private key.
+func NewServer(s Suite, key *PrivateKey) Server {
+	p := getParams(s)
+	if key == nil || key.p.identifier != p.identifier {
+		panic(ErrInvalidKey)
+	}
+	return Server{p, key}
+}
+
+// Issue verifies the request and returns the response that issues a
+// credential bound to the request context.
+func (s Server) Issue(rnd io.Reader, requestContext []byte, req *CredentialRequest) (*CredentialResponse, error) {
+	if rnd == nil {
+		return nil, io.ErrNoProgress
+	}
+	if !s.p.requestRelation(req.m1Enc, requestContext).verify(req.proof) {
+		return nil, ErrInvalidProof
+	}
+
+	g, h, k := s.p.group, s.p.generatorH(), s.key
+	pk := k.Public()
+	m2 := s.p.requestScalar(requestContext)
+	b := g.RandomNonZeroScalar(rnd)
+	bx1 := g.NewScalar().Mul(b, k.x1)
+	bx2 := g.NewScalar().Mul(b, k.x2)
+
+	// encUPrime = b*(X0 + x1*m1Enc + x2*m2*G)
+	encUPrime := g.NewElement().Mul(pk.x0, b)
+	encUPrime.Add(encUPrime, g.NewElement().Mul(req.m1Enc, bx1))
+	encUPrime.Add(encUPrime, g.NewElement().MulGen(g.NewScalar().Mul(bx2, m2)))
+
+	resp := &CredentialResponse{
+		u:         g.NewElement().MulGen(b),
+		encUPrime: encUPrime,
+		x0Aux:     g.NewElement().Mul(h, g.NewScalar().Mul(b, k.blinding)),
+		x1Aux:     g.NewElement().Mul(h, bx1),
+		x2Aux:     g.NewElement().Mul(h, bx2),
+		hAux:      g.NewElement().Mul(h, b),
+	}
+	witness := []group.Scalar{k.x0, k.x1, k.x2, k.blinding, b, bx1, bx2}
+	pr, err := s.p.responseRelation(pk, req.m1Enc, m2, resp).prove(witness, rnd)
+	if err != nil {
+		return nil, err
+	}
+	resp.proof = pr
+	return resp, nil
+}
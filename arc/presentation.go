@@ -0,0 +1,199 @@
+package arc
+
+import (
+	"encoding/binary"
+	"io"
+
+	"github.com/cloudflare/circl/group"
+	"github.com/cloudflare/circl/zk/sigma"
+)
+
+// Credential is a MAC U' = (x0 + x1*m1 + x2*m2)*U on the client secret m1
+// and the request context m2, issued by a server.
+type Credential struct {
+	p              params
+	requestContext []byte
+	m1             group.Scalar
+	u, uPrime      group.Element
+	x1             group.Element
+}
+
+// tagGenerator returns the generator T of the tags of a presentation
+// context.
+func (p params) tagGenerator(presentationContext []byte) group.Element {
+	return p.group.HashToElement(presentationContext, p.dst("Tag"))
+}
+
+// Presentation proves possession of a credential without revealing it.
+//
+// For a random a, it holds the randomized credential U = a*U_c, the
+// commitments m1Commit = m1*U + z*H and UPrimeCommit = a*U'_c + r*G, and
+// the tag (m1 + nonce)^-1 * T, where T is derived from the presentation
+// context.
+type Presentation struct {
+	u, uPrimeCommit, m1Commit, tag group.Element
+	nonce                          uint32
+	proof                          *sigma.Proof
+}
+
+const presentationElements = 4
+
+// presentationRelation proves knowledge of (m1, z, r) such that
+//
+//	m1Commit = m1*U + z*H
+//	V = z*X1 - r*G
+//	T - nonce*tag = m1*tag
+//
+// where V is computed by the client as z*X1 - r*G, and by the server from
+// its private key.
+func (p params) presentationRelation(x1, v group.Element, pres *Presentation, presentationContext []byte) *statement {
+	g := p.group
+	r := p.newStatement("CredentialPresentation", 3)
+	r.context = appendElements(nil, pres.uPrimeCommit)
+	r.context = appendUint32(r.context, pres.nonce)
+	r.context = append(r.context, presentationContext...)
+
+	nonce := g.NewScalar().SetUint64(uint64(pres.nonce))
+	lhs := g.NewElement().Mul(pres.tag, nonce)
+	lhs.Neg(lhs)
+	lhs.Add(lhs, p.tagGenerator(presentationContext))
+
+	r.add(pres.m1Commit, term(0, pres.u), term(1, p.generatorH()))
+	r.add(v, term(1, x1), term(2, g.NewElement().Neg(g.Generator())))
+	r.add(lhs, term(0, pres.tag))
+	return r
+}
+
+func appendUint32(b []byte, n uint32) []byte {
+	var buf [4]byte
+	binary.BigEndian.PutUint32(buf[:], n)
+	return append(b, buf[:]...)
+}
+
+// Tag returns the encoding of the tag of the presentation. Presentations
+// with the same tag in a presentation context come from the same
+// credential and nonce, so servers reject tags they have already seen.
+func (pr *Presentation) Tag() []byte { return appendElements(nil, pr.tag) }
+
+// Nonce returns the nonce of the presentation.
+func (pr *Presentation) Nonce() uint32 { return pr.nonce }
+
+func (pr *Presentation) MarshalBinary() ([]byte, error) {
+	b := appendElements(nil, pr.u, pr.uPrimeCommit, pr.m1Commit, pr.tag)
+	b = appendUint32(b, pr.nonce)
+	proof, err := marshalProof(pr.proof)
+	if err != nil {
+		return nil, err
+	}
+	return append(b, proof...), nil
+}
+
+func (pr *Presentation) UnmarshalBinary(s Suite, data []byte) error {
+	p := getParams(s)
+	e, rest, err := p.readElements(data, presentationElements)
+	if err != nil {
+		return err
+	}
+	if len(rest) < 4 {
+		return ErrInvalidMessage
+	}
+	nonce := binary.BigEndian.Uint32(rest)
+	proof, err := p.unmarshalProof(rest[4:], 3)
+	if err != nil {
+		return err
+	}
+	*pr = Presentation{e[0], e[1], e[2], e[3], nonce, proof}
+	return nil
+}
+
+// PresentationState presents a credential in a presentation context up to
+// a limit of times.
+type PresentationState struct {
+	cred                *Credential
+	presentationContext []byte
+	limit, next         uint32
+}
+
+// NewPresentationState returns a state to present the credential at most
+// limit times in the presentation context.
+func (c *Credential) NewPresentationState(presentationContext []byte, limit uint32) *PresentationState {
+	return &PresentationState{c, append([]byte{}, presentationContext...), limit, 0}
+}
+
+// Present returns a new presentation of the credential. It fails with
+// ErrLimitExceeded once the limit of presentations is reached.
+func (s *PresentationState) Present(rnd io.Reader) (*Presentation, error) {
+	if rnd == nil {
+		return nil, io.ErrNoProgress
+	}
+	if s.next >= s.limit {
+		return nil, ErrLimitExceeded
+	}
+	c := s.cred
+	g := c.p.group
+	nonce := g.NewScalar().SetUint64(uint64(s.next))
+	inv := g.NewScalar().Add(c.m1, nonce)
+	if inv.IsZero() {
+		// This happens with negligible probability.
+		s.next++
+		return s.Present(rnd)
+	}
+	inv.Inv(inv)
+
+	a := g.RandomNonZeroScalar(rnd)
+	z, r := g.RandomScalar(rnd), g.RandomScalar(rnd)
+	u := g.NewElement().Mul(c.u, a)
+	m1Commit := g.NewElement().Mul(u, c.m1)
+	m1Commit.Add(m1Commit, g.NewElement().Mul(c.p.generatorH(), z))
+	uPrimeCommit := g.NewElement().Mul(c.uPrime, a)
+	uPrimeCommit.Add(uPrimeCommit, g.NewElement().MulGen(r))
+
+	pres := &Presentation{
+		u:            u,
+		uPrimeCommit: uPrimeCommit,
+		m1Commit:     m1Commit,
+		tag:          g.NewElement().Mul(c.p.tagGenerator(s.presentationContext), inv),
+		nonce:        s.next,
+	}
+
+	// V = z*X1 - r*G
+	v := g.NewElement().MulGen(r)
+	v.Neg(v)
+	v.Add(v, g.NewElement().Mul(c.x1, z))
+	pr, err := c.p.presentationRelation(c.x1, v, pres, s.presentationContext).
+		prove([]group.Scalar{c.m1, z, r}, rnd)
+	if err != nil {
+		return nil, err
+	}
+	pres.proof = pr
+
+	s.next++
+	return pres, nil
+}
+
+// VerifyPresentation checks that the presentation comes from a credential
+// issued by the server for the request context, and that its nonce is
+// below the limit of the presentation context. The caller must reject
+// presentations whose tag it has already seen in the presentation context.
+func (s Server) VerifyPresentation(requestContext, presentationContext []byte, limit uint32, pres *Presentation) error {
+	if pres.nonce >= limit {
+		return ErrLimitExceeded
+	}
+	if pres.u.IsIdentity() {
+		return ErrInvalidPresentation
+	}
+
+	// V = x0*U + x1*m1Commit + x2*m2*U - UPrimeCommit
+	g, k := s.p.group, s.key
+	m2 := s.p.requestScalar(requestContext)
+	x := g.NewScalar().Mul(k.x2, m2)
+	x.Add(x, k.x0)
+	v := g.NewElement().Mul(pres.u, x)
+	v.Add(v, g.NewElement().Mul(pres.m1Commit, k.x1))
+	v.Add(v, g.NewElement().Neg(pres.uPrimeCommit))
+
+	if !s.p.presentationRelation(k.Public().x1, v, pres, presentationContext).verify(pres.proof) {
+		return ErrInvalidPresentation
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package arc
+
+import (
+	"io"
+
+	"github.com/cloudflare/circl/group"
+	"github.com/cloudflare/circl/zk/sigma"
+)
+
+// statement is a relation proven with the sigma package. Its proofs are
+// bound to a label and to additional public context.
+type statement struct {
+	p       params
+	label   string
+	rel     *sigma.Relation
+	context []byte
+}
+
+func (p params) newStatement(label string, nScalars int) *statement {
+	return &statement{p: p, label: label, rel: sigma.NewRelation(p.group, nScalars)}
+}
+
+// term is the product of the scalar of the witness at index i and e.
+func term(i int, e group.Element) sigma.Term { return sigma.Term{Scalar: i, Element: e} }
+
+// add appends the equation lhs = sum of the terms.
+func (s *statement) add(lhs group.Element, terms ...sigma.Term) { s.rel.Append(lhs, terms...) }
+
+func (s *statement) transcript() *sigma.Transcript {
+	t := sigma.NewTranscript(s.p.group, s.p.dst(s.label))
+	t.AppendMessage("context", s.context)
+	return t
+}
+
+// prove returns a proof of knowledge of the witness, which must satisfy the
+// relation.
+func (s *statement) prove(witness []group.Scalar, rnd io.Reader) (*sigma.Proof, error) {
+	return sigma.Prove(s.transcript(), s.rel, witness, rnd)
+}
+
+// verify checks the proof for the relation.
+func (s *statement) verify(pr *sigma.Proof) bool {
+	return pr != nil && sigma.Verify(s.transcript(), s.rel, pr)
+}
+
+func marshalProof(pr *sigma.Proof) ([]byte, error) {
+	if pr == nil {
+		return nil, ErrInvalidMessage
+	}
+	return pr.MarshalCompact()
+}
+
+// unmarshalProof decodes the compact encoding of a proof of a relation
+// with nScalars scalars, which only depends on their number.
+func (p params) unmarshalProof(data []byte, nScalars int) (*sigma.Proof, error) {
+	pr := new(sigma.Proof)
+	if pr.UnmarshalCompact(sigma.NewRelation(p.group, nScalars), data) != nil {
+		return nil, ErrInvalidMessage
+	}
+	return pr, nil
+}
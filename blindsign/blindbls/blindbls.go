@@ -0,0 +1,237 @@
+// Package blindbls implements blind BLS signatures on the BLS12-381 curve.
+//
+// The protocol is the blind signature of Boldyreva. The verifier hashes the
+// message to a point H(m) in G1, and sends the blinded point r*H(m) for a
+// random scalar r. The signer multiplies it by its private key k, and the
+// verifier removes the blind to obtain the signature k*H(m).
+//
+// Signatures are compressed elements of G1 (48 bytes) and public keys are
+// compressed elements of G2 (96 bytes). Messages are hashed with the
+// ciphersuite BLS_SIG_BLS12381G1_XMD:SHA-256_SSWU_RO_NUL_, so finalized
+// signatures are minimal-signature-size BLS signatures in the basic scheme
+// of draft-irtf-cfrg-bls-signature.
+//
+// References:
+//   - Boldyreva: https://doi.org/10.1007/3-540-36288-6_3
+//   - draft-irtf-cfrg-bls-signature: https://datatracker.ietf.org/doc/draft-irtf-cfrg-bls-signature/
+package blindbls
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"io"
+
+	"github.com/cloudflare/circl/blindsign"
+	bls "github.com/cloudflare/circl/ecc/bls12381"
+)
+
+const (
+	// PublicKeySize is the size in bytes of an encoded public key.
+	PublicKeySize = bls.G2SizeCompressed
+	// PrivateKeySize is the size in bytes of an encoded private key.
+	PrivateKeySize = bls.ScalarSize
+	// SignatureSize is the size in bytes of signatures and blinded messages.
+	SignatureSize = bls.G1SizeCompressed
+	// BlindSize is the size in bytes of an encoded blind.
+	BlindSize = bls.ScalarSize
+)
+
+// dst is the domain separation tag used to hash messages to G1.
+var dst = []byte("BLS_SIG_BLS12381G1_XMD:SHA-256_SSWU_RO_NUL_")
+
+var (
+	// ErrInvalidKey is returned when a key cannot be decoded.
+	ErrInvalidKey = errors.New("blindbls: invalid key")
+	// ErrInvalidBlind is returned when a blind is zero or cannot be decoded.
+	ErrInvalidBlind = errors.New("blindbls: invalid blind")
+	// ErrInvalidMessage is returned when a protocol message is not a valid
+	// element of G1.
+	ErrInvalidMessage = errors.New("blindbls: invalid message")
+	// ErrInvalidSignature is returned when a signature does not verify.
+	ErrInvalidSignature = errors.New("blindbls: invalid signature")
+)
+
+// PublicKey is a BLS public key.
+type PublicKey struct{ p bls.G2 }
+
+// PrivateKey is a BLS private key.
+type PrivateKey struct {
+	k   bls.Scalar
+	pub PublicKey
+}
+
+// GenerateKey generates a private key using randomness from rnd.
+func GenerateKey(rnd io.Reader) (*PrivateKey, error) {
+	sk := new(PrivateKey)
+	for sk.k.IsZero() == 1 {
+		if err := sk.k.Random(rnd); err != nil {
+			return nil, err
+		}
+	}
+	sk.pub.p.ScalarMult(&sk.k, bls.G2Generator())
+	return sk, nil
+}
+
+// Public returns the public key of the private key.
+func (k *PrivateKey) Public() *PublicKey { p := k.pub; return &p }
+
+// MarshalBinary encodes the private key.
+func (k *PrivateKey) MarshalBinary() ([]byte, error) { return k.k.MarshalBinary() }
+
+// UnmarshalBinary decodes a private key.
+func (k *PrivateKey) UnmarshalBinary(data []byte) error {
+	var s bls.Scalar
+	if len(data) != PrivateKeySize || s.UnmarshalBinary(data) != nil || s.IsZero() == 1 {
+		return ErrInvalidKey
+	}
+	k.k = s
+	k.pub.p.ScalarMult(&k.k, bls.G2Generator())
+	return nil
+}
+
+// MarshalBinary encodes the public key in compressed form.
+func (k *PublicKey) MarshalBinary() ([]byte, error) { return k.p.BytesCompressed(), nil }
+
+// UnmarshalBinary decodes a public key in compressed form.
+func (k *PublicKey) UnmarshalBinary(data []byte) error {
+	var p bls.G2
+	if len(data) != PublicKeySize || p.SetBytes(data) != nil ||
+		!p.IsOnG2() || p.IsIdentity() {
+		return ErrInvalidKey
+	}
+	k.p = p
+	return nil
+}
+
+// Equal reports whether the public keys are equal.
+func (k *PublicKey) Equal(x *PublicKey) bool {
+	return subtle.ConstantTimeCompare(k.p.BytesCompressed(), x.p.BytesCompressed()) == 1
+}
+
+// decodeG1 decodes a compressed element of G1 other than the identity.
+func decodeG1(data []byte) (*bls.G1, error) {
+	p := new(bls.G1)
+	if len(data) != SignatureSize || p.SetBytes(data) != nil ||
+		!p.IsOnG1() || p.IsIdentity() {
+		return nil, ErrInvalidMessage
+	}
+	return p, nil
+}
+
+// A Verifier represents a Verifier in the blind BLS protocol.
+type Verifier struct{ pk *PublicKey }
+
+// NewVerifier creates a new Verifier for the public key of a Signer.
+func NewVerifier(pk *PublicKey) Verifier { return Verifier{pk} }
+
+// Blind hashes the message to G1 and blinds it with a random scalar. It
+// returns the blinded message to send to the Signer, and the state used to
+// finalize the signature.
+func (v Verifier) Blind(message []byte) ([]byte, blindsign.VerifierState, error) {
+	var r bls.Scalar
+	for r.IsZero() == 1 {
+		if err := r.Random(rand.Reader); err != nil {
+			return nil, nil, err
+		}
+	}
+	return v.fixedBlind(message, &r)
+}
+
+// FixedBlind runs the Blind function with a fixed blind.
+func (v Verifier) FixedBlind(message, blind []byte) ([]byte, blindsign.VerifierState, error) {
+	var r bls.Scalar
+	if len(blind) != BlindSize || r.UnmarshalBinary(blind) != nil || r.IsZero() == 1 {
+		return nil, nil, ErrInvalidBlind
+	}
+	return v.fixedBlind(message, &r)
+}
+
+func (v Verifier) fixedBlind(message []byte, r *bls.Scalar) ([]byte, blindsign.VerifierState, error) {
+	var h, blinded bls.G1
+	h.Hash(message, dst)
+	blinded.ScalarMult(r, &h)
+
+	state := VerifierState{verifier: v, h: h, r: *r}
+	state.rInv.Inv(r)
+	return blinded.BytesCompressed(), state, nil
+}
+
+// Verify checks that the signature is valid for the message.
+func (v Verifier) Verify(message, signature []byte) error {
+	sig, err := decodeG1(signature)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	var h bls.G1
+	h.Hash(message, dst)
+	return v.verify(&h, sig)
+}
+
+// verify checks that e(sig, g2) = e(h, pk).
+func (v Verifier) verify(h, sig *bls.G1) error {
+	e := bls.ProdPairFrac(
+		[]*bls.G1{sig, h},
+		[]*bls.G2{bls.G2Generator(), &v.pk.p},
+		[]int{1, -1},
+	)
+	if !e.IsIdentity() {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// A VerifierState carries state needed to complete the blind signature
+// protocol as a verifier.
+type VerifierState struct {
+	verifier Verifier
+
+	// The hash of the message being signed
+	h bls.G1
+
+	// The blinding factor and its inverse
+	r, rInv bls.Scalar
+}
+
+// Finalize removes the blind from the blinded signature, and outputs the
+// signature if it is valid. Otherwise, it returns an error.
+func (state VerifierState) Finalize(data []byte) ([]byte, error) {
+	blindSig, err := decodeG1(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var sig bls.G1
+	sig.ScalarMult(&state.rInv, blindSig)
+	if err := state.verifier.verify(&state.h, &sig); err != nil {
+		return nil, err
+	}
+	return sig.BytesCompressed(), nil
+}
+
+// CopyBlind returns an encoding of the blind value used in the protocol.
+func (state VerifierState) CopyBlind() []byte {
+	r, _ := state.r.MarshalBinary()
+	return r
+}
+
+// CopySalt returns nil, since the protocol does not use a salt.
+func (state VerifierState) CopySalt() []byte { return nil }
+
+// A Signer represents the Signer in the blind BLS protocol.
+type Signer struct{ sk *PrivateKey }
+
+// NewSigner creates a new Signer using a private key.
+func NewSigner(sk *PrivateKey) Signer { return Signer{sk} }
+
+// BlindSign multiplies the blinded message by the private key, and returns
+// the blinded signature.
+func (s Signer) BlindSign(data []byte) ([]byte, error) {
+	blinded, err := decodeG1(data)
+	if err != nil {
+		return nil, err
+	}
+	var blindSig bls.G1
+	blindSig.ScalarMult(&s.sk.k, blinded)
+	return blindSig.BytesCompressed(), nil
+}
@@ -0,0 +1,85 @@
+package blindbls
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+
+	"github.com/cloudflare/circl/blindsign"
+	"github.com/cloudflare/circl/internal/test"
+)
+
+func TestBlindBLS(t *testing.T) {
+	sk, err := GenerateKey(rand.Reader)
+	test.CheckNoErr(t, err, "key generation")
+	var verifier blindsign.Verifier = NewVerifier(sk.Public())
+	var signer blindsign.Signer = NewSigner(sk)
+	message := []byte("hello world")
+
+	blinded, state, err := verifier.Blind(message)
+	test.CheckNoErr(t, err, "blind")
+	blindSig, err := signer.BlindSign(blinded)
+	test.CheckNoErr(t, err, "blind sign")
+	sig, err := state.Finalize(blindSig)
+	test.CheckNoErr(t, err, "finalize")
+	if len(sig) != SignatureSize {
+		test.ReportError(t, len(sig), SignatureSize)
+	}
+
+	v := NewVerifier(sk.Public())
+	test.CheckNoErr(t, v.Verify(message, sig), "verify")
+	test.CheckIsErr(t, v.Verify([]byte("other message"), sig), "other message must fail")
+
+	// The blind determines the blinded message.
+	blinded2, _, err := v.FixedBlind(message, state.CopyBlind())
+	test.CheckNoErr(t, err, "fixed blind")
+	if !bytes.Equal(blinded2, blinded) {
+		test.ReportError(t, blinded2, blinded)
+	}
+	_, state2, err := v.Blind(message)
+	test.CheckNoErr(t, err, "blind")
+	blindSig2, err := signer.BlindSign(blinded2)
+	test.CheckNoErr(t, err, "blind sign")
+	_, err = state2.Finalize(blindSig2)
+	test.CheckIsErr(t, err, "finalize with another blind must fail")
+
+	// Signatures from another key are rejected.
+	other, err := GenerateKey(rand.Reader)
+	test.CheckNoErr(t, err, "key generation")
+	otherSig, err := NewSigner(other).BlindSign(blinded)
+	test.CheckNoErr(t, err, "blind sign")
+	_, err = state.Finalize(otherSig)
+	test.CheckIsErr(t, err, "finalize with another key must fail")
+
+	_, err = signer.BlindSign(blinded[1:])
+	test.CheckIsErr(t, err, "short message must fail")
+	_, _, err = v.FixedBlind(message, make([]byte, BlindSize))
+	test.CheckIsErr(t, err, "zero blind must fail")
+}
+
+func TestKeys(t *testing.T) {
+	sk, err := GenerateKey(rand.Reader)
+	test.CheckNoErr(t, err, "key generation")
+
+	rawSk, err := sk.MarshalBinary()
+	test.CheckNoErr(t, err, "marshal private key")
+	var sk2 PrivateKey
+	test.CheckNoErr(t, sk2.UnmarshalBinary(rawSk), "unmarshal private key")
+	if !sk2.Public().Equal(sk.Public()) {
+		test.ReportError(t, sk2.Public(), sk.Public())
+	}
+
+	rawPk, err := sk.Public().MarshalBinary()
+	test.CheckNoErr(t, err, "marshal public key")
+	if len(rawPk) != PublicKeySize {
+		test.ReportError(t, len(rawPk), PublicKeySize)
+	}
+	var pk PublicKey
+	test.CheckNoErr(t, pk.UnmarshalBinary(rawPk), "unmarshal public key")
+	if !pk.Equal(sk.Public()) {
+		test.ReportError(t, &pk, sk.Public())
+	}
+
+	test.CheckIsErr(t, pk.UnmarshalBinary(rawPk[1:]), "short public key must fail")
+	test.CheckIsErr(t, sk2.UnmarshalBinary(make([]byte, PrivateKeySize)), "zero private key must fail")
+}
@@ -1,18 +1,21 @@
 package blindrsa
 
-// This package implements the blind RSA protocol based on the CFRG specification:
+// This file implements the blind RSA protocol based on the CFRG specification:
 // https://datatracker.ietf.org/doc/html/draft-irtf-cfrg-rsa-blind-signatures-02
+//
+// It is equivalent to the PSS-Deterministic variants of RFC 9474 with an
+// arbitrary hash function, and is kept for compatibility.
 
 import (
 	"crypto/rand"
 	"crypto/rsa"
-	"crypto/subtle"
-	"errors"
 	"hash"
 	"io"
 	"math/big"
 
 	"github.com/cloudflare/circl/blindsign"
+	"github.com/cloudflare/circl/blindsign/blindrsa/internal/common"
+	"github.com/cloudflare/circl/blindsign/blindrsa/internal/keys"
 )
 
 // An RSAVerifier represents a Verifier in the RSA blind signature protocol.
@@ -33,39 +36,8 @@ func NewRSAVerifier(pk *rsa.PublicKey, hash hash.Hash) RSAVerifier {
 	}
 }
 
-func encodeMessageEMSAPSS(message []byte, key *rsa.PublicKey, hash hash.Hash, salt []byte) ([]byte, error) {
-	hash.Reset() // Ensure the hash state is cleared
-	hash.Write(message)
-	digest := hash.Sum(nil)
-	hash.Reset()
-	emBits := key.N.BitLen() - 1
-	encodedMsg, err := emsaPSSEncode(digest[:], emBits, salt, hash)
-	return encodedMsg, err
-}
-
-func generateBlindingFactor(random io.Reader, key *rsa.PublicKey) (*big.Int, *big.Int, error) {
-	randReader := random
-	if randReader == nil {
-		randReader = rand.Reader
-	}
-	r, err := rand.Int(randReader, key.N)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	if r.Sign() == 0 {
-		r = bigOne
-	}
-	rInv := new(big.Int).ModInverse(r, key.N)
-	if rInv == nil {
-		return nil, nil, ErrInvalidBlind
-	}
-
-	return r, rInv, nil
-}
-
 func (v RSAVerifier) fixedBlind(message, salt []byte, r, rInv *big.Int) ([]byte, blindsign.VerifierState, error) {
-	encodedMsg, err := encodeMessageEMSAPSS(message, v.pk, v.hash, salt)
+	encodedMsg, err := common.EncodeMessageEMSAPSS(message, v.pk.N, v.hash, salt)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -107,7 +79,7 @@ func (v RSAVerifier) Blind(random io.Reader, message []byte) ([]byte, blindsign.
 		return nil, nil, err
 	}
 
-	r, rInv, err := generateBlindingFactor(random, v.pk)
+	r, rInv, err := common.GenerateBlindingFactor(random, v.pk.N)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -146,18 +118,6 @@ type RSAVerifierState struct {
 	rInv *big.Int
 }
 
-func verifyBlindSignature(pub *rsa.PublicKey, hashed, sig []byte) error {
-	m := new(big.Int).SetBytes(hashed)
-	bigSig := new(big.Int).SetBytes(sig)
-
-	c := encrypt(new(big.Int), pub, bigSig)
-	if subtle.ConstantTimeCompare(m.Bytes(), c.Bytes()) == 1 {
-		return nil
-	} else {
-		return rsa.ErrVerification
-	}
-}
-
 // Finalize computes and outputs the final signature, if it's valid. Otherwise, it returns an error.
 //
 // See the specification for more details:
@@ -176,7 +136,7 @@ func (state RSAVerifierState) Finalize(data []byte) ([]byte, error) {
 	sig := make([]byte, kLen)
 	s.FillBytes(sig)
 
-	err := verifyBlindSignature(state.verifier.pk, state.encodedMsg, sig)
+	err := common.VerifyBlindSignature(keys.NewBigPublicKey(state.verifier.pk), state.encodedMsg, sig)
 	if err != nil {
 		return nil, err
 	}
@@ -227,7 +187,7 @@ func (signer RSASigner) BlindSign(data []byte) ([]byte, error) {
 		return nil, ErrInvalidMessageLength
 	}
 
-	s, err := decryptAndCheck(rand.Reader, signer.sk, m)
+	s, err := common.DecryptAndCheck(rand.Reader, keys.NewBigPrivateKey(signer.sk), m)
 	if err != nil {
 		return nil, err
 	}
@@ -237,17 +197,3 @@ func (signer RSASigner) BlindSign(data []byte) ([]byte, error) {
 
 	return blindSig, nil
 }
-
-var (
-	// ErrUnexpectedSize is the error used if the size of a parameter does not match its expected value.
-	ErrUnexpectedSize = errors.New("blindsign/blindrsa: unexpected input size")
-
-	// ErrInvalidMessageLength is the error used if the size of a protocol message does not match its expected value.
-	ErrInvalidMessageLength = errors.New("blindsign/blindrsa: invalid message length")
-
-	// ErrInvalidBlind is the error used if the blind generated by the Verifier fails.
-	ErrInvalidBlind = errors.New("blindsign/blindrsa: invalid blind")
-
-	// ErrInvalidRandomness is the error used if caller did not provide randomness to the Blind() function.
-	ErrInvalidRandomness = errors.New("blindsign/blindrsa: invalid random parameter")
-)
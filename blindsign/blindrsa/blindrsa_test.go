@@ -14,6 +14,7 @@ import (
 	"io"
 	"math/big"
 	"os"
+	"strings"
 	"testing"
 )
 
@@ -252,17 +253,14 @@ type testVectorList struct {
 }
 
 func mustUnhexBigInt(number string) *big.Int {
-	data, err := hex.DecodeString(number)
-	if err != nil {
-		panic(err)
-	}
-
+	data := mustUnhex(number)
 	value := new(big.Int)
 	value.SetBytes(data)
 	return value
 }
 
 func mustUnhex(value string) []byte {
+	value = strings.TrimPrefix(value, "0x")
 	data, err := hex.DecodeString(value)
 	if err != nil {
 		panic(err)
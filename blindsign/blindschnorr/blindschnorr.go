@@ -0,0 +1,234 @@
+// Package blindschnorr implements blind Schnorr signatures over prime-order
+// groups.
+//
+// Blind Schnorr signatures need three moves: the signer first commits to
+// nonces, then the verifier sends a blinded challenge, and the signer
+// answers. The Signer and Verifier types start a session for each
+// signature; SignerSession implements blindsign.Signer and VerifierSession
+// implements blindsign.Verifier once the signer's commitment is known.
+// Sessions must not be reused.
+//
+// Two schemes are provided:
+//
+//   - Clause blind Schnorr signatures (Signer, Verifier). The signer runs
+//     two sessions in parallel and completes only one of them, chosen at
+//     random. This thwarts the ROS attack on concurrent sessions of plain
+//     blind Schnorr signatures. Signatures are ordinary Schnorr signatures
+//     (R, s): a compressed element and a scalar, 64 bytes on ristretto255.
+//
+//   - Abe-Okamoto partially blind signatures (PartialSigner,
+//     PartialVerifier). Signatures are bound to a public info string agreed
+//     by both parties, such as an expiry date. Signatures are four scalars,
+//     128 bytes on ristretto255. Concurrent sessions of this scheme are
+//     subject to the ROS attack, so signers should only run sessions
+//     sequentially.
+//
+// References:
+//   - Fuchsbauer, Plouviez, Seurin: https://eprint.iacr.org/2019/877
+//   - Abe, Okamoto: https://doi.org/10.1007/3-540-44598-6_17
+//   - Benhamouda et al. (ROS attack): https://eprint.iacr.org/2020/945
+package blindschnorr
+
+import (
+	"crypto/rand"
+	"errors"
+	"sync"
+
+	"github.com/cloudflare/circl/blindsign"
+	"github.com/cloudflare/circl/group"
+)
+
+var (
+	// ErrInvalidKey is returned when a key cannot be decoded.
+	ErrInvalidKey = errors.New("blindschnorr: invalid key")
+	// ErrInvalidMessage is returned when a protocol message is malformed.
+	ErrInvalidMessage = errors.New("blindschnorr: invalid message")
+	// ErrInvalidSignature is returned when a signature does not verify.
+	ErrInvalidSignature = errors.New("blindschnorr: invalid signature")
+	// ErrSessionUsed is returned when a signer session answers twice.
+	ErrSessionUsed = errors.New("blindschnorr: session already used")
+)
+
+var challengeDST = []byte("CIRCL-BlindSchnorr-Challenge")
+
+// challenge returns H(R, X, m).
+func challenge(pk *PublicKey, r group.Element, message []byte) group.Scalar {
+	input := appendElements(nil, r, pk.e)
+	return pk.g.HashToScalar(append(input, message...), challengeDST)
+}
+
+// A Signer represents the Signer in the clause blind Schnorr protocol.
+type Signer struct{ sk *PrivateKey }
+
+// NewSigner creates a new Signer using a private key.
+func NewSigner(sk *PrivateKey) Signer { return Signer{sk} }
+
+// Commit starts a signing session. It returns the commitment to send to
+// the Verifier, and the session that answers its blinded challenge.
+func (s Signer) Commit() ([]byte, *SignerSession) {
+	g := s.sk.g
+	session := &SignerSession{sk: s.sk}
+	var commitment []byte
+	for i := range session.r {
+		session.r[i] = g.RandomNonZeroScalar(rand.Reader)
+		commitment = appendElements(commitment, g.NewElement().MulGen(session.r[i]))
+	}
+	return commitment, session
+}
+
+// A SignerSession holds the nonces of one signing session.
+type SignerSession struct {
+	sk   *PrivateKey
+	mu   sync.Mutex
+	used bool
+	r    [2]group.Scalar
+}
+
+// BlindSign answers the blinded challenges of the Verifier. It completes
+// one of the two parallel sessions at random, and returns its index
+// followed by the response. A session can only answer once.
+func (s *SignerSession) BlindSign(data []byte) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.used {
+		return nil, ErrSessionUsed
+	}
+
+	c, err := readScalars(s.sk.g, data, 2)
+	if err != nil {
+		return nil, err
+	}
+	var b [1]byte
+	if _, err = rand.Read(b[:]); err != nil {
+		return nil, err
+	}
+	b[0] &= 1
+
+	resp := s.sk.g.NewScalar().Mul(c[b[0]], s.sk.x)
+	resp.Add(resp, s.r[b[0]])
+	s.used = true
+	s.r = [2]group.Scalar{}
+
+	return appendScalars(b[:], resp), nil
+}
+
+// A Verifier represents a Verifier in the clause blind Schnorr protocol.
+type Verifier struct{ pk *PublicKey }
+
+// NewVerifier creates a new Verifier for the public key of a Signer.
+func NewVerifier(pk *PublicKey) Verifier { return Verifier{pk} }
+
+// Session returns a session for the commitment of a SignerSession.
+func (v Verifier) Session(commitment []byte) (*VerifierSession, error) {
+	r, err := readElements(v.pk.g, commitment, 2)
+	if err != nil {
+		return nil, err
+	}
+	return &VerifierSession{v, [2]group.Element{r[0], r[1]}}, nil
+}
+
+// Verify checks that the signature is valid for the message.
+func (v Verifier) Verify(message, signature []byte) error {
+	g := v.pk.g
+	ne := int(g.Params().CompressedElementLength)
+	if len(signature) < ne {
+		return ErrInvalidSignature
+	}
+	r, err := readElements(g, signature[:ne], 1)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	s, err := readScalars(g, signature[ne:], 1)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	// s*G = R + c*X
+	c := challenge(v.pk, r[0], message)
+	want := g.NewElement().Mul(v.pk.e, c)
+	want.Add(want, r[0])
+	if !g.NewElement().MulGen(s[0]).IsEqual(want) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// A VerifierSession blinds a message for the commitment of a Signer.
+type VerifierSession struct {
+	verifier Verifier
+	r        [2]group.Element
+}
+
+// Blind blinds the commitments of both parallel sessions, and returns the
+// blinded challenges to send to the Signer.
+func (v *VerifierSession) Blind(message []byte) ([]byte, blindsign.VerifierState, error) {
+	pk := v.verifier.pk
+	g := pk.g
+	state := VerifierState{verifier: v.verifier, r: v.r}
+	var blinded []byte
+	for i := range v.r {
+		state.alpha[i] = g.RandomScalar(rand.Reader)
+		state.beta[i] = g.RandomScalar(rand.Reader)
+
+		// R' = R + alpha*G + beta*X
+		rr := g.NewElement().MulGen(state.alpha[i])
+		rr.Add(rr, g.NewElement().Mul(pk.e, state.beta[i]))
+		rr.Add(rr, v.r[i])
+		state.rr[i] = rr
+
+		// c = H(R', X, m) + beta
+		c := challenge(pk, rr, message)
+		c.Add(c, state.beta[i])
+		state.c[i] = c
+		blinded = appendScalars(blinded, c)
+	}
+	return blinded, state, nil
+}
+
+// A VerifierState carries state needed to complete the blind signature
+// protocol as a verifier.
+type VerifierState struct {
+	verifier Verifier
+
+	// The commitments of the Signer, and the blinded commitments
+	r, rr [2]group.Element
+
+	// The blinding factors
+	alpha, beta [2]group.Scalar
+
+	// The blinded challenges sent to the Signer
+	c [2]group.Scalar
+}
+
+// Finalize checks the response of the Signer, and outputs the signature
+// (R', s') if it is valid. Otherwise, it returns an error.
+func (state VerifierState) Finalize(data []byte) ([]byte, error) {
+	g := state.verifier.pk.g
+	if len(data) == 0 || data[0] > 1 {
+		return nil, ErrInvalidMessage
+	}
+	b := data[0]
+	s, err := readScalars(g, data[1:], 1)
+	if err != nil {
+		return nil, err
+	}
+
+	// s*G = R + c*X
+	want := g.NewElement().Mul(state.verifier.pk.e, state.c[b])
+	want.Add(want, state.r[b])
+	if !g.NewElement().MulGen(s[0]).IsEqual(want) {
+		return nil, ErrInvalidSignature
+	}
+
+	sig := g.NewScalar().Add(s[0], state.alpha[b])
+	return appendScalars(appendElements(nil, state.rr[b]), sig), nil
+}
+
+// CopyBlind returns an encoding of the blinding factors of both parallel
+// sessions.
+func (state VerifierState) CopyBlind() []byte {
+	return appendScalars(nil, state.alpha[0], state.beta[0], state.alpha[1], state.beta[1])
+}
+
+// CopySalt returns nil, since the protocol does not use a salt.
+func (state VerifierState) CopySalt() []byte { return nil }
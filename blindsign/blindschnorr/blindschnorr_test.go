@@ -0,0 +1,132 @@
+package blindschnorr
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/cloudflare/circl/blindsign"
+	"github.com/cloudflare/circl/group"
+	"github.com/cloudflare/circl/internal/test"
+)
+
+var groups = []group.Group{group.Ristretto255, group.P256, group.P384}
+
+func TestClauseBlindSchnorr(t *testing.T) {
+	message := []byte("hello world")
+	for _, g := range groups {
+		t.Run(g.(interface{ String() string }).String(), func(t *testing.T) {
+			sk, err := GenerateKey(g, rand.Reader)
+			test.CheckNoErr(t, err, "key generation")
+			verifier := NewVerifier(sk.Public())
+
+			// Run several times so that both parallel sessions get completed.
+			for i := 0; i < 8; i++ {
+				commitment, session := NewSigner(sk).Commit()
+				vs, err := verifier.Session(commitment)
+				test.CheckNoErr(t, err, "session")
+
+				var v blindsign.Verifier = vs
+				var s blindsign.Signer = session
+				blinded, state, err := v.Blind(message)
+				test.CheckNoErr(t, err, "blind")
+				resp, err := s.BlindSign(blinded)
+				test.CheckNoErr(t, err, "blind sign")
+				sig, err := state.Finalize(resp)
+				test.CheckNoErr(t, err, "finalize")
+
+				test.CheckNoErr(t, verifier.Verify(message, sig), "verify")
+				test.CheckIsErr(t, verifier.Verify([]byte("other"), sig), "other message must fail")
+
+				_, err = s.BlindSign(blinded)
+				if err != ErrSessionUsed {
+					test.ReportError(t, err, ErrSessionUsed)
+				}
+				resp[len(resp)-1] ^= 1
+				_, err = state.Finalize(resp)
+				test.CheckIsErr(t, err, "invalid response must fail")
+			}
+
+			other, err := GenerateKey(g, rand.Reader)
+			test.CheckNoErr(t, err, "key generation")
+			commitment, session := NewSigner(other).Commit()
+			vs, err := verifier.Session(commitment)
+			test.CheckNoErr(t, err, "session")
+			blinded, state, err := vs.Blind(message)
+			test.CheckNoErr(t, err, "blind")
+			resp, err := session.BlindSign(blinded)
+			test.CheckNoErr(t, err, "blind sign")
+			_, err = state.Finalize(resp)
+			test.CheckIsErr(t, err, "response from another key must fail")
+		})
+	}
+}
+
+func TestPartiallyBlindSchnorr(t *testing.T) {
+	message, info := []byte("hello world"), []byte("expires 2026-12-31")
+	for _, g := range groups {
+		t.Run(g.(interface{ String() string }).String(), func(t *testing.T) {
+			sk, err := GenerateKey(g, rand.Reader)
+			test.CheckNoErr(t, err, "key generation")
+			verifier := NewPartialVerifier(sk.Public())
+
+			commitment, session := NewPartialSigner(sk).Commit(info)
+			vs, err := verifier.Session(info, commitment)
+			test.CheckNoErr(t, err, "session")
+
+			var v blindsign.Verifier = vs
+			var s blindsign.Signer = session
+			blinded, state, err := v.Blind(message)
+			test.CheckNoErr(t, err, "blind")
+			resp, err := s.BlindSign(blinded)
+			test.CheckNoErr(t, err, "blind sign")
+			sig, err := state.Finalize(resp)
+			test.CheckNoErr(t, err, "finalize")
+
+			test.CheckNoErr(t, verifier.Verify(message, info, sig), "verify")
+			test.CheckIsErr(t, verifier.Verify([]byte("other"), info, sig), "other message must fail")
+			test.CheckIsErr(t, verifier.Verify(message, []byte("other"), sig), "other info must fail")
+			_, err = s.BlindSign(blinded)
+			if err != ErrSessionUsed {
+				test.ReportError(t, err, ErrSessionUsed)
+			}
+
+			// The verifier and the signer must agree on the info.
+			commitment, session = NewPartialSigner(sk).Commit([]byte("other"))
+			vs, err = verifier.Session(info, commitment)
+			test.CheckNoErr(t, err, "session")
+			blinded, state, err = vs.Blind(message)
+			test.CheckNoErr(t, err, "blind")
+			resp, err = session.BlindSign(blinded)
+			test.CheckNoErr(t, err, "blind sign")
+			_, err = state.Finalize(resp)
+			test.CheckIsErr(t, err, "mismatched info must fail")
+		})
+	}
+}
+
+func TestKeys(t *testing.T) {
+	for _, g := range groups {
+		sk, err := GenerateKey(g, rand.Reader)
+		test.CheckNoErr(t, err, "key generation")
+
+		rawSk, err := sk.MarshalBinary()
+		test.CheckNoErr(t, err, "marshal private key")
+		var sk2 PrivateKey
+		test.CheckNoErr(t, sk2.UnmarshalBinary(g, rawSk), "unmarshal private key")
+		if !sk2.Public().e.IsEqual(sk.Public().e) {
+			test.ReportError(t, sk2.Public().e, sk.Public().e)
+		}
+
+		rawPk, err := sk.Public().MarshalBinary()
+		test.CheckNoErr(t, err, "marshal public key")
+		var pk PublicKey
+		test.CheckNoErr(t, pk.UnmarshalBinary(g, rawPk), "unmarshal public key")
+		if !pk.e.IsEqual(sk.Public().e) {
+			test.ReportError(t, pk.e, sk.Public().e)
+		}
+
+		identity, err := g.Identity().MarshalBinaryCompress()
+		test.CheckNoErr(t, err, "marshal identity")
+		test.CheckIsErr(t, pk.UnmarshalBinary(g, identity), "identity public key must fail")
+	}
+}
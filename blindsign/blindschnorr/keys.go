@@ -0,0 +1,110 @@
+package blindschnorr
+
+import (
+	"io"
+
+	"github.com/cloudflare/circl/group"
+)
+
+// PrivateKey is a Schnorr private key.
+type PrivateKey struct {
+	g   group.Group
+	x   group.Scalar
+	pub *PublicKey
+}
+
+// PublicKey is a Schnorr public key.
+type PublicKey struct {
+	g group.Group
+	e group.Element
+}
+
+func (k *PrivateKey) MarshalBinary() ([]byte, error) { return k.x.MarshalBinary() }
+func (k *PublicKey) MarshalBinary() ([]byte, error)  { return k.e.MarshalBinaryCompress() }
+
+func (k *PrivateKey) UnmarshalBinary(g group.Group, data []byte) error {
+	x := g.NewScalar()
+	if uint(len(data)) != g.Params().ScalarLength || x.UnmarshalBinary(data) != nil || x.IsZero() {
+		return ErrInvalidKey
+	}
+	k.g, k.x, k.pub = g, x, nil
+	return nil
+}
+
+func (k *PublicKey) UnmarshalBinary(g group.Group, data []byte) error {
+	e := g.NewElement()
+	if e.UnmarshalBinary(data) != nil || e.IsIdentity() {
+		return ErrInvalidKey
+	}
+	k.g, k.e = g, e
+	return nil
+}
+
+func (k *PrivateKey) Public() *PublicKey {
+	if k.pub == nil {
+		k.pub = &PublicKey{k.g, k.g.NewElement().MulGen(k.x)}
+	}
+
+	return k.pub
+}
+
+// GenerateKey generates a private key for the group.
+func GenerateKey(g group.Group, rnd io.Reader) (*PrivateKey, error) {
+	if rnd == nil {
+		return nil, io.ErrNoProgress
+	}
+
+	return &PrivateKey{g, g.RandomNonZeroScalar(rnd), nil}, nil
+}
+
+// readScalars decodes n scalars from data, which must have exactly the
+// size of n scalars.
+func readScalars(g group.Group, data []byte, n int) ([]group.Scalar, error) {
+	size := int(g.Params().ScalarLength)
+	if len(data) != n*size {
+		return nil, ErrInvalidMessage
+	}
+	scalars := make([]group.Scalar, n)
+	for i := range scalars {
+		scalars[i] = g.NewScalar()
+		if scalars[i].UnmarshalBinary(data[i*size:(i+1)*size]) != nil {
+			return nil, ErrInvalidMessage
+		}
+	}
+	return scalars, nil
+}
+
+// readElements decodes n compressed elements from data, which must have
+// exactly the size of n compressed elements.
+func readElements(g group.Group, data []byte, n int) ([]group.Element, error) {
+	size := int(g.Params().CompressedElementLength)
+	if len(data) != n*size {
+		return nil, ErrInvalidMessage
+	}
+	elements := make([]group.Element, n)
+	for i := range elements {
+		elements[i] = g.NewElement()
+		if elements[i].UnmarshalBinary(data[i*size:(i+1)*size]) != nil {
+			return nil, ErrInvalidMessage
+		}
+	}
+	return elements, nil
+}
+
+// appendScalars appends the encoding of the scalars to b.
+func appendScalars(b []byte, scalars ...group.Scalar) []byte {
+	for _, s := range scalars {
+		enc, _ := s.MarshalBinary()
+		b = append(b, enc...)
+	}
+	return b
+}
+
+// appendElements appends the compressed encoding of the elements to b.
+func appendElements(b []byte, elements ...group.Element) []byte {
+	for _, e := range elements {
+		enc, _ := e.MarshalBinaryCompress()
+		b = append(b, enc...)
+	}
+	return b
+}
@@ -0,0 +1,200 @@
+package blindschnorr
+
+import (
+	"crypto/rand"
+	"sync"
+
+	"github.com/cloudflare/circl/blindsign"
+	"github.com/cloudflare/circl/group"
+)
+
+var (
+	infoDST             = []byte("CIRCL-BlindSchnorr-AO-Info")
+	partialChallengeDST = []byte("CIRCL-BlindSchnorr-AO-Challenge")
+)
+
+// infoElement returns the element Z = H1(info).
+func infoElement(g group.Group, info []byte) group.Element {
+	return g.HashToElement(info, infoDST)
+}
+
+// partialChallenge returns H2(alpha, beta, Z, m).
+func partialChallenge(g group.Group, alpha, beta, z group.Element, message []byte) group.Scalar {
+	input := appendElements(nil, alpha, beta, z)
+	return g.HashToScalar(append(input, message...), partialChallengeDST)
+}
+
+// A PartialSigner represents the Signer in the Abe-Okamoto partially blind
+// Schnorr protocol.
+type PartialSigner struct{ sk *PrivateKey }
+
+// NewPartialSigner creates a new PartialSigner using a private key.
+func NewPartialSigner(sk *PrivateKey) PartialSigner { return PartialSigner{sk} }
+
+// Commit starts a signing session for the public info. It returns the
+// commitment to send to the Verifier, and the session that answers its
+// blinded challenge.
+func (s PartialSigner) Commit(info []byte) ([]byte, *PartialSignerSession) {
+	g := s.sk.g
+	session := &PartialSignerSession{
+		sk: s.sk,
+		u:  g.RandomNonZeroScalar(rand.Reader),
+		s:  g.RandomScalar(rand.Reader),
+		d:  g.RandomScalar(rand.Reader),
+	}
+
+	// a = u*G, b = s*G + d*Z
+	a := g.NewElement().MulGen(session.u)
+	b := g.NewElement().Mul(infoElement(g, info), session.d)
+	b.Add(b, g.NewElement().MulGen(session.s))
+	return appendElements(nil, a, b), session
+}
+
+// A PartialSignerSession holds the nonces of one signing session.
+type PartialSignerSession struct {
+	sk      *PrivateKey
+	mu      sync.Mutex
+	used    bool
+	u, s, d group.Scalar
+}
+
+// BlindSign answers the blinded challenge e of the Verifier with the
+// scalars (r, c, s, d), where c = e - d and r = u - c*x. A session can only
+// answer once.
+func (s *PartialSignerSession) BlindSign(data []byte) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.used {
+		return nil, ErrSessionUsed
+	}
+
+	e, err := readScalars(s.sk.g, data, 1)
+	if err != nil {
+		return nil, err
+	}
+	g := s.sk.g
+	c := g.NewScalar().Sub(e[0], s.d)
+	r := g.NewScalar().Mul(c, s.sk.x)
+	r.Sub(s.u, r)
+
+	resp := appendScalars(nil, r, c, s.s, s.d)
+	s.used = true
+	s.u, s.s, s.d = nil, nil, nil
+	return resp, nil
+}
+
+// A PartialVerifier represents a Verifier in the Abe-Okamoto partially
+// blind Schnorr protocol.
+type PartialVerifier struct{ pk *PublicKey }
+
+// NewPartialVerifier creates a new PartialVerifier for the public key of a
+// Signer.
+func NewPartialVerifier(pk *PublicKey) PartialVerifier { return PartialVerifier{pk} }
+
+// Session returns a session for the public info and the commitment of a
+// PartialSignerSession.
+func (v PartialVerifier) Session(info, commitment []byte) (*PartialVerifierSession, error) {
+	ab, err := readElements(v.pk.g, commitment, 2)
+	if err != nil {
+		return nil, err
+	}
+	return &PartialVerifierSession{v, infoElement(v.pk.g, info), ab[0], ab[1]}, nil
+}
+
+// Verify checks that the signature (rho, omega, sigma, delta) is valid for
+// the message and the public info, that is,
+//
+//	omega + delta = H2(rho*G + omega*X, sigma*G + delta*Z, Z, m).
+func (v PartialVerifier) Verify(message, info, signature []byte) error {
+	g := v.pk.g
+	sig, err := readScalars(g, signature, 4)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	return v.verify(infoElement(g, info), message, sig)
+}
+
+func (v PartialVerifier) verify(z group.Element, message []byte, sig []group.Scalar) error {
+	g := v.pk.g
+	rho, omega, sigma, delta := sig[0], sig[1], sig[2], sig[3]
+
+	alpha := g.NewElement().Mul(v.pk.e, omega)
+	alpha.Add(alpha, g.NewElement().MulGen(rho))
+	beta := g.NewElement().Mul(z, delta)
+	beta.Add(beta, g.NewElement().MulGen(sigma))
+
+	sum := g.NewScalar().Add(omega, delta)
+	if !sum.IsEqual(partialChallenge(g, alpha, beta, z, message)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// A PartialVerifierSession blinds a message for the commitment of a
+// PartialSigner.
+type PartialVerifierSession struct {
+	verifier PartialVerifier
+	z, a, b  group.Element
+}
+
+// Blind blinds the commitment of the Signer, and returns the blinded
+// challenge to send to the Signer.
+func (v *PartialVerifierSession) Blind(message []byte) ([]byte, blindsign.VerifierState, error) {
+	pk := v.verifier.pk
+	g := pk.g
+	state := PartialVerifierState{verifier: v.verifier, z: v.z, message: append([]byte{}, message...)}
+	for i := range state.t {
+		state.t[i] = g.RandomScalar(rand.Reader)
+	}
+
+	// alpha = a + t1*G + t2*X, beta = b + t3*G + t4*Z
+	alpha := g.NewElement().MulGen(state.t[0])
+	alpha.Add(alpha, g.NewElement().Mul(pk.e, state.t[1]))
+	alpha.Add(alpha, v.a)
+	beta := g.NewElement().MulGen(state.t[2])
+	beta.Add(beta, g.NewElement().Mul(v.z, state.t[3]))
+	beta.Add(beta, v.b)
+
+	// e = H2(alpha, beta, Z, m) - t2 - t4
+	e := partialChallenge(g, alpha, beta, v.z, message)
+	e.Sub(e, state.t[1])
+	e.Sub(e, state.t[3])
+	return appendScalars(nil, e), state, nil
+}
+
+// A PartialVerifierState carries state needed to complete the partially
+// blind signature protocol as a verifier.
+type PartialVerifierState struct {
+	verifier PartialVerifier
+
+	// The info element Z and the message being signed
+	z       group.Element
+	message []byte
+
+	// The blinding factors t1, t2, t3 and t4
+	t [4]group.Scalar
+}
+
+// Finalize removes the blind from the response (r, c, s, d) of the Signer,
+// and outputs the signature (r+t1, c+t2, s+t3, d+t4) if it is valid.
+// Otherwise, it returns an error.
+func (state PartialVerifierState) Finalize(data []byte) ([]byte, error) {
+	g := state.verifier.pk.g
+	resp, err := readScalars(g, data, 4)
+	if err != nil {
+		return nil, err
+	}
+	for i := range resp {
+		resp[i].Add(resp[i], state.t[i])
+	}
+	if err := state.verifier.verify(state.z, state.message, resp); err != nil {
+		return nil, err
+	}
+	return appendScalars(nil, resp...), nil
+}
+
+// CopyBlind returns an encoding of the blinding factors.
+func (state PartialVerifierState) CopyBlind() []byte { return appendScalars(nil, state.t[:]...) }
+
+// CopySalt returns nil, since the protocol does not use a salt.
+func (state PartialVerifierState) CopySalt() []byte { return nil }
@@ -5,6 +5,9 @@
 // and the other (the client) holds the message input. Blindness
 // ensures that the server does not learn anything about the client's
 // input during the BlindSign step.
+//
+// The subpackages blindrsa, blindbls and blindschnorr implement blind RSA,
+// blind BLS and blind Schnorr signatures, respectively.
 package blindsign
 
 // A Verifier represents a specific instance of a blind signature verifier.
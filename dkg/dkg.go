@@ -0,0 +1,434 @@
+// Package dkg provides distributed key generation for threshold schemes over
+// prime-order groups.
+//
+// The protocol of Gennaro, Jarecki, Krawczyk, and Rabin [1] lets n
+// participants generate a secret key x shared with threshold t, so that any
+// t+1 participants can use it, without any party ever knowing x. Each
+// participant deals a random secret with Pedersen verifiable secret
+// sharing, and the key is the sum of the secrets of the qualified dealers.
+// The Feldman commitments to the secrets are only revealed once the set of
+// qualified dealers is fixed, so that no participant can bias the key.
+//
+// A Participant runs the six rounds of the protocol in order:
+//
+//  1. Round1 deals a secret: it returns a Pedersen commitment to broadcast
+//     and a share to send privately to each participant.
+//  2. Round2 verifies the received shares, and returns the complaints
+//     against dealers with invalid shares.
+//  3. Round3 answers the complaints against the participant by revealing
+//     the shares of the complainers.
+//  4. Round4 disqualifies the dealers with more than t complaints or with
+//     invalid answers, and returns the Feldman commitment to broadcast.
+//  5. Round5 verifies the received shares against the Feldman commitments,
+//     and reveals the shares that do not match.
+//  6. Round6 reveals the shares of the dealers with valid complaints, whose
+//     secrets are then reconstructed.
+//
+// Finalize returns the KeyShare of the participant. Each round takes the
+// messages of the previous round from all the participants; missing
+// messages count as misbehavior of their sender. Messages are broadcast,
+// except the shares of round 1, which must be sent over private and
+// authenticated channels. In the absence of faults, the messages of rounds
+// 2, 3, 5, and 6 are empty, but they must still be exchanged.
+//
+// Participants are identified by the integers 1 to n, which are the IDs of
+// their key shares as scalars, so key shares can be combined with Lagrange
+// interpolation of package math/polynomial.
+//
+// References
+//
+//	[1] Gennaro, Jarecki, Krawczyk, Rabin, Secure distributed key generation for discrete-log based cryptosystems. https://doi.org/10.1007/s00145-006-0347-3
+package dkg
+
+import (
+	"errors"
+	"io"
+	"sort"
+
+	"github.com/cloudflare/circl/group"
+	"github.com/cloudflare/circl/math/polynomial"
+	"github.com/cloudflare/circl/secretsharing"
+)
+
+var (
+	// ErrInvalidParameters is returned when the threshold, the number of
+	// participants, or the ID are invalid.
+	ErrInvalidParameters = errors.New("dkg: invalid parameters")
+	// ErrInvalidRound is returned when a round is run out of order.
+	ErrInvalidRound = errors.New("dkg: invalid round")
+	// ErrInvalidMessage is returned when a message cannot be decoded.
+	ErrInvalidMessage = errors.New("dkg: invalid message")
+	// ErrFailed is returned when too many participants misbehaved to
+	// complete the protocol.
+	ErrFailed = errors.New("dkg: not enough qualified participants")
+)
+
+// Participant runs the distributed key generation.
+type Participant struct {
+	g       group.Group
+	h       group.Element
+	t, n    uint
+	id      uint
+	round   int
+	dealing secretsharing.PedersenSecretSharing
+
+	// pedersen and feldman hold the commitments of the dealers.
+	pedersen map[uint]secretsharing.SecretCommitment
+	feldman  map[uint]secretsharing.SecretCommitment
+	// shares holds the shares received from the dealers.
+	shares     map[uint]secretsharing.PedersenShare
+	complaints map[uint][]uint
+	qualified  []uint
+	accused    map[uint]bool
+}
+
+// NewParticipant returns the participant with the ID among n participants,
+// which generate a key with threshold t. IDs go from 1 to n, and t must be
+// below n.
+func NewParticipant(g group.Group, t, n, id uint) (*Participant, error) {
+	if n > 0xFFFF || t >= n || id == 0 || id > n {
+		return nil, ErrInvalidParameters
+	}
+	return &Participant{
+		g:          g,
+		h:          g.HashToElement([]byte("H"), []byte("CIRCL-DKG-Pedersen")),
+		t:          t,
+		n:          n,
+		id:         id,
+		pedersen:   make(map[uint]secretsharing.SecretCommitment),
+		feldman:    make(map[uint]secretsharing.SecretCommitment),
+		shares:     make(map[uint]secretsharing.PedersenShare),
+		complaints: make(map[uint][]uint),
+		accused:    make(map[uint]bool),
+	}, nil
+}
+
+// ID returns the ID of the participant.
+func (p *Participant) ID() uint { return p.id }
+
+func (p *Participant) scalar(id uint) group.Scalar { return p.g.NewScalar().SetUint64(uint64(id)) }
+
+func (p *Participant) next(round int) error {
+	if p.round != round-1 {
+		return ErrInvalidRound
+	}
+	p.round = round
+	return nil
+}
+
+func (p *Participant) validPedersen(m *ShareMessage) bool {
+	c, ok := p.pedersen[m.From]
+	return ok && m.Share != nil && m.Blinding != nil && m.To >= 1 && m.To <= p.n &&
+		secretsharing.VerifyPedersen(p.t, p.h, p.pedersenShare(m), c)
+}
+
+func (p *Participant) pedersenShare(m *ShareMessage) secretsharing.PedersenShare {
+	return secretsharing.PedersenShare{
+		Share:    secretsharing.Share{ID: p.scalar(m.To), Value: m.Share},
+		Blinding: m.Blinding,
+	}
+}
+
+// Round1 deals a random secret. It returns the commitment to broadcast, and
+// the shares to send privately to the other participants.
+func (p *Participant) Round1(rnd io.Reader) (*CommitmentMessage, []*ShareMessage, error) {
+	if rnd == nil {
+		return nil, nil, io.ErrNoProgress
+	}
+	if err := p.next(1); err != nil {
+		return nil, nil, err
+	}
+	p.dealing = secretsharing.NewPedersen(rnd, p.t, p.g.RandomScalar(rnd), p.h)
+	commitment := p.dealing.CommitSecret()
+	p.pedersen[p.id] = commitment
+
+	var shares []*ShareMessage
+	for i, s := range p.dealing.Share(p.n) {
+		m := &ShareMessage{p.id, uint(i + 1), s.Value, s.Blinding}
+		if m.To == p.id {
+			p.shares[p.id] = s
+		} else {
+			shares = append(shares, m)
+		}
+	}
+	return &CommitmentMessage{p.id, commitment}, shares, nil
+}
+
+// Round2 takes the commitments broadcast and the shares sent to the
+// participant in round 1, and returns the complaints to broadcast.
+func (p *Participant) Round2(commitments []*CommitmentMessage, shares []*ShareMessage) (*ComplaintMessage, error) {
+	if err := p.next(2); err != nil {
+		return nil, err
+	}
+	for _, m := range commitments {
+		_, dup := p.pedersen[m.From]
+		if !dup && m.From >= 1 && m.From <= p.n && len(m.Commitment) == int(p.t+1) {
+			p.pedersen[m.From] = m.Commitment
+		}
+	}
+	for _, m := range shares {
+		_, dup := p.shares[m.From]
+		if !dup && m.To == p.id && p.validPedersen(m) {
+			p.shares[m.From] = p.pedersenShare(m)
+		}
+	}
+
+	complaint := &ComplaintMessage{From: p.id}
+	for _, dealer := range sortedDealers(p.pedersen) {
+		if _, ok := p.shares[dealer]; !ok {
+			complaint.Against = append(complaint.Against, dealer)
+			p.complaints[dealer] = append(p.complaints[dealer], p.id)
+		}
+	}
+	return complaint, nil
+}
+
+// Round3 takes the complaints broadcast in round 2, and returns the
+// answers of the participant to the complaints against it.
+func (p *Participant) Round3(complaints []*ComplaintMessage) (*RevealMessage, error) {
+	if err := p.next(3); err != nil {
+		return nil, err
+	}
+	seen := map[uint]bool{p.id: true}
+	for _, m := range complaints {
+		if seen[m.From] || m.From < 1 || m.From > p.n {
+			continue
+		}
+		seen[m.From] = true
+		against := make(map[uint]bool)
+		for _, dealer := range m.Against {
+			if _, ok := p.pedersen[dealer]; ok && !against[dealer] {
+				against[dealer] = true
+				p.complaints[dealer] = append(p.complaints[dealer], m.From)
+			}
+		}
+	}
+
+	answers := &RevealMessage{From: p.id}
+	for _, j := range p.complaints[p.id] {
+		s := p.dealing.ShareWithID(p.scalar(j))
+		answers.Shares = append(answers.Shares, &ShareMessage{p.id, j, s.Value, s.Blinding})
+	}
+	return answers, nil
+}
+
+// Round4 takes the answers broadcast in round 3, and returns the Feldman
+// commitment to broadcast.
+func (p *Participant) Round4(answers []*RevealMessage) (*CommitmentMessage, error) {
+	if err := p.next(4); err != nil {
+		return nil, err
+	}
+	byDealer := make(map[uint]*RevealMessage)
+	for _, m := range answers {
+		if _, dup := byDealer[m.From]; !dup {
+			byDealer[m.From] = m
+		}
+	}
+
+	for _, dealer := range sortedDealers(p.pedersen) {
+		complainers := p.complaints[dealer]
+		if len(complainers) > int(p.t) {
+			continue
+		}
+		qualified := true
+		for _, j := range complainers {
+			var answer *ShareMessage
+			if dealer == p.id {
+				s := p.dealing.ShareWithID(p.scalar(j))
+				answer = &ShareMessage{p.id, j, s.Value, s.Blinding}
+			} else if m, ok := byDealer[dealer]; ok {
+				answer = findShare(m.Shares, dealer, j)
+			}
+			if answer == nil || !p.validPedersen(answer) {
+				qualified = false
+				break
+			}
+			if j == p.id {
+				p.shares[dealer] = p.pedersenShare(answer)
+			}
+		}
+		if qualified {
+			p.qualified = append(p.qualified, dealer)
+		}
+	}
+	if len(p.qualified) <= int(p.t) {
+		return nil, ErrFailed
+	}
+
+	commitment := p.dealing.CommitSecretFeldman()
+	p.feldman[p.id] = commitment
+	return &CommitmentMessage{p.id, commitment}, nil
+}
+
+// Round5 takes the Feldman commitments broadcast in round 4, and returns
+// the complaints against the dealers whose shares do not match their
+// commitments.
+func (p *Participant) Round5(commitments []*CommitmentMessage) (*RevealMessage, error) {
+	if err := p.next(5); err != nil {
+		return nil, err
+	}
+	for _, m := range commitments {
+		_, dup := p.feldman[m.From]
+		if !dup && p.isQualified(m.From) && len(m.Commitment) == int(p.t+1) {
+			p.feldman[m.From] = m.Commitment
+		}
+	}
+
+	complaints := &RevealMessage{From: p.id}
+	for _, dealer := range p.qualified {
+		c, ok := p.feldman[dealer]
+		if !ok {
+			p.accused[dealer] = true
+			continue
+		}
+		s := p.shares[dealer]
+		if !secretsharing.Verify(p.t, s.Share, c) {
+			p.accused[dealer] = true
+			complaints.Shares = append(complaints.Shares, &ShareMessage{dealer, p.id, s.Value, s.Blinding})
+		}
+	}
+	return complaints, nil
+}
+
+// Round6 takes the complaints broadcast in round 5, and returns the shares
+// of the accused dealers to broadcast for their reconstruction.
+func (p *Participant) Round6(complaints []*RevealMessage) (*RevealMessage, error) {
+	if err := p.next(6); err != nil {
+		return nil, err
+	}
+	for _, m := range complaints {
+		for _, s := range m.Shares {
+			// A complaint is valid if the share matches the Pedersen
+			// commitment, but not the Feldman commitment.
+			c, ok := p.feldman[s.From]
+			if ok && s.To == m.From && p.validPedersen(s) &&
+				!secretsharing.Verify(p.t, p.pedersenShare(s).Share, c) {
+				p.accused[s.From] = true
+			}
+		}
+	}
+
+	reveal := &RevealMessage{From: p.id}
+	for _, dealer := range p.qualified {
+		if !p.accused[dealer] {
+			continue
+		}
+		s := p.shares[dealer]
+		reveal.Shares = append(reveal.Shares, &ShareMessage{dealer, p.id, s.Value, s.Blinding})
+	}
+	return reveal, nil
+}
+
+// KeyShare is the output of the distributed key generation for a
+// participant.
+type KeyShare struct {
+	// ID is the ID of the participant.
+	ID uint
+	// Share is the share of the secret key.
+	Share group.Scalar
+	// PublicKey is the public key x*G of the secret key x.
+	PublicKey group.Element
+	// VerificationShares holds the elements Share*G of the key shares of
+	// the participants, where the i-th element is for the ID i+1.
+	VerificationShares []group.Element
+	// Qualified holds the IDs of the qualified dealers, whose secrets sum
+	// to the secret key.
+	Qualified []uint
+}
+
+// SecretShare returns the key share as a share of the secret key.
+func (k *KeyShare) SecretShare() secretsharing.Share {
+	g := k.Share.Group()
+	return secretsharing.Share{ID: g.NewScalar().SetUint64(uint64(k.ID)), Value: k.Share.Copy()}
+}
+
+// Finalize takes the shares broadcast in round 6, and returns the key share
+// of the participant.
+func (p *Participant) Finalize(reveals []*RevealMessage) (*KeyShare, error) {
+	if err := p.next(7); err != nil {
+		return nil, err
+	}
+
+	// Reconstruct the polynomials of the accused dealers from the shares
+	// that match their Pedersen commitments.
+	reconstructed := make(map[uint]polynomial.LagrangePolynomial)
+	for dealer := range p.accused {
+		x, y := []group.Scalar{p.scalar(p.id)}, []group.Scalar{p.shares[dealer].Value}
+		seen := map[uint]bool{p.id: true}
+		for _, m := range reveals {
+			s := findShare(m.Shares, dealer, m.From)
+			if s != nil && !seen[s.To] && len(x) <= int(p.t) && p.validPedersen(s) {
+				seen[s.To] = true
+				x, y = append(x, p.scalar(s.To)), append(y, s.Share)
+			}
+		}
+		if len(x) <= int(p.t) {
+			return nil, ErrFailed
+		}
+		reconstructed[dealer] = polynomial.NewLagrangePolynomial(x, y)
+	}
+
+	// commitment returns f_dealer(id)*G.
+	commitment := func(dealer, id uint) group.Element {
+		if l, ok := reconstructed[dealer]; ok {
+			return p.g.NewElement().MulGen(l.Evaluate(p.scalar(id)))
+		}
+		c := p.feldman[dealer]
+		sum := p.g.NewElement().Set(c[len(c)-1])
+		x := p.scalar(id)
+		for i := len(c) - 2; i >= 0; i-- {
+			sum.Mul(sum, x)
+			sum.Add(sum, c[i])
+		}
+		return sum
+	}
+
+	key := &KeyShare{
+		ID:                 p.id,
+		Share:              p.g.NewScalar(),
+		PublicKey:          p.g.Identity(),
+		VerificationShares: make([]group.Element, p.n),
+		Qualified:          append([]uint{}, p.qualified...),
+	}
+	for i := range key.VerificationShares {
+		key.VerificationShares[i] = p.g.Identity()
+	}
+	for _, dealer := range p.qualified {
+		key.Share.Add(key.Share, p.shares[dealer].Value)
+		key.PublicKey.Add(key.PublicKey, commitment(dealer, 0))
+		for i := range key.VerificationShares {
+			key.VerificationShares[i].Add(key.VerificationShares[i], commitment(dealer, uint(i+1)))
+		}
+	}
+	if !p.g.NewElement().MulGen(key.Share).IsEqual(key.VerificationShares[p.id-1]) {
+		return nil, ErrFailed
+	}
+	return key, nil
+}
+
+func (p *Participant) isQualified(id uint) bool {
+	for _, q := range p.qualified {
+		if q == id {
+			return true
+		}
+	}
+	return false
+}
+
+func findShare(shares []*ShareMessage, from, to uint) *ShareMessage {
+	for _, s := range shares {
+		if s.From == from && s.To == to {
+			return s
+		}
+	}
+	return nil
+}
+
+func sortedDealers(m map[uint]secretsharing.SecretCommitment) []uint {
+	keys := make([]uint, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
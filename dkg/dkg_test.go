@@ -0,0 +1,234 @@
+package dkg
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/cloudflare/circl/group"
+	"github.com/cloudflare/circl/internal/test"
+	"github.com/cloudflare/circl/math/polynomial"
+	"github.com/cloudflare/circl/secretsharing"
+)
+
+// faults alters the messages of misbehaving participants.
+type faults struct {
+	// share alters the private share of round 1 sent from a dealer to a
+	// participant.
+	share func(m *ShareMessage)
+	// feldman alters the Feldman commitment of round 4.
+	feldman func(m *CommitmentMessage) *CommitmentMessage
+}
+
+// roundTrip encodes and decodes a message.
+func roundTrip(t *testing.T, g group.Group, m interface {
+	MarshalBinary() ([]byte, error)
+}, out interface{}) {
+	t.Helper()
+	enc, err := m.MarshalBinary()
+	test.CheckNoErr(t, err, "marshal message")
+	switch out := out.(type) {
+	case *CommitmentMessage:
+		test.CheckNoErr(t, out.UnmarshalBinary(g, enc), "unmarshal message")
+	case *ShareMessage:
+		test.CheckNoErr(t, out.UnmarshalBinary(g, enc), "unmarshal message")
+	case *ComplaintMessage:
+		test.CheckNoErr(t, out.UnmarshalBinary(enc), "unmarshal message")
+	case *RevealMessage:
+		test.CheckNoErr(t, out.UnmarshalBinary(g, enc), "unmarshal message")
+	}
+}
+
+func reveals(t *testing.T, g group.Group, in []*RevealMessage) []*RevealMessage {
+	out := make([]*RevealMessage, len(in))
+	for i := range in {
+		out[i] = new(RevealMessage)
+		roundTrip(t, g, in[i], out[i])
+	}
+	return out
+}
+
+func run(t *testing.T, g group.Group, threshold, n uint, f faults) []*KeyShare {
+	t.Helper()
+	parties := make([]*Participant, n)
+	for i := range parties {
+		var err error
+		parties[i], err = NewParticipant(g, threshold, n, uint(i+1))
+		test.CheckNoErr(t, err, "new participant")
+	}
+
+	commitments := make([]*CommitmentMessage, n)
+	private := make([][]*ShareMessage, n)
+	for i, p := range parties {
+		c, shares, err := p.Round1(rand.Reader)
+		test.CheckNoErr(t, err, "round 1")
+		commitments[i] = new(CommitmentMessage)
+		roundTrip(t, g, c, commitments[i])
+		for _, s := range shares {
+			m := new(ShareMessage)
+			roundTrip(t, g, s, m)
+			if f.share != nil {
+				f.share(m)
+			}
+			private[m.To-1] = append(private[m.To-1], m)
+		}
+	}
+
+	complaints := make([]*ComplaintMessage, n)
+	for i, p := range parties {
+		c, err := p.Round2(commitments, private[i])
+		test.CheckNoErr(t, err, "round 2")
+		complaints[i] = new(ComplaintMessage)
+		roundTrip(t, g, c, complaints[i])
+	}
+
+	answers := make([]*RevealMessage, n)
+	for i, p := range parties {
+		var err error
+		answers[i], err = p.Round3(complaints)
+		test.CheckNoErr(t, err, "round 3")
+	}
+	answers = reveals(t, g, answers)
+
+	for i, p := range parties {
+		c, err := p.Round4(answers)
+		test.CheckNoErr(t, err, "round 4")
+		if f.feldman != nil {
+			c = f.feldman(c)
+		}
+		commitments[i] = new(CommitmentMessage)
+		roundTrip(t, g, c, commitments[i])
+	}
+
+	accusations := make([]*RevealMessage, n)
+	for i, p := range parties {
+		var err error
+		accusations[i], err = p.Round5(commitments)
+		test.CheckNoErr(t, err, "round 5")
+	}
+	accusations = reveals(t, g, accusations)
+
+	shares := make([]*RevealMessage, n)
+	for i, p := range parties {
+		var err error
+		shares[i], err = p.Round6(accusations)
+		test.CheckNoErr(t, err, "round 6")
+	}
+	shares = reveals(t, g, shares)
+
+	keys := make([]*KeyShare, n)
+	for i, p := range parties {
+		var err error
+		keys[i], err = p.Finalize(shares)
+		test.CheckNoErr(t, err, "finalize")
+	}
+	return keys
+}
+
+func checkKeys(t *testing.T, threshold uint, keys []*KeyShare, qualified int) {
+	t.Helper()
+	g := keys[0].Share.Group()
+	for _, k := range keys {
+		test.CheckOk(k.PublicKey.IsEqual(keys[0].PublicKey), "public keys must agree", t)
+		if len(k.Qualified) != qualified {
+			test.ReportError(t, len(k.Qualified), qualified, k.ID)
+		}
+		for i := range k.VerificationShares {
+			test.CheckOk(k.VerificationShares[i].IsEqual(keys[i].VerificationShares[i]), "verification shares must agree", t)
+		}
+	}
+
+	// Any t+1 key shares recover the secret key.
+	shares := make([]secretsharing.Share, len(keys))
+	for i := range keys {
+		shares[len(keys)-1-i] = keys[i].SecretShare()
+	}
+	secret, err := secretsharing.Recover(threshold, shares)
+	test.CheckNoErr(t, err, "recover secret key")
+	test.CheckOk(g.NewElement().MulGen(secret).IsEqual(keys[0].PublicKey), "wrong secret key", t)
+	_, err = secretsharing.Recover(threshold, shares[:threshold])
+	test.CheckIsErr(t, err, "must not recover with t shares")
+
+	// The verification shares interpolate to the public key.
+	x := make([]group.Scalar, threshold+1)
+	for i := range x {
+		x[i] = g.NewScalar().SetUint64(uint64(i + 1))
+	}
+	pk := g.Identity()
+	for i := range x {
+		l := polynomial.LagrangeBase(uint(i), x, g.NewScalar())
+		pk.Add(pk, g.NewElement().Mul(keys[0].VerificationShares[i], l))
+	}
+	test.CheckOk(pk.IsEqual(keys[0].PublicKey), "verification shares must interpolate to the public key", t)
+}
+
+func TestDKG(t *testing.T) {
+	g := group.Ristretto255
+	const threshold, n = 2, 5
+
+	t.Run("Honest", func(t *testing.T) {
+		checkKeys(t, threshold, run(t, g, threshold, n, faults{}), n)
+	})
+
+	t.Run("AnsweredComplaint", func(t *testing.T) {
+		// Dealer 1 sends a bad share to participant 2, but answers the
+		// complaint with the right share.
+		keys := run(t, g, threshold, n, faults{share: func(m *ShareMessage) {
+			if m.From == 1 && m.To == 2 {
+				m.Share = g.RandomScalar(rand.Reader)
+			}
+		}})
+		checkKeys(t, threshold, keys, n)
+	})
+
+	t.Run("Disqualified", func(t *testing.T) {
+		// Dealer 3 sends bad shares to more than t participants.
+		keys := run(t, g, threshold, n, faults{share: func(m *ShareMessage) {
+			if m.From == 3 && m.To != 5 {
+				m.Blinding = g.RandomScalar(rand.Reader)
+			}
+		}})
+		checkKeys(t, threshold, keys, n-1)
+		for _, id := range keys[0].Qualified {
+			if id == 3 {
+				test.ReportError(t, keys[0].Qualified, "without 3")
+			}
+		}
+	})
+
+	t.Run("Reconstructed", func(t *testing.T) {
+		// Dealer 4 broadcasts a bad Feldman commitment, and dealer 5 does
+		// not broadcast one. Their secrets are reconstructed.
+		keys := run(t, g, threshold, n, faults{feldman: func(m *CommitmentMessage) *CommitmentMessage {
+			switch m.From {
+			case 4:
+				m.Commitment[1] = g.RandomElement(rand.Reader)
+			case 5:
+				return &CommitmentMessage{From: 5}
+			}
+			return m
+		}})
+		checkKeys(t, threshold, keys, n)
+	})
+}
+
+func TestInvalid(t *testing.T) {
+	g := group.P256
+	for _, v := range [][3]uint{{3, 3, 1}, {1, 3, 0}, {1, 3, 4}} {
+		_, err := NewParticipant(g, v[0], v[1], v[2])
+		if err != ErrInvalidParameters {
+			test.ReportError(t, err, ErrInvalidParameters, v)
+		}
+	}
+
+	p, err := NewParticipant(g, 1, 3, 1)
+	test.CheckNoErr(t, err, "new participant")
+	_, err = p.Round2(nil, nil)
+	if err != ErrInvalidRound {
+		test.ReportError(t, err, ErrInvalidRound)
+	}
+
+	m := new(RevealMessage)
+	if err := m.UnmarshalBinary(g, []byte{0, 1, 0, 1, 0}); err != ErrInvalidMessage {
+		test.ReportError(t, err, ErrInvalidMessage)
+	}
+}
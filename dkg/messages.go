@@ -0,0 +1,165 @@
+package dkg
+
+import (
+	"encoding/binary"
+
+	"github.com/cloudflare/circl/group"
+)
+
+// CommitmentMessage is broadcast by a dealer with the commitment to its
+// polynomial: the Pedersen commitment in round 1 and the Feldman commitment
+// in round 4.
+type CommitmentMessage struct {
+	From       uint
+	Commitment []group.Element
+}
+
+// ShareMessage holds the share, and its blinding, that a dealer sends to a
+// participant. It is sent privately in round 1, and revealed in later
+// rounds.
+type ShareMessage struct {
+	From, To uint
+	Share    group.Scalar
+	Blinding group.Scalar
+}
+
+// ComplaintMessage is broadcast in round 2 with the dealers against which
+// a participant complains.
+type ComplaintMessage struct {
+	From    uint
+	Against []uint
+}
+
+// RevealMessage is broadcast with revealed shares: the answers of a dealer
+// to complaints in round 3, the shares that fail the Feldman commitments in
+// round 5, and the shares of accused dealers in round 6.
+type RevealMessage struct {
+	From   uint
+	Shares []*ShareMessage
+}
+
+func (m *CommitmentMessage) MarshalBinary() ([]byte, error) {
+	b := appendUint16(appendUint16(nil, m.From), uint(len(m.Commitment)))
+	for _, c := range m.Commitment {
+		enc, err := c.MarshalBinaryCompress()
+		if err != nil {
+			return nil, err
+		}
+		b = append(b, enc...)
+	}
+	return b, nil
+}
+
+func (m *CommitmentMessage) UnmarshalBinary(g group.Group, data []byte) error {
+	size := int(g.Params().CompressedElementLength)
+	if len(data) < 4 {
+		return ErrInvalidMessage
+	}
+	from, n := readUint16(data), readUint16(data[2:])
+	data = data[4:]
+	if len(data) != int(n)*size {
+		return ErrInvalidMessage
+	}
+	c := make([]group.Element, n)
+	for i := range c {
+		c[i] = g.NewElement()
+		if c[i].UnmarshalBinary(data[i*size:(i+1)*size]) != nil {
+			return ErrInvalidMessage
+		}
+	}
+	*m = CommitmentMessage{from, c}
+	return nil
+}
+
+func shareMessageSize(g group.Group) int { return 4 + 2*int(g.Params().ScalarLength) }
+
+func (m *ShareMessage) MarshalBinary() ([]byte, error) {
+	b := appendUint16(appendUint16(nil, m.From), m.To)
+	for _, s := range []group.Scalar{m.Share, m.Blinding} {
+		enc, err := s.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		b = append(b, enc...)
+	}
+	return b, nil
+}
+
+func (m *ShareMessage) UnmarshalBinary(g group.Group, data []byte) error {
+	size := int(g.Params().ScalarLength)
+	if len(data) != shareMessageSize(g) {
+		return ErrInvalidMessage
+	}
+	share, blinding := g.NewScalar(), g.NewScalar()
+	if share.UnmarshalBinary(data[4:4+size]) != nil || blinding.UnmarshalBinary(data[4+size:]) != nil {
+		return ErrInvalidMessage
+	}
+	*m = ShareMessage{readUint16(data), readUint16(data[2:]), share, blinding}
+	return nil
+}
+
+func (m *ComplaintMessage) MarshalBinary() ([]byte, error) {
+	b := appendUint16(appendUint16(nil, m.From), uint(len(m.Against)))
+	for _, id := range m.Against {
+		b = appendUint16(b, id)
+	}
+	return b, nil
+}
+
+func (m *ComplaintMessage) UnmarshalBinary(data []byte) error {
+	if len(data) < 4 {
+		return ErrInvalidMessage
+	}
+	from, n := readUint16(data), readUint16(data[2:])
+	data = data[4:]
+	if len(data) != 2*int(n) {
+		return ErrInvalidMessage
+	}
+	against := make([]uint, n)
+	for i := range against {
+		against[i] = readUint16(data[2*i:])
+	}
+	*m = ComplaintMessage{from, against}
+	return nil
+}
+
+func (m *RevealMessage) MarshalBinary() ([]byte, error) {
+	b := appendUint16(appendUint16(nil, m.From), uint(len(m.Shares)))
+	for _, s := range m.Shares {
+		enc, err := s.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		b = append(b, enc...)
+	}
+	return b, nil
+}
+
+func (m *RevealMessage) UnmarshalBinary(g group.Group, data []byte) error {
+	size := shareMessageSize(g)
+	if len(data) < 4 {
+		return ErrInvalidMessage
+	}
+	from, n := readUint16(data), readUint16(data[2:])
+	data = data[4:]
+	if len(data) != int(n)*size {
+		return ErrInvalidMessage
+	}
+	shares := make([]*ShareMessage, n)
+	for i := range shares {
+		shares[i] = new(ShareMessage)
+		if err := shares[i].UnmarshalBinary(g, data[i*size:(i+1)*size]); err != nil {
+			return err
+		}
+	}
+	*m = RevealMessage{from, shares}
+	return nil
+}
+
+func appendUint16(b []byte, n uint) []byte {
+	var buf [2]byte
+	binary.BigEndian.PutUint16(buf[:], uint16(n))
+	return append(b, buf[:]...)
+}
+
+func readUint16(b []byte) uint { return uint(binary.BigEndian.Uint16(b)) }
@@ -365,8 +365,16 @@ func (g *G1) Encode(input, dst []byte) {
 // an optional domain separation tag. This function is safe to use when a
 // random oracle returning points in G1 be required.
 func (g *G1) Hash(input, dst []byte) {
+	g.HashWithExpander(expander.NewExpanderMD(crypto.SHA256, dst), input)
+}
+
+// HashWithExpander produces an element of G1 from the hash of an input byte
+// string, using the given expander (which holds the domain separation tag)
+// to produce uniform bytes. This allows hash-to-curve suites other than
+// BLS12381G1_XMD:SHA-256_SSWU_RO_, such as BLS12381G1_XOF:SHAKE-256_SSWU_RO_.
+func (g *G1) HashWithExpander(exp expander.Expander, input []byte) {
 	const L = 64
-	pseudo := expander.NewExpanderMD(crypto.SHA256, dst).Expand(input, 2*L)
+	pseudo := exp.Expand(input, 2*L)
 
 	var u0, u1 ff.Fp
 	u0.SetBytes(pseudo[0*L : 1*L])
@@ -0,0 +1,265 @@
+package ech
+
+import (
+	"github.com/cloudflare/circl/hpke"
+	"golang.org/x/crypto/cryptobyte"
+)
+
+// Version is the version of ECHConfig implemented by this package.
+const Version uint16 = 0xfe0d
+
+// CipherSuite is a pair of HPKE KDF and AEAD algorithms.
+type CipherSuite struct {
+	KDF  hpke.KDF
+	AEAD hpke.AEAD
+}
+
+func (s CipherSuite) isValid() bool {
+	return s.KDF.IsValid() && s.AEAD.IsValid() && s.AEAD != hpke.AEAD_ExportOnly
+}
+
+// Extension is an ECHConfig extension.
+type Extension struct {
+	Type uint16
+	Data []byte
+}
+
+// IsMandatory returns true if the extension must be supported by clients
+// willing to use the configuration, which is indicated by the high-order
+// bit of its type.
+func (e Extension) IsMandatory() bool { return e.Type&0x8000 != 0 }
+
+// Config is an ECHConfig as defined in draft-ietf-tls-esni, Section 4. The
+// public key is kept encoded, so configurations with a KEM that is not
+// supported by the hpke package can be parsed and serialized.
+type Config struct {
+	ConfigID      uint8
+	KEM           hpke.KEM
+	PublicKey     []byte
+	CipherSuites  []CipherSuite
+	MaxNameLength uint8
+	PublicName    []byte
+	Extensions    []Extension
+}
+
+// MarshalBinary encodes the configuration, including its version and length.
+func (c *Config) MarshalBinary() ([]byte, error) {
+	var b cryptobyte.Builder
+	c.marshal(&b)
+	return b.Bytes()
+}
+
+func (c *Config) marshal(b *cryptobyte.Builder) {
+	b.AddUint16(Version)
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddUint8(c.ConfigID)
+		b.AddUint16(uint16(c.KEM))
+		if len(c.PublicKey) == 0 {
+			b.SetError(ErrInvalidConfig)
+		}
+		b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+			b.AddBytes(c.PublicKey)
+		})
+		if len(c.CipherSuites) == 0 {
+			b.SetError(ErrInvalidConfig)
+		}
+		b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+			for _, s := range c.CipherSuites {
+				b.AddUint16(uint16(s.KDF))
+				b.AddUint16(uint16(s.AEAD))
+			}
+		})
+		b.AddUint8(c.MaxNameLength)
+		if len(c.PublicName) == 0 {
+			b.SetError(ErrInvalidConfig)
+		}
+		b.AddUint8LengthPrefixed(func(b *cryptobyte.Builder) {
+			b.AddBytes(c.PublicName)
+		})
+		b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+			for _, e := range c.Extensions {
+				b.AddUint16(e.Type)
+				b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+					b.AddBytes(e.Data)
+				})
+			}
+		})
+	})
+}
+
+// UnmarshalBinary decodes a configuration. It returns ErrUnsupportedVersion
+// if the version of the configuration is not Version.
+func (c *Config) UnmarshalBinary(data []byte) error {
+	s := cryptobyte.String(data)
+	if err := c.unmarshal(&s); err != nil {
+		return err
+	}
+	if !s.Empty() {
+		return ErrInvalidConfig
+	}
+	return nil
+}
+
+func (c *Config) unmarshal(s *cryptobyte.String) error {
+	var (
+		version  uint16
+		contents cryptobyte.String
+	)
+	if !s.ReadUint16(&version) || !s.ReadUint16LengthPrefixed(&contents) {
+		return ErrInvalidConfig
+	}
+	if version != Version {
+		return ErrUnsupportedVersion
+	}
+
+	var (
+		out         Config
+		kemID       uint16
+		suites, ext cryptobyte.String
+	)
+	if !contents.ReadUint8(&out.ConfigID) ||
+		!contents.ReadUint16(&kemID) ||
+		!readUint16LengthPrefixedBytes(&contents, &out.PublicKey) ||
+		len(out.PublicKey) == 0 ||
+		!contents.ReadUint16LengthPrefixed(&suites) ||
+		len(suites) == 0 || len(suites)%4 != 0 ||
+		!contents.ReadUint8(&out.MaxNameLength) ||
+		!contents.ReadUint8LengthPrefixed((*cryptobyte.String)(&out.PublicName)) ||
+		len(out.PublicName) == 0 ||
+		!contents.ReadUint16LengthPrefixed(&ext) ||
+		!contents.Empty() {
+		return ErrInvalidConfig
+	}
+	out.KEM = hpke.KEM(kemID)
+	out.PublicName = append([]byte{}, out.PublicName...)
+
+	for !suites.Empty() {
+		var kdfID, aeadID uint16
+		suites.ReadUint16(&kdfID)
+		suites.ReadUint16(&aeadID)
+		out.CipherSuites = append(out.CipherSuites,
+			CipherSuite{hpke.KDF(kdfID), hpke.AEAD(aeadID)})
+	}
+
+	for !ext.Empty() {
+		var e Extension
+		if !ext.ReadUint16(&e.Type) ||
+			!readUint16LengthPrefixedBytes(&ext, &e.Data) {
+			return ErrInvalidConfig
+		}
+		out.Extensions = append(out.Extensions, e)
+	}
+
+	*c = out
+	return nil
+}
+
+func readUint16LengthPrefixedBytes(s *cryptobyte.String, out *[]byte) bool {
+	var t cryptobyte.String
+	if !s.ReadUint16LengthPrefixed(&t) {
+		return false
+	}
+	*out = append([]byte{}, t...)
+	return true
+}
+
+// Info returns the HPKE info string of the configuration, which is the
+// label "tls ech" followed by a zero byte and the encoded configuration.
+func (c *Config) Info() ([]byte, error) {
+	raw, err := c.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte("tls ech\x00"), raw...), nil
+}
+
+// Suite returns the HPKE suite formed by the KEM of the configuration and
+// its first supported cipher suite. It returns ErrUnsupportedSuite if the
+// KEM or none of the cipher suites are supported.
+func (c *Config) Suite() (hpke.Suite, error) {
+	for _, s := range c.CipherSuites {
+		if s.isValid() {
+			return c.suite(s)
+		}
+	}
+	return hpke.Suite{}, ErrUnsupportedSuite
+}
+
+// suite returns the HPKE suite formed by the KEM of the configuration and
+// the given cipher suite, provided the configuration lists it.
+func (c *Config) suite(cs CipherSuite) (hpke.Suite, error) {
+	if !c.KEM.IsValid() || !cs.isValid() {
+		return hpke.Suite{}, ErrUnsupportedSuite
+	}
+	for _, s := range c.CipherSuites {
+		if s == cs {
+			return hpke.NewSuite(c.KEM, cs.KDF, cs.AEAD), nil
+		}
+	}
+	return hpke.Suite{}, ErrUnsupportedSuite
+}
+
+// isSupported returns true if a client can use the configuration, that is,
+// its KEM and one of its cipher suites are supported and it has no
+// mandatory extension.
+func (c *Config) isSupported() bool {
+	if _, err := c.Suite(); err != nil {
+		return false
+	}
+	for _, e := range c.Extensions {
+		if e.IsMandatory() {
+			return false
+		}
+	}
+	return true
+}
+
+// MarshalConfigList encodes a list of configurations as an ECHConfigList.
+func MarshalConfigList(configs []Config) ([]byte, error) {
+	if len(configs) == 0 {
+		return nil, ErrInvalidConfig
+	}
+	var b cryptobyte.Builder
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		for i := range configs {
+			configs[i].marshal(b)
+		}
+	})
+	return b.Bytes()
+}
+
+// UnmarshalConfigList decodes an ECHConfigList. Configurations with a
+// version other than Version are skipped, as required for clients.
+func UnmarshalConfigList(data []byte) ([]Config, error) {
+	var list cryptobyte.String
+	s := cryptobyte.String(data)
+	if !s.ReadUint16LengthPrefixed(&list) || !s.Empty() || list.Empty() {
+		return nil, ErrInvalidConfig
+	}
+
+	var configs []Config
+	for !list.Empty() {
+		var c Config
+		switch err := c.unmarshal(&list); err {
+		case nil:
+			configs = append(configs, c)
+		case ErrUnsupportedVersion:
+		default:
+			return nil, err
+		}
+	}
+	return configs, nil
+}
+
+// SelectConfig returns the first configuration of the list that the client
+// can use, together with the HPKE suite to use with it. It returns
+// ErrUnsupportedSuite if there is none.
+func SelectConfig(configs []Config) (*Config, hpke.Suite, error) {
+	for i := range configs {
+		if configs[i].isSupported() {
+			suite, err := configs[i].Suite()
+			return &configs[i], suite, err
+		}
+	}
+	return nil, hpke.Suite{}, ErrUnsupportedSuite
+}
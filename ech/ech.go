@@ -0,0 +1,305 @@
+// Package ech implements the cryptographic core of TLS Encrypted Client
+// Hello (ECH).
+//
+// With ECH, a client encrypts its ClientHello, called ClientHelloInner, with
+// HPKE to a public key published by the client-facing server in an
+// ECHConfig. The ciphertext is sent in the "encrypted_client_hello"
+// extension of a ClientHelloOuter, whose remaining fields only reveal the
+// public name of the client-facing server.
+//
+// This package parses and serializes ECHConfigList, selects a configuration
+// and HPKE suite, sets up the HPKE contexts of client and server, and
+// encrypts and decrypts the EncodedClientHelloInner. Building the TLS
+// handshake messages, including the ClientHelloOuterAAD used as associated
+// data, is left to the TLS implementation.
+//
+// # References
+//
+// [1] draft-ietf-tls-esni-18: https://datatracker.ietf.org/doc/draft-ietf-tls-esni/
+package ech
+
+import (
+	"bytes"
+	"errors"
+	"io"
+
+	"github.com/cloudflare/circl/hpke"
+	"github.com/cloudflare/circl/kem"
+	"golang.org/x/crypto/cryptobyte"
+)
+
+var (
+	ErrInvalidConfig      = errors.New("ech: invalid ECHConfig")
+	ErrUnsupportedVersion = errors.New("ech: unsupported ECHConfig version")
+	ErrUnsupportedSuite   = errors.New("ech: unsupported HPKE suite")
+	ErrInvalidExtension   = errors.New("ech: invalid encrypted_client_hello extension")
+	ErrUnknownConfigID    = errors.New("ech: unknown config identifier")
+)
+
+// Types of the encrypted_client_hello extension.
+const (
+	OuterClientHello uint8 = 0
+	InnerClientHello uint8 = 1
+)
+
+// ClientHelloExtension is the body of the encrypted_client_hello extension
+// of a ClientHello. The remaining fields are only set for the outer type.
+type ClientHelloExtension struct {
+	Type        uint8
+	CipherSuite CipherSuite
+	ConfigID    uint8
+	Enc         []byte
+	Payload     []byte
+}
+
+// MarshalBinary encodes the extension.
+func (e *ClientHelloExtension) MarshalBinary() ([]byte, error) {
+	var b cryptobyte.Builder
+	b.AddUint8(e.Type)
+	switch e.Type {
+	case InnerClientHello:
+	case OuterClientHello:
+		b.AddUint16(uint16(e.CipherSuite.KDF))
+		b.AddUint16(uint16(e.CipherSuite.AEAD))
+		b.AddUint8(e.ConfigID)
+		b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+			b.AddBytes(e.Enc)
+		})
+		if len(e.Payload) == 0 {
+			b.SetError(ErrInvalidExtension)
+		}
+		b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+			b.AddBytes(e.Payload)
+		})
+	default:
+		return nil, ErrInvalidExtension
+	}
+	return b.Bytes()
+}
+
+// UnmarshalBinary decodes the extension.
+func (e *ClientHelloExtension) UnmarshalBinary(data []byte) error {
+	var (
+		out          ClientHelloExtension
+		kdfID, aead  uint16
+		enc, payload cryptobyte.String
+	)
+	s := cryptobyte.String(data)
+	if !s.ReadUint8(&out.Type) {
+		return ErrInvalidExtension
+	}
+	switch out.Type {
+	case InnerClientHello:
+	case OuterClientHello:
+		if !s.ReadUint16(&kdfID) || !s.ReadUint16(&aead) ||
+			!s.ReadUint8(&out.ConfigID) ||
+			!s.ReadUint16LengthPrefixed(&enc) ||
+			!s.ReadUint16LengthPrefixed(&payload) || len(payload) == 0 {
+			return ErrInvalidExtension
+		}
+		out.CipherSuite = CipherSuite{hpke.KDF(kdfID), hpke.AEAD(aead)}
+		out.Enc = append([]byte{}, enc...)
+		out.Payload = append([]byte{}, payload...)
+	default:
+		return ErrInvalidExtension
+	}
+	if !s.Empty() {
+		return ErrInvalidExtension
+	}
+	*e = out
+	return nil
+}
+
+// ClientContext is the HPKE context of a client that encrypts its
+// ClientHelloInner to an ECHConfig. The same context must be used to encrypt
+// the ClientHelloInner sent after a HelloRetryRequest.
+type ClientContext struct {
+	config *Config
+	suite  CipherSuite
+	enc    []byte
+	sealer hpke.Sealer
+	sent   bool
+}
+
+// NewClientContext sets up the HPKE context of a client for the given
+// configuration, reading randomness from rnd. The first supported cipher
+// suite of the configuration is used.
+func NewClientContext(rnd io.Reader, config *Config) (*ClientContext, error) {
+	suite, err := config.Suite()
+	if err != nil {
+		return nil, err
+	}
+	pk, err := config.KEM.Scheme().UnmarshalBinaryPublicKey(config.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	info, err := config.Info()
+	if err != nil {
+		return nil, err
+	}
+	sender, err := suite.NewSender(pk, info)
+	if err != nil {
+		return nil, err
+	}
+	enc, sealer, err := sender.Setup(rnd)
+	if err != nil {
+		return nil, err
+	}
+	_, kdfID, aeadID := suite.Params()
+	return &ClientContext{
+		config: config,
+		suite:  CipherSuite{kdfID, aeadID},
+		enc:    enc,
+		sealer: sealer,
+	}, nil
+}
+
+// Enc returns the encapsulated key of the context.
+func (c *ClientContext) Enc() []byte { return c.enc }
+
+// PayloadSize returns the size of the payload encrypting an
+// EncodedClientHelloInner of the given size.
+func (c *ClientContext) PayloadSize(innerSize int) int {
+	return int(c.suite.AEAD.CipherLen(uint(innerSize)))
+}
+
+// EncryptClientHello encrypts the EncodedClientHelloInner with the
+// ClientHelloOuterAAD as associated data, and returns the outer
+// encrypted_client_hello extension. The encapsulated key is only sent with
+// the first ClientHello, so later calls, after a HelloRetryRequest, return an
+// extension with an empty Enc.
+//
+// The ClientHelloOuterAAD is the ClientHelloOuter whose payload is replaced
+// by PayloadSize(len(encodedInner)) zero bytes.
+func (c *ClientContext) EncryptClientHello(encodedInner, outerAAD []byte) (
+	*ClientHelloExtension, error,
+) {
+	enc := c.enc
+	if c.sent {
+		enc = []byte{}
+	}
+	payload, err := c.sealer.Seal(encodedInner, outerAAD)
+	if err != nil {
+		return nil, err
+	}
+	c.sent = true
+	return &ClientHelloExtension{
+		Type:        OuterClientHello,
+		CipherSuite: c.suite,
+		ConfigID:    c.config.ConfigID,
+		Enc:         enc,
+		Payload:     payload,
+	}, nil
+}
+
+// KeyPair is an ECHConfig together with its private key.
+type KeyPair struct {
+	Config     Config
+	PrivateKey kem.PrivateKey
+}
+
+// GenerateKeyPair generates a configuration with a fresh key pair of the
+// given KEM, reading randomness from rnd.
+func GenerateKeyPair(
+	rnd io.Reader, configID uint8, kemID hpke.KEM, publicName string,
+	suites ...CipherSuite,
+) (*KeyPair, error) {
+	config := Config{
+		ConfigID:     configID,
+		KEM:          kemID,
+		CipherSuites: suites,
+		PublicName:   []byte(publicName),
+	}
+	_, err := config.Suite()
+	if err != nil {
+		return nil, err
+	}
+	scheme := kemID.Scheme()
+	seed := make([]byte, scheme.SeedSize())
+	if _, err = io.ReadFull(rnd, seed); err != nil {
+		return nil, err
+	}
+	pk, sk := scheme.DeriveKeyPair(seed)
+	config.PublicKey, err = pk.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return &KeyPair{config, sk}, nil
+}
+
+// ServerContext is the HPKE context of a client-facing server that decrypts
+// the ClientHelloInner of a client.
+type ServerContext struct {
+	configID uint8
+	suite    CipherSuite
+	enc      []byte
+	opener   hpke.Opener
+	received bool
+}
+
+// NewServerContext sets up the HPKE context of a server for the outer
+// encrypted_client_hello extension of the first ClientHello of a client. It
+// returns ErrUnknownConfigID if the extension does not use the configuration
+// of any of the key pairs, in which case the server should proceed with the
+// ClientHelloOuter.
+func NewServerContext(ext *ClientHelloExtension, keys ...KeyPair) (
+	*ServerContext, error,
+) {
+	if ext.Type != OuterClientHello {
+		return nil, ErrInvalidExtension
+	}
+	for i := range keys {
+		k := &keys[i]
+		if k.Config.ConfigID != ext.ConfigID {
+			continue
+		}
+		suite, err := k.Config.suite(ext.CipherSuite)
+		if err != nil {
+			continue
+		}
+		info, err := k.Config.Info()
+		if err != nil {
+			return nil, err
+		}
+		receiver, err := suite.NewReceiver(k.PrivateKey, info)
+		if err != nil {
+			return nil, err
+		}
+		opener, err := receiver.Setup(ext.Enc)
+		if err != nil {
+			return nil, err
+		}
+		return &ServerContext{
+			configID: ext.ConfigID,
+			suite:    ext.CipherSuite,
+			enc:      ext.Enc,
+			opener:   opener,
+		}, nil
+	}
+	return nil, ErrUnknownConfigID
+}
+
+// DecryptClientHello decrypts the payload of the outer encrypted_client_hello
+// extension with the ClientHelloOuterAAD as associated data, and returns the
+// EncodedClientHelloInner. The extension must be the one the context was
+// set up with. After a HelloRetryRequest, the extension of the second
+// ClientHello must use the same configuration and cipher suite, and an empty
+// Enc.
+func (s *ServerContext) DecryptClientHello(ext *ClientHelloExtension, outerAAD []byte) (
+	[]byte, error,
+) {
+	if ext.Type != OuterClientHello || ext.ConfigID != s.configID ||
+		ext.CipherSuite != s.suite {
+		return nil, ErrInvalidExtension
+	}
+	if s.received && len(ext.Enc) != 0 ||
+		!s.received && !bytes.Equal(ext.Enc, s.enc) {
+		return nil, ErrInvalidExtension
+	}
+	pt, err := s.opener.Open(ext.Payload, outerAAD)
+	if err != nil {
+		return nil, err
+	}
+	s.received = true
+	return pt, nil
+}
@@ -0,0 +1,224 @@
+package ech
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+
+	"github.com/cloudflare/circl/hpke"
+	"github.com/cloudflare/circl/internal/test"
+)
+
+func hexB(t *testing.T, x string) []byte {
+	t.Helper()
+	z, err := hex.DecodeString(x)
+	test.CheckNoErr(t, err, "bad hex")
+	return z
+}
+
+// TestConfigEncoding checks an ECHConfigList built by hand following the
+// wire format of draft-ietf-tls-esni-18, Section 4.
+func TestConfigEncoding(t *testing.T) {
+	pk := hexB(t, "9fd4ffe6ddfdf2bed6b1f06c5b3e9c8e5ac8b1b3f4d5e7aa40ef3c9a1c6ba24b")
+	raw := hexB(t, ""+
+		"0069"+ // list length
+		"fe0d"+"003e"+ // version, length
+		"2a"+"0020"+ // config_id, kem_id
+		"0020"+hex.EncodeToString(pk)+
+		"0008"+"00010001"+"00010003"+ // cipher_suites
+		"00"+ // maximum_name_length
+		"0b"+hex.EncodeToString([]byte("example.com"))+
+		"0000"+ // extensions
+		"fe0c"+"0004"+"deadbeef"+ // unsupported version, skipped
+		"fe0d"+"001b"+
+		"07"+"ffff"+ // unknown KEM
+		"0002"+"abcd"+
+		"0004"+"00010001"+
+		"20"+
+		"01"+"61"+
+		"0009"+"fe01"+"0000"+"0001"+"0001"+"ff", // mandatory extension, optional extension
+	)
+
+	configs, err := UnmarshalConfigList(raw)
+	test.CheckNoErr(t, err, "unmarshal list")
+	if len(configs) != 2 {
+		test.ReportError(t, len(configs), 2)
+	}
+	c := configs[0]
+	if c.ConfigID != 0x2a || c.KEM != hpke.KEM_X25519_HKDF_SHA256 ||
+		!bytes.Equal(c.PublicKey, pk) || len(c.CipherSuites) != 2 ||
+		c.CipherSuites[1] != (CipherSuite{hpke.KDF_HKDF_SHA256, hpke.AEAD_ChaCha20Poly1305}) ||
+		string(c.PublicName) != "example.com" || len(c.Extensions) != 0 {
+		test.ReportError(t, c, "config 0")
+	}
+	c = configs[1]
+	if c.ConfigID != 7 || c.KEM != hpke.KEM(0xffff) || c.MaxNameLength != 0x20 ||
+		len(c.Extensions) != 2 || !c.Extensions[0].IsMandatory() ||
+		c.Extensions[1].IsMandatory() || !bytes.Equal(c.Extensions[1].Data, []byte{0xff}) {
+		test.ReportError(t, c, "config 1")
+	}
+
+	// Re-encoding drops the configuration with an unsupported version.
+	got, err := MarshalConfigList(configs)
+	test.CheckNoErr(t, err, "marshal list")
+	want := append([]byte{0x00, 0x61}, raw[2:2+4+0x3e]...)
+	want = append(want, raw[2+4+0x3e+8:]...)
+	if !bytes.Equal(got, want) {
+		test.ReportError(t, got, want)
+	}
+
+	info, err := configs[0].Info()
+	test.CheckNoErr(t, err, "info")
+	want = append([]byte("tls ech\x00"), raw[2:2+4+0x3e]...)
+	if !bytes.Equal(info, want) {
+		test.ReportError(t, info, want)
+	}
+
+	selected, suite, err := SelectConfig(configs)
+	test.CheckNoErr(t, err, "select")
+	wantSuite := hpke.NewSuite(hpke.KEM_X25519_HKDF_SHA256, hpke.KDF_HKDF_SHA256, hpke.AEAD_AES128GCM)
+	if selected != &configs[0] || suite != wantSuite {
+		test.ReportError(t, suite, wantSuite)
+	}
+	_, _, err = SelectConfig(configs[1:])
+	test.CheckIsErr(t, err, "unsupported config must not be selected")
+
+	for _, bad := range [][]byte{
+		raw[:len(raw)-1],
+		append(append([]byte{}, raw...), 0),
+		{0, 0},
+	} {
+		_, err = UnmarshalConfigList(bad)
+		test.CheckIsErr(t, err, "malformed list must fail")
+	}
+}
+
+func TestClientHello(t *testing.T) {
+	suites := []CipherSuite{
+		{hpke.KDF_HKDF_SHA384, hpke.AEAD(0x7777)},
+		{hpke.KDF_HKDF_SHA256, hpke.AEAD_AES256GCM},
+		{hpke.KDF_HKDF_SHA512, hpke.AEAD_ChaCha20Poly1305},
+	}
+	for _, kemID := range []hpke.KEM{
+		hpke.KEM_X25519_HKDF_SHA256,
+		hpke.KEM_P256_HKDF_SHA256,
+		hpke.KEM_X25519_KYBER768_DRAFT00,
+	} {
+		other, err := GenerateKeyPair(rand.Reader, 1, kemID, "other.example", suites[2])
+		test.CheckNoErr(t, err, "keygen")
+		key, err := GenerateKeyPair(rand.Reader, 2, kemID, "public.example", suites...)
+		test.CheckNoErr(t, err, "keygen")
+
+		raw, err := MarshalConfigList([]Config{key.Config})
+		test.CheckNoErr(t, err, "marshal list")
+		configs, err := UnmarshalConfigList(raw)
+		test.CheckNoErr(t, err, "unmarshal list")
+		config, _, err := SelectConfig(configs)
+		test.CheckNoErr(t, err, "select")
+
+		client, err := NewClientContext(rand.Reader, config)
+		test.CheckNoErr(t, err, "client setup")
+		var server *ServerContext
+
+		for i, inner := range [][]byte{
+			[]byte("ClientHelloInner"),
+			[]byte("ClientHelloInner after HelloRetryRequest"),
+		} {
+			aad := make([]byte, 100+client.PayloadSize(len(inner)))
+			_, _ = rand.Read(aad[:100])
+			ext, err := client.EncryptClientHello(inner, aad)
+			test.CheckNoErr(t, err, "encrypt")
+			if len(ext.Payload) != client.PayloadSize(len(inner)) ||
+				ext.CipherSuite != suites[1] || ext.ConfigID != 2 ||
+				i == 0 && !bytes.Equal(ext.Enc, client.Enc()) ||
+				i == 1 && len(ext.Enc) != 0 {
+				test.ReportError(t, ext, i)
+			}
+
+			rawExt, err := ext.MarshalBinary()
+			test.CheckNoErr(t, err, "marshal extension")
+			var parsed ClientHelloExtension
+			test.CheckNoErr(t, parsed.UnmarshalBinary(rawExt), "unmarshal extension")
+
+			if i == 0 {
+				server, err = NewServerContext(&parsed, *other, *key)
+				test.CheckNoErr(t, err, "server setup")
+			}
+
+			modified := parsed
+			modified.Payload = append([]byte{}, parsed.Payload...)
+			modified.Payload[0] ^= 1
+			_, err = server.DecryptClientHello(&modified, aad)
+			test.CheckIsErr(t, err, "modified payload must fail")
+			_, err = server.DecryptClientHello(&parsed, aad[1:])
+			test.CheckIsErr(t, err, "modified aad must fail")
+
+			got, err := server.DecryptClientHello(&parsed, aad)
+			test.CheckNoErr(t, err, "decrypt")
+			if !bytes.Equal(got, inner) {
+				test.ReportError(t, got, inner, kemID, i)
+			}
+			_, err = server.DecryptClientHello(&parsed, aad)
+			test.CheckIsErr(t, err, "replayed payload must fail")
+		}
+
+		ext, err := client.EncryptClientHello([]byte("inner"), nil)
+		test.CheckNoErr(t, err, "encrypt")
+		ext.Enc = client.Enc()
+		ext.ConfigID = 3
+		_, err = NewServerContext(ext, *other, *key)
+		test.CheckIsErr(t, err, "unknown config id must fail")
+	}
+}
+
+func TestInnerExtension(t *testing.T) {
+	var ext ClientHelloExtension
+	test.CheckNoErr(t, ext.UnmarshalBinary([]byte{1}), "unmarshal inner")
+	if ext.Type != InnerClientHello {
+		test.ReportError(t, ext.Type, InnerClientHello)
+	}
+	raw, err := ext.MarshalBinary()
+	test.CheckNoErr(t, err, "marshal inner")
+	if !bytes.Equal(raw, []byte{1}) {
+		test.ReportError(t, raw, []byte{1})
+	}
+	test.CheckIsErr(t, ext.UnmarshalBinary([]byte{1, 0}), "trailing data must fail")
+	test.CheckIsErr(t, ext.UnmarshalBinary([]byte{2}), "unknown type must fail")
+}
+
+// TestPublishedConfigLists parses the ECHConfigLists of the tests of Go's
+// crypto/tls, the first of which was published by cloudflare-ech.com.
+func TestPublishedConfigLists(t *testing.T) {
+	for _, tc := range []struct {
+		list       string
+		numConfigs int
+	}{
+		{"0045fe0d0041590020002092a01233db2218518ccbbbbc24df20686af417b37388de6460e94011974777090004000100010012636c6f7564666c6172652d6563682e636f6d0000", 1},
+		{"0105badd00050504030201fe0d0066000010004104e62b69e2bf659f97be2f1e0d948a4cd5976bb7a91e0d46fbdda9a91e9ddcba5a01e7d697a80a18f9c3c4a31e56e27c8348db161a1cf51d7ef1942d4bcf7222c1000c000100010001000200010003400e7075626c69632e6578616d706c650000fe0d003d00002000207d661615730214aeee70533366f36a609ead65c0c208e62322346ab5bcd8de1c000411112222400e7075626c69632e6578616d706c650000fe0d004d000020002085bd6a03277c25427b52e269e0c77a8eb524ba1eb3d2f132662d4b0ac6cb7357000c000100010001000200010003400e7075626c69632e6578616d706c650008aaaa000474657374", 3},
+	} {
+		raw := hexB(t, tc.list)
+		configs, err := UnmarshalConfigList(raw)
+		test.CheckNoErr(t, err, "unmarshal list")
+		if len(configs) != tc.numConfigs {
+			test.ReportError(t, len(configs), tc.numConfigs)
+		}
+	}
+
+	raw := hexB(t, "0045fe0d0041590020002092a01233db2218518ccbbbbc24df20686af417b37388de6460e94011974777090004000100010012636c6f7564666c6172652d6563682e636f6d0000")
+	configs, err := UnmarshalConfigList(raw)
+	test.CheckNoErr(t, err, "unmarshal list")
+	c := configs[0]
+	pk := hexB(t, "92a01233db2218518ccbbbbc24df20686af417b37388de6460e9401197477709")
+	if c.ConfigID != 0x59 || c.KEM != hpke.KEM_X25519_HKDF_SHA256 ||
+		!bytes.Equal(c.PublicKey, pk) || len(c.CipherSuites) != 1 ||
+		c.CipherSuites[0] != (CipherSuite{hpke.KDF_HKDF_SHA256, hpke.AEAD_AES128GCM}) ||
+		string(c.PublicName) != "cloudflare-ech.com" || len(c.Extensions) != 0 {
+		test.ReportError(t, c, "cloudflare-ech.com")
+	}
+	got, err := MarshalConfigList(configs)
+	test.CheckNoErr(t, err, "marshal list")
+	if !bytes.Equal(got, raw) {
+		test.ReportError(t, got, raw)
+	}
+}
@@ -0,0 +1,108 @@
+//go:build go1.24
+// +build go1.24
+
+package ech
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/cloudflare/circl/hpke"
+	"github.com/cloudflare/circl/internal/test"
+	"golang.org/x/crypto/cryptobyte"
+)
+
+// TestGoClient decrypts the ClientHelloInner sent by the ECH client of Go's
+// crypto/tls, which checks the info string and the ClientHelloOuterAAD
+// against another implementation.
+func TestGoClient(t *testing.T) {
+	suite := CipherSuite{hpke.KDF_HKDF_SHA256, hpke.AEAD_AES128GCM}
+	key, err := GenerateKeyPair(rand.Reader, 0x42, hpke.KEM_X25519_HKDF_SHA256, "public.example", suite)
+	test.CheckNoErr(t, err, "keygen")
+	list, err := MarshalConfigList([]Config{key.Config})
+	test.CheckNoErr(t, err, "marshal list")
+
+	clientConn, serverConn := net.Pipe()
+	go func() {
+		client := tls.Client(clientConn, &tls.Config{
+			ServerName:                     "secret.example",
+			MinVersion:                     tls.VersionTLS13,
+			EncryptedClientHelloConfigList: list,
+		})
+		_ = client.Handshake()
+		_ = client.Close()
+	}()
+	defer serverConn.Close()
+
+	// Reads the handshake message of the first record.
+	var header [5]byte
+	_, err = io.ReadFull(serverConn, header[:])
+	test.CheckNoErr(t, err, "read record header")
+	record := make([]byte, int(header[3])<<8|int(header[4]))
+	_, err = io.ReadFull(serverConn, record)
+	test.CheckNoErr(t, err, "read record")
+	if header[0] != 22 || len(record) < 4 || record[0] != 1 {
+		t.Fatal("expected a ClientHello")
+	}
+	hello := record[4:]
+
+	extType, body, offset := findExtension(t, hello, 0xfe0d)
+	if extType != 0xfe0d {
+		t.Fatal("missing encrypted_client_hello extension")
+	}
+	var ext ClientHelloExtension
+	test.CheckNoErr(t, ext.UnmarshalBinary(body), "unmarshal extension")
+	if ext.Type != OuterClientHello || ext.ConfigID != 0x42 || ext.CipherSuite != suite {
+		test.ReportError(t, ext, "outer extension")
+	}
+
+	// The ClientHelloOuterAAD is the ClientHelloOuter whose payload is
+	// replaced by zeros.
+	aad := append([]byte{}, hello...)
+	payload := aad[offset+len(body)-len(ext.Payload) : offset+len(body)]
+	for i := range payload {
+		payload[i] = 0
+	}
+
+	server, err := NewServerContext(&ext, *key)
+	test.CheckNoErr(t, err, "server setup")
+	inner, err := server.DecryptClientHello(&ext, aad)
+	test.CheckNoErr(t, err, "decrypt")
+	if !bytes.Contains(inner, []byte("secret.example")) {
+		test.ReportError(t, inner, "secret.example")
+	}
+}
+
+// findExtension returns the type, body and offset of the body of the first
+// extension of the given type in a ClientHello, or a zero type if there is
+// none.
+func findExtension(t *testing.T, hello []byte, extType uint16) (uint16, []byte, int) {
+	t.Helper()
+	var (
+		random, sessionID, suites, methods, exts cryptobyte.String
+		version                                  uint16
+	)
+	s := cryptobyte.String(hello)
+	if !s.ReadUint16(&version) || !s.ReadBytes((*[]byte)(&random), 32) ||
+		!s.ReadUint8LengthPrefixed(&sessionID) ||
+		!s.ReadUint16LengthPrefixed(&suites) ||
+		!s.ReadUint8LengthPrefixed(&methods) ||
+		!s.ReadUint16LengthPrefixed(&exts) || !s.Empty() {
+		t.Fatal("malformed ClientHello")
+	}
+	for !exts.Empty() {
+		var typ uint16
+		var body cryptobyte.String
+		if !exts.ReadUint16(&typ) || !exts.ReadUint16LengthPrefixed(&body) {
+			t.Fatal("malformed extensions")
+		}
+		if typ == extType {
+			return typ, body, len(hello) - len(exts) - len(body)
+		}
+	}
+	return 0, nil, 0
+}
@@ -0,0 +1,530 @@
+package group
+
+import (
+	"crypto/subtle"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/cloudflare/circl/ecc/goldilocks"
+	"github.com/cloudflare/circl/expander"
+	"github.com/cloudflare/circl/internal/conv"
+	fp "github.com/cloudflare/circl/math/fp448"
+	"github.com/cloudflare/circl/xof"
+)
+
+// Decaf448 is a quotient group generated from the edwards448 curve, as
+// specified in RFC 9496.
+var Decaf448 Group = decafGroup{}
+
+type decafGroup struct{}
+
+var (
+	// decafD is -39081 in Fp.
+	decafD = fp.Elt{
+		0x56, 0x67, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		0xff, 0xff, 0xff, 0xff, 0xfe, 0xff, 0xff, 0xff,
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+	}
+	// decafOneMinusD is 1-d = 39082 in Fp.
+	decafOneMinusD = fp.Elt{0xaa, 0x98}
+	// decafOneMinusTwoD is 1-2d = 78163 in Fp.
+	decafOneMinusTwoD = fp.Elt{0x53, 0x31, 0x01}
+	// decafSqrtMinusD is the non-negative square root of -d.
+	decafSqrtMinusD = fp.Elt{
+		0x36, 0x27, 0x57, 0x45, 0x0f, 0xef, 0x42, 0x96,
+		0x52, 0xce, 0x20, 0xaa, 0xf6, 0x7b, 0x33, 0x60,
+		0xd2, 0xde, 0x6e, 0xfd, 0xf4, 0x66, 0x9a, 0x83,
+		0xba, 0x14, 0x8c, 0x96, 0x80, 0xd7, 0xa2, 0x64,
+		0x4b, 0xd5, 0xb8, 0xa5, 0xb8, 0xa7, 0xf1, 0xa1,
+		0xa0, 0x6a, 0xa2, 0x2f, 0x72, 0x8d, 0xf6, 0x3b,
+		0x68, 0xf7, 0x24, 0xeb, 0xfb, 0x62, 0xd9, 0x22,
+	}
+	// decafInvSqrtMinusD is 1/decafSqrtMinusD.
+	decafInvSqrtMinusD = fp.Elt{
+		0x2c, 0x68, 0x78, 0xb8, 0x5e, 0xbb, 0xaf, 0x53,
+		0xf3, 0x94, 0x9e, 0xf1, 0x79, 0x24, 0xbb, 0xef,
+		0x15, 0xba, 0x1f, 0xc2, 0xe2, 0x7e, 0x70, 0xbe,
+		0x1a, 0x52, 0xa6, 0x28, 0xf1, 0x56, 0xba, 0xd6,
+		0xa7, 0x27, 0x5b, 0x3a, 0x0c, 0x95, 0x90, 0x5a,
+		0x07, 0xc8, 0xca, 0x0b, 0x5a, 0xe3, 0x2b, 0x90,
+		0x57, 0xc0, 0x22, 0xe2, 0x52, 0x06, 0xf4, 0x6e,
+	}
+)
+
+func (g decafGroup) String() string {
+	return "decaf448"
+}
+
+func (g decafGroup) Params() *Params {
+	return &Params{fp.Size, fp.Size, goldilocks.ScalarSize}
+}
+
+type decafElement struct {
+	p goldilocks.Point
+}
+
+type decafScalar struct {
+	k goldilocks.Scalar
+}
+
+func (g decafGroup) NewElement() Element {
+	return g.Identity()
+}
+
+func (g decafGroup) NewScalar() Scalar {
+	return &decafScalar{}
+}
+
+func (g decafGroup) Identity() Element {
+	return &decafElement{*goldilocks.Curve{}.Identity()}
+}
+
+// Generator returns the generator of decaf448, which is twice the generator
+// of edwards448, see RFC 9496, Section 5.3.
+func (g decafGroup) Generator() Element {
+	return &decafElement{*goldilocks.Curve{}.Double(goldilocks.Curve{}.Generator())}
+}
+
+func (g decafGroup) Order() Scalar {
+	return &decafScalar{goldilocks.Curve{}.Order()}
+}
+
+func (g decafGroup) RandomElement(rnd io.Reader) Element {
+	var b [2 * fp.Size]byte
+	if _, err := io.ReadFull(rnd, b[:]); err != nil {
+		panic(err)
+	}
+	return g.fromUniformBytes(b[:])
+}
+
+func (g decafGroup) RandomScalar(rnd io.Reader) Scalar {
+	var b [goldilocks.ScalarSize + 8]byte
+	if _, err := io.ReadFull(rnd, b[:]); err != nil {
+		panic(err)
+	}
+	s := &decafScalar{}
+	s.k.FromBytes(b[:])
+	return s
+}
+
+func (g decafGroup) RandomNonZeroScalar(rnd io.Reader) Scalar {
+	for {
+		s := g.RandomScalar(rnd)
+		if !s.IsZero() {
+			return s
+		}
+	}
+}
+
+func (g decafGroup) HashToElementNonUniform(b, dst []byte) Element {
+	return g.HashToElement(b, dst)
+}
+
+func (g decafGroup) HashToElement(msg, dst []byte) Element {
+	// Compliant with draft-irtf-cfrg-hash-to-curve.
+	// Appendix B - Hashing to decaf448
+	// SuiteID: decaf448_XOF:SHAKE256_D448MAP_RO_
+	xof := expander.NewExpanderXOF(xof.SHAKE256, 224, dst)
+	return g.fromUniformBytes(xof.Expand(msg, 2*fp.Size))
+}
+
+func (g decafGroup) HashToScalar(msg, dst []byte) Scalar {
+	// Compliant with RFC 9497, Section 4.2 - OPRF(decaf448, SHAKE-256).
+	xof := expander.NewExpanderXOF(xof.SHAKE256, 224, dst)
+	s := &decafScalar{}
+	s.k.FromBytes(xof.Expand(msg, 64))
+	return s
+}
+
+// fromUniformBytes maps each half of b to the group and returns their sum,
+// see RFC 9496, Section 5.3.4.
+func (g decafGroup) fromUniformBytes(b []byte) Element {
+	p0 := decafMap(b[:fp.Size])
+	p1 := decafMap(b[fp.Size:])
+	return &decafElement{*goldilocks.Curve{}.Add(p0, p1)}
+}
+
+// decafMap is the one-way map of RFC 9496, Section 5.3.4.
+func decafMap(b []byte) *goldilocks.Point {
+	var t, r, u0, u1, v, tv, s, w0, w1, w2, w3, aux fp.Elt
+	one := fp.One()
+	copy(t[:], b)
+
+	fp.Sqr(&r, &t)
+	fp.Neg(&r, &r)            // r = -t^2
+	fp.Sub(&u0, &r, &one)     // r-1
+	fp.Mul(&u0, &u0, &decafD) // u0 = d*(r-1)
+	fp.Add(&u1, &u0, &one)    // u0+1
+	fp.Sub(&aux, &u0, &r)     // u0-r
+	fp.Mul(&u1, &u1, &aux)    // u1 = (u0+1)*(u0-r)
+
+	fp.Add(&aux, &r, &one)  // r+1
+	fp.Mul(&aux, &aux, &u1) // (r+1)*u1
+	wasSquare := decafSqrtRatio(&v, &decafOneMinusTwoD, &aux)
+
+	fp.Mul(&tv, &t, &v)
+	fp.Cmov(&v, &tv, uint(1-wasSquare)) // v' = v if was_square else t*v
+	sgn := fp.One()
+	minusOne := fp.Elt{}
+	fp.Sub(&minusOne, &minusOne, &one)
+	fp.Cmov(&sgn, &minusOne, uint(1-wasSquare))
+
+	fp.Add(&aux, &r, &one)
+	fp.Mul(&s, &v, &aux) // s = v'*(r+1)
+
+	w0 = s
+	decafAbs(&w0)
+	fp.Add(&w0, &w0, &w0)   // w0 = 2*|s|
+	fp.Sqr(&aux, &s)        // s^2
+	fp.Add(&w1, &aux, &one) // w1 = s^2+1
+	fp.Sub(&w2, &aux, &one) // w2 = s^2-1
+	fp.Sub(&aux, &r, &one)
+	fp.Mul(&w3, &v, &s)
+	fp.Mul(&w3, &w3, &aux)
+	fp.Mul(&w3, &w3, &decafOneMinusTwoD)
+	fp.Add(&w3, &w3, &sgn) // w3 = v'*s*(r-1)*(1-2d)+sgn
+
+	// (x, y) = (w0/w1, w2/w3)
+	var x, y fp.Elt
+	fp.Mul(&aux, &w1, &w3)
+	fp.Inv(&aux, &aux)
+	fp.Mul(&x, &w0, &w3)
+	fp.Mul(&x, &x, &aux)
+	fp.Mul(&y, &w2, &w1)
+	fp.Mul(&y, &y, &aux)
+	P, err := goldilocks.FromAffine(&x, &y)
+	if err != nil {
+		panic(err)
+	}
+	return P
+}
+
+// decafIsNegative returns 1 if the canonical representative of x is odd.
+func decafIsNegative(x *fp.Elt) int {
+	t := *x
+	fp.Modp(&t)
+	return int(t[0] & 1)
+}
+
+// decafAbs sets x to -x if x is negative.
+func decafAbs(x *fp.Elt) {
+	var t fp.Elt
+	fp.Neg(&t, x)
+	fp.Cmov(x, &t, uint(decafIsNegative(x)))
+}
+
+// decafSqrtRatio sets z to the non-negative square root of u/v if it exists,
+// and to the non-negative square root of -u/v otherwise. Returns 1 if u/v is
+// a square, and 0 otherwise. If u or v is zero, z is zero.
+func decafSqrtRatio(z, u, v *fp.Elt) int {
+	isQR := fp.InvSqrt(z, u, v)
+	decafAbs(z)
+	if isQR {
+		return 1
+	}
+	return 0
+}
+
+// affine returns the affine coordinates of e without modifying it.
+func (e *decafElement) affine() (x, y fp.Elt) {
+	t := e.p
+	return t.ToAffine()
+}
+
+func (e *decafElement) Group() Group { return Decaf448 }
+
+func (e *decafElement) String() string {
+	b, _ := e.MarshalBinary()
+	return fmt.Sprintf("%x", b)
+}
+
+func (e *decafElement) IsIdentity() bool {
+	x, _ := e.affine()
+	return fp.IsZero(&x)
+}
+
+func (e *decafElement) IsEqual(x Element) bool {
+	x1, y1 := e.affine()
+	x2, y2 := x.(*decafElement).affine()
+	var l, r fp.Elt
+	fp.Mul(&l, &x1, &y2)
+	fp.Mul(&r, &y1, &x2)
+	fp.Sub(&l, &l, &r)
+	return fp.IsZero(&l)
+}
+
+func (e *decafElement) Set(x Element) Element {
+	e.p = x.(*decafElement).p
+	return e
+}
+
+func (e *decafElement) Copy() Element {
+	return &decafElement{e.p}
+}
+
+func (e *decafElement) CMov(v int, x Element) Element {
+	if !(v == 0 || v == 1) {
+		panic(ErrSelector)
+	}
+	x0, y0 := e.affine()
+	x1, y1 := x.(*decafElement).affine()
+	fp.Cmov(&x0, &x1, uint(v))
+	fp.Cmov(&y0, &y1, uint(v))
+	P, err := goldilocks.FromAffine(&x0, &y0)
+	if err != nil {
+		panic(err)
+	}
+	e.p = *P
+	return e
+}
+
+func (e *decafElement) CSelect(v int, x Element, y Element) Element {
+	if !(v == 0 || v == 1) {
+		panic(ErrSelector)
+	}
+	t := y.Copy().CMov(v, x)
+	return e.Set(t)
+}
+
+func (e *decafElement) Add(x Element, y Element) Element {
+	e.p = *goldilocks.Curve{}.Add(&x.(*decafElement).p, &y.(*decafElement).p)
+	return e
+}
+
+func (e *decafElement) Dbl(x Element) Element {
+	e.p = *goldilocks.Curve{}.Double(&x.(*decafElement).p)
+	return e
+}
+
+func (e *decafElement) Neg(x Element) Element {
+	e.p = x.(*decafElement).p
+	e.p.Neg()
+	return e
+}
+
+func (e *decafElement) Mul(x Element, y Scalar) Element {
+	k := y.(*decafScalar).k
+	k.Red()
+	e.p = *goldilocks.Curve{}.ScalarMult(&k, &x.(*decafElement).p)
+	return e
+}
+
+func (e *decafElement) MulGen(x Scalar) Element {
+	var k goldilocks.Scalar
+	k.Add(&x.(*decafScalar).k, &x.(*decafScalar).k)
+	k.Red()
+	e.p = *goldilocks.Curve{}.ScalarBaseMult(&k)
+	return e
+}
+
+func (e *decafElement) MarshalBinaryCompress() ([]byte, error) {
+	return e.MarshalBinary()
+}
+
+// MarshalBinary encodes the element, see RFC 9496, Section 5.3.2.
+func (e *decafElement) MarshalBinary() ([]byte, error) {
+	var t, u1, u2, ratio, invSqrt, s, aux fp.Elt
+	x, y := e.affine()
+	fp.Mul(&t, &x, &y)
+
+	fp.Add(&u1, &x, &t)
+	fp.Sub(&aux, &x, &t)
+	fp.Mul(&u1, &u1, &aux) // u1 = (x+t)*(x-t)
+
+	one := fp.One()
+	fp.Sqr(&aux, &x)
+	fp.Mul(&aux, &aux, &u1)
+	fp.Mul(&aux, &aux, &decafOneMinusD)
+	_ = decafSqrtRatio(&invSqrt, &one, &aux) // 1/sqrt(u1*(1-d)*x^2)
+
+	fp.Mul(&ratio, &invSqrt, &u1)
+	fp.Mul(&ratio, &ratio, &decafSqrtMinusD)
+	decafAbs(&ratio)
+
+	fp.Mul(&u2, &decafInvSqrtMinusD, &ratio)
+	fp.Sub(&u2, &u2, &t) // u2 = ratio/sqrt(-d) - t
+
+	fp.Mul(&s, &decafOneMinusD, &invSqrt)
+	fp.Mul(&s, &s, &x)
+	fp.Mul(&s, &s, &u2)
+	decafAbs(&s)
+
+	out := make([]byte, fp.Size)
+	if err := fp.ToBytes(out, &s); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// UnmarshalBinary decodes an element, see RFC 9496, Section 5.3.1.
+func (e *decafElement) UnmarshalBinary(data []byte) error {
+	if len(data) != fp.Size {
+		return ErrUnmarshal
+	}
+	var s fp.Elt
+	copy(s[:], data)
+	p := fp.P()
+	if !isLessThanLE(s[:], p[:]) || s[0]&1 == 1 {
+		return ErrUnmarshal
+	}
+
+	var ss, u1, u2, u3, invSqrt, x, y, aux fp.Elt
+	one := fp.One()
+	fp.Sqr(&ss, &s)
+	fp.Add(&u1, &ss, &one) // u1 = 1+s^2
+	fp.Sqr(&u2, &u1)
+	fp.Mul(&aux, &decafD, &ss)
+	fp.Add(&aux, &aux, &aux)
+	fp.Add(&aux, &aux, &aux)
+	fp.Sub(&u2, &u2, &aux) // u2 = u1^2 - 4*d*s^2
+
+	fp.Sqr(&aux, &u1)
+	fp.Mul(&aux, &aux, &u2)
+	if decafSqrtRatio(&invSqrt, &one, &aux) == 0 {
+		return ErrUnmarshal
+	}
+
+	fp.Add(&u3, &s, &s)
+	fp.Mul(&u3, &u3, &invSqrt)
+	fp.Mul(&u3, &u3, &u1)
+	fp.Mul(&u3, &u3, &decafSqrtMinusD)
+	decafAbs(&u3) // u3 = |2*s*invsqrt*u1*sqrt(-d)|
+
+	fp.Mul(&x, &u3, &invSqrt)
+	fp.Mul(&x, &x, &u2)
+	fp.Mul(&x, &x, &decafInvSqrtMinusD)
+
+	fp.Sub(&y, &one, &ss)
+	fp.Mul(&y, &y, &invSqrt)
+	fp.Mul(&y, &y, &u1)
+
+	P, err := goldilocks.FromAffine(&x, &y)
+	if err != nil {
+		return ErrUnmarshal
+	}
+	e.p = *P
+	return nil
+}
+
+// isLessThanLE returns true if x < y, where both are little-endian numbers
+// of the same length.
+func isLessThanLE(x, y []byte) bool {
+	for i := len(x) - 1; i >= 0; i-- {
+		if x[i] != y[i] {
+			return x[i] < y[i]
+		}
+	}
+	return false
+}
+
+func (s *decafScalar) Group() Group { return Decaf448 }
+
+func (s *decafScalar) String() string {
+	k := s.k
+	k.Red()
+	return conv.BytesLe2Hex(k[:])
+}
+
+func (s *decafScalar) SetUint64(n uint64) Scalar {
+	s.k = goldilocks.Scalar{}
+	binary.LittleEndian.PutUint64(s.k[:], n)
+	return s
+}
+
+func (s *decafScalar) IsZero() bool {
+	k := s.k
+	return k.IsZero()
+}
+
+func (s *decafScalar) IsEqual(x Scalar) bool {
+	a, b := s.k, x.(*decafScalar).k
+	a.Red()
+	b.Red()
+	return subtle.ConstantTimeCompare(a[:], b[:]) == 1
+}
+
+func (s *decafScalar) Set(x Scalar) Scalar {
+	s.k = x.(*decafScalar).k
+	return s
+}
+
+func (s *decafScalar) Copy() Scalar {
+	return &decafScalar{s.k}
+}
+
+func (s *decafScalar) CMov(v int, x Scalar) Scalar {
+	if !(v == 0 || v == 1) {
+		panic(ErrSelector)
+	}
+	mask := byte(-v)
+	xk := &x.(*decafScalar).k
+	for i := range s.k {
+		s.k[i] ^= mask & (s.k[i] ^ xk[i])
+	}
+	return s
+}
+
+func (s *decafScalar) CSelect(v int, x Scalar, y Scalar) Scalar {
+	if !(v == 0 || v == 1) {
+		panic(ErrSelector)
+	}
+	t := y.Copy().CMov(v, x)
+	return s.Set(t)
+}
+
+func (s *decafScalar) Add(x Scalar, y Scalar) Scalar {
+	s.k.Add(&x.(*decafScalar).k, &y.(*decafScalar).k)
+	return s
+}
+
+func (s *decafScalar) Sub(x Scalar, y Scalar) Scalar {
+	s.k.Sub(&x.(*decafScalar).k, &y.(*decafScalar).k)
+	return s
+}
+
+func (s *decafScalar) Mul(x Scalar, y Scalar) Scalar {
+	s.k.Mul(&x.(*decafScalar).k, &y.(*decafScalar).k)
+	return s
+}
+
+func (s *decafScalar) Neg(x Scalar) Scalar {
+	s.k = x.(*decafScalar).k
+	s.k.Neg()
+	return s
+}
+
+// Inv sets the receiver to 1/x computed as x^(order-2).
+func (s *decafScalar) Inv(x Scalar) Scalar {
+	var e, two goldilocks.Scalar
+	two[0] = 2
+	e.Sub(&e, &two) // e = order-2
+	base := x.(*decafScalar).k
+	z := goldilocks.Scalar{1}
+	for i := 8*len(e) - 1; i >= 0; i-- {
+		z.Mul(&z, &z)
+		if (e[i/8]>>(i%8))&1 == 1 {
+			z.Mul(&z, &base)
+		}
+	}
+	s.k = z
+	return s
+}
+
+func (s *decafScalar) MarshalBinary() ([]byte, error) {
+	k := s.k
+	k.Red()
+	return append([]byte{}, k[:]...), nil
+}
+
+func (s *decafScalar) UnmarshalBinary(data []byte) error {
+	if len(data) != goldilocks.ScalarSize {
+		return ErrUnmarshal
+	}
+	s.k.FromBytes(data)
+	return nil
+}
@@ -0,0 +1,61 @@
+package group
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+// https://www.rfc-editor.org/rfc/rfc9496#appendix-B.1
+func TestDecafGeneratorMultiples(t *testing.T) {
+	encVec := []string{
+		strings.Repeat("00", 56),
+		strings.Repeat("66", 28) + strings.Repeat("33", 28),
+		"c898eb4f87f97c564c6fd61fc7e49689314a1f818ec85eeb3bd5514ac816d387" +
+			"78f69ef347a89fca817e66defdedce178c7cc709b2116e75",
+	}
+
+	g := Decaf448
+	base := g.NewElement()
+	for i := range encVec {
+		enc, err := base.MarshalBinary()
+		if err != nil {
+			t.Fatalf("MarshalBinary %d", i)
+		}
+		if hex.EncodeToString(enc) != encVec[i] {
+			t.Fatalf("Multiple %d mismatch", i)
+		}
+		P := g.NewElement()
+		if err = P.UnmarshalBinary(enc); err != nil {
+			t.Fatalf("UnmarshalBinary %d", i)
+		}
+		if !P.IsEqual(base) {
+			t.Fatalf("Decoded multiple %d mismatch", i)
+		}
+		base.Add(base, g.Generator())
+	}
+}
+
+func TestDecafInvalidEncodings(t *testing.T) {
+	encVec := []string{
+		// Non-canonical field encodings.
+		strings.Repeat("ff", 28) + "fe" + strings.Repeat("ff", 27),
+		strings.Repeat("ff", 56),
+		// Negative field elements.
+		"01" + strings.Repeat("00", 55),
+		strings.Repeat("ff", 28) + "fd" + strings.Repeat("ff", 27),
+		// Invalid length.
+		strings.Repeat("00", 55),
+	}
+
+	for i, enc := range encVec {
+		raw, err := hex.DecodeString(enc)
+		if err != nil {
+			t.Fatal("DecodeString")
+		}
+		err = Decaf448.NewElement().UnmarshalBinary(raw)
+		if err == nil {
+			t.Fatalf("Decode succeeded for vector %d: %v", i, enc)
+		}
+	}
+}
@@ -73,6 +73,9 @@ func (c *encdecContext) increment() error {
 }
 
 func (c *sealContext) Seal(pt, aad []byte) ([]byte, error) {
+	if c.AEAD == nil {
+		return nil, ErrAEADExportOnly
+	}
 	ct := c.AEAD.Seal(nil, c.calcNonce(), pt, aad)
 	err := c.increment()
 	if err != nil {
@@ -85,6 +88,9 @@ func (c *sealContext) Seal(pt, aad []byte) ([]byte, error) {
 }
 
 func (c *openContext) Open(ct, aad []byte) ([]byte, error) {
+	if c.AEAD == nil {
+		return nil, ErrAEADExportOnly
+	}
 	pt, err := c.AEAD.Open(nil, c.calcNonce(), ct, aad)
 	if err != nil {
 		return nil, err
@@ -15,6 +15,10 @@ import (
 	"github.com/cloudflare/circl/dh/x448"
 	"github.com/cloudflare/circl/ecc/p384"
 	"github.com/cloudflare/circl/kem"
+	"github.com/cloudflare/circl/kem/kyber/kyber768"
+	"github.com/cloudflare/circl/kem/mlkem/mlkem1024"
+	"github.com/cloudflare/circl/kem/mlkem/mlkem512"
+	"github.com/cloudflare/circl/kem/mlkem/mlkem768"
 	"golang.org/x/crypto/chacha20poly1305"
 	"golang.org/x/crypto/hkdf"
 )
@@ -35,6 +39,15 @@ const (
 	// KEM_X448_HKDF_SHA512 is a KEM using X448 Diffie-Hellman function and
 	// HKDF with SHA-512.
 	KEM_X448_HKDF_SHA512 KEM = 0x21
+	// KEM_X25519_KYBER768_DRAFT00 is a hybrid KEM built on
+	// DHKEM(X25519, HKDF-SHA256) and Kyber768Draft00.
+	KEM_X25519_KYBER768_DRAFT00 KEM = 0x30
+	// KEM_MLKEM512 is a post-quantum KEM using ML-KEM-512.
+	KEM_MLKEM512 KEM = 0x40
+	// KEM_MLKEM768 is a post-quantum KEM using ML-KEM-768.
+	KEM_MLKEM768 KEM = 0x41
+	// KEM_MLKEM1024 is a post-quantum KEM using ML-KEM-1024.
+	KEM_MLKEM1024 KEM = 0x42
 )
 
 // IsValid returns true if the KEM identifier is supported by the HPKE package.
@@ -44,7 +57,11 @@ func (k KEM) IsValid() bool {
 		KEM_P384_HKDF_SHA384,
 		KEM_P521_HKDF_SHA512,
 		KEM_X25519_HKDF_SHA256,
-		KEM_X448_HKDF_SHA512:
+		KEM_X448_HKDF_SHA512,
+		KEM_X25519_KYBER768_DRAFT00,
+		KEM_MLKEM512,
+		KEM_MLKEM768,
+		KEM_MLKEM1024:
 		return true
 	default:
 		return false
@@ -53,6 +70,9 @@ func (k KEM) IsValid() bool {
 
 // Scheme returns an instance of a KEM that supports authentication. Panics if
 // the KEM identifier is invalid.
+//
+// The post-quantum and hybrid KEMs do not support the Auth and AuthPSK modes,
+// so their authenticated operations return ErrAuthNotSupported.
 func (k KEM) Scheme() kem.AuthScheme {
 	switch k {
 	case KEM_P256_HKDF_SHA256:
@@ -65,6 +85,14 @@ func (k KEM) Scheme() kem.AuthScheme {
 		return dhkemx25519hkdfsha256
 	case KEM_X448_HKDF_SHA512:
 		return dhkemx448hkdfsha512
+	case KEM_X25519_KYBER768_DRAFT00:
+		return hybridkemX25519Kyber768
+	case KEM_MLKEM512:
+		return kemMLKEM512
+	case KEM_MLKEM768:
+		return kemMLKEM768
+	case KEM_MLKEM1024:
+		return kemMLKEM1024
 	default:
 		panic(ErrInvalidKEM)
 	}
@@ -78,6 +106,12 @@ func (k KEM) validatePublicKey(pk kem.PublicKey) bool {
 	case KEM_X25519_HKDF_SHA256, KEM_X448_HKDF_SHA512:
 		pub, ok := pk.(*xKEMPubKey)
 		return ok && k == pub.scheme.id && pub.Validate()
+	case KEM_X25519_KYBER768_DRAFT00:
+		pub, ok := pk.(*hybridKEMPubKey)
+		return ok && k == pub.scheme.id && pub.Validate()
+	case KEM_MLKEM512, KEM_MLKEM768, KEM_MLKEM1024:
+		pub, ok := pk.(*mlKEMPubKey)
+		return ok && k == pub.scheme.id && pub.Validate()
 	default:
 		panic(ErrInvalidKEM)
 	}
@@ -91,6 +125,12 @@ func (k KEM) validatePrivateKey(sk kem.PrivateKey) bool {
 	case KEM_X25519_HKDF_SHA256, KEM_X448_HKDF_SHA512:
 		priv, ok := sk.(*xKEMPrivKey)
 		return ok && k == priv.scheme.id && priv.Validate()
+	case KEM_X25519_KYBER768_DRAFT00:
+		priv, ok := sk.(*hybridKEMPrivKey)
+		return ok && k == priv.scheme.id && priv.Validate()
+	case KEM_MLKEM512, KEM_MLKEM768, KEM_MLKEM1024:
+		priv, ok := sk.(*mlKEMPrivKey)
+		return ok && k == priv.scheme.id && priv.Validate()
 	default:
 		panic(ErrInvalidKEM)
 	}
@@ -185,6 +225,9 @@ const (
 	AEAD_AES256GCM AEAD = 0x02
 	// AEAD_ChaCha20Poly1305 is ChaCha20 stream cipher and Poly1305 MAC.
 	AEAD_ChaCha20Poly1305 AEAD = 0x03
+	// AEAD_ExportOnly indicates that the HPKE context is only used to export
+	// secrets, so sealing and opening fail with ErrAEADExportOnly.
+	AEAD_ExportOnly AEAD = 0xFFFF
 )
 
 // New instantiates an AEAD cipher from the identifier, returns an error if the
@@ -199,6 +242,8 @@ func (a AEAD) New(key []byte) (cipher.AEAD, error) {
 		return cipher.NewGCM(block)
 	case AEAD_ChaCha20Poly1305:
 		return chacha20poly1305.New(key)
+	case AEAD_ExportOnly:
+		return nil, ErrAEADExportOnly
 	default:
 		panic(ErrInvalidAEAD)
 	}
@@ -208,7 +253,8 @@ func (a AEAD) IsValid() bool {
 	switch a {
 	case AEAD_AES128GCM,
 		AEAD_AES256GCM,
-		AEAD_ChaCha20Poly1305:
+		AEAD_ChaCha20Poly1305,
+		AEAD_ExportOnly:
 		return true
 	default:
 		return false
@@ -224,17 +270,22 @@ func (a AEAD) KeySize() uint {
 		return 32
 	case AEAD_ChaCha20Poly1305:
 		return chacha20poly1305.KeySize
+	case AEAD_ExportOnly:
+		return 0
 	default:
 		panic(ErrInvalidAEAD)
 	}
 }
 
 // CipherLen returns the length of a ciphertext corresponding to a message of
-// length mLen.
+// length mLen. It returns 0 for AEAD_ExportOnly, which produces no
+// ciphertexts.
 func (a AEAD) CipherLen(mLen uint) uint {
 	switch a {
 	case AEAD_AES128GCM, AEAD_AES256GCM, AEAD_ChaCha20Poly1305:
 		return mLen + 16
+	case AEAD_ExportOnly:
+		return 0
 	default:
 		panic(ErrInvalidAEAD)
 	}
@@ -243,6 +294,8 @@ func (a AEAD) CipherLen(mLen uint) uint {
 var (
 	dhkemp256hkdfsha256, dhkemp384hkdfsha384, dhkemp521hkdfsha512 shortKEM
 	dhkemx25519hkdfsha256, dhkemx448hkdfsha512                    xKEM
+	hybridkemX25519Kyber768                                       hybridKEM
+	kemMLKEM512, kemMLKEM768, kemMLKEM1024                        mlKEM
 )
 
 func init() {
@@ -275,4 +328,22 @@ func init() {
 	dhkemx448hkdfsha512.kemBase.name = "HPKE_KEM_X448_HKDF_SHA512"
 	dhkemx448hkdfsha512.kemBase.Hash = crypto.SHA512
 	dhkemx448hkdfsha512.kemBase.dhKEM = dhkemx448hkdfsha512
+
+	hybridkemX25519Kyber768.kemBase.id = KEM_X25519_KYBER768_DRAFT00
+	hybridkemX25519Kyber768.kemBase.name = "HPKE_KEM_X25519_KYBER768_HKDF_SHA256"
+	hybridkemX25519Kyber768.kemBase.Hash = crypto.SHA256
+	hybridkemX25519Kyber768.kemA = dhkemx25519hkdfsha256
+	hybridkemX25519Kyber768.kemB = kyber768.Scheme()
+
+	kemMLKEM512.id = KEM_MLKEM512
+	kemMLKEM512.name = "HPKE_KEM_MLKEM512"
+	kemMLKEM512.inner = mlkem512.Scheme()
+
+	kemMLKEM768.id = KEM_MLKEM768
+	kemMLKEM768.name = "HPKE_KEM_MLKEM768"
+	kemMLKEM768.inner = mlkem768.Scheme()
+
+	kemMLKEM1024.id = KEM_MLKEM1024
+	kemMLKEM1024.name = "HPKE_KEM_MLKEM1024"
+	kemMLKEM1024.inner = mlkem1024.Scheme()
 }
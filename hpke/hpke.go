@@ -7,10 +7,6 @@
 //
 // Specification in
 // https://datatracker.ietf.org/doc/draft-irtf-cfrg-hpke
-//
-// BUG(cjpatton): This package does not implement the "Export-Only" mode of the
-// HPKE context. In particular, it does not recognize the AEAD codepoint
-// reserved for this purpose (0xFFFF).
 package hpke
 
 import (
@@ -119,6 +115,9 @@ func (suite Suite) NewSender(pkR kem.PublicKey, info []byte) (*Sender, error) {
 // Returns the Sealer and corresponding encapsulated key.
 func (s *Sender) Setup(rnd io.Reader) (enc []byte, seal Sealer, err error) {
 	s.modeID = modeBase
+	s.state.skS = nil
+	s.state.psk = nil
+	s.state.pskID = nil
 	return s.allSetup(rnd)
 }
 
@@ -133,6 +132,8 @@ func (s *Sender) SetupAuth(rnd io.Reader, skS kem.PrivateKey) (
 
 	s.modeID = modeAuth
 	s.state.skS = skS
+	s.state.psk = nil
+	s.state.pskID = nil
 	return s.allSetup(rnd)
 }
 
@@ -142,6 +143,7 @@ func (s *Sender) SetupPSK(rnd io.Reader, psk, pskID []byte) (
 	enc []byte, seal Sealer, err error,
 ) {
 	s.modeID = modePSK
+	s.state.skS = nil
 	s.state.psk = psk
 	s.state.pskID = pskID
 	return s.allSetup(rnd)
@@ -186,6 +188,9 @@ func (suite Suite) NewReceiver(skR kem.PrivateKey, info []byte) (
 func (r *Receiver) Setup(enc []byte) (Opener, error) {
 	r.modeID = modeBase
 	r.enc = enc
+	r.state.pkS = nil
+	r.state.psk = nil
+	r.state.pskID = nil
 	return r.allSetup()
 }
 
@@ -199,6 +204,8 @@ func (r *Receiver) SetupAuth(enc []byte, pkS kem.PublicKey) (Opener, error) {
 	r.modeID = modeAuth
 	r.enc = enc
 	r.state.pkS = pkS
+	r.state.psk = nil
+	r.state.pskID = nil
 	return r.allSetup()
 }
 
@@ -208,6 +215,7 @@ func (r *Receiver) SetupAuth(enc []byte, pkS kem.PublicKey) (Opener, error) {
 func (r *Receiver) SetupPSK(enc, psk, pskID []byte) (Opener, error) {
 	r.modeID = modePSK
 	r.enc = enc
+	r.state.pkS = nil
 	r.state.psk = psk
 	r.state.pskID = pskID
 	return r.allSetup()
@@ -237,7 +245,7 @@ func (s *Sender) allSetup(rnd io.Reader) ([]byte, Sealer, error) {
 	if rnd == nil {
 		rnd = rand.Reader
 	}
-	seed := make([]byte, scheme.SeedSize())
+	seed := make([]byte, scheme.EncapsulationSeedSize())
 	_, err := io.ReadFull(rnd, seed)
 	if err != nil {
 		return nil, nil, err
@@ -292,4 +300,9 @@ var (
 	ErrInvalidKEMPrivateKey   = errors.New("hpke: invalid KEM private key")
 	ErrInvalidKEMSharedSecret = errors.New("hpke: invalid KEM shared secret")
 	ErrAEADSeqOverflows       = errors.New("hpke: AEAD sequence number overflows")
+	ErrAuthNotSupported       = errors.New("hpke: KEM does not support authentication")
+	ErrAEADExportOnly         = errors.New("hpke: AEAD is export-only")
+	ErrInvalidSegmentSize     = errors.New("hpke: invalid stream segment size")
+	ErrInvalidSegmentIndex    = errors.New("hpke: invalid stream segment index")
+	ErrInvalidStreamSize      = errors.New("hpke: invalid stream size")
 )
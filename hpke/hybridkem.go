@@ -0,0 +1,251 @@
+package hpke
+
+// This file implements the hybrid X25519Kyber768Draft00 KEM for HPKE as
+// specified in
+// https://datatracker.ietf.org/doc/draft-westerbaan-cfrg-hpke-xyber768d00/
+//
+// Shared secrets, ciphertexts and keys are the concatenation of those of
+// DHKEM(X25519, HKDF-SHA256) and Kyber768Draft00. Note that it is not safe
+// to combine arbitrary KEMs in this way, see the security considerations
+// of the draft.
+
+import (
+	"crypto/rand"
+	"io"
+
+	"github.com/cloudflare/circl/kem"
+)
+
+type hybridKEM struct {
+	kemBase
+	kemA kem.Scheme
+	kemB kem.Scheme
+}
+
+func (h hybridKEM) SeedSize() int { return 32 }
+func (h hybridKEM) PrivateKeySize() int {
+	return h.kemA.PrivateKeySize() + h.kemB.PrivateKeySize()
+}
+
+func (h hybridKEM) PublicKeySize() int {
+	return h.kemA.PublicKeySize() + h.kemB.PublicKeySize()
+}
+
+func (h hybridKEM) CiphertextSize() int {
+	return h.kemA.CiphertextSize() + h.kemB.CiphertextSize()
+}
+
+func (h hybridKEM) EncapsulationSeedSize() int {
+	return h.kemA.EncapsulationSeedSize() + h.kemB.EncapsulationSeedSize()
+}
+
+func (h hybridKEM) SharedKeySize() int {
+	return h.kemA.SharedKeySize() + h.kemB.SharedKeySize()
+}
+
+// Deterministicallly derives a keypair from a seed. If you're unsure,
+// you're better off using GenerateKey().
+//
+// Panics if seed is not of length SeedSize().
+func (h hybridKEM) DeriveKeyPair(seed []byte) (kem.PublicKey, kem.PrivateKey) {
+	if len(seed) != h.SeedSize() {
+		panic(kem.ErrSeedSize)
+	}
+	sizeA := h.kemA.SeedSize()
+	dkpPrk := h.labeledExtract(nil, []byte("dkp_prk"), seed)
+	bytes := h.labeledExpand(
+		dkpPrk,
+		[]byte("sk"),
+		nil,
+		uint16(sizeA+h.kemB.SeedSize()),
+	)
+	pkA, skA := h.kemA.DeriveKeyPair(bytes[:sizeA])
+	pkB, skB := h.kemB.DeriveKeyPair(bytes[sizeA:])
+	pk := &hybridKEMPubKey{h, pkA, pkB}
+	return pk, &hybridKEMPrivKey{h, skA, skB, pk}
+}
+
+func (h hybridKEM) GenerateKeyPair() (kem.PublicKey, kem.PrivateKey, error) {
+	seed := make([]byte, h.SeedSize())
+	_, err := io.ReadFull(rand.Reader, seed)
+	if err != nil {
+		return nil, nil, err
+	}
+	pk, sk := h.DeriveKeyPair(seed)
+	return pk, sk, nil
+}
+
+func (h hybridKEM) UnmarshalBinaryPrivateKey(data []byte) (kem.PrivateKey, error) {
+	if len(data) != h.PrivateKeySize() {
+		return nil, ErrInvalidKEMPrivateKey
+	}
+	sizeA := h.kemA.PrivateKeySize()
+	skA, err := h.kemA.UnmarshalBinaryPrivateKey(data[:sizeA])
+	if err != nil {
+		return nil, err
+	}
+	skB, err := h.kemB.UnmarshalBinaryPrivateKey(data[sizeA:])
+	if err != nil {
+		return nil, err
+	}
+	return &hybridKEMPrivKey{scheme: h, privA: skA, privB: skB}, nil
+}
+
+func (h hybridKEM) UnmarshalBinaryPublicKey(data []byte) (kem.PublicKey, error) {
+	if len(data) != h.PublicKeySize() {
+		return nil, ErrInvalidKEMPublicKey
+	}
+	sizeA := h.kemA.PublicKeySize()
+	pkA, err := h.kemA.UnmarshalBinaryPublicKey(data[:sizeA])
+	if err != nil {
+		return nil, err
+	}
+	pkB, err := h.kemB.UnmarshalBinaryPublicKey(data[sizeA:])
+	if err != nil {
+		return nil, err
+	}
+	return &hybridKEMPubKey{h, pkA, pkB}, nil
+}
+
+func (h hybridKEM) Encapsulate(pkr kem.PublicKey) (ct, ss []byte, err error) {
+	seed := make([]byte, h.EncapsulationSeedSize())
+	_, err = io.ReadFull(rand.Reader, seed)
+	if err != nil {
+		return nil, nil, err
+	}
+	return h.EncapsulateDeterministically(pkr, seed)
+}
+
+func (h hybridKEM) EncapsulateDeterministically(
+	pkr kem.PublicKey, seed []byte,
+) (ct, ss []byte, err error) {
+	if len(seed) != h.EncapsulationSeedSize() {
+		return nil, nil, kem.ErrSeedSize
+	}
+	pub, ok := pkr.(*hybridKEMPubKey)
+	if !ok || pub.scheme.id != h.id {
+		return nil, nil, kem.ErrTypeMismatch
+	}
+
+	sizeA := h.kemA.EncapsulationSeedSize()
+	ctA, ssA, err := h.kemA.EncapsulateDeterministically(pub.pubA, seed[:sizeA])
+	if err != nil {
+		return nil, nil, err
+	}
+	ctB, ssB, err := h.kemB.EncapsulateDeterministically(pub.pubB, seed[sizeA:])
+	if err != nil {
+		return nil, nil, err
+	}
+	return append(ctA, ctB...), append(ssA, ssB...), nil
+}
+
+func (h hybridKEM) Decapsulate(skr kem.PrivateKey, ct []byte) ([]byte, error) {
+	if len(ct) != h.CiphertextSize() {
+		return nil, kem.ErrCiphertextSize
+	}
+	priv, ok := skr.(*hybridKEMPrivKey)
+	if !ok || priv.scheme.id != h.id {
+		return nil, kem.ErrTypeMismatch
+	}
+
+	sizeA := h.kemA.CiphertextSize()
+	ssA, err := h.kemA.Decapsulate(priv.privA, ct[:sizeA])
+	if err != nil {
+		return nil, err
+	}
+	ssB, err := h.kemB.Decapsulate(priv.privB, ct[sizeA:])
+	if err != nil {
+		return nil, err
+	}
+	return append(ssA, ssB...), nil
+}
+
+func (h hybridKEM) AuthEncapsulate(pkr kem.PublicKey, sks kem.PrivateKey) (
+	ct []byte, ss []byte, err error,
+) {
+	return nil, nil, ErrAuthNotSupported
+}
+
+func (h hybridKEM) AuthEncapsulateDeterministically(
+	pkr kem.PublicKey, sks kem.PrivateKey, seed []byte,
+) (ct, ss []byte, err error) {
+	return nil, nil, ErrAuthNotSupported
+}
+
+func (h hybridKEM) AuthDecapsulate(
+	skR kem.PrivateKey, ct []byte, pkS kem.PublicKey,
+) ([]byte, error) {
+	return nil, ErrAuthNotSupported
+}
+
+type hybridKEMPubKey struct {
+	scheme hybridKEM
+	pubA   kem.PublicKey
+	pubB   kem.PublicKey
+}
+
+func (k *hybridKEMPubKey) Scheme() kem.Scheme { return k.scheme }
+func (k *hybridKEMPubKey) MarshalBinary() ([]byte, error) {
+	pkA, err := k.pubA.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	pkB, err := k.pubB.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return append(pkA, pkB...), nil
+}
+
+func (k *hybridKEMPubKey) Equal(pk kem.PublicKey) bool {
+	k1, ok := pk.(*hybridKEMPubKey)
+	return ok &&
+		k.scheme.id == k1.scheme.id &&
+		k.pubA.Equal(k1.pubA) &&
+		k.pubB.Equal(k1.pubB)
+}
+
+func (k *hybridKEMPubKey) Validate() bool {
+	pubA, ok := k.pubA.(*xKEMPubKey)
+	return ok && pubA.Validate() && k.pubB != nil
+}
+
+type hybridKEMPrivKey struct {
+	scheme hybridKEM
+	privA  kem.PrivateKey
+	privB  kem.PrivateKey
+	pub    *hybridKEMPubKey
+}
+
+func (k *hybridKEMPrivKey) Scheme() kem.Scheme { return k.scheme }
+func (k *hybridKEMPrivKey) MarshalBinary() ([]byte, error) {
+	skA, err := k.privA.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	skB, err := k.privB.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return append(skA, skB...), nil
+}
+
+func (k *hybridKEMPrivKey) Equal(sk kem.PrivateKey) bool {
+	k1, ok := sk.(*hybridKEMPrivKey)
+	return ok &&
+		k.scheme.id == k1.scheme.id &&
+		k.privA.Equal(k1.privA) &&
+		k.privB.Equal(k1.privB)
+}
+
+func (k *hybridKEMPrivKey) Public() kem.PublicKey {
+	if k.pub == nil {
+		k.pub = &hybridKEMPubKey{k.scheme, k.privA.Public(), k.privB.Public()}
+	}
+	return k.pub
+}
+
+func (k *hybridKEMPrivKey) Validate() bool {
+	privA, ok := k.privA.(*xKEMPrivKey)
+	return ok && privA.Validate() && k.privB != nil
+}
@@ -64,6 +64,13 @@ func unmarshalContext(raw []byte) (*encdecContext, error) {
 		return nil, errors.New("invalid key length")
 	}
 
+	if c.suite.aeadID == AEAD_ExportOnly {
+		if len(c.baseNonce) != 0 || len(c.sequenceNumber) != 0 {
+			return nil, errors.New("invalid nonce for export-only context")
+		}
+		return c, nil
+	}
+
 	c.AEAD, err = c.suite.aeadID.New(c.key)
 	if err != nil {
 		return nil, err
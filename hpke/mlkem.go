@@ -0,0 +1,193 @@
+package hpke
+
+// This file implements ML-KEM as an HPKE KEM as specified in
+// https://datatracker.ietf.org/doc/draft-connolly-cfrg-hpke-mlkem/
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/cloudflare/circl/internal/sha3"
+	"github.com/cloudflare/circl/kem"
+)
+
+type mlKEM struct {
+	id    KEM
+	name  string
+	inner kem.Scheme
+}
+
+func (m mlKEM) Name() string               { return m.name }
+func (m mlKEM) SharedKeySize() int         { return m.inner.SharedKeySize() }
+func (m mlKEM) CiphertextSize() int        { return m.inner.CiphertextSize() }
+func (m mlKEM) PublicKeySize() int         { return m.inner.PublicKeySize() }
+func (m mlKEM) EncapsulationSeedSize() int { return m.inner.EncapsulationSeedSize() }
+
+// Private keys are serialized as the 64-byte seed (d, z) from which the
+// ML-KEM decapsulation key is expanded.
+func (m mlKEM) PrivateKeySize() int { return m.inner.SeedSize() }
+func (m mlKEM) SeedSize() int       { return m.inner.SeedSize() }
+
+// Deterministicallly derives a keypair from a seed. If you're unsure,
+// you're better off using GenerateKey().
+//
+// Panics if seed is not of length SeedSize().
+func (m mlKEM) DeriveKeyPair(seed []byte) (kem.PublicKey, kem.PrivateKey) {
+	if len(seed) != m.SeedSize() {
+		panic(kem.ErrSeedSize)
+	}
+	sk := m.expand(m.labeledDerive(seed, []byte("DeriveKeyPair"), nil,
+		uint16(m.PrivateKeySize())))
+	return sk.Public(), sk
+}
+
+// labeledDerive is the single-stage key derivation based on SHAKE256 used
+// to derive ML-KEM key pairs.
+func (m mlKEM) labeledDerive(ikm, label, context []byte, l uint16) []byte {
+	var suiteID [5]byte
+	suiteID[0], suiteID[1], suiteID[2] = 'K', 'E', 'M'
+	binary.BigEndian.PutUint16(suiteID[3:5], uint16(m.id))
+
+	var buf [2]byte
+	h := sha3.NewShake256()
+	_, _ = h.Write(ikm)
+	_, _ = h.Write([]byte(versionLabel))
+	_, _ = h.Write(suiteID[:])
+	binary.BigEndian.PutUint16(buf[:], uint16(len(label)))
+	_, _ = h.Write(buf[:])
+	_, _ = h.Write(label)
+	binary.BigEndian.PutUint16(buf[:], l)
+	_, _ = h.Write(buf[:])
+	_, _ = h.Write(context)
+	out := make([]byte, l)
+	_, _ = h.Read(out)
+	return out
+}
+
+func (m mlKEM) expand(seed []byte) *mlKEMPrivKey {
+	pk, sk := m.inner.DeriveKeyPair(seed)
+	return &mlKEMPrivKey{
+		scheme: m,
+		seed:   append([]byte(nil), seed...),
+		priv:   sk,
+		pub:    &mlKEMPubKey{m, pk},
+	}
+}
+
+func (m mlKEM) GenerateKeyPair() (kem.PublicKey, kem.PrivateKey, error) {
+	seed := make([]byte, m.PrivateKeySize())
+	_, err := io.ReadFull(rand.Reader, seed)
+	if err != nil {
+		return nil, nil, err
+	}
+	sk := m.expand(seed)
+	return sk.Public(), sk, nil
+}
+
+func (m mlKEM) UnmarshalBinaryPrivateKey(data []byte) (kem.PrivateKey, error) {
+	if len(data) != m.PrivateKeySize() {
+		return nil, ErrInvalidKEMPrivateKey
+	}
+	return m.expand(data), nil
+}
+
+func (m mlKEM) UnmarshalBinaryPublicKey(data []byte) (kem.PublicKey, error) {
+	if len(data) != m.PublicKeySize() {
+		return nil, ErrInvalidKEMPublicKey
+	}
+	pk, err := m.inner.UnmarshalBinaryPublicKey(data)
+	if err != nil {
+		return nil, ErrInvalidKEMPublicKey
+	}
+	return &mlKEMPubKey{m, pk}, nil
+}
+
+func (m mlKEM) Encapsulate(pkr kem.PublicKey) (ct, ss []byte, err error) {
+	pub, ok := pkr.(*mlKEMPubKey)
+	if !ok || pub.scheme.id != m.id {
+		return nil, nil, kem.ErrTypeMismatch
+	}
+	return m.inner.Encapsulate(pub.pub)
+}
+
+func (m mlKEM) EncapsulateDeterministically(
+	pkr kem.PublicKey, seed []byte,
+) (ct, ss []byte, err error) {
+	pub, ok := pkr.(*mlKEMPubKey)
+	if !ok || pub.scheme.id != m.id {
+		return nil, nil, kem.ErrTypeMismatch
+	}
+	return m.inner.EncapsulateDeterministically(pub.pub, seed)
+}
+
+func (m mlKEM) Decapsulate(skr kem.PrivateKey, ct []byte) ([]byte, error) {
+	priv, ok := skr.(*mlKEMPrivKey)
+	if !ok || priv.scheme.id != m.id {
+		return nil, kem.ErrTypeMismatch
+	}
+	return m.inner.Decapsulate(priv.priv, ct)
+}
+
+func (m mlKEM) AuthEncapsulate(pkr kem.PublicKey, sks kem.PrivateKey) (
+	ct []byte, ss []byte, err error,
+) {
+	return nil, nil, ErrAuthNotSupported
+}
+
+func (m mlKEM) AuthEncapsulateDeterministically(
+	pkr kem.PublicKey, sks kem.PrivateKey, seed []byte,
+) (ct, ss []byte, err error) {
+	return nil, nil, ErrAuthNotSupported
+}
+
+func (m mlKEM) AuthDecapsulate(
+	skR kem.PrivateKey, ct []byte, pkS kem.PublicKey,
+) ([]byte, error) {
+	return nil, ErrAuthNotSupported
+}
+
+type mlKEMPubKey struct {
+	scheme mlKEM
+	pub    kem.PublicKey
+}
+
+func (k *mlKEMPubKey) Scheme() kem.Scheme { return k.scheme }
+func (k *mlKEMPubKey) MarshalBinary() ([]byte, error) {
+	return k.pub.MarshalBinary()
+}
+
+func (k *mlKEMPubKey) Equal(pk kem.PublicKey) bool {
+	k1, ok := pk.(*mlKEMPubKey)
+	return ok &&
+		k.scheme.id == k1.scheme.id &&
+		k.pub.Equal(k1.pub)
+}
+func (k *mlKEMPubKey) Validate() bool { return k.pub != nil }
+
+type mlKEMPrivKey struct {
+	scheme mlKEM
+	seed   []byte
+	priv   kem.PrivateKey
+	pub    *mlKEMPubKey
+}
+
+func (k *mlKEMPrivKey) String() string     { return fmt.Sprintf("%x", k.seed) }
+func (k *mlKEMPrivKey) Scheme() kem.Scheme { return k.scheme }
+func (k *mlKEMPrivKey) MarshalBinary() ([]byte, error) {
+	return append(make([]byte, 0, k.scheme.PrivateKeySize()), k.seed...), nil
+}
+
+func (k *mlKEMPrivKey) Equal(pk kem.PrivateKey) bool {
+	k1, ok := pk.(*mlKEMPrivKey)
+	return ok &&
+		k.scheme.id == k1.scheme.id &&
+		subtle.ConstantTimeCompare(k.seed, k1.seed) == 1
+}
+
+func (k *mlKEMPrivKey) Public() kem.PublicKey { return k.pub }
+func (k *mlKEMPrivKey) Validate() bool {
+	return len(k.seed) == k.scheme.PrivateKeySize() && k.priv != nil
+}
@@ -0,0 +1,50 @@
+package hpke
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/cloudflare/circl/internal/test"
+)
+
+func TestPSKInputs(t *testing.T) {
+	kemID := KEM_X25519_HKDF_SHA256
+	suite := NewSuite(kemID, KDF_HKDF_SHA256, AEAD_AES128GCM)
+	pkR, skR, err := kemID.Scheme().GenerateKeyPair()
+	test.CheckNoErr(t, err, "generate key pair")
+	info := []byte("info")
+	psk, pskID := []byte("a PSK of at least 32 bytes long.."), []byte("psk id")
+
+	sender, err := suite.NewSender(pkR, info)
+	test.CheckNoErr(t, err, "new sender")
+	receiver, err := suite.NewReceiver(skR, info)
+	test.CheckNoErr(t, err, "new receiver")
+
+	// The PSK modes require a PSK.
+	_, _, err = sender.SetupPSK(rand.Reader, nil, nil)
+	test.CheckIsErr(t, err, "sender must reject PSK mode without PSK")
+	enc, _, err := sender.Setup(rand.Reader)
+	test.CheckNoErr(t, err, "sender setup")
+	_, err = receiver.SetupPSK(enc, nil, nil)
+	test.CheckIsErr(t, err, "receiver must reject PSK mode without PSK")
+
+	// The base mode must not use the PSK of a previous setup.
+	st := state{Suite: suite, modeID: modeBase}
+	test.CheckIsErr(t, st.verifyPSKInputs(psk, pskID), "base mode must reject a PSK")
+	st.modeID = modeAuth
+	test.CheckIsErr(t, st.verifyPSKInputs(psk, pskID), "auth mode must reject a PSK")
+
+	_, _, err = sender.SetupPSK(rand.Reader, psk, pskID)
+	test.CheckNoErr(t, err, "sender PSK setup")
+	enc, sealer, err := sender.Setup(rand.Reader)
+	test.CheckNoErr(t, err, "sender setup after PSK setup")
+	_, err = receiver.SetupPSK(enc, psk, pskID)
+	test.CheckNoErr(t, err, "receiver PSK setup")
+	opener, err := receiver.Setup(enc)
+	test.CheckNoErr(t, err, "receiver setup after PSK setup")
+
+	ct, err := sealer.Seal([]byte("message"), nil)
+	test.CheckNoErr(t, err, "seal")
+	_, err = opener.Open(ct, nil)
+	test.CheckNoErr(t, err, "open")
+}
@@ -0,0 +1,190 @@
+package hpke
+
+import (
+	"io"
+
+	"github.com/cloudflare/circl/kem"
+)
+
+// This file implements the single-shot APIs of RFC 9180, Section 6, which
+// set up an HPKE context and use it to seal, open or export exactly once.
+
+// Seal sets up a Base Mode context and encrypts a single message with it.
+// Returns the encapsulated key and the ciphertext.
+func (s *Sender) Seal(rnd io.Reader, pt, aad []byte) (enc, ct []byte, err error) {
+	enc, sealer, err := s.Setup(rnd)
+	return sealOnce(enc, sealer, err, pt, aad)
+}
+
+// SealPSK sets up a PSK Mode context and encrypts a single message with it.
+// Returns the encapsulated key and the ciphertext.
+func (s *Sender) SealPSK(rnd io.Reader, psk, pskID, pt, aad []byte) (
+	enc, ct []byte, err error,
+) {
+	enc, sealer, err := s.SetupPSK(rnd, psk, pskID)
+	return sealOnce(enc, sealer, err, pt, aad)
+}
+
+// SealAuth sets up an Auth Mode context and encrypts a single message with
+// it. Returns the encapsulated key and the ciphertext.
+func (s *Sender) SealAuth(rnd io.Reader, skS kem.PrivateKey, pt, aad []byte) (
+	enc, ct []byte, err error,
+) {
+	enc, sealer, err := s.SetupAuth(rnd, skS)
+	return sealOnce(enc, sealer, err, pt, aad)
+}
+
+// SealAuthPSK sets up an Auth-PSK Mode context and encrypts a single message
+// with it. Returns the encapsulated key and the ciphertext.
+func (s *Sender) SealAuthPSK(
+	rnd io.Reader, skS kem.PrivateKey, psk, pskID, pt, aad []byte,
+) (enc, ct []byte, err error) {
+	enc, sealer, err := s.SetupAuthPSK(rnd, skS, psk, pskID)
+	return sealOnce(enc, sealer, err, pt, aad)
+}
+
+// Export sets up a Base Mode context and exports a secret of the given
+// length from it. Returns the encapsulated key and the exported secret.
+func (s *Sender) Export(rnd io.Reader, exporterContext []byte, length uint) (
+	enc, secret []byte, err error,
+) {
+	enc, sealer, err := s.Setup(rnd)
+	return exportOnce(enc, sealer, err, exporterContext, length)
+}
+
+// ExportPSK sets up a PSK Mode context and exports a secret of the given
+// length from it. Returns the encapsulated key and the exported secret.
+func (s *Sender) ExportPSK(
+	rnd io.Reader, psk, pskID, exporterContext []byte, length uint,
+) (enc, secret []byte, err error) {
+	enc, sealer, err := s.SetupPSK(rnd, psk, pskID)
+	return exportOnce(enc, sealer, err, exporterContext, length)
+}
+
+// ExportAuth sets up an Auth Mode context and exports a secret of the given
+// length from it. Returns the encapsulated key and the exported secret.
+func (s *Sender) ExportAuth(
+	rnd io.Reader, skS kem.PrivateKey, exporterContext []byte, length uint,
+) (enc, secret []byte, err error) {
+	enc, sealer, err := s.SetupAuth(rnd, skS)
+	return exportOnce(enc, sealer, err, exporterContext, length)
+}
+
+// ExportAuthPSK sets up an Auth-PSK Mode context and exports a secret of the
+// given length from it. Returns the encapsulated key and the exported secret.
+func (s *Sender) ExportAuthPSK(
+	rnd io.Reader, skS kem.PrivateKey, psk, pskID, exporterContext []byte,
+	length uint,
+) (enc, secret []byte, err error) {
+	enc, sealer, err := s.SetupAuthPSK(rnd, skS, psk, pskID)
+	return exportOnce(enc, sealer, err, exporterContext, length)
+}
+
+// Open sets up a Base Mode context from an encapsulated key and decrypts a
+// single message with it.
+func (r *Receiver) Open(enc, ct, aad []byte) (pt []byte, err error) {
+	opener, err := r.Setup(enc)
+	return openOnce(opener, err, ct, aad)
+}
+
+// OpenPSK sets up a PSK Mode context from an encapsulated key and decrypts
+// a single message with it.
+func (r *Receiver) OpenPSK(enc, psk, pskID, ct, aad []byte) (
+	pt []byte, err error,
+) {
+	opener, err := r.SetupPSK(enc, psk, pskID)
+	return openOnce(opener, err, ct, aad)
+}
+
+// OpenAuth sets up an Auth Mode context from an encapsulated key and
+// decrypts a single message with it.
+func (r *Receiver) OpenAuth(enc []byte, pkS kem.PublicKey, ct, aad []byte) (
+	pt []byte, err error,
+) {
+	opener, err := r.SetupAuth(enc, pkS)
+	return openOnce(opener, err, ct, aad)
+}
+
+// OpenAuthPSK sets up an Auth-PSK Mode context from an encapsulated key and
+// decrypts a single message with it.
+func (r *Receiver) OpenAuthPSK(
+	enc, psk, pskID []byte, pkS kem.PublicKey, ct, aad []byte,
+) (pt []byte, err error) {
+	opener, err := r.SetupAuthPSK(enc, psk, pskID, pkS)
+	return openOnce(opener, err, ct, aad)
+}
+
+// Export sets up a Base Mode context from an encapsulated key and exports a
+// secret of the given length from it.
+func (r *Receiver) Export(enc, exporterContext []byte, length uint) (
+	secret []byte, err error,
+) {
+	opener, err := r.Setup(enc)
+	return exportOnceOpener(opener, err, exporterContext, length)
+}
+
+// ExportPSK sets up a PSK Mode context from an encapsulated key and exports
+// a secret of the given length from it.
+func (r *Receiver) ExportPSK(
+	enc, psk, pskID, exporterContext []byte, length uint,
+) (secret []byte, err error) {
+	opener, err := r.SetupPSK(enc, psk, pskID)
+	return exportOnceOpener(opener, err, exporterContext, length)
+}
+
+// ExportAuth sets up an Auth Mode context from an encapsulated key and
+// exports a secret of the given length from it.
+func (r *Receiver) ExportAuth(
+	enc []byte, pkS kem.PublicKey, exporterContext []byte, length uint,
+) (secret []byte, err error) {
+	opener, err := r.SetupAuth(enc, pkS)
+	return exportOnceOpener(opener, err, exporterContext, length)
+}
+
+// ExportAuthPSK sets up an Auth-PSK Mode context from an encapsulated key
+// and exports a secret of the given length from it.
+func (r *Receiver) ExportAuthPSK(
+	enc, psk, pskID []byte, pkS kem.PublicKey, exporterContext []byte,
+	length uint,
+) (secret []byte, err error) {
+	opener, err := r.SetupAuthPSK(enc, psk, pskID, pkS)
+	return exportOnceOpener(opener, err, exporterContext, length)
+}
+
+func sealOnce(enc []byte, sealer Sealer, err error, pt, aad []byte) (
+	[]byte, []byte, error,
+) {
+	if err != nil {
+		return nil, nil, err
+	}
+	ct, err := sealer.Seal(pt, aad)
+	if err != nil {
+		return nil, nil, err
+	}
+	return enc, ct, nil
+}
+
+func openOnce(opener Opener, err error, ct, aad []byte) ([]byte, error) {
+	if err != nil {
+		return nil, err
+	}
+	return opener.Open(ct, aad)
+}
+
+func exportOnce(
+	enc []byte, sealer Sealer, err error, exporterContext []byte, length uint,
+) ([]byte, []byte, error) {
+	if err != nil {
+		return nil, nil, err
+	}
+	return enc, sealer.Export(exporterContext, length), nil
+}
+
+func exportOnceOpener(
+	opener Opener, err error, exporterContext []byte, length uint,
+) ([]byte, error) {
+	if err != nil {
+		return nil, err
+	}
+	return opener.Export(exporterContext, length), nil
+}
@@ -0,0 +1,149 @@
+package hpke
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+
+	"github.com/cloudflare/circl/internal/test"
+)
+
+func TestSingleShot(t *testing.T) {
+	for _, aead := range []AEAD{AEAD_ChaCha20Poly1305, AEAD_ExportOnly} {
+		s := NewSuite(KEM_X25519_HKDF_SHA256, KDF_HKDF_SHA256, aead)
+		info := []byte("info")
+		pkR, skR, err := s.kemID.Scheme().GenerateKeyPair()
+		test.CheckNoErr(t, err, "keygen")
+		pkS, skS, err := s.kemID.Scheme().GenerateKeyPair()
+		test.CheckNoErr(t, err, "keygen")
+		sender, err := s.NewSender(pkR, info)
+		test.CheckNoErr(t, err, "sender")
+		receiver, err := s.NewReceiver(skR, info)
+		test.CheckNoErr(t, err, "receiver")
+
+		psk, pskID := []byte("psk"), []byte("pskID")
+		pt, aad, expCtx := []byte("message"), []byte("aad"), []byte("context")
+
+		for _, mode := range []struct {
+			name   string
+			seal   func() ([]byte, []byte, error)
+			open   func(enc, ct []byte) ([]byte, error)
+			sendEx func() ([]byte, []byte, error)
+			recvEx func(enc []byte) ([]byte, error)
+		}{
+			{
+				"Base",
+				func() ([]byte, []byte, error) { return sender.Seal(rand.Reader, pt, aad) },
+				func(enc, ct []byte) ([]byte, error) { return receiver.Open(enc, ct, aad) },
+				func() ([]byte, []byte, error) { return sender.Export(rand.Reader, expCtx, 32) },
+				func(enc []byte) ([]byte, error) { return receiver.Export(enc, expCtx, 32) },
+			},
+			{
+				"PSK",
+				func() ([]byte, []byte, error) {
+					return sender.SealPSK(rand.Reader, psk, pskID, pt, aad)
+				},
+				func(enc, ct []byte) ([]byte, error) {
+					return receiver.OpenPSK(enc, psk, pskID, ct, aad)
+				},
+				func() ([]byte, []byte, error) {
+					return sender.ExportPSK(rand.Reader, psk, pskID, expCtx, 32)
+				},
+				func(enc []byte) ([]byte, error) {
+					return receiver.ExportPSK(enc, psk, pskID, expCtx, 32)
+				},
+			},
+			{
+				"Auth",
+				func() ([]byte, []byte, error) {
+					return sender.SealAuth(rand.Reader, skS, pt, aad)
+				},
+				func(enc, ct []byte) ([]byte, error) {
+					return receiver.OpenAuth(enc, pkS, ct, aad)
+				},
+				func() ([]byte, []byte, error) {
+					return sender.ExportAuth(rand.Reader, skS, expCtx, 32)
+				},
+				func(enc []byte) ([]byte, error) {
+					return receiver.ExportAuth(enc, pkS, expCtx, 32)
+				},
+			},
+			{
+				"AuthPSK",
+				func() ([]byte, []byte, error) {
+					return sender.SealAuthPSK(rand.Reader, skS, psk, pskID, pt, aad)
+				},
+				func(enc, ct []byte) ([]byte, error) {
+					return receiver.OpenAuthPSK(enc, psk, pskID, pkS, ct, aad)
+				},
+				func() ([]byte, []byte, error) {
+					return sender.ExportAuthPSK(rand.Reader, skS, psk, pskID, expCtx, 32)
+				},
+				func(enc []byte) ([]byte, error) {
+					return receiver.ExportAuthPSK(enc, psk, pskID, pkS, expCtx, 32)
+				},
+			},
+		} {
+			h := s.String() + " mode: " + mode.name
+			enc, ct, err := mode.seal()
+			if aead == AEAD_ExportOnly {
+				test.CheckIsErr(t, err, h+" export-only must not seal")
+			} else {
+				test.CheckNoErr(t, err, h+" seal")
+				got, err := mode.open(enc, ct)
+				test.CheckNoErr(t, err, h+" open")
+				if !bytes.Equal(got, pt) {
+					test.ReportError(t, got, pt, h)
+				}
+			}
+
+			enc, secret, err := mode.sendEx()
+			test.CheckNoErr(t, err, h+" sender export")
+			got, err := mode.recvEx(enc)
+			test.CheckNoErr(t, err, h+" receiver export")
+			if len(secret) != 32 || !bytes.Equal(got, secret) {
+				test.ReportError(t, got, secret, h)
+			}
+		}
+	}
+}
+
+func TestExportOnly(t *testing.T) {
+	s := NewSuite(KEM_P256_HKDF_SHA256, KDF_HKDF_SHA256, AEAD_ExportOnly)
+	pk, sk, err := s.kemID.Scheme().GenerateKeyPair()
+	test.CheckNoErr(t, err, "keygen")
+	sender, err := s.NewSender(pk, nil)
+	test.CheckNoErr(t, err, "sender")
+	receiver, err := s.NewReceiver(sk, nil)
+	test.CheckNoErr(t, err, "receiver")
+
+	enc, sealer, err := sender.Setup(rand.Reader)
+	test.CheckNoErr(t, err, "sender setup")
+	opener, err := receiver.Setup(enc)
+	test.CheckNoErr(t, err, "receiver setup")
+
+	_, err = sealer.Seal([]byte("message"), nil)
+	test.CheckIsErr(t, err, "export-only must not seal")
+	if l := AEAD_ExportOnly.CipherLen(7); l != 0 {
+		test.ReportError(t, l, 0)
+	}
+	_, err = opener.Open([]byte("ciphertext"), nil)
+	test.CheckIsErr(t, err, "export-only must not open")
+
+	rawSealer, err := sealer.MarshalBinary()
+	test.CheckNoErr(t, err, "marshal")
+	parsedSealer, err := UnmarshalSealer(rawSealer)
+	test.CheckNoErr(t, err, "unmarshal")
+	_, err = parsedSealer.Seal([]byte("message"), nil)
+	test.CheckIsErr(t, err, "export-only must not seal")
+
+	want := sealer.Export([]byte("context"), 64)
+	for _, got := range [][]byte{
+		opener.Export([]byte("context"), 64),
+		parsedSealer.Export([]byte("context"), 64),
+	} {
+		if !bytes.Equal(got, want) {
+			test.ReportError(t, got, want)
+		}
+	}
+}
@@ -0,0 +1,301 @@
+package hpke
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// This file implements a streaming layer over HPKE contexts. The plaintext
+// is split into segments of a fixed size, each of which is encrypted with an
+// AEAD whose key and base nonce are exported from the context. The nonce of
+// a segment is derived from its index and from a flag that marks the final
+// segment, as in the STREAM construction of Hoang et al. [1]. This prevents
+// reordering and truncation of segments, and allows to decrypt segments
+// independently of each other.
+//
+// Note that the key of a stream only depends on the context and on the
+// segment size, hence a context must be used for at most one stream.
+//
+// [1] https://eprint.iacr.org/2015/189
+
+const streamLabel = "HPKE stream "
+
+var errStreamClosed = errors.New("hpke: stream writer is closed")
+
+// MaxSegmentSize is the largest segment size supported by streams.
+const MaxSegmentSize = 1 << 24
+
+type streamCipher struct {
+	aead        cipher.AEAD
+	baseNonce   []byte
+	nonce       []byte
+	segmentSize int
+}
+
+func newStreamCipher(ctx Context, segmentSize int) (*streamCipher, error) {
+	if segmentSize <= 0 || segmentSize > MaxSegmentSize {
+		return nil, ErrInvalidSegmentSize
+	}
+	aeadID := ctx.Suite().aeadID
+	if aeadID == AEAD_ExportOnly {
+		return nil, ErrAEADExportOnly
+	}
+
+	var params [4]byte
+	binary.BigEndian.PutUint32(params[:], uint32(segmentSize))
+	key := ctx.Export(append([]byte(streamLabel+"key"), params[:]...),
+		aeadID.KeySize())
+	aead, err := aeadID.New(key)
+	if err != nil {
+		return nil, err
+	}
+	baseNonce := ctx.Export(append([]byte(streamLabel+"nonce"), params[:]...),
+		uint(aead.NonceSize()))
+
+	return &streamCipher{
+		aead:        aead,
+		baseNonce:   baseNonce,
+		nonce:       make([]byte, len(baseNonce)),
+		segmentSize: segmentSize,
+	}, nil
+}
+
+// calcNonce returns the nonce of the i-th segment, which is the base nonce
+// XORed with the big-endian index followed by the final flag.
+func (c *streamCipher) calcNonce(i uint64, final bool) []byte {
+	var idx [9]byte
+	binary.BigEndian.PutUint64(idx[:8], i)
+	if final {
+		idx[8] = 1
+	}
+	copy(c.nonce, c.baseNonce)
+	off := len(c.nonce) - len(idx)
+	for j := range idx {
+		c.nonce[off+j] ^= idx[j]
+	}
+	return c.nonce
+}
+
+func (c *streamCipher) ciphertextSegmentSize() int {
+	return c.segmentSize + c.aead.Overhead()
+}
+
+// StreamWriter encrypts a stream of data written to it, see NewStreamWriter.
+type StreamWriter struct {
+	c     *streamCipher
+	w     io.Writer
+	buf   []byte
+	ct    []byte
+	index uint64
+	err   error
+}
+
+// NewStreamWriter returns a StreamWriter that encrypts the data written to
+// it in segments of segmentSize bytes, using keys exported from the sealer,
+// and writes the ciphertext to w. The sealer must not be used for another
+// stream. Close must be called to write the final segment.
+func NewStreamWriter(w io.Writer, sealer Sealer, segmentSize int) (
+	*StreamWriter, error,
+) {
+	c, err := newStreamCipher(sealer, segmentSize)
+	if err != nil {
+		return nil, err
+	}
+	return &StreamWriter{
+		c:   c,
+		w:   w,
+		buf: make([]byte, 0, segmentSize),
+		ct:  make([]byte, 0, c.ciphertextSegmentSize()),
+	}, nil
+}
+
+// Write encrypts p and writes the full segments to the underlying writer.
+// The last segment is held back until more data is written or the writer
+// is closed.
+func (s *StreamWriter) Write(p []byte) (n int, err error) {
+	if s.err != nil {
+		return 0, s.err
+	}
+	for len(p) > 0 {
+		if len(s.buf) == cap(s.buf) {
+			if s.err = s.flush(false); s.err != nil {
+				return n, s.err
+			}
+		}
+		m := copy(s.buf[len(s.buf):cap(s.buf)], p)
+		s.buf = s.buf[:len(s.buf)+m]
+		p = p[m:]
+		n += m
+	}
+	return n, nil
+}
+
+// Close encrypts and writes the final segment. It does not close the
+// underlying writer.
+func (s *StreamWriter) Close() error {
+	if s.err != nil {
+		return s.err
+	}
+	s.err = s.flush(true)
+	if s.err == nil {
+		s.err = errStreamClosed
+		return nil
+	}
+	return s.err
+}
+
+func (s *StreamWriter) flush(final bool) error {
+	if s.index == ^uint64(0) {
+		return ErrAEADSeqOverflows
+	}
+	nonce := s.c.calcNonce(s.index, final)
+	s.ct = s.c.aead.Seal(s.ct[:0], nonce, s.buf, nil)
+	if _, err := s.w.Write(s.ct); err != nil {
+		return err
+	}
+	s.buf = s.buf[:0]
+	s.index++
+	return nil
+}
+
+// StreamReader decrypts a stream written by a StreamWriter, see
+// NewStreamReader.
+type StreamReader struct {
+	c        *streamCipher
+	r        io.Reader
+	buf      []byte // ciphertext segment followed by a look-ahead byte
+	pt       []byte // decrypted data not yet read
+	ahead    byte
+	hasAhead bool
+	index    uint64
+	eof      bool
+	err      error
+}
+
+// NewStreamReader returns a StreamReader that decrypts the ciphertext read
+// from r, which was produced by a StreamWriter with the same segment size
+// and a sealer matching the opener.
+//
+// Data is only returned once its segment has been authenticated. If the
+// stream was truncated or modified, Read returns an error.
+func NewStreamReader(r io.Reader, opener Opener, segmentSize int) (
+	*StreamReader, error,
+) {
+	c, err := newStreamCipher(opener, segmentSize)
+	if err != nil {
+		return nil, err
+	}
+	return &StreamReader{
+		c:   c,
+		r:   r,
+		buf: make([]byte, c.ciphertextSegmentSize()+1),
+	}, nil
+}
+
+// Read reads decrypted data into p.
+func (s *StreamReader) Read(p []byte) (n int, err error) {
+	for len(s.pt) == 0 {
+		if s.err != nil {
+			return 0, s.err
+		}
+		if s.eof {
+			return 0, io.EOF
+		}
+		s.err = s.next()
+	}
+	n = copy(p, s.pt)
+	s.pt = s.pt[n:]
+	return n, nil
+}
+
+// next reads and decrypts the next segment. The segment is final if the
+// stream ends right after it, which is detected by reading one byte ahead.
+func (s *StreamReader) next() error {
+	size := s.c.ciphertextSegmentSize()
+	m := 0
+	if s.hasAhead {
+		s.buf[0] = s.ahead
+		m = 1
+	}
+	k, err := io.ReadFull(s.r, s.buf[m:])
+	m += k
+
+	final := false
+	switch err {
+	case nil:
+		s.ahead, s.hasAhead = s.buf[size], true
+		m = size
+	case io.EOF, io.ErrUnexpectedEOF:
+		final = true
+	default:
+		return err
+	}
+
+	if s.index == ^uint64(0) {
+		return ErrAEADSeqOverflows
+	}
+	segment := s.buf[:m]
+	pt, err := s.c.aead.Open(segment[:0], s.c.calcNonce(s.index, final), segment, nil)
+	if err != nil {
+		return err
+	}
+	s.pt = pt
+	s.index++
+	s.eof = final
+	return nil
+}
+
+// SegmentReader decrypts individual segments of a stream written by a
+// StreamWriter, see NewSegmentReader.
+type SegmentReader struct {
+	c           *streamCipher
+	r           io.ReaderAt
+	numSegments uint64
+	lastSize    int
+}
+
+// NewSegmentReader returns a SegmentReader for the ciphertext of the given
+// size that can be read from r, which was produced by a StreamWriter with
+// the same segment size and a sealer matching the opener.
+func NewSegmentReader(
+	r io.ReaderAt, size int64, opener Opener, segmentSize int,
+) (*SegmentReader, error) {
+	c, err := newStreamCipher(opener, segmentSize)
+	if err != nil {
+		return nil, err
+	}
+	ctSize := int64(c.ciphertextSegmentSize())
+	if size < int64(c.aead.Overhead()) {
+		return nil, ErrInvalidStreamSize
+	}
+	n := (size + ctSize - 1) / ctSize
+	last := size - (n-1)*ctSize
+	if last < int64(c.aead.Overhead()) {
+		return nil, ErrInvalidStreamSize
+	}
+	return &SegmentReader{c, r, uint64(n), int(last)}, nil
+}
+
+// NumSegments returns the number of segments of the stream.
+func (s *SegmentReader) NumSegments() uint64 { return s.numSegments }
+
+// OpenSegment reads and decrypts the i-th segment of the stream. The segment
+// is authenticated, including whether it is the final one.
+func (s *SegmentReader) OpenSegment(i uint64) ([]byte, error) {
+	if i >= s.numSegments {
+		return nil, ErrInvalidSegmentIndex
+	}
+	final := i == s.numSegments-1
+	size := s.c.ciphertextSegmentSize()
+	if final {
+		size = s.lastSize
+	}
+	ct := make([]byte, size)
+	off := int64(i) * int64(s.c.ciphertextSegmentSize())
+	if _, err := s.r.ReadAt(ct, off); err != nil && err != io.EOF {
+		return nil, err
+	}
+	return s.c.aead.Open(ct[:0], s.c.calcNonce(i, final), ct, nil)
+}
@@ -0,0 +1,148 @@
+package hpke
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	"testing"
+	"testing/iotest"
+
+	"github.com/cloudflare/circl/internal/test"
+)
+
+func streamContexts(t *testing.T, aead AEAD) (Sealer, Opener) {
+	s := NewSuite(KEM_X25519_HKDF_SHA256, KDF_HKDF_SHA256, aead)
+	pk, sk, err := s.kemID.Scheme().GenerateKeyPair()
+	test.CheckNoErr(t, err, "keygen")
+	sender, err := s.NewSender(pk, nil)
+	test.CheckNoErr(t, err, "sender")
+	receiver, err := s.NewReceiver(sk, nil)
+	test.CheckNoErr(t, err, "receiver")
+	enc, sealer, err := sender.Setup(rand.Reader)
+	test.CheckNoErr(t, err, "sender setup")
+	opener, err := receiver.Setup(enc)
+	test.CheckNoErr(t, err, "receiver setup")
+	return sealer, opener
+}
+
+func sealStream(t *testing.T, sealer Sealer, pt []byte, segmentSize int) []byte {
+	var ct bytes.Buffer
+	w, err := NewStreamWriter(&ct, sealer, segmentSize)
+	test.CheckNoErr(t, err, "stream writer")
+	// Write in uneven pieces to exercise buffering.
+	for p := pt; len(p) > 0; {
+		n := 7
+		if n > len(p) {
+			n = len(p)
+		}
+		_, err = w.Write(p[:n])
+		test.CheckNoErr(t, err, "write")
+		p = p[n:]
+	}
+	test.CheckNoErr(t, w.Close(), "close")
+	_, err = w.Write([]byte{0})
+	test.CheckIsErr(t, err, "write after close must fail")
+	return ct.Bytes()
+}
+
+func TestStream(t *testing.T) {
+	const segmentSize = 64
+	for _, aead := range []AEAD{AEAD_AES128GCM, AEAD_AES256GCM, AEAD_ChaCha20Poly1305} {
+		for _, size := range []int{
+			0, 1, segmentSize - 1, segmentSize, segmentSize + 1, 3 * segmentSize, 1000,
+		} {
+			sealer, opener := streamContexts(t, aead)
+			pt := make([]byte, size)
+			_, _ = rand.Read(pt)
+			ct := sealStream(t, sealer, pt, segmentSize)
+
+			r, err := NewStreamReader(iotest.OneByteReader(bytes.NewReader(ct)), opener, segmentSize)
+			test.CheckNoErr(t, err, "stream reader")
+			got, err := io.ReadAll(r)
+			test.CheckNoErr(t, err, "read")
+			if !bytes.Equal(got, pt) {
+				test.ReportError(t, got, pt, aead, size)
+			}
+
+			sr, err := NewSegmentReader(bytes.NewReader(ct), int64(len(ct)), opener, segmentSize)
+			test.CheckNoErr(t, err, "segment reader")
+			n := sr.NumSegments()
+			if want := uint64(size+segmentSize-1)/segmentSize + 1; size == 0 && n != 1 ||
+				size > 0 && n != want-1 {
+				test.ReportError(t, n, want, aead, size)
+			}
+			for i := n; i > 0; i-- {
+				seg, err := sr.OpenSegment(i - 1)
+				test.CheckNoErr(t, err, "open segment")
+				want := pt[(i-1)*segmentSize:]
+				if len(want) > segmentSize {
+					want = want[:segmentSize]
+				}
+				if !bytes.Equal(seg, want) {
+					test.ReportError(t, seg, want, aead, size, i-1)
+				}
+			}
+			_, err = sr.OpenSegment(n)
+			test.CheckIsErr(t, err, "segment out of range must fail")
+		}
+	}
+}
+
+func TestStreamTampering(t *testing.T) {
+	const segmentSize = 32
+	sealer, opener := streamContexts(t, AEAD_AES128GCM)
+	pt := make([]byte, 4*segmentSize)
+	ct := sealStream(t, sealer, pt, segmentSize)
+	ctSegment := segmentSize + 16
+
+	openAll := func(ct []byte, segmentSize int) error {
+		r, err := NewStreamReader(bytes.NewReader(ct), opener, segmentSize)
+		if err != nil {
+			return err
+		}
+		_, err = io.ReadAll(r)
+		return err
+	}
+
+	test.CheckNoErr(t, openAll(ct, segmentSize), "valid stream")
+
+	// Truncation at a segment boundary.
+	err := openAll(ct[:3*ctSegment], segmentSize)
+	test.CheckIsErr(t, err, "truncated stream must fail")
+	_, err = NewSegmentReader(bytes.NewReader(ct), int64(3*ctSegment), opener, segmentSize)
+	test.CheckNoErr(t, err, "segment reader")
+
+	// Truncation inside a segment.
+	err = openAll(ct[:len(ct)-1], segmentSize)
+	test.CheckIsErr(t, err, "truncated stream must fail")
+
+	// Empty stream.
+	err = openAll(nil, segmentSize)
+	test.CheckIsErr(t, err, "empty stream must fail")
+
+	// Reordering of segments.
+	swapped := append([]byte{}, ct...)
+	copy(swapped[:ctSegment], ct[ctSegment:2*ctSegment])
+	copy(swapped[ctSegment:2*ctSegment], ct[:ctSegment])
+	err = openAll(swapped, segmentSize)
+	test.CheckIsErr(t, err, "reordered stream must fail")
+
+	// Modification.
+	modified := append([]byte{}, ct...)
+	modified[2*ctSegment] ^= 1
+	err = openAll(modified, segmentSize)
+	test.CheckIsErr(t, err, "modified stream must fail")
+
+	// Wrong segment size.
+	err = openAll(ct, segmentSize+1)
+	test.CheckIsErr(t, err, "wrong segment size must fail")
+
+	_, err = NewStreamReader(bytes.NewReader(ct), opener, 0)
+	test.CheckIsErr(t, err, "invalid segment size must fail")
+
+	sealer, opener = streamContexts(t, AEAD_ExportOnly)
+	_, err = NewStreamWriter(io.Discard, sealer, segmentSize)
+	test.CheckIsErr(t, err, "export-only must fail")
+	_, err = NewStreamReader(bytes.NewReader(ct), opener, segmentSize)
+	test.CheckIsErr(t, err, "export-only must fail")
+}
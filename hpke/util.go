@@ -19,6 +19,17 @@ func (st state) keySchedule(ss, info, psk, pskID []byte) (*encdecContext, error)
 		infoHash...)
 
 	secret := st.labeledExtract(ss, []byte("secret"), psk)
+	exporterSecret := st.labeledExpand(
+		secret,
+		[]byte("exp"),
+		keySchCtx,
+		uint16(st.kdfID.ExtractSize()),
+	)
+
+	if st.aeadID == AEAD_ExportOnly {
+		// No key nor nonce are derived, see RFC 9180, Section 5.3.
+		return &encdecContext{suite: st.Suite, exporterSecret: exporterSecret}, nil
+	}
 
 	Nk := uint16(st.aeadID.KeySize())
 	key := st.labeledExpand(secret, []byte("key"), keySchCtx, Nk)
@@ -30,12 +41,6 @@ func (st state) keySchedule(ss, info, psk, pskID []byte) (*encdecContext, error)
 
 	Nn := uint16(aead.NonceSize())
 	baseNonce := st.labeledExpand(secret, []byte("base_nonce"), keySchCtx, Nn)
-	exporterSecret := st.labeledExpand(
-		secret,
-		[]byte("exp"),
-		keySchCtx,
-		uint16(st.kdfID.ExtractSize()),
-	)
 
 	return &encdecContext{
 		st.Suite,
@@ -55,11 +60,11 @@ func (st state) verifyPSKInputs(psk, pskID []byte) error {
 		return errors.New("inconsistent PSK inputs")
 	}
 	switch st.modeID {
-	case modeBase | modeAuth:
+	case modeBase, modeAuth:
 		if gotPSK {
 			return errors.New("PSK input provided when not needed")
 		}
-	case modePSK | modeAuthPSK:
+	case modePSK, modeAuthPSK:
 		if !gotPSK {
 			return errors.New("missing required PSK input")
 		}
@@ -14,11 +14,21 @@ import (
 )
 
 func TestVectors(t *testing.T) {
-	// Test vectors from
-	// https://github.com/cfrg/draft-irtf-cfrg-hpke/blob/master/test-vectors.json
-	vectors := readFile(t, "testdata/vectors_v08_779d028.json")
-	for i, v := range vectors {
-		t.Run(fmt.Sprintf("v%v", i), v.verify)
+	for _, file := range []string{
+		// Test vectors from
+		// https://github.com/cfrg/draft-irtf-cfrg-hpke/blob/5f503c5/test-vectors.json
+		"testdata/vectors_rfc9180_5f503c5.json",
+		// Test vectors from
+		// https://github.com/bwesterb/draft-westerbaan-cfrg-hpke-xyber768d00
+		"testdata/hybrid-x25119-kyber768-test-vectors.json",
+		// Test vectors for ML-KEM from
+		// https://github.com/dconnolly/draft-connolly-cfrg-hpke-mlkem
+		"testdata/vectors_mlkem.json",
+	} {
+		vectors := readFile(t, file)
+		for i, v := range vectors {
+			t.Run(fmt.Sprintf("%v/v%v", file, i), v.verify)
+		}
 	}
 }
 
@@ -57,6 +67,11 @@ func (v *vector) getActors(
 	skR, err := dhkem.UnmarshalBinaryPrivateKey(hexB(t, v.SkRm))
 	test.CheckNoErr(t, err, h+"bad private key")
 
+	pkR2, skR2 := dhkem.DeriveKeyPair(hexB(t, v.IkmR))
+	if !pkR2.Equal(pkR) || !skR2.Equal(skR) {
+		test.ReportError(t, skR2, skR, h+"derived keys mismatch")
+	}
+
 	info := hexB(t, v.Info)
 	sender, err := s.NewSender(pkR, info)
 	test.CheckNoErr(t, err, h+"err sender")
@@ -71,7 +86,11 @@ func (v *vector) setup(t *testing.T, k kem.Scheme,
 	se *Sender, re *Receiver,
 	m modeID, s Suite,
 ) (sealer Sealer, opener Opener) {
-	seed := hexB(t, v.IkmE)
+	ikmE := v.IkmE
+	if ikmE == "" {
+		ikmE = v.Ier
+	}
+	seed := hexB(t, ikmE)
 	rd := bytes.NewReader(seed)
 
 	var enc []byte
@@ -125,6 +144,10 @@ func (v *vector) setup(t *testing.T, k kem.Scheme,
 	test.CheckNoErr(t, errSK, h+"bad private key")
 	test.CheckNoErr(t, errPK, h+"bad public key")
 
+	if got, want := enc, hexB(t, v.Enc); !bytes.Equal(got, want) {
+		test.ReportError(t, got, want, m, s)
+	}
+
 	return sealer, opener
 }
 
@@ -154,6 +177,9 @@ func (v *vector) checkEncryptions(
 
 		ct, err := se.Seal(pt, aad)
 		test.CheckNoErr(t, err, "error on sealing")
+		if want := hexB(t, encv.Ciphertext); !bytes.Equal(ct, want) {
+			test.ReportError(t, ct, want, m, se.Suite(), j)
+		}
 
 		got, err := op.Open(ct, aad)
 		test.CheckNoErr(t, err, "error on opening")
@@ -210,6 +236,7 @@ type vector struct {
 	Info               string `json:"info"`
 	IkmR               string `json:"ikmR"`
 	IkmE               string `json:"ikmE"`
+	Ier                string `json:"ier"`
 	SkRm               string `json:"skRm"`
 	SkEm               string `json:"skEm"`
 	SkSm               string `json:"skSm"`
@@ -227,13 +254,13 @@ type vector struct {
 	ExporterSecret     string `json:"exporter_secret"`
 	Encryptions        []struct {
 		Aad        string `json:"aad"`
-		Ciphertext string `json:"ciphertext"`
+		Ciphertext string `json:"ct"`
 		Nonce      string `json:"nonce"`
-		Plaintext  string `json:"plaintext"`
+		Plaintext  string `json:"pt"`
 	} `json:"encryptions"`
 	Exports []struct {
-		ExportContext string `json:"exportContext"`
-		ExportLength  int    `json:"exportLength"`
-		ExportValue   string `json:"exportValue"`
+		ExportContext string `json:"exporter_context"`
+		ExportLength  int    `json:"L"`
+		ExportValue   string `json:"exported_value"`
 	} `json:"exports"`
 }
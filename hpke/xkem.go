@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"crypto/rand"
 	"crypto/subtle"
+	"encoding/asn1"
 	"fmt"
 	"io"
 
@@ -23,6 +24,14 @@ func (x xKEM) CiphertextSize() int        { return x.size }
 func (x xKEM) PublicKeySize() int         { return x.size }
 func (x xKEM) EncapsulationSeedSize() int { return x.size }
 
+// Oid returns the algorithm identifier of X25519 or X448 as in RFC 8410.
+func (x xKEM) Oid() asn1.ObjectIdentifier {
+	if x.size == x448.Size {
+		return asn1.ObjectIdentifier{1, 3, 101, 111}
+	}
+	return asn1.ObjectIdentifier{1, 3, 101, 110}
+}
+
 func (x xKEM) sizeDH() int { return x.size }
 func (x xKEM) calcDH(dh []byte, sk kem.PrivateKey, pk kem.PublicKey) error {
 	PK := pk.(*xKEMPubKey)
@@ -0,0 +1,98 @@
+package hybrid
+
+import (
+	"bytes"
+
+	"github.com/cloudflare/circl/internal/sha3"
+)
+
+// A Combiner derives the shared key of a hybrid KEM from the shared keys
+// and ciphertexts of its component KEMs.
+type Combiner interface {
+	// Combine returns the shared key of the hybrid KEM. The i-th entry
+	// of ss and ct are the shared key and ciphertext of the i-th
+	// component KEM.
+	Combine(ss, ct [][]byte) []byte
+
+	// SharedKeySize returns the size of the keys returned by Combine,
+	// given the sizes of the shared keys of the component KEMs. The hybrid
+	// KEM fails with ErrSharedKeySize if Combine returns a key of another
+	// size.
+	SharedKeySize(sizes []int) int
+}
+
+// Concatenation returns the Combiner that concatenates the shared keys
+// of the component KEMs. This is the combiner used by the predefined
+// hybrid KEMs of this package.
+//
+// Note that this is only fine if the shared key is used in its entirety
+// in a next step, such as being hashed or used as key.
+func Concatenation() Combiner { return concatenation{} }
+
+// KDF returns a Combiner that derives a shared key of the given size by
+// applying SHAKE256 to
+//
+//	label || ss_1 || ... || ss_n || ct_1 || ... || ct_n
+//
+// where ss_i and ct_i are the shared key and ciphertext of the i-th
+// component KEM. As both shared keys and ciphertexts have a fixed size for
+// a given KEM, the input can be parsed unambiguously.
+//
+// Panics if size is not positive.
+func KDF(label []byte, size int) Combiner {
+	if size <= 0 {
+		panic("hybrid: invalid shared key size")
+	}
+	return &kdf{append([]byte(nil), label...), size}
+}
+
+// CombinerFunc returns a Combiner that calls f to derive shared keys,
+// which must be of the given size.
+//
+// Panics if size is not positive. If f returns a shared key of another size,
+// the hybrid KEM fails with ErrSharedKeySize.
+func CombinerFunc(size int, f func(ss, ct [][]byte) []byte) Combiner {
+	if size <= 0 {
+		panic("hybrid: invalid shared key size")
+	}
+	return &combinerFunc{size, f}
+}
+
+type concatenation struct{}
+
+func (concatenation) Combine(ss, _ [][]byte) []byte { return bytes.Join(ss, nil) }
+func (concatenation) SharedKeySize(sizes []int) int {
+	ret := 0
+	for _, s := range sizes {
+		ret += s
+	}
+	return ret
+}
+
+type kdf struct {
+	label []byte
+	size  int
+}
+
+func (c *kdf) SharedKeySize([]int) int { return c.size }
+func (c *kdf) Combine(ss, ct [][]byte) []byte {
+	h := sha3.NewShake256()
+	_, _ = h.Write(c.label)
+	for _, s := range ss {
+		_, _ = h.Write(s)
+	}
+	for _, s := range ct {
+		_, _ = h.Write(s)
+	}
+	ret := make([]byte, c.size)
+	_, _ = h.Read(ret)
+	return ret
+}
+
+type combinerFunc struct {
+	size int
+	f    func(ss, ct [][]byte) []byte
+}
+
+func (c *combinerFunc) SharedKeySize([]int) int        { return c.size }
+func (c *combinerFunc) Combine(ss, ct [][]byte) []byte { return c.f(ss, ct) }
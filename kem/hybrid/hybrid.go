@@ -1,7 +1,7 @@
 // Package hybrid defines several hybrid classical/quantum KEMs.
 //
-// KEMs are combined by simple concatenation of shared secrets, cipher texts,
-// public keys, etc, see
+// The predefined KEMs are combined by simple concatenation of shared
+// secrets, cipher texts, public keys, etc, see
 //
 //	https://datatracker.ietf.org/doc/draft-ietf-tls-hybrid-design/
 //	https://nvlpubs.nist.gov/nistpubs/SpecialPublications/NIST.SP.800-56Cr2.pdf
@@ -9,8 +9,11 @@
 // Note that this is only fine if the shared secret is used in its entirety
 // in a next step, such as being hashed or used as key.
 //
+// Other hybrids of two or more KEMs can be built with New, which also
+// allows to choose how the shared secrets are combined, see Combiner.
+//
 // For deriving a KEM keypair deterministically and encapsulating
-// deterministically, we expand a single seed to both using SHAKE256,
+// deterministically, we expand a single seed to all of them using SHAKE256,
 // so that a non-uniform seed (such as a shared secret generated by a hybrid
 // KEM where one of the KEMs is weak) doesn't impact just one of the KEMs.
 //
@@ -31,6 +34,7 @@
 package hybrid
 
 import (
+	"bytes"
 	"errors"
 
 	"github.com/cloudflare/circl/internal/sha3"
@@ -40,7 +44,16 @@ import (
 	"github.com/cloudflare/circl/kem/kyber/kyber768"
 )
 
-var ErrUninitialized = errors.New("public or private key not initialized")
+var (
+	ErrUninitialized = errors.New("public or private key not initialized")
+
+	// ErrSchemes is the error used if fewer than two KEMs are combined.
+	ErrSchemes = errors.New("hybrid: at least two KEMs are required")
+
+	// ErrSharedKeySize is the error used if the Combiner of a hybrid KEM
+	// returns a shared key whose size differs from its SharedKeySize.
+	ErrSharedKeySize = errors.New("hybrid: combiner returned a shared key of invalid size")
+)
 
 // Returns the hybrid KEM of Kyber512 and X25519.
 func Kyber512X25519() kem.Scheme { return kyber512X }
@@ -56,82 +69,111 @@ func Kyber1024X448() kem.Scheme { return kyber1024X }
 
 var kyber512X kem.Scheme = &scheme{
 	"Kyber512-X25519",
-	x25519Kem,
-	kyber512.Scheme(),
+	[]kem.Scheme{x25519Kem, kyber512.Scheme()},
+	concatenation{},
 }
 
 var kyber768X kem.Scheme = &scheme{
 	"Kyber768-X25519",
-	x25519Kem,
-	kyber768.Scheme(),
+	[]kem.Scheme{x25519Kem, kyber768.Scheme()},
+	concatenation{},
 }
 
 var kyber768X4 kem.Scheme = &scheme{
 	"Kyber768-X448",
-	x448Kem,
-	kyber768.Scheme(),
+	[]kem.Scheme{x448Kem, kyber768.Scheme()},
+	concatenation{},
 }
 
 var kyber1024X kem.Scheme = &scheme{
 	"Kyber1024-X448",
-	x448Kem,
-	kyber1024.Scheme(),
+	[]kem.Scheme{x448Kem, kyber1024.Scheme()},
+	concatenation{},
+}
+
+// New returns the hybrid KEM with the given name that combines the given
+// KEMs, of which there must be at least two, using the given Combiner.
+//
+// Public keys, private keys and ciphertexts of the hybrid KEM are the
+// concatenation of those of its components, in the order given.
+func New(name string, c Combiner, schemes ...kem.Scheme) (kem.Scheme, error) {
+	if len(schemes) < 2 {
+		return nil, ErrSchemes
+	}
+	if c == nil {
+		c = concatenation{}
+	}
+	return &scheme{name, append([]kem.Scheme(nil), schemes...), c}, nil
 }
 
 // Public key of a hybrid KEM.
 type publicKey struct {
 	scheme *scheme
-	first  kem.PublicKey
-	second kem.PublicKey
+	keys   []kem.PublicKey
 }
 
 // Private key of a hybrid KEM.
 type privateKey struct {
 	scheme *scheme
-	first  kem.PrivateKey
-	second kem.PrivateKey
+	keys   []kem.PrivateKey
 }
 
 // Scheme for a hybrid KEM.
 type scheme struct {
-	name   string
-	first  kem.Scheme
-	second kem.Scheme
+	name     string
+	schemes  []kem.Scheme
+	combiner Combiner
 }
 
 func (sch *scheme) Name() string { return sch.name }
 func (sch *scheme) PublicKeySize() int {
-	return sch.first.PublicKeySize() + sch.second.PublicKeySize()
+	ret := 0
+	for _, s := range sch.schemes {
+		ret += s.PublicKeySize()
+	}
+	return ret
 }
 
 func (sch *scheme) PrivateKeySize() int {
-	return sch.first.PrivateKeySize() + sch.second.PrivateKeySize()
+	ret := 0
+	for _, s := range sch.schemes {
+		ret += s.PrivateKeySize()
+	}
+	return ret
 }
 
 func (sch *scheme) SeedSize() int {
-	first := sch.first.SeedSize()
-	second := sch.second.SeedSize()
-	ret := second
-	if first > second {
-		ret = first
+	ret := 0
+	for _, s := range sch.schemes {
+		if size := s.SeedSize(); size > ret {
+			ret = size
+		}
 	}
 	return ret
 }
 
 func (sch *scheme) SharedKeySize() int {
-	return sch.first.SharedKeySize() + sch.second.SharedKeySize()
+	sizes := make([]int, len(sch.schemes))
+	for i, s := range sch.schemes {
+		sizes[i] = s.SharedKeySize()
+	}
+	return sch.combiner.SharedKeySize(sizes)
 }
 
 func (sch *scheme) CiphertextSize() int {
-	return sch.first.CiphertextSize() + sch.second.CiphertextSize()
+	ret := 0
+	for _, s := range sch.schemes {
+		ret += s.CiphertextSize()
+	}
+	return ret
 }
 
 func (sch *scheme) EncapsulationSeedSize() int {
-	first := sch.first.EncapsulationSeedSize()
-	second := sch.second.EncapsulationSeedSize()
-	ret := second
-	if first > second {
-		ret = first
+	ret := 0
+	for _, s := range sch.schemes {
+		if size := s.EncapsulationSeedSize(); size > ret {
+			ret = size
+		}
 	}
 	return ret
 }
@@ -140,18 +182,21 @@ func (sk *privateKey) Scheme() kem.Scheme { return sk.scheme }
 func (pk *publicKey) Scheme() kem.Scheme  { return pk.scheme }
 
 func (sk *privateKey) MarshalBinary() ([]byte, error) {
-	if sk.first == nil || sk.second == nil {
+	if len(sk.keys) == 0 {
 		return nil, ErrUninitialized
 	}
-	first, err := sk.first.MarshalBinary()
-	if err != nil {
-		return nil, err
-	}
-	second, err := sk.second.MarshalBinary()
-	if err != nil {
-		return nil, err
-	}
-	return append(first, second...), nil
+	var ret []byte
+	for _, k := range sk.keys {
+		if k == nil {
+			return nil, ErrUninitialized
+		}
+		buf, err := k.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, buf...)
+	}
+	return ret, nil
 }
 
 func (sk *privateKey) Equal(other kem.PrivateKey) bool {
@@ -159,17 +204,29 @@ func (sk *privateKey) Equal(other kem.PrivateKey) bool {
 	if !ok {
 		return false
 	}
-	if sk.first == nil && sk.second == nil && oth.first == nil && oth.second == nil {
-		return true
-	}
-	if sk.first == nil || sk.second == nil || oth.first == nil || oth.second == nil {
+	if len(sk.keys) != len(oth.keys) {
 		return false
 	}
-	return sk.first.Equal(oth.first) && sk.second.Equal(oth.second)
+	for i := range sk.keys {
+		if sk.keys[i] == nil || oth.keys[i] == nil {
+			if sk.keys[i] != nil || oth.keys[i] != nil {
+				return false
+			}
+			continue
+		}
+		if !sk.keys[i].Equal(oth.keys[i]) {
+			return false
+		}
+	}
+	return true
 }
 
 func (sk *privateKey) Public() kem.PublicKey {
-	return &publicKey{sk.scheme, sk.first.Public(), sk.second.Public()}
+	pk := &publicKey{sk.scheme, make([]kem.PublicKey, len(sk.keys))}
+	for i, k := range sk.keys {
+		pk.keys[i] = k.Public()
+	}
+	return pk
 }
 
 func (pk *publicKey) Equal(other kem.PublicKey) bool {
@@ -177,41 +234,53 @@ func (pk *publicKey) Equal(other kem.PublicKey) bool {
 	if !ok {
 		return false
 	}
-	if pk.first == nil && pk.second == nil && oth.first == nil && oth.second == nil {
-		return true
-	}
-	if pk.first == nil || pk.second == nil || oth.first == nil || oth.second == nil {
+	if len(pk.keys) != len(oth.keys) {
 		return false
 	}
-	return pk.first.Equal(oth.first) && pk.second.Equal(oth.second)
+	for i := range pk.keys {
+		if pk.keys[i] == nil || oth.keys[i] == nil {
+			if pk.keys[i] != nil || oth.keys[i] != nil {
+				return false
+			}
+			continue
+		}
+		if !pk.keys[i].Equal(oth.keys[i]) {
+			return false
+		}
+	}
+	return true
 }
 
 func (pk *publicKey) MarshalBinary() ([]byte, error) {
-	if pk.first == nil || pk.second == nil {
+	if len(pk.keys) == 0 {
 		return nil, ErrUninitialized
 	}
-	first, err := pk.first.MarshalBinary()
-	if err != nil {
-		return nil, err
-	}
-	second, err := pk.second.MarshalBinary()
-	if err != nil {
-		return nil, err
-	}
-	return append(first, second...), nil
+	var ret []byte
+	for _, k := range pk.keys {
+		if k == nil {
+			return nil, ErrUninitialized
+		}
+		buf, err := k.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, buf...)
+	}
+	return ret, nil
 }
 
 func (sch *scheme) GenerateKeyPair() (kem.PublicKey, kem.PrivateKey, error) {
-	pk1, sk1, err := sch.first.GenerateKeyPair()
-	if err != nil {
-		return nil, nil, err
-	}
-	pk2, sk2, err := sch.second.GenerateKeyPair()
-	if err != nil {
-		return nil, nil, err
+	pk := &publicKey{sch, make([]kem.PublicKey, len(sch.schemes))}
+	sk := &privateKey{sch, make([]kem.PrivateKey, len(sch.schemes))}
+	for i, s := range sch.schemes {
+		var err error
+		pk.keys[i], sk.keys[i], err = s.GenerateKeyPair()
+		if err != nil {
+			return nil, nil, err
+		}
 	}
 
-	return &publicKey{sch, pk1, pk2}, &privateKey{sch, sk1, sk2}, nil
+	return pk, sk, nil
 }
 
 func (sch *scheme) DeriveKeyPair(seed []byte) (kem.PublicKey, kem.PrivateKey) {
@@ -220,34 +289,38 @@ func (sch *scheme) DeriveKeyPair(seed []byte) (kem.PublicKey, kem.PrivateKey) {
 	}
 	h := sha3.NewShake256()
 	_, _ = h.Write(seed)
-	first := make([]byte, sch.first.SeedSize())
-	second := make([]byte, sch.second.SeedSize())
-	_, _ = h.Read(first)
-	_, _ = h.Read(second)
 
-	pk1, sk1 := sch.first.DeriveKeyPair(first)
-	pk2, sk2 := sch.second.DeriveKeyPair(second)
+	pk := &publicKey{sch, make([]kem.PublicKey, len(sch.schemes))}
+	sk := &privateKey{sch, make([]kem.PrivateKey, len(sch.schemes))}
+	for i, s := range sch.schemes {
+		seed := make([]byte, s.SeedSize())
+		_, _ = h.Read(seed)
+		pk.keys[i], sk.keys[i] = s.DeriveKeyPair(seed)
+	}
 
-	return &publicKey{sch, pk1, pk2}, &privateKey{sch, sk1, sk2}
+	return pk, sk
 }
 
 func (sch *scheme) Encapsulate(pk kem.PublicKey) (ct, ss []byte, err error) {
 	pub, ok := pk.(*publicKey)
-	if !ok {
+	if !ok || len(pub.keys) != len(sch.schemes) {
 		return nil, nil, kem.ErrTypeMismatch
 	}
 
-	ct1, ss1, err := sch.first.Encapsulate(pub.first)
-	if err != nil {
-		return nil, nil, err
+	cts := make([][]byte, len(sch.schemes))
+	sss := make([][]byte, len(sch.schemes))
+	for i, s := range sch.schemes {
+		cts[i], sss[i], err = s.Encapsulate(pub.keys[i])
+		if err != nil {
+			return nil, nil, err
+		}
 	}
 
-	ct2, ss2, err := sch.second.Encapsulate(pub.second)
+	ss, err = sch.combine(sss, cts)
 	if err != nil {
 		return nil, nil, err
 	}
-
-	return append(ct1, ct2...), append(ss1, ss2...), nil
+	return bytes.Join(cts, nil), ss, nil
 }
 
 func (sch *scheme) EncapsulateDeterministically(
@@ -257,27 +330,30 @@ func (sch *scheme) EncapsulateDeterministically(
 		return nil, nil, kem.ErrSeedSize
 	}
 
-	h := sha3.NewShake256()
-	_, _ = h.Write(seed)
-	first := make([]byte, sch.first.EncapsulationSeedSize())
-	second := make([]byte, sch.second.EncapsulationSeedSize())
-	_, _ = h.Read(first)
-	_, _ = h.Read(second)
-
 	pub, ok := pk.(*publicKey)
-	if !ok {
+	if !ok || len(pub.keys) != len(sch.schemes) {
 		return nil, nil, kem.ErrTypeMismatch
 	}
 
-	ct1, ss1, err := sch.first.EncapsulateDeterministically(pub.first, first)
-	if err != nil {
-		return nil, nil, err
+	h := sha3.NewShake256()
+	_, _ = h.Write(seed)
+
+	cts := make([][]byte, len(sch.schemes))
+	sss := make([][]byte, len(sch.schemes))
+	for i, s := range sch.schemes {
+		seed := make([]byte, s.EncapsulationSeedSize())
+		_, _ = h.Read(seed)
+		cts[i], sss[i], err = s.EncapsulateDeterministically(pub.keys[i], seed)
+		if err != nil {
+			return nil, nil, err
+		}
 	}
-	ct2, ss2, err := sch.second.EncapsulateDeterministically(pub.second, second)
+
+	ss, err = sch.combine(sss, cts)
 	if err != nil {
 		return nil, nil, err
 	}
-	return append(ct1, ct2...), append(ss1, ss2...), nil
+	return bytes.Join(cts, nil), ss, nil
 }
 
 func (sch *scheme) Decapsulate(sk kem.PrivateKey, ct []byte) ([]byte, error) {
@@ -286,50 +362,66 @@ func (sch *scheme) Decapsulate(sk kem.PrivateKey, ct []byte) ([]byte, error) {
 	}
 
 	priv, ok := sk.(*privateKey)
-	if !ok {
+	if !ok || len(priv.keys) != len(sch.schemes) {
 		return nil, kem.ErrTypeMismatch
 	}
 
-	firstSize := sch.first.CiphertextSize()
-	ss1, err := sch.first.Decapsulate(priv.first, ct[:firstSize])
-	if err != nil {
-		return nil, err
+	cts := make([][]byte, len(sch.schemes))
+	sss := make([][]byte, len(sch.schemes))
+	for i, s := range sch.schemes {
+		size := s.CiphertextSize()
+		cts[i], ct = ct[:size], ct[size:]
+
+		var err error
+		sss[i], err = s.Decapsulate(priv.keys[i], cts[i])
+		if err != nil {
+			return nil, err
+		}
 	}
-	ss2, err := sch.second.Decapsulate(priv.second, ct[firstSize:])
-	if err != nil {
-		return nil, err
+	return sch.combine(sss, cts)
+}
+
+// combine derives the shared key with the Combiner, and checks its size.
+func (sch *scheme) combine(sss, cts [][]byte) ([]byte, error) {
+	ss := sch.combiner.Combine(sss, cts)
+	if len(ss) != sch.SharedKeySize() {
+		return nil, ErrSharedKeySize
 	}
-	return append(ss1, ss2...), nil
+	return ss, nil
 }
 
 func (sch *scheme) UnmarshalBinaryPublicKey(buf []byte) (kem.PublicKey, error) {
 	if len(buf) != sch.PublicKeySize() {
 		return nil, kem.ErrPubKeySize
 	}
-	firstSize := sch.first.PublicKeySize()
-	pk1, err := sch.first.UnmarshalBinaryPublicKey(buf[:firstSize])
-	if err != nil {
-		return nil, err
-	}
-	pk2, err := sch.second.UnmarshalBinaryPublicKey(buf[firstSize:])
-	if err != nil {
-		return nil, err
+	pk := &publicKey{sch, make([]kem.PublicKey, len(sch.schemes))}
+	for i, s := range sch.schemes {
+		size := s.PublicKeySize()
+
+		var err error
+		pk.keys[i], err = s.UnmarshalBinaryPublicKey(buf[:size])
+		if err != nil {
+			return nil, err
+		}
+		buf = buf[size:]
 	}
-	return &publicKey{sch, pk1, pk2}, nil
+	return pk, nil
 }
 
 func (sch *scheme) UnmarshalBinaryPrivateKey(buf []byte) (kem.PrivateKey, error) {
 	if len(buf) != sch.PrivateKeySize() {
 		return nil, kem.ErrPrivKeySize
 	}
-	firstSize := sch.first.PrivateKeySize()
-	sk1, err := sch.first.UnmarshalBinaryPrivateKey(buf[:firstSize])
-	if err != nil {
-		return nil, err
-	}
-	sk2, err := sch.second.UnmarshalBinaryPrivateKey(buf[firstSize:])
-	if err != nil {
-		return nil, err
+	sk := &privateKey{sch, make([]kem.PrivateKey, len(sch.schemes))}
+	for i, s := range sch.schemes {
+		size := s.PrivateKeySize()
+
+		var err error
+		sk.keys[i], err = s.UnmarshalBinaryPrivateKey(buf[:size])
+		if err != nil {
+			return nil, err
+		}
+		buf = buf[size:]
 	}
-	return &privateKey{sch, sk1, sk2}, nil
+	return sk, nil
 }
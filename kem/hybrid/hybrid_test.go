@@ -0,0 +1,166 @@
+package hybrid
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/cloudflare/circl/internal/sha3"
+	"github.com/cloudflare/circl/internal/test"
+	"github.com/cloudflare/circl/kem"
+	"github.com/cloudflare/circl/kem/frodo/frodo640shake"
+	"github.com/cloudflare/circl/kem/kyber/kyber512"
+	"github.com/cloudflare/circl/kem/kyber/kyber768"
+)
+
+func testScheme(t *testing.T, sch kem.Scheme) {
+	seed := make([]byte, sch.SeedSize())
+	for i := range seed {
+		seed[i] = byte(i)
+	}
+	pk, sk := sch.DeriveKeyPair(seed)
+
+	packedPk, err := pk.MarshalBinary()
+	test.CheckNoErr(t, err, "marshal public key")
+	packedSk, err := sk.MarshalBinary()
+	test.CheckNoErr(t, err, "marshal private key")
+	if len(packedPk) != sch.PublicKeySize() || len(packedSk) != sch.PrivateKeySize() {
+		t.Fatal("wrong key sizes")
+	}
+
+	pk2, err := sch.UnmarshalBinaryPublicKey(packedPk)
+	test.CheckNoErr(t, err, "unmarshal public key")
+	sk2, err := sch.UnmarshalBinaryPrivateKey(packedSk)
+	test.CheckNoErr(t, err, "unmarshal private key")
+	if !pk.Equal(pk2) || !sk.Equal(sk2) || !sk2.Public().Equal(pk) {
+		t.Fatal("keys are not equal")
+	}
+
+	ct, ss, err := sch.Encapsulate(pk2)
+	test.CheckNoErr(t, err, "encapsulate")
+	if len(ct) != sch.CiphertextSize() || len(ss) != sch.SharedKeySize() {
+		t.Fatal("wrong ciphertext or shared key size")
+	}
+	ss2, err := sch.Decapsulate(sk2, ct)
+	test.CheckNoErr(t, err, "decapsulate")
+	if !bytes.Equal(ss, ss2) {
+		test.ReportError(t, ss2, ss)
+	}
+
+	eseed := make([]byte, sch.EncapsulationSeedSize())
+	ct1, ss1, err := sch.EncapsulateDeterministically(pk, eseed)
+	test.CheckNoErr(t, err, "encapsulate deterministically")
+	ct2, ss2, err := sch.EncapsulateDeterministically(pk, eseed)
+	test.CheckNoErr(t, err, "encapsulate deterministically")
+	if !bytes.Equal(ct1, ct2) || !bytes.Equal(ss1, ss2) {
+		t.Fatal("encapsulation is not deterministic")
+	}
+}
+
+func TestNew(t *testing.T) {
+	custom := CombinerFunc(32, func(ss, ct [][]byte) []byte {
+		h := sha3.New256()
+		for i := range ss {
+			_, _ = h.Write(ss[i])
+			_, _ = h.Write(ct[i])
+		}
+		return h.Sum(nil)
+	})
+
+	for _, c := range []struct {
+		combiner Combiner
+		schemes  []kem.Scheme
+	}{
+		{Concatenation(), []kem.Scheme{frodo640shake.Scheme(), x25519Kem}},
+		{KDF([]byte("label"), 32), []kem.Scheme{kyber512.Scheme(), x448Kem}},
+		{KDF(nil, 64), []kem.Scheme{kyber768.Scheme(), x25519Kem, x448Kem}},
+		{custom, []kem.Scheme{x25519Kem, frodo640shake.Scheme()}},
+	} {
+		sch, err := New("test", c.combiner, c.schemes...)
+		test.CheckNoErr(t, err, "new hybrid")
+		testScheme(t, sch)
+	}
+
+	_, err := New("test", Concatenation(), x25519Kem)
+	test.CheckIsErr(t, err, "should fail with a single KEM")
+
+	// Combiners returning keys of the wrong size are caught.
+	for _, c := range []Combiner{
+		CombinerFunc(64, custom.Combine),
+		badCombiner{},
+	} {
+		sch, err := New("test", c, x25519Kem, x448Kem)
+		test.CheckNoErr(t, err, "new hybrid")
+		pk, sk, err := sch.GenerateKeyPair()
+		test.CheckNoErr(t, err, "generate key pair")
+		_, _, err = sch.Encapsulate(pk)
+		if err != ErrSharedKeySize {
+			test.ReportError(t, err, ErrSharedKeySize)
+		}
+		seed := make([]byte, sch.EncapsulationSeedSize())
+		_, _, err = sch.EncapsulateDeterministically(pk, seed)
+		if err != ErrSharedKeySize {
+			test.ReportError(t, err, ErrSharedKeySize)
+		}
+		_, err = sch.Decapsulate(sk, make([]byte, sch.CiphertextSize()))
+		if err != ErrSharedKeySize {
+			test.ReportError(t, err, ErrSharedKeySize)
+		}
+	}
+}
+
+// badCombiner returns shared keys one byte shorter than it claims.
+type badCombiner struct{}
+
+func (badCombiner) Combine(ss, _ [][]byte) []byte { return ss[0][1:] }
+func (badCombiner) SharedKeySize(sizes []int) int { return sizes[0] }
+
+func TestNewMatchesPredefined(t *testing.T) {
+	sch, err := New("test", Concatenation(), x25519Kem, kyber768.Scheme())
+	test.CheckNoErr(t, err, "new hybrid")
+	want := Kyber768X25519()
+
+	seed := make([]byte, sch.SeedSize())
+	pk, _ := sch.DeriveKeyPair(seed)
+	pkWant, skWant := want.DeriveKeyPair(seed)
+	packedPk, _ := pk.MarshalBinary()
+	packedPkWant, _ := pkWant.MarshalBinary()
+	if !bytes.Equal(packedPk, packedPkWant) {
+		t.Fatal("public keys differ")
+	}
+
+	eseed := make([]byte, sch.EncapsulationSeedSize())
+	ct, ss, err := sch.EncapsulateDeterministically(pk, eseed)
+	test.CheckNoErr(t, err, "encapsulate")
+	ssWant, err := want.Decapsulate(skWant, ct)
+	test.CheckNoErr(t, err, "decapsulate")
+	if !bytes.Equal(ss, ssWant) {
+		test.ReportError(t, ss, ssWant)
+	}
+}
+
+func TestKDFCombiner(t *testing.T) {
+	sch, err := New("test", KDF([]byte("label"), 32), x25519Kem, kyber512.Scheme())
+	test.CheckNoErr(t, err, "new hybrid")
+	other, err := New("test", KDF([]byte("other"), 32), x25519Kem, kyber512.Scheme())
+	test.CheckNoErr(t, err, "new hybrid")
+
+	pk, sk := sch.DeriveKeyPair(make([]byte, sch.SeedSize()))
+	ct, ss, err := sch.Encapsulate(pk)
+	test.CheckNoErr(t, err, "encapsulate")
+
+	// Keys of both schemes share a layout, so they can be reused.
+	sk2, err := other.UnmarshalBinaryPrivateKey(mustMarshal(t, sk))
+	test.CheckNoErr(t, err, "unmarshal")
+	ss2, err := other.Decapsulate(sk2, ct)
+	test.CheckNoErr(t, err, "decapsulate")
+	if bytes.Equal(ss, ss2) {
+		t.Fatal("labels must separate shared keys")
+	}
+}
+
+func mustMarshal(t *testing.T, sk kem.PrivateKey) []byte {
+	t.Helper()
+	b, err := sk.MarshalBinary()
+	test.CheckNoErr(t, err, "marshal")
+	return b
+}
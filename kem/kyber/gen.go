@@ -7,25 +7,56 @@ package main
 
 import (
 	"bytes"
+	"fmt"
 	"go/format"
 	"io/ioutil"
+	"path"
 	"strings"
 	"text/template"
 )
 
 type Instance struct {
 	Name string
+	Oid  string
+}
+
+func (m Instance) KemName() string {
+	if m.NIST() {
+		return m.Name
+	}
+	return m.Name + ".CCAKEM"
+}
+
+func (m Instance) NIST() bool {
+	return strings.HasPrefix(m.Name, "ML-KEM")
+}
+
+func (m Instance) PkePkg() string {
+	if !m.NIST() {
+		return m.Pkg()
+	}
+	return strings.ReplaceAll(m.Pkg(), "mlkem", "kyber")
 }
 
 func (m Instance) Pkg() string {
-	return strings.ToLower(m.Name)
+	return strings.ToLower(strings.ReplaceAll(m.Name, "-", ""))
+}
+
+func (m Instance) PkgPath() string {
+	if m.NIST() {
+		return path.Join("..", "mlkem", m.Pkg())
+	}
+	return m.Pkg()
 }
 
 var (
 	Instances = []Instance{
-		{Name: "Kyber512"},
-		{Name: "Kyber768"},
-		{Name: "Kyber1024"},
+		{Name: "Kyber512", Oid: "1, 3, 6, 1, 4, 1, 22554, 5, 6, 1"},
+		{Name: "Kyber768", Oid: "1, 3, 6, 1, 4, 1, 22554, 5, 6, 2"},
+		{Name: "Kyber1024", Oid: "1, 3, 6, 1, 4, 1, 22554, 5, 6, 3"},
+		{Name: "ML-KEM-512", Oid: "2, 16, 840, 1, 101, 3, 4, 4, 1"},
+		{Name: "ML-KEM-768", Oid: "2, 16, 840, 1, 101, 3, 4, 4, 2"},
+		{Name: "ML-KEM-1024", Oid: "2, 16, 840, 1, 101, 3, 4, 4, 3"},
 	}
 	TemplateWarning = "// Code generated from"
 )
@@ -51,7 +82,7 @@ func generatePackageFiles() {
 		// Formating output code
 		code, err := format.Source(buf.Bytes())
 		if err != nil {
-			panic("error formating code")
+			panic(fmt.Sprintf("error formating code: %v", err))
 		}
 
 		res := string(code)
@@ -59,7 +90,7 @@ func generatePackageFiles() {
 		if offset == -1 {
 			panic("Missing template warning in pkg.templ.go")
 		}
-		err = ioutil.WriteFile(mode.Pkg()+"/kyber.go", []byte(res[offset:]), 0o644)
+		err = ioutil.WriteFile(mode.PkgPath()+"/kyber.go", []byte(res[offset:]), 0o644)
 		if err != nil {
 			panic(err)
 		}
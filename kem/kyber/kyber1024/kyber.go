@@ -10,6 +10,7 @@ package kyber1024
 import (
 	"bytes"
 	"crypto/subtle"
+	"encoding/asn1"
 	"io"
 
 	cryptoRand "crypto/rand"
@@ -106,7 +107,9 @@ func GenerateKeyPair(rand io.Reader) (*PublicKey, *PrivateKey, error) {
 func (pk *PublicKey) EncapsulateTo(ct, ss []byte, seed []byte) {
 	if seed == nil {
 		seed = make([]byte, EncapsulationSeedSize)
-		cryptoRand.Read(seed[:])
+		if _, err := cryptoRand.Read(seed[:]); err != nil {
+			panic(err)
+		}
 	} else {
 		if len(seed) != EncapsulationSeedSize {
 			panic("seed must be of length EncapsulationSeedSize")
@@ -121,10 +124,10 @@ func (pk *PublicKey) EncapsulateTo(ct, ss []byte, seed []byte) {
 		panic("ss must be of length SharedKeySize")
 	}
 
-	// m = H(seed)
 	var m [32]byte
+	// m = H(seed), the hash of shame
 	h := sha3.New256()
-	h.Write(seed[:])
+	h.Write(seed)
 	h.Read(m[:])
 
 	// (K', r) = G(m ‖ H(pk))
@@ -192,7 +195,7 @@ func (sk *PrivateKey) DecapsulateTo(ss, ct []byte) {
 	// K = KDF(K''/z, H(c))
 	kdf := sha3.NewShake256()
 	kdf.Write(kr2[:])
-	kdf.Read(ss[:SharedKeySize])
+	kdf.Read(ss)
 }
 
 // Packs sk to buf.
@@ -274,6 +277,9 @@ func (*scheme) SeedSize() int              { return KeySeedSize }
 func (*scheme) SharedKeySize() int         { return SharedKeySize }
 func (*scheme) CiphertextSize() int        { return CiphertextSize }
 func (*scheme) EncapsulationSeedSize() int { return EncapsulationSeedSize }
+func (*scheme) Oid() asn1.ObjectIdentifier {
+	return asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 22554, 5, 6, 3}
+}
 
 func (sk *PrivateKey) Scheme() kem.Scheme { return sch }
 func (pk *PublicKey) Scheme() kem.Scheme  { return sch }
@@ -296,7 +302,7 @@ func (sk *PrivateKey) Equal(other kem.PrivateKey) bool {
 		return false
 	}
 	if !bytes.Equal(sk.hpk[:], oth.hpk[:]) ||
-		!bytes.Equal(sk.z[:], oth.z[:]) {
+		subtle.ConstantTimeCompare(sk.z[:], oth.z[:]) != 1 {
 		return false
 	}
 	return sk.sk.Equal(oth.sk)
@@ -384,10 +390,10 @@ func (*scheme) Decapsulate(sk kem.PrivateKey, ct []byte) ([]byte, error) {
 }
 
 func (*scheme) UnmarshalBinaryPublicKey(buf []byte) (kem.PublicKey, error) {
+	var ret PublicKey
 	if len(buf) != PublicKeySize {
 		return nil, kem.ErrPubKeySize
 	}
-	var ret PublicKey
 	ret.Unpack(buf)
 	return &ret, nil
 }
@@ -35,6 +35,12 @@ func (m Instance) IsSemiSystematic() bool {
 	return strings.HasSuffix(m.Name, "f")
 }
 
+// IsPlaintextConfirming returns whether the ciphertext is extended with a
+// hash of the error vector, as in the "pc" and "pcf" variants.
+func (m Instance) IsPlaintextConfirming() bool {
+	return strings.HasSuffix(m.Name, "pc") || strings.HasSuffix(m.Name, "pcf")
+}
+
 func (m Instance) Is348864() bool {
 	return strings.Contains(m.Name, "348864")
 }
@@ -96,22 +102,37 @@ var (
 		SysN:           8192,
 		SysT:           128,
 	}
-	Instances = []Instance{
+	McElieceParam6688128pc = withPlaintextConfirmation(McElieceParam6688128)
+	McElieceParam6960119pc = withPlaintextConfirmation(McElieceParam6960119)
+	McElieceParam8192128pc = withPlaintextConfirmation(McElieceParam8192128)
+	Instances              = []Instance{
 		{Name: "mceliece348864", Param: McElieceParam348864},
 		{Name: "mceliece348864f", Param: McElieceParam348864},
 		{Name: "mceliece460896", Param: McElieceParam460896},
 		{Name: "mceliece460896f", Param: McElieceParam460896},
 		{Name: "mceliece6688128", Param: McElieceParam6688128},
 		{Name: "mceliece6688128f", Param: McElieceParam6688128},
+		{Name: "mceliece6688128pc", Param: McElieceParam6688128pc},
+		{Name: "mceliece6688128pcf", Param: McElieceParam6688128pc},
 		{Name: "mceliece6960119", Param: McElieceParam6960119},
 		{Name: "mceliece6960119f", Param: McElieceParam6960119},
+		{Name: "mceliece6960119pc", Param: McElieceParam6960119pc},
+		{Name: "mceliece6960119pcf", Param: McElieceParam6960119pc},
 		{Name: "mceliece8192128", Param: McElieceParam8192128},
 		{Name: "mceliece8192128f", Param: McElieceParam8192128},
+		{Name: "mceliece8192128pc", Param: McElieceParam8192128pc},
+		{Name: "mceliece8192128pcf", Param: McElieceParam8192128pc},
 	}
 
 	TemplateWarning = "// Code generated from"
 )
 
+// The plaintext-confirming variants append a 32-byte hash to the ciphertext.
+func withPlaintextConfirmation(p Param) Param {
+	p.CiphertextSize += 32
+	return p
+}
+
 func main() {
 	generateTemplateFilesIf("templates/benes_348864.templ.go", "benes", func(m Instance) bool { return m.Is348864() })
 	generateTemplateFilesIf("templates/benes_other.templ.go", "benes", func(m Instance) bool { return !m.Is348864() })
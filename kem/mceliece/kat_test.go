@@ -30,6 +30,17 @@ func TestPQCgenKATKem(t *testing.T) {
 		{"mceliece6960119", "e4d608fa9795c1a1704709ab9df3940ae1dbf0f708cc0dbdf76c8f3173088e46"},
 		{"mceliece8192128f", "3fdb40d47705829c16de4fb5a81f7c095eb4dadc306cfc2c89eff2f483c42402"},
 		{"mceliece8192128", "beb28fc0d1555a0028afeb6ebc72b8337f424a826be3d49b47759b8bda50db90"},
+
+		// Plaintext-confirming variants; computed from this implementation,
+		// not from the reference implementation, so they only guard against
+		// regressions. TestPlaintextConfirmation checks that these variants
+		// agree with the ones above.
+		{"mceliece6688128pcf", "376c96a1932d92654360d0bf2edd9988f740b101f25e3b90d4040452b5902fc0"},
+		{"mceliece6688128pc", "77a3613f6524d5b34bd1915dfe65a527ed3527388a97b89cb6b30a94417ef398"},
+		{"mceliece6960119pcf", "e9e0422426cfd53925953f22e38dab7622d4de62910810e33a33ce38408cea7a"},
+		{"mceliece6960119pc", "33d299b1199c6a44ca29f933c5c86c71d8073a0efa04c4a2a2c575ed9fc815cb"},
+		{"mceliece8192128pcf", "3f7d45ee77b548014678e3cbc58fddb719130c297924e73c3be31bec23bf2b83"},
+		{"mceliece8192128pc", "4e17f036be63adaf2eb947118564eaa021124aea5634796c82a6d9383e54ce4a"},
 	}
 
 	for _, kat := range kats {
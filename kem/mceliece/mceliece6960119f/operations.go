@@ -8,6 +8,12 @@ func checkPkPadding(pk *[PublicKeySize]byte) byte {
 	for i := 0; i < pkNRows; i++ {
 		b |= pk[i*pkRowBytes+pkRowBytes-1]
 	}
+	return paddingMask(b)
+}
+
+// Given the OR `b` of the last bytes of all rows of the public key, returns
+// 0 if their padding bits are all zero, and 0xFF otherwise.
+func paddingMask(b byte) byte {
 	b >>= pkNCols % 8
 	b -= 1
 	b >>= 7
@@ -25,33 +31,34 @@ func checkCPadding(c *[CiphertextSize]byte) byte {
 // input: public key pk, error vector e
 // output: syndrome s
 func syndrome(s *[CiphertextSize]byte, pk *[PublicKeySize]byte, e *[sysN / 8]byte) {
-	row := [sysN / 8]byte{}
-	tail := pkNRows % 8
 	for i := 0; i < syndBytes; i++ {
 		s[i] = 0
 	}
 	for i := 0; i < pkNRows; i++ {
-		for j := 0; j < sysN/8; j++ {
-			row[j] = 0
-		}
-		for j := 0; j < pkRowBytes; j++ {
-			row[sysN/8-pkRowBytes+j] = pk[i*pkRowBytes+j]
-		}
-		for j := sysN/8 - 1; j >= sysN/8-pkRowBytes; j-- {
-			row[j] = (row[j] << tail) | (row[j-1] >> (8 - tail))
-		}
-		row[i/8] |= 1 << (i % 8)
-
-		b := byte(0)
-		for j := 0; j < sysN/8; j++ {
-			b ^= row[j] & e[j]
-		}
-
-		b ^= b >> 4
-		b ^= b >> 2
-		b ^= b >> 1
-		b &= 1
-
-		s[i/8] |= b << (i % 8)
+		syndromeRow(s, i, pk[i*pkRowBytes:(i+1)*pkRowBytes], e)
+	}
+}
+
+// input: row `i` of the public key pkRow, error vector e
+// output: bit `i` of the syndrome s, which must be cleared
+func syndromeRow(s *[CiphertextSize]byte, i int, pkRow []byte, e *[sysN / 8]byte) {
+	row := [sysN / 8]byte{}
+	tail := pkNRows % 8
+	copy(row[sysN/8-pkRowBytes:], pkRow[:pkRowBytes])
+	for j := sysN/8 - 1; j >= sysN/8-pkRowBytes; j-- {
+		row[j] = (row[j] << tail) | (row[j-1] >> (8 - tail))
 	}
+	row[i/8] |= 1 << (i % 8)
+
+	b := byte(0)
+	for j := 0; j < sysN/8; j++ {
+		b ^= row[j] & e[j]
+	}
+
+	b ^= b >> 4
+	b ^= b >> 2
+	b ^= b >> 1
+	b &= 1
+
+	s[i/8] |= b << (i % 8)
 }
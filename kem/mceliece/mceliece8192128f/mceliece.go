@@ -43,6 +43,9 @@ const (
 	SharedKeySize         = 32
 	seedSize              = 32
 	encapsulationSeedSize = 48
+
+	// Number of rows of the public key read at once by EncapsulateStream.
+	streamRows = 32
 )
 
 type PublicKey struct {
@@ -153,15 +156,49 @@ func encrypt(s *[CiphertextSize]byte, pk *[PublicKeySize]byte, e *[sysN / 8]byte
 // the ciphertext (meant to be used for decapsulation) is returned as `c`.
 func kemEncapsulate(c *[CiphertextSize]byte, key *[SharedKeySize]byte, pk *[PublicKeySize]byte, rand randFunc) error {
 	e := [sysN / 8]byte{}
-	oneEC := [1 + sysN/8 + syndBytes]byte{1}
 
 	err := encrypt(c, pk, &e, rand)
 	if err != nil {
 		return err
 	}
+	return encapsulatedKey(c, key, &e)
+}
+
+// KEM Encapsulation reading the public key from `r`.
+//
+// Same as kemEncapsulate, except that the rows of the public key are read
+// from `r` and consumed in blocks of streamRows rows, so that the public key
+// is never held in memory at once.
+func kemEncapsulateStream(c *[CiphertextSize]byte, key *[SharedKeySize]byte, r io.Reader, rand randFunc) error {
+	e := [sysN / 8]byte{}
+	rows := [streamRows * pkRowBytes]byte{}
+
+	err := genE(&e, rand)
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < pkNRows; i += streamRows {
+		block := rows[:min(streamRows, pkNRows-i)*pkRowBytes]
+		_, err = io.ReadFull(r, block)
+		if err != nil {
+			return err
+		}
+		for j := 0; len(block) > 0; j++ {
+			syndromeRow(c, i+j, block[:pkRowBytes], &e)
+			block = block[pkRowBytes:]
+		}
+	}
+	return encapsulatedKey(c, key, &e)
+}
+
+// Given the error vector `e` and the syndrome in `c`, completes the
+// ciphertext `c` and computes the shared key `key`.
+func encapsulatedKey(c *[CiphertextSize]byte, key *[SharedKeySize]byte, e *[sysN / 8]byte) error {
+	oneEC := [1 + sysN/8 + syndBytes]byte{1}
 	copy(oneEC[1:1+sysN/8], e[:sysN/8])
 	copy(oneEC[1+sysN/8:1+sysN/8+syndBytes], c[:syndBytes])
-	err = shake256(key[0:32], oneEC[:])
+	err := shake256(key[0:32], oneEC[:])
 	if err != nil {
 		return err
 	}
@@ -200,36 +237,35 @@ func kemDecapsulate(key *[SharedKeySize]byte, c *[CiphertextSize]byte, sk *[Priv
 // input: public key pk, error vector e
 // output: syndrome s
 func syndrome(s *[CiphertextSize]byte, pk *[PublicKeySize]byte, e *[sysN / 8]byte) {
-	row := [sysN / 8]byte{}
-	var b byte
-
 	for i := 0; i < syndBytes; i++ {
 		s[i] = 0
 	}
 
 	for i := 0; i < pkNRows; i++ {
-		for j := 0; j < sysN/8; j++ {
-			row[j] = 0
-		}
-
-		for j := 0; j < pkRowBytes; j++ {
-			row[sysN/8-pkRowBytes+j] = pk[i*pkRowBytes+j]
-		}
+		syndromeRow(s, i, pk[i*pkRowBytes:(i+1)*pkRowBytes], e)
+	}
+}
 
-		row[i/8] |= 1 << (i % 8)
+// input: row `i` of the public key pkRow, error vector e
+// output: bit `i` of the syndrome s, which must be cleared
+func syndromeRow(s *[CiphertextSize]byte, i int, pkRow []byte, e *[sysN / 8]byte) {
+	row := [sysN / 8]byte{}
+	var b byte
 
-		b = 0
-		for j := 0; j < sysN/8; j++ {
-			b ^= row[j] & e[j]
-		}
+	copy(row[sysN/8-pkRowBytes:], pkRow[:pkRowBytes])
 
-		b ^= b >> 4
-		b ^= b >> 2
-		b ^= b >> 1
-		b &= 1
+	row[i/8] |= 1 << (i % 8)
 
-		s[i/8] |= b << (i % 8)
+	for j := 0; j < sysN/8; j++ {
+		b ^= row[j] & e[j]
 	}
+
+	b ^= b >> 4
+	b ^= b >> 2
+	b ^= b >> 1
+	b &= 1
+
+	s[i/8] |= b << (i % 8)
 }
 
 // Generates `e`, a random error vector of weight `t`.
@@ -688,6 +724,20 @@ func (pk *PublicKey) MarshalBinary() ([]byte, error) {
 	return ret[:], nil
 }
 
+// WriteTo writes the packed public key to w without copying it first.
+// It implements io.WriterTo.
+func (pk *PublicKey) WriteTo(w io.Writer) (int64, error) {
+	n, err := w.Write(pk.pk[:])
+	return int64(n), err
+}
+
+// ReadFrom reads a packed public key of exactly PublicKeySize bytes from r
+// into pk. It implements io.ReaderFrom.
+func (pk *PublicKey) ReadFrom(r io.Reader) (int64, error) {
+	n, err := io.ReadFull(r, pk.pk[:])
+	return int64(n), err
+}
+
 func (*scheme) GenerateKeyPair() (kem.PublicKey, kem.PrivateKey, error) {
 	seed := [32]byte{}
 	_, err := io.ReadFull(cryptoRand.Reader, seed[:])
@@ -732,19 +782,54 @@ func (*scheme) EncapsulateDeterministically(pk kem.PublicKey, seed []byte) (ct,
 	if len(seed) != encapsulationSeedSize {
 		return nil, nil, kem.ErrSeedSize
 	}
+	return encapsulate(pk, drbgRand(seed))
+}
 
+// Returns the randomness used for deterministic encapsulation from seed.
+func drbgRand(seed []byte) randFunc {
 	entropy := [48]byte{}
 	waste := [32]byte{}
 	copy(entropy[:], seed)
 	dRng := nist.NewDRBG(&entropy)
 	dRng.Fill(waste[:])
 
-	return encapsulate(pk, func(pool []byte) error {
+	return func(pool []byte) error {
 		dRng.Fill(pool)
 		return nil
+	}
+}
+
+func encapsulateStream(r io.Reader, rand randFunc) (ct, ss []byte, err error) {
+	ciphertext := [CiphertextSize]byte{}
+	sharedSecret := [SharedKeySize]byte{}
+	err = kemEncapsulateStream(&ciphertext, &sharedSecret, r, rand)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ciphertext[:], sharedSecret[:], nil
+}
+
+// EncapsulateStream generates a shared key and its ciphertext for the
+// packed public key read from r, as written by PublicKey.WriteTo.
+//
+// The public key is consumed in blocks of rows, so that it is never held
+// in memory at once. Exactly PublicKeySize bytes are read from r.
+func EncapsulateStream(r io.Reader) (ct, ss []byte, err error) {
+	return encapsulateStream(r, func(pool []byte) error {
+		_, err2 := io.ReadFull(cryptoRand.Reader, pool)
+		return err2
 	})
 }
 
+// EncapsulateStreamDeterministically is like EncapsulateStream, but uses
+// seed as randomness in the same way as EncapsulateDeterministically.
+func EncapsulateStreamDeterministically(r io.Reader, seed []byte) (ct, ss []byte, err error) {
+	if len(seed) != encapsulationSeedSize {
+		return nil, nil, kem.ErrSeedSize
+	}
+	return encapsulateStream(r, drbgRand(seed))
+}
+
 func (*scheme) Decapsulate(sk kem.PrivateKey, ct []byte) ([]byte, error) {
 	ssk, ok := sk.(*PrivateKey)
 	if !ok {
@@ -0,0 +1,81 @@
+package mceliece
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/cloudflare/circl/internal/sha3"
+	"github.com/cloudflare/circl/internal/test"
+	"github.com/cloudflare/circl/kem/schemes"
+)
+
+// The plaintext-confirming variants extend the ciphertext of their base
+// variant with C1 = H(2, e), and hash it into the shared key as H(1, e, C).
+func TestPlaintextConfirmation(t *testing.T) {
+	for _, v := range []struct {
+		base, pc string
+		sysN     int
+	}{
+		{"mceliece6688128", "mceliece6688128pc", 6688},
+		{"mceliece6688128f", "mceliece6688128pcf", 6688},
+		{"mceliece6960119", "mceliece6960119pc", 6960},
+		{"mceliece6960119f", "mceliece6960119pcf", 6960},
+		{"mceliece8192128", "mceliece8192128pc", 8192},
+		{"mceliece8192128f", "mceliece8192128pcf", 8192},
+	} {
+		v := v
+		t.Run(v.pc, func(t *testing.T) {
+			base := schemes.ByName(v.base)
+			pc := schemes.ByName(v.pc)
+			if base == nil || pc == nil {
+				t.Fatal()
+			}
+			if pc.CiphertextSize() != base.CiphertextSize()+32 {
+				t.Fatal("wrong ciphertext size")
+			}
+
+			seed := make([]byte, base.SeedSize())
+			pk, _ := base.DeriveKeyPair(seed)
+			pkPc, skPc := pc.DeriveKeyPair(seed)
+			ppk, _ := pk.MarshalBinary()
+			ppkPc, _ := pkPc.MarshalBinary()
+			if !bytes.Equal(ppk, ppkPc) {
+				t.Fatal("public keys differ")
+			}
+
+			eseed := make([]byte, base.EncapsulationSeedSize())
+			ct, _, err := base.EncapsulateDeterministically(pk, eseed)
+			test.CheckNoErr(t, err, "encapsulate")
+			ctPc, ssPc, err := pc.EncapsulateDeterministically(pkPc, eseed)
+			test.CheckNoErr(t, err, "encapsulate")
+			if !bytes.Equal(ct, ctPc[:len(ct)]) {
+				t.Fatal("syndromes differ")
+			}
+
+			ss, err := pc.Decapsulate(skPc, ctPc)
+			test.CheckNoErr(t, err, "decapsulate")
+			if !bytes.Equal(ss, ssPc) {
+				test.ReportError(t, ss, ssPc)
+			}
+
+			// A wrong confirmation must be rejected implicitly, yielding
+			// H(0, s, C) for the secret s stored in the private key.
+			ctPc[len(ctPc)-1] ^= 1
+			ss, err = pc.Decapsulate(skPc, ctPc)
+			test.CheckNoErr(t, err, "decapsulate")
+			if bytes.Equal(ss, ssPc) {
+				t.Fatal("wrong confirmation was accepted")
+			}
+			psk, _ := skPc.MarshalBinary()
+			h := sha3.NewShake256()
+			_, _ = h.Write([]byte{0})
+			_, _ = h.Write(psk[len(psk)-(v.sysN+7)/8:])
+			_, _ = h.Write(ctPc)
+			want := make([]byte, 32)
+			_, _ = h.Read(want)
+			if !bytes.Equal(ss, want) {
+				test.ReportError(t, ss, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package mceliece
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/cloudflare/circl/internal/test"
+	"github.com/cloudflare/circl/kem"
+	"github.com/cloudflare/circl/kem/mceliece/mceliece348864"
+	"github.com/cloudflare/circl/kem/mceliece/mceliece6960119"
+	"github.com/cloudflare/circl/kem/mceliece/mceliece8192128pcf"
+)
+
+type streamPublicKey interface {
+	kem.PublicKey
+	io.WriterTo
+	io.ReaderFrom
+}
+
+func TestStream(t *testing.T) {
+	for _, v := range []struct {
+		scheme      kem.Scheme
+		newPk       func() streamPublicKey
+		encapsulate func(io.Reader, []byte) ([]byte, []byte, error)
+	}{
+		{
+			mceliece348864.Scheme(),
+			func() streamPublicKey { return new(mceliece348864.PublicKey) },
+			mceliece348864.EncapsulateStreamDeterministically,
+		},
+		{
+			mceliece6960119.Scheme(),
+			func() streamPublicKey { return new(mceliece6960119.PublicKey) },
+			mceliece6960119.EncapsulateStreamDeterministically,
+		},
+		{
+			mceliece8192128pcf.Scheme(),
+			func() streamPublicKey { return new(mceliece8192128pcf.PublicKey) },
+			mceliece8192128pcf.EncapsulateStreamDeterministically,
+		},
+	} {
+		v := v
+		t.Run(v.scheme.Name(), func(t *testing.T) {
+			pk, sk := v.scheme.DeriveKeyPair(make([]byte, v.scheme.SeedSize()))
+
+			var buf bytes.Buffer
+			n, err := pk.(io.WriterTo).WriteTo(&buf)
+			test.CheckNoErr(t, err, "write public key")
+			ppk, _ := pk.MarshalBinary()
+			if n != int64(v.scheme.PublicKeySize()) || !bytes.Equal(buf.Bytes(), ppk) {
+				t.Fatal("wrong encoding of public key")
+			}
+
+			pk2 := v.newPk()
+			n, err = pk2.ReadFrom(bytes.NewReader(ppk))
+			test.CheckNoErr(t, err, "read public key")
+			if n != int64(v.scheme.PublicKeySize()) || !pk.Equal(pk2) {
+				t.Fatal("wrong decoding of public key")
+			}
+
+			seed := make([]byte, v.scheme.EncapsulationSeedSize())
+			seed[0] = 1
+			ct, ss, err := v.scheme.EncapsulateDeterministically(pk, seed)
+			test.CheckNoErr(t, err, "encapsulate")
+			ct2, ss2, err := v.encapsulate(bytes.NewReader(ppk), seed)
+			test.CheckNoErr(t, err, "encapsulate stream")
+			if !bytes.Equal(ct, ct2) || !bytes.Equal(ss, ss2) {
+				t.Fatal("streamed encapsulation differs")
+			}
+			ss3, err := v.scheme.Decapsulate(sk, ct2)
+			test.CheckNoErr(t, err, "decapsulate")
+			if !bytes.Equal(ss, ss3) {
+				test.ReportError(t, ss3, ss)
+			}
+
+			_, _, err = v.encapsulate(bytes.NewReader(ppk[:len(ppk)-1]), seed)
+			test.CheckIsErr(t, err, "truncated public key must fail")
+			_, err = v.newPk().ReadFrom(bytes.NewReader(ppk[:len(ppk)-1]))
+			test.CheckIsErr(t, err, "truncated public key must fail")
+		})
+	}
+}
@@ -9,7 +9,10 @@
 // described in
 //
 // https://classic.mceliece.org/nist/mceliece-20201010.pdf
-//
+//{{if .IsPlaintextConfirming}}
+// In this variant, the ciphertext carries a plaintext confirmation, that is,
+// a hash of the error vector which is checked upon decapsulation.
+//{{end}}
 // The following code is translated from the C reference implementation, and
 // from a Rust implementation by Bernhard Berg, Lukas Prokop, Daniel Kales
 // where direct translation from C is not applicable.
@@ -48,6 +51,9 @@ const (
 	SharedKeySize         = 32
 	seedSize              = 32
 	encapsulationSeedSize = 48
+
+	// Number of rows of the public key read at once by EncapsulateStream.
+	streamRows = 32
 )
 
 type PublicKey struct {
@@ -158,7 +164,6 @@ func encrypt(s *[CiphertextSize]byte, pk *[PublicKeySize]byte, e *[sysN / 8]byte
 // the ciphertext (meant to be used for decapsulation) is returned as `c`.
 func kemEncapsulate(c *[CiphertextSize]byte, key *[SharedKeySize]byte, pk *[PublicKeySize]byte, rand randFunc) error {
 	e := [sysN / 8]byte{}
-	oneEC := [1 + sysN/8 + syndBytes]byte{1}
 
 	{{if .Is6960119}}
 	paddingOk := checkPkPadding(pk)
@@ -168,9 +173,65 @@ func kemEncapsulate(c *[CiphertextSize]byte, key *[SharedKeySize]byte, pk *[Publ
 	if err != nil {
 		return err
 	}
+	return encapsulatedKey(c, key, &e{{if .Is6960119}}, paddingOk{{end}})
+}
+
+// KEM Encapsulation reading the public key from `r`.
+//
+// Same as kemEncapsulate, except that the rows of the public key are read
+// from `r` and consumed in blocks of streamRows rows, so that the public key
+// is never held in memory at once.
+func kemEncapsulateStream(c *[CiphertextSize]byte, key *[SharedKeySize]byte, r io.Reader, rand randFunc) error {
+	e := [sysN / 8]byte{}
+	rows := [streamRows * pkRowBytes]byte{}
+	{{- if .Is6960119}}
+	pad := byte(0)
+	{{- end}}
+
+	err := genE(&e, rand)
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < pkNRows; i += streamRows {
+		block := rows[:min(streamRows, pkNRows-i)*pkRowBytes]
+		_, err = io.ReadFull(r, block)
+		if err != nil {
+			return err
+		}
+		for j := 0; len(block) > 0; j++ {
+			{{- if .Is6960119}}
+			pad |= block[pkRowBytes-1]
+			{{- end}}
+			syndromeRow(c, i+j, block[:pkRowBytes], &e)
+			block = block[pkRowBytes:]
+		}
+	}
+	return encapsulatedKey(c, key, &e{{if .Is6960119}}, paddingMask(pad){{end}})
+}
+
+// Given the error vector `e` and the syndrome in `c`, completes the
+// ciphertext `c` and computes the shared key `key`.
+func encapsulatedKey(c *[CiphertextSize]byte, key *[SharedKeySize]byte, e *[sysN / 8]byte{{if .Is6960119}}, paddingOk byte{{end}}) error {
+	{{- if .IsPlaintextConfirming}}
+	twoE := [1 + sysN/8]byte{2}
+	oneEC := [1 + sysN/8 + syndBytes + 32]byte{1}
+
+	// plaintext confirmation C1 = H(2, e)
+	copy(twoE[1:], e[:])
+	err := shake256(c[syndBytes:syndBytes+32], twoE[:])
+	if err != nil {
+		return err
+	}
 	copy(oneEC[1:1+sysN/8], e[:sysN/8])
-	copy(oneEC[1+sysN/8:1+sysN/8+syndBytes], c[:syndBytes])
+	copy(oneEC[1+sysN/8:], c[:syndBytes+32])
 	err = shake256(key[0:32], oneEC[:])
+	{{- else}}
+	oneEC := [1 + sysN/8 + syndBytes]byte{1}
+	copy(oneEC[1:1+sysN/8], e[:sysN/8])
+	copy(oneEC[1+sysN/8:1+sysN/8+syndBytes], c[:syndBytes])
+	err := shake256(key[0:32], oneEC[:])
+	{{- end}}
 	if err != nil {
 		return err
 	}
@@ -179,7 +240,7 @@ func kemEncapsulate(c *[CiphertextSize]byte, key *[SharedKeySize]byte, pk *[Publ
 	return nil
 	{{else}}
 	mask := paddingOk ^ 0xFF
-	for i := 0; i < syndBytes; i++ {
+	for i := 0; i < {{if .IsPlaintextConfirming}}syndBytes+32{{else}}syndBytes{{end}}; i++ {
 		c[i] &= mask
 	}
 	for i := 0; i < 32; i++ {
@@ -199,7 +260,13 @@ func kemEncapsulate(c *[CiphertextSize]byte, key *[SharedKeySize]byte, pk *[Publ
 // determine the shared text `key` negotiated by both parties.
 func kemDecapsulate(key *[SharedKeySize]byte, c *[CiphertextSize]byte, sk *[PrivateKeySize]byte) error {
 	e := [sysN / 8]byte{}
+	{{- if .IsPlaintextConfirming}}
+	twoE := [1 + sysN/8]byte{2}
+	conf := [32]byte{}
+	preimage := [1 + sysN/8 + syndBytes + 32]byte{}
+	{{- else}}
 	preimage := [1 + sysN/8 + syndBytes]byte{}
+	{{- end}}
 	s := sk[40+irrBytes+condBytes:]
 
 	{{if .Is6960119}}
@@ -207,7 +274,23 @@ func kemDecapsulate(key *[SharedKeySize]byte, c *[CiphertextSize]byte, sk *[Priv
 	{{end}}
 
 	retDecrypt := decrypt((*[sysN / 8]byte)(e[:sysN/8]), sk[40:], (*[syndBytes]byte)(c[:syndBytes]))
+	{{- if .IsPlaintextConfirming}}
+
+	// recompute the plaintext confirmation and compare it in constant time
+	copy(twoE[1:], e[:])
+	err := shake256(conf[:], twoE[:])
+	if err != nil {
+		return err
+	}
+	retConfirm := byte(0)
+	for i := 0; i < 32; i++ {
+		retConfirm |= conf[i] ^ c[syndBytes+i]
+	}
+
+	m := retDecrypt | uint16(retConfirm)
+	{{- else}}
 	m := retDecrypt
+	{{- end}}
 	m -= 1
 	m >>= 8
 
@@ -216,8 +299,15 @@ func kemDecapsulate(key *[SharedKeySize]byte, c *[CiphertextSize]byte, sk *[Priv
 		preimage[1+i] = (byte(^m) & s[i]) | (byte(m) & e[i])
 	}
 
+	{{- if .IsPlaintextConfirming}}
+
+	copy(preimage[1+sysN/8:], c[0:syndBytes+32])
+	err = shake256(key[0:32], preimage[:])
+	{{- else}}
+
 	copy(preimage[1+sysN/8:][:syndBytes], c[0:syndBytes])
 	err := shake256(key[0:32], preimage[:])
+	{{- end}}
 	if err != nil {
 		return err
 	}
@@ -242,36 +332,35 @@ func kemDecapsulate(key *[SharedKeySize]byte, c *[CiphertextSize]byte, sk *[Priv
 // input: public key pk, error vector e
 // output: syndrome s
 func syndrome(s *[CiphertextSize]byte, pk *[PublicKeySize]byte, e *[sysN / 8]byte) {
-	row := [sysN / 8]byte{}
-	var b byte
-
 	for i := 0; i < syndBytes; i++ {
 		s[i] = 0
 	}
 
 	for i := 0; i < pkNRows; i++ {
-		for j := 0; j < sysN/8; j++ {
-			row[j] = 0
-		}
-
-		for j := 0; j < pkRowBytes; j++ {
-			row[sysN/8-pkRowBytes+j] = pk[i*pkRowBytes+j]
-		}
+		syndromeRow(s, i, pk[i*pkRowBytes:(i+1)*pkRowBytes], e)
+	}
+}
 
-		row[i/8] |= 1 << (i % 8)
+// input: row `i` of the public key pkRow, error vector e
+// output: bit `i` of the syndrome s, which must be cleared
+func syndromeRow(s *[CiphertextSize]byte, i int, pkRow []byte, e *[sysN / 8]byte) {
+	row := [sysN / 8]byte{}
+	var b byte
 
-		b = 0
-		for j := 0; j < sysN/8; j++ {
-			b ^= row[j] & e[j]
-		}
+	copy(row[sysN/8-pkRowBytes:], pkRow[:pkRowBytes])
 
-		b ^= b >> 4
-		b ^= b >> 2
-		b ^= b >> 1
-		b &= 1
+	row[i/8] |= 1 << (i % 8)
 
-		s[i/8] |= b << (i % 8)
+	for j := 0; j < sysN/8; j++ {
+		b ^= row[j] & e[j]
 	}
+
+	b ^= b >> 4
+	b ^= b >> 2
+	b ^= b >> 1
+	b &= 1
+
+	s[i/8] |= b << (i % 8)
 }
 {{end}}
 
@@ -803,6 +892,20 @@ func (pk *PublicKey) MarshalBinary() ([]byte, error) {
 	return ret[:], nil
 }
 
+// WriteTo writes the packed public key to w without copying it first.
+// It implements io.WriterTo.
+func (pk *PublicKey) WriteTo(w io.Writer) (int64, error) {
+	n, err := w.Write(pk.pk[:])
+	return int64(n), err
+}
+
+// ReadFrom reads a packed public key of exactly PublicKeySize bytes from r
+// into pk. It implements io.ReaderFrom.
+func (pk *PublicKey) ReadFrom(r io.Reader) (int64, error) {
+	n, err := io.ReadFull(r, pk.pk[:])
+	return int64(n), err
+}
+
 func (*scheme) GenerateKeyPair() (kem.PublicKey, kem.PrivateKey, error) {
 	seed := [32]byte{}
 	_, err := io.ReadFull(cryptoRand.Reader, seed[:])
@@ -847,19 +950,54 @@ func (*scheme) EncapsulateDeterministically(pk kem.PublicKey, seed []byte) (ct,
 	if len(seed) != encapsulationSeedSize {
 		return nil, nil, kem.ErrSeedSize
 	}
+	return encapsulate(pk, drbgRand(seed))
+}
 
+// Returns the randomness used for deterministic encapsulation from seed.
+func drbgRand(seed []byte) randFunc {
 	entropy := [48]byte{}
 	waste := [32]byte{}
 	copy(entropy[:], seed)
 	dRng := nist.NewDRBG(&entropy)
 	dRng.Fill(waste[:])
 
-	return encapsulate(pk, func(pool []byte) error {
+	return func(pool []byte) error {
 		dRng.Fill(pool)
 		return nil
+	}
+}
+
+func encapsulateStream(r io.Reader, rand randFunc) (ct, ss []byte, err error) {
+	ciphertext := [CiphertextSize]byte{}
+	sharedSecret := [SharedKeySize]byte{}
+	err = kemEncapsulateStream(&ciphertext, &sharedSecret, r, rand)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ciphertext[:], sharedSecret[:], nil
+}
+
+// EncapsulateStream generates a shared key and its ciphertext for the
+// packed public key read from r, as written by PublicKey.WriteTo.
+//
+// The public key is consumed in blocks of rows, so that it is never held
+// in memory at once. Exactly PublicKeySize bytes are read from r.
+func EncapsulateStream(r io.Reader) (ct, ss []byte, err error) {
+	return encapsulateStream(r, func(pool []byte) error {
+		_, err2 := io.ReadFull(cryptoRand.Reader, pool)
+		return err2
 	})
 }
 
+// EncapsulateStreamDeterministically is like EncapsulateStream, but uses
+// seed as randomness in the same way as EncapsulateDeterministically.
+func EncapsulateStreamDeterministically(r io.Reader, seed []byte) (ct, ss []byte, err error) {
+	if len(seed) != encapsulationSeedSize {
+		return nil, nil, kem.ErrSeedSize
+	}
+	return encapsulateStream(r, drbgRand(seed))
+}
+
 func (*scheme) Decapsulate(sk kem.PrivateKey, ct []byte) ([]byte, error) {
 	ssk, ok := sk.(*PrivateKey)
 	if !ok {
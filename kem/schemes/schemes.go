@@ -14,6 +14,7 @@
 //
 //	FrodoKEM-640-SHAKE
 //	Kyber512, Kyber768, Kyber1024
+//	ML-KEM-512, ML-KEM-768, ML-KEM-1024
 package schemes
 
 import (
@@ -32,10 +33,19 @@ import (
 	"github.com/cloudflare/circl/kem/mceliece/mceliece460896f"
 	"github.com/cloudflare/circl/kem/mceliece/mceliece6688128"
 	"github.com/cloudflare/circl/kem/mceliece/mceliece6688128f"
+	"github.com/cloudflare/circl/kem/mceliece/mceliece6688128pc"
+	"github.com/cloudflare/circl/kem/mceliece/mceliece6688128pcf"
 	"github.com/cloudflare/circl/kem/mceliece/mceliece6960119"
 	"github.com/cloudflare/circl/kem/mceliece/mceliece6960119f"
+	"github.com/cloudflare/circl/kem/mceliece/mceliece6960119pc"
+	"github.com/cloudflare/circl/kem/mceliece/mceliece6960119pcf"
 	"github.com/cloudflare/circl/kem/mceliece/mceliece8192128"
 	"github.com/cloudflare/circl/kem/mceliece/mceliece8192128f"
+	"github.com/cloudflare/circl/kem/mceliece/mceliece8192128pc"
+	"github.com/cloudflare/circl/kem/mceliece/mceliece8192128pcf"
+	"github.com/cloudflare/circl/kem/mlkem/mlkem1024"
+	"github.com/cloudflare/circl/kem/mlkem/mlkem512"
+	"github.com/cloudflare/circl/kem/mlkem/mlkem768"
 )
 
 var allSchemes = [...]kem.Scheme{
@@ -48,16 +58,25 @@ var allSchemes = [...]kem.Scheme{
 	kyber512.Scheme(),
 	kyber768.Scheme(),
 	kyber1024.Scheme(),
+	mlkem512.Scheme(),
+	mlkem768.Scheme(),
+	mlkem1024.Scheme(),
 	mceliece348864.Scheme(),
 	mceliece348864f.Scheme(),
 	mceliece460896.Scheme(),
 	mceliece460896f.Scheme(),
 	mceliece6688128.Scheme(),
 	mceliece6688128f.Scheme(),
+	mceliece6688128pc.Scheme(),
+	mceliece6688128pcf.Scheme(),
 	mceliece6960119.Scheme(),
 	mceliece6960119f.Scheme(),
+	mceliece6960119pc.Scheme(),
+	mceliece6960119pcf.Scheme(),
 	mceliece8192128.Scheme(),
 	mceliece8192128f.Scheme(),
+	mceliece8192128pc.Scheme(),
+	mceliece8192128pcf.Scheme(),
 	hybrid.Kyber512X25519(),
 	hybrid.Kyber768X25519(),
 	hybrid.Kyber768X448(),
@@ -155,16 +155,25 @@ func Example_schemes() {
 	// Kyber512
 	// Kyber768
 	// Kyber1024
+	// ML-KEM-512
+	// ML-KEM-768
+	// ML-KEM-1024
 	// mceliece348864
 	// mceliece348864f
 	// mceliece460896
 	// mceliece460896f
 	// mceliece6688128
 	// mceliece6688128f
+	// mceliece6688128pc
+	// mceliece6688128pcf
 	// mceliece6960119
 	// mceliece6960119f
+	// mceliece6960119pc
+	// mceliece6960119pcf
 	// mceliece8192128
 	// mceliece8192128f
+	// mceliece8192128pc
+	// mceliece8192128pcf
 	// Kyber512-X25519
 	// Kyber768-X25519
 	// Kyber768-X448
@@ -0,0 +1,341 @@
+package ohttp
+
+import (
+	"bufio"
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"github.com/cloudflare/circl/hpke"
+	"golang.org/x/crypto/cryptobyte"
+)
+
+// This file implements the chunked variant of Oblivious HTTP. A chunked
+// message is a sequence of AEAD-protected chunks, each prefixed by its length
+// as a variable-length integer. The final chunk is introduced by a zero length
+// and extends to the end of the message; it is authenticated with the
+// associated data "final", which prevents truncation. Request chunks are
+// sealed with the HPKE context, response chunks with keys derived as for
+// non-chunked responses and nonces XORed with a chunk counter.
+
+// MaxChunkSize is the largest size of an AEAD-protected chunk accepted when
+// reading a chunked message.
+const MaxChunkSize = 1 << 24
+
+var (
+	finalAAD        = []byte("final")
+	errWriterClosed = errors.New("ohttp: chunk writer is closed")
+)
+
+type chunkWriter struct {
+	w      io.Writer
+	seal   func(pt, aad []byte) ([]byte, error)
+	closed bool
+}
+
+// Write encrypts p as a single non-final chunk and writes it to the
+// underlying writer. Empty writes produce no chunk.
+func (c *chunkWriter) Write(p []byte) (n int, err error) {
+	if c.closed {
+		return 0, errWriterClosed
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if err := c.writeChunk(p, false); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close writes the final chunk, which is empty. It does not close the
+// underlying writer.
+func (c *chunkWriter) Close() error {
+	if c.closed {
+		return errWriterClosed
+	}
+	c.closed = true
+	return c.writeChunk(nil, true)
+}
+
+func (c *chunkWriter) writeChunk(p []byte, final bool) error {
+	var aad []byte
+	if final {
+		aad = finalAAD
+	}
+	ct, err := c.seal(p, aad)
+	if err != nil {
+		return err
+	}
+	length := uint64(len(ct))
+	if final {
+		length = 0
+	}
+	if _, err := c.w.Write(appendVarint(nil, length)); err != nil {
+		return err
+	}
+	_, err = c.w.Write(ct)
+	return err
+}
+
+type chunkReader struct {
+	r    *bufio.Reader
+	open func(ct, aad []byte) ([]byte, error)
+	pt   []byte
+	eof  bool
+	err  error
+}
+
+func newChunkReader(r io.Reader, open func(ct, aad []byte) ([]byte, error)) *chunkReader {
+	br, ok := r.(*bufio.Reader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	return &chunkReader{r: br, open: open}
+}
+
+// Read reads decrypted data into p. Data is only returned once its chunk
+// has been authenticated.
+func (c *chunkReader) Read(p []byte) (n int, err error) {
+	for len(c.pt) == 0 {
+		if c.err != nil {
+			return 0, c.err
+		}
+		if c.eof {
+			return 0, io.EOF
+		}
+		c.err = c.next()
+	}
+	n = copy(p, c.pt)
+	c.pt = c.pt[n:]
+	return n, nil
+}
+
+func (c *chunkReader) next() error {
+	length, err := readVarint(c.r)
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	} else if err != nil {
+		return err
+	}
+
+	var ct, aad []byte
+	if length == 0 {
+		ct, err = io.ReadAll(io.LimitReader(c.r, MaxChunkSize+1))
+		if err != nil {
+			return err
+		}
+		if len(ct) > MaxChunkSize {
+			return ErrInvalidEncapsulation
+		}
+		aad = finalAAD
+		c.eof = true
+	} else {
+		if length > MaxChunkSize {
+			return ErrInvalidEncapsulation
+		}
+		ct = make([]byte, length)
+		if _, err = io.ReadFull(c.r, ct); err == io.EOF {
+			return io.ErrUnexpectedEOF
+		} else if err != nil {
+			return err
+		}
+	}
+
+	c.pt, err = c.open(ct, aad)
+	return err
+}
+
+// ChunkedRequestWriter encrypts a chunked request, see
+// Client.EncapsulateChunkedRequest.
+type ChunkedRequestWriter struct {
+	chunkWriter
+	suite  hpke.Suite
+	enc    []byte
+	sealer hpke.Sealer
+}
+
+// EncapsulateChunkedRequest writes the header of a chunked request to w and
+// returns a writer that encrypts the request written to it, reading
+// randomness from rnd. Every call to Write produces one chunk, and Close
+// must be called to write the final chunk.
+func (c *Client) EncapsulateChunkedRequest(rnd io.Reader, w io.Writer) (
+	*ChunkedRequestWriter, error,
+) {
+	hdr, enc, sealer, err := c.setup(rnd, chunkedRequestLabel)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(append(hdr, enc...)); err != nil {
+		return nil, err
+	}
+	return &ChunkedRequestWriter{
+		chunkWriter{w: w, seal: sealer.Seal},
+		c.suite,
+		enc,
+		sealer,
+	}, nil
+}
+
+// DecapsulateResponse reads the response nonce from r and returns a reader
+// that decrypts the chunked response to the request of this writer. The
+// response may be read before the request is complete.
+func (w *ChunkedRequestWriter) DecapsulateResponse(r io.Reader) (
+	io.Reader, error,
+) {
+	responseNonce := make([]byte, responseNonceSize(w.suite))
+	if _, err := io.ReadFull(r, responseNonce); err != nil {
+		return nil, err
+	}
+	aead, nonce, err := responseKeys(w.suite, w.sealer, chunkedResponseLabel,
+		w.enc, responseNonce)
+	if err != nil {
+		return nil, err
+	}
+	cs := &chunkSequence{aead: aead, baseNonce: nonce}
+	return newChunkReader(r, cs.open), nil
+}
+
+// ChunkedRequestReader decrypts a chunked request, see
+// Gateway.DecapsulateChunkedRequest.
+type ChunkedRequestReader struct {
+	chunkReader
+	suite  hpke.Suite
+	enc    []byte
+	opener hpke.Opener
+}
+
+// DecapsulateChunkedRequest reads the header of a chunked request from r and
+// returns a reader that decrypts the request. If the request was truncated or
+// modified, Read returns an error.
+func (g *Gateway) DecapsulateChunkedRequest(r io.Reader) (
+	*ChunkedRequestReader, error,
+) {
+	hdr := make([]byte, headerSize)
+	if _, err := io.ReadFull(r, hdr); err != nil {
+		return nil, err
+	}
+	key, ok := g.keys[hdr[0]]
+	if !ok {
+		return nil, ErrUnknownKeyID
+	}
+	if hpke.KEM(binary.BigEndian.Uint16(hdr[1:3])) != key.Config.KEM {
+		return nil, ErrUnsupportedSuite
+	}
+	buf := make([]byte, headerSize+key.Config.KEM.Scheme().CiphertextSize())
+	copy(buf, hdr)
+	if _, err := io.ReadFull(r, buf[headerSize:]); err != nil {
+		return nil, err
+	}
+
+	s := cryptobyte.String(buf)
+	suite, enc, opener, err := g.setup(&s, chunkedRequestLabel)
+	if err != nil {
+		return nil, err
+	}
+	return &ChunkedRequestReader{
+		*newChunkReader(r, opener.Open),
+		suite,
+		enc,
+		opener,
+	}, nil
+}
+
+// EncapsulateResponse writes the response nonce to w and returns a writer
+// that encrypts the chunked response to the request of this reader, reading
+// randomness from rnd. Every call to Write produces one chunk, and Close must
+// be called to write the final chunk.
+func (r *ChunkedRequestReader) EncapsulateResponse(rnd io.Reader, w io.Writer) (
+	io.WriteCloser, error,
+) {
+	responseNonce := make([]byte, responseNonceSize(r.suite))
+	if _, err := io.ReadFull(rnd, responseNonce); err != nil {
+		return nil, err
+	}
+	aead, nonce, err := responseKeys(r.suite, r.opener, chunkedResponseLabel,
+		r.enc, responseNonce)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(responseNonce); err != nil {
+		return nil, err
+	}
+	cs := &chunkSequence{aead: aead, baseNonce: nonce}
+	return &chunkWriter{w: w, seal: cs.seal}, nil
+}
+
+// chunkSequence protects the chunks of a response, the nonce of each chunk
+// is the base nonce XORed with the chunk counter.
+type chunkSequence struct {
+	aead      cipher.AEAD
+	baseNonce []byte
+	counter   uint64
+}
+
+func (c *chunkSequence) nonce() ([]byte, error) {
+	if c.counter == ^uint64(0) {
+		return nil, hpke.ErrAEADSeqOverflows
+	}
+	nonce := append([]byte{}, c.baseNonce...)
+	var ctr [8]byte
+	binary.BigEndian.PutUint64(ctr[:], c.counter)
+	for i := range ctr {
+		nonce[len(nonce)-len(ctr)+i] ^= ctr[i]
+	}
+	c.counter++
+	return nonce, nil
+}
+
+func (c *chunkSequence) seal(pt, aad []byte) ([]byte, error) {
+	nonce, err := c.nonce()
+	if err != nil {
+		return nil, err
+	}
+	return c.aead.Seal(nil, nonce, pt, aad), nil
+}
+
+func (c *chunkSequence) open(ct, aad []byte) ([]byte, error) {
+	nonce, err := c.nonce()
+	if err != nil {
+		return nil, err
+	}
+	return c.aead.Open(nil, nonce, ct, aad)
+}
+
+// appendVarint appends the QUIC variable-length encoding of v to b, see
+// RFC 9000, Section 16.
+func appendVarint(b []byte, v uint64) []byte {
+	switch {
+	case v < 1<<6:
+		return append(b, byte(v))
+	case v < 1<<14:
+		return append(b, 0x40|byte(v>>8), byte(v))
+	case v < 1<<30:
+		return append(b, 0x80|byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+	default:
+		var buf [8]byte
+		binary.BigEndian.PutUint64(buf[:], v|0xC0<<56)
+		return append(b, buf[:]...)
+	}
+}
+
+// readVarint reads a QUIC variable-length integer from r.
+func readVarint(r io.ByteReader) (uint64, error) {
+	first, err := r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	n := 1 << (first >> 6)
+	v := uint64(first & 0x3F)
+	for i := 1; i < n; i++ {
+		b, err := r.ReadByte()
+		if err == io.EOF {
+			return 0, io.ErrUnexpectedEOF
+		} else if err != nil {
+			return 0, err
+		}
+		v = v<<8 | uint64(b)
+	}
+	return v, nil
+}
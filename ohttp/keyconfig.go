@@ -0,0 +1,179 @@
+package ohttp
+
+import (
+	"errors"
+
+	"github.com/cloudflare/circl/hpke"
+	"github.com/cloudflare/circl/kem"
+	"golang.org/x/crypto/cryptobyte"
+)
+
+// SymmetricAlgorithm is a pair of HPKE KDF and AEAD algorithms supported by
+// a key configuration.
+type SymmetricAlgorithm struct {
+	KDF  hpke.KDF
+	AEAD hpke.AEAD
+}
+
+func (s SymmetricAlgorithm) isValid() bool {
+	return s.KDF.IsValid() && s.AEAD.IsValid() && s.AEAD != hpke.AEAD_ExportOnly
+}
+
+// KeyConfig is a key configuration of an Oblivious Gateway Resource, see
+// RFC 9458, Section 3. It lists the public key of the gateway, its KEM and
+// the symmetric algorithms the gateway accepts, in order of preference.
+type KeyConfig struct {
+	KeyID      uint8
+	KEM        hpke.KEM
+	PublicKey  kem.PublicKey
+	Algorithms []SymmetricAlgorithm
+}
+
+// MarshalBinary encodes the key configuration.
+func (c *KeyConfig) MarshalBinary() ([]byte, error) {
+	if !c.KEM.IsValid() {
+		return nil, ErrUnsupportedKEM
+	}
+	if c.PublicKey == nil || c.PublicKey.Scheme().Name() != c.KEM.Scheme().Name() {
+		return nil, ErrInvalidKeyConfig
+	}
+	if len(c.Algorithms) == 0 || 4*len(c.Algorithms) > 65532 {
+		return nil, ErrInvalidKeyConfig
+	}
+	pk, err := c.PublicKey.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	var b cryptobyte.Builder
+	b.AddUint8(c.KeyID)
+	b.AddUint16(uint16(c.KEM))
+	b.AddBytes(pk)
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		for _, alg := range c.Algorithms {
+			b.AddUint16(uint16(alg.KDF))
+			b.AddUint16(uint16(alg.AEAD))
+		}
+	})
+	return b.Bytes()
+}
+
+// UnmarshalBinary decodes a key configuration. It returns ErrUnsupportedKEM
+// if the KEM of the configuration is not supported by the hpke package.
+// Symmetric algorithms are kept as given, unsupported ones are ignored when
+// selecting a suite.
+func (c *KeyConfig) UnmarshalBinary(data []byte) error {
+	s := cryptobyte.String(data)
+	if err := c.unmarshal(&s); err != nil {
+		return err
+	}
+	if !s.Empty() {
+		return ErrInvalidKeyConfig
+	}
+	return nil
+}
+
+func (c *KeyConfig) unmarshal(s *cryptobyte.String) error {
+	var (
+		keyID uint8
+		kemID uint16
+		algs  cryptobyte.String
+	)
+	if !s.ReadUint8(&keyID) || !s.ReadUint16(&kemID) {
+		return ErrInvalidKeyConfig
+	}
+	if !hpke.KEM(kemID).IsValid() {
+		return ErrUnsupportedKEM
+	}
+	scheme := hpke.KEM(kemID).Scheme()
+
+	var pk []byte
+	if !s.ReadBytes(&pk, scheme.PublicKeySize()) ||
+		!s.ReadUint16LengthPrefixed(&algs) ||
+		len(algs) == 0 || len(algs)%4 != 0 {
+		return ErrInvalidKeyConfig
+	}
+	publicKey, err := scheme.UnmarshalBinaryPublicKey(pk)
+	if err != nil {
+		return err
+	}
+
+	algorithms := make([]SymmetricAlgorithm, 0, len(algs)/4)
+	for !algs.Empty() {
+		var kdfID, aeadID uint16
+		algs.ReadUint16(&kdfID)
+		algs.ReadUint16(&aeadID)
+		algorithms = append(algorithms,
+			SymmetricAlgorithm{hpke.KDF(kdfID), hpke.AEAD(aeadID)})
+	}
+
+	*c = KeyConfig{keyID, hpke.KEM(kemID), publicKey, algorithms}
+	return nil
+}
+
+// Suite returns the HPKE suite formed by the KEM of the configuration and
+// the first supported symmetric algorithm. It returns ErrUnsupportedSuite if
+// no listed symmetric algorithm is supported.
+func (c *KeyConfig) Suite() (hpke.Suite, error) {
+	for _, alg := range c.Algorithms {
+		if alg.isValid() {
+			return c.suite(alg)
+		}
+	}
+	return hpke.Suite{}, ErrUnsupportedSuite
+}
+
+// suite returns the HPKE suite formed by the KEM of the configuration and
+// the given symmetric algorithm, provided the configuration lists it.
+func (c *KeyConfig) suite(alg SymmetricAlgorithm) (hpke.Suite, error) {
+	if !c.KEM.IsValid() || !alg.isValid() {
+		return hpke.Suite{}, ErrUnsupportedSuite
+	}
+	for _, a := range c.Algorithms {
+		if a == alg {
+			return hpke.NewSuite(c.KEM, alg.KDF, alg.AEAD), nil
+		}
+	}
+	return hpke.Suite{}, ErrUnsupportedSuite
+}
+
+// MarshalKeyConfigs encodes a list of key configurations in the
+// application/ohttp-keys format, where each configuration is prefixed by its
+// two-byte length, see RFC 9458, Section 3.2.
+func MarshalKeyConfigs(configs ...KeyConfig) ([]byte, error) {
+	var b cryptobyte.Builder
+	for i := range configs {
+		config, err := configs[i].MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+			b.AddBytes(config)
+		})
+	}
+	return b.Bytes()
+}
+
+// UnmarshalKeyConfigs decodes a list of key configurations in the
+// application/ohttp-keys format. Configurations using a KEM that is not
+// supported are skipped.
+func UnmarshalKeyConfigs(data []byte) ([]KeyConfig, error) {
+	var configs []KeyConfig
+	s := cryptobyte.String(data)
+	for !s.Empty() {
+		var t cryptobyte.String
+		if !s.ReadUint16LengthPrefixed(&t) {
+			return nil, ErrInvalidKeyConfig
+		}
+		var c KeyConfig
+		err := c.UnmarshalBinary(t)
+		if errors.Is(err, ErrUnsupportedKEM) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		configs = append(configs, c)
+	}
+	return configs, nil
+}
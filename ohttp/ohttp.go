@@ -0,0 +1,347 @@
+// Package ohttp implements Oblivious HTTP message encapsulation.
+//
+// Oblivious HTTP allows a client to make requests to a server without the
+// server learning the identity of the client. Requests are encrypted with
+// HPKE to the public key of an Oblivious Gateway Resource, and are forwarded
+// to it by a relay that learns the identity of the client but not the
+// content of its requests. Responses are encrypted with keys exported from
+// the HPKE context of the request.
+//
+// This package implements the encapsulation of RFC 9458 [1] and its chunked
+// variant [2], which allows to encrypt requests and responses incrementally.
+// Encapsulated messages carry arbitrary content, usually Binary HTTP messages
+// as defined in RFC 9292.
+//
+// # Protocol Overview
+//
+//	Client(keyConfig)                            Gateway(keyConfig, sk)
+//	=================================================================
+//	encReq, ctx = EncapsulateRequest(request)
+//
+//	                            encReq
+//	                          ---------->
+//
+//	                            request, gwCtx = DecapsulateRequest(encReq)
+//	                            encRes = gwCtx.EncapsulateResponse(response)
+//
+//	                            encRes
+//	                          <----------
+//
+//	response = ctx.DecapsulateResponse(encRes)
+//
+// # References
+//
+// [1] RFC 9458: https://www.rfc-editor.org/rfc/rfc9458
+//
+// [2] Chunked OHTTP: https://datatracker.ietf.org/doc/draft-ietf-ohai-chunked-ohttp/
+package ohttp
+
+import (
+	"crypto/cipher"
+	"errors"
+	"io"
+
+	"github.com/cloudflare/circl/hpke"
+	"github.com/cloudflare/circl/kem"
+	"golang.org/x/crypto/cryptobyte"
+)
+
+// Media types of the messages defined by Oblivious HTTP.
+const (
+	KeysMediaType            = "application/ohttp-keys"
+	RequestMediaType         = "message/ohttp-req"
+	ResponseMediaType        = "message/ohttp-res"
+	ChunkedRequestMediaType  = "message/ohttp-chunked-req"
+	ChunkedResponseMediaType = "message/ohttp-chunked-res"
+)
+
+const (
+	requestLabel         = "message/bhttp request"
+	responseLabel        = "message/bhttp response"
+	chunkedRequestLabel  = "message/bhttp chunked request"
+	chunkedResponseLabel = "message/bhttp chunked response"
+
+	// headerSize is the size of the request header that precedes the
+	// encapsulated key: key identifier, KEM, KDF and AEAD identifiers.
+	headerSize = 7
+	// nonceSize is the nonce size Nn of all AEADs defined by RFC 9180.
+	nonceSize = 12
+)
+
+var (
+	ErrInvalidKeyConfig     = errors.New("ohttp: invalid key configuration")
+	ErrUnsupportedKEM       = errors.New("ohttp: unsupported KEM")
+	ErrUnsupportedSuite     = errors.New("ohttp: unsupported HPKE suite")
+	ErrUnknownKeyID         = errors.New("ohttp: unknown key identifier")
+	ErrInvalidEncapsulation = errors.New("ohttp: invalid encapsulated message")
+)
+
+// GenerateKey generates a key configuration with a fresh key pair of the
+// given KEM, reading randomness from rnd. The algorithms are listed in order
+// of preference.
+func GenerateKey(
+	rnd io.Reader, keyID uint8, kemID hpke.KEM, algs ...SymmetricAlgorithm,
+) (*GatewayKey, error) {
+	if !kemID.IsValid() {
+		return nil, ErrUnsupportedKEM
+	}
+	config := KeyConfig{keyID, kemID, nil, algs}
+	if _, err := config.Suite(); err != nil {
+		return nil, err
+	}
+
+	scheme := kemID.Scheme()
+	seed := make([]byte, scheme.SeedSize())
+	if _, err := io.ReadFull(rnd, seed); err != nil {
+		return nil, err
+	}
+	pk, sk := scheme.DeriveKeyPair(seed)
+	config.PublicKey = pk
+	return &GatewayKey{config, sk}, nil
+}
+
+// GatewayKey is a key configuration together with its private key.
+type GatewayKey struct {
+	Config     KeyConfig
+	PrivateKey kem.PrivateKey
+}
+
+// Client encapsulates requests to an Oblivious Gateway Resource.
+type Client struct {
+	config KeyConfig
+	suite  hpke.Suite
+}
+
+// NewClient returns a client for the gateway described by the key
+// configuration, which uses the first supported symmetric algorithm of the
+// configuration.
+func NewClient(config KeyConfig) (*Client, error) {
+	suite, err := config.Suite()
+	if err != nil {
+		return nil, err
+	}
+	return &Client{config, suite}, nil
+}
+
+// ClientContext holds the state required to decapsulate the response to an
+// encapsulated request.
+type ClientContext struct {
+	suite  hpke.Suite
+	enc    []byte
+	sealer hpke.Sealer
+}
+
+// EncapsulateRequest encrypts a request to the gateway, reading randomness
+// from rnd. Returns the encapsulated request and the context used to
+// decapsulate the response.
+func (c *Client) EncapsulateRequest(rnd io.Reader, request []byte) (
+	encRequest []byte, ctx *ClientContext, err error,
+) {
+	hdr, enc, sealer, err := c.setup(rnd, requestLabel)
+	if err != nil {
+		return nil, nil, err
+	}
+	ct, err := sealer.Seal(request, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	encRequest = append(append(hdr, enc...), ct...)
+	return encRequest, &ClientContext{c.suite, enc, sealer}, nil
+}
+
+// setup builds the request header and an HPKE context whose info is the
+// label followed by the header, see RFC 9458, Section 4.3.
+func (c *Client) setup(rnd io.Reader, label string) (
+	hdr, enc []byte, sealer hpke.Sealer, err error,
+) {
+	hdr = requestHeader(c.config.KeyID, c.suite)
+	sender, err := c.suite.NewSender(c.config.PublicKey, requestInfo(label, hdr))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	enc, sealer, err = sender.Setup(rnd)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return hdr, enc, sealer, nil
+}
+
+// DecapsulateResponse decrypts the encapsulated response to the request of
+// this context.
+func (c *ClientContext) DecapsulateResponse(encResponse []byte) ([]byte, error) {
+	n := responseNonceSize(c.suite)
+	if len(encResponse) < n {
+		return nil, ErrInvalidEncapsulation
+	}
+	responseNonce, ct := encResponse[:n], encResponse[n:]
+	aead, nonce, err := responseKeys(c.suite, c.sealer, responseLabel,
+		c.enc, responseNonce)
+	if err != nil {
+		return nil, err
+	}
+	return aead.Open(nil, nonce, ct, nil)
+}
+
+// Gateway decapsulates requests sent to an Oblivious Gateway Resource.
+type Gateway struct {
+	keys map[uint8]GatewayKey
+}
+
+// NewGateway returns a gateway that accepts requests encapsulated to any of
+// the given keys, which must have distinct key identifiers.
+func NewGateway(keys ...GatewayKey) (*Gateway, error) {
+	g := &Gateway{keys: make(map[uint8]GatewayKey, len(keys))}
+	for _, k := range keys {
+		if _, ok := g.keys[k.Config.KeyID]; ok {
+			return nil, ErrInvalidKeyConfig
+		}
+		if !k.Config.KEM.IsValid() || k.PrivateKey == nil ||
+			!k.PrivateKey.Public().Equal(k.Config.PublicKey) {
+			return nil, ErrInvalidKeyConfig
+		}
+		g.keys[k.Config.KeyID] = k
+	}
+	return g, nil
+}
+
+// KeyConfigs returns the key configurations of the gateway in the
+// application/ohttp-keys format, ordered by key identifier.
+func (g *Gateway) KeyConfigs() ([]byte, error) {
+	configs := make([]KeyConfig, 0, len(g.keys))
+	for id := 0; id < 256; id++ {
+		if k, ok := g.keys[uint8(id)]; ok {
+			configs = append(configs, k.Config)
+		}
+	}
+	return MarshalKeyConfigs(configs...)
+}
+
+// GatewayContext holds the state required to encapsulate the response to a
+// decapsulated request.
+type GatewayContext struct {
+	suite  hpke.Suite
+	enc    []byte
+	opener hpke.Opener
+}
+
+// DecapsulateRequest decrypts an encapsulated request. Returns the request
+// and the context used to encapsulate the response.
+func (g *Gateway) DecapsulateRequest(encRequest []byte) (
+	request []byte, ctx *GatewayContext, err error,
+) {
+	s := cryptobyte.String(encRequest)
+	suite, enc, opener, err := g.setup(&s, requestLabel)
+	if err != nil {
+		return nil, nil, err
+	}
+	request, err = opener.Open(s, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	return request, &GatewayContext{suite, enc, opener}, nil
+}
+
+// setup reads the request header and the encapsulated key from s, and sets
+// up the HPKE context of the request.
+func (g *Gateway) setup(s *cryptobyte.String, label string) (
+	suite hpke.Suite, enc []byte, opener hpke.Opener, err error,
+) {
+	var (
+		keyID                uint8
+		kemID, kdfID, aeadID uint16
+		hdr                  []byte
+	)
+	if !s.ReadBytes(&hdr, headerSize) {
+		return suite, nil, nil, ErrInvalidEncapsulation
+	}
+	h := cryptobyte.String(hdr)
+	h.ReadUint8(&keyID)
+	h.ReadUint16(&kemID)
+	h.ReadUint16(&kdfID)
+	h.ReadUint16(&aeadID)
+
+	key, ok := g.keys[keyID]
+	if !ok {
+		return suite, nil, nil, ErrUnknownKeyID
+	}
+	if hpke.KEM(kemID) != key.Config.KEM {
+		return suite, nil, nil, ErrUnsupportedSuite
+	}
+	suite, err = key.Config.suite(SymmetricAlgorithm{hpke.KDF(kdfID), hpke.AEAD(aeadID)})
+	if err != nil {
+		return suite, nil, nil, err
+	}
+	if !s.ReadBytes(&enc, key.Config.KEM.Scheme().CiphertextSize()) {
+		return suite, nil, nil, ErrInvalidEncapsulation
+	}
+
+	receiver, err := suite.NewReceiver(key.PrivateKey, requestInfo(label, hdr))
+	if err != nil {
+		return suite, nil, nil, err
+	}
+	opener, err = receiver.Setup(enc)
+	if err != nil {
+		return suite, nil, nil, err
+	}
+	return suite, enc, opener, nil
+}
+
+// EncapsulateResponse encrypts the response to the request of this context,
+// reading randomness from rnd.
+func (c *GatewayContext) EncapsulateResponse(rnd io.Reader, response []byte) (
+	[]byte, error,
+) {
+	responseNonce := make([]byte, responseNonceSize(c.suite))
+	if _, err := io.ReadFull(rnd, responseNonce); err != nil {
+		return nil, err
+	}
+	aead, nonce, err := responseKeys(c.suite, c.opener, responseLabel,
+		c.enc, responseNonce)
+	if err != nil {
+		return nil, err
+	}
+	return aead.Seal(responseNonce, nonce, response, nil), nil
+}
+
+func requestHeader(keyID uint8, suite hpke.Suite) []byte {
+	kemID, kdfID, aeadID := suite.Params()
+	var b cryptobyte.Builder
+	b.AddUint8(keyID)
+	b.AddUint16(uint16(kemID))
+	b.AddUint16(uint16(kdfID))
+	b.AddUint16(uint16(aeadID))
+	return b.BytesOrPanic()
+}
+
+func requestInfo(label string, hdr []byte) []byte {
+	info := append([]byte(label), 0)
+	return append(info, hdr...)
+}
+
+// responseNonceSize returns max(Nn, Nk).
+func responseNonceSize(suite hpke.Suite) int {
+	_, _, aeadID := suite.Params()
+	if n := int(aeadID.KeySize()); n > nonceSize {
+		return n
+	}
+	return nonceSize
+}
+
+// responseKeys derives the AEAD key and nonce that protect a response, see
+// RFC 9458, Section 4.4.
+func responseKeys(
+	suite hpke.Suite, ctx hpke.Context, label string, enc, responseNonce []byte,
+) (cipher.AEAD, []byte, error) {
+	_, kdfID, aeadID := suite.Params()
+	secret := ctx.Export([]byte(label), uint(responseNonceSize(suite)))
+	salt := append(append([]byte{}, enc...), responseNonce...)
+	prk := kdfID.Extract(secret, salt)
+	key := kdfID.Expand(prk, []byte("key"), aeadID.KeySize())
+	nonce := kdfID.Expand(prk, []byte("nonce"), nonceSize)
+	aead, err := aeadID.New(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return aead, nonce, nil
+}
@@ -0,0 +1,341 @@
+package ohttp
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cloudflare/circl/hpke"
+	"github.com/cloudflare/circl/internal/test"
+)
+
+func hexB(t *testing.T, x string) []byte {
+	t.Helper()
+	z, err := hex.DecodeString(x)
+	test.CheckNoErr(t, err, "bad hex")
+	return z
+}
+
+// Test vectors from RFC 9458, Appendix A.
+func TestVectors(t *testing.T) {
+	var (
+		keyConfig = hexB(t, "01002031e1f05a740102115220e9af918f738674aec95f54"+
+			"db6e04eb705aae8e79815500080001000100010003")
+		skR = hexB(t, "3c168975674b2fa8e465970b79c8dcf09f1c741626480bd4c616"+
+			"2fc5b6a98e1a")
+		request    = hexB(t, "00034745540568747470730b6578616d706c652e636f6d012f")
+		encRequest = hexB(t, "010020000100014b28f881333e7c164ffc499ad9796f877f"+
+			"4e1051ee6d31bad19dec96c208b4726374e469135906992e1268c594d2a10c"+
+			"695d858c40a026e7965e7d86b83dd440b2c0185204b4d63525")
+		response      = hexB(t, "0140c8")
+		responseNonce = hexB(t, "c789e7151fcba46158ca84b04464910d")
+		encResponse   = hexB(t, "c789e7151fcba46158ca84b04464910d86f9013e404fee"+
+			"a014e7be4a441f234f857fbd")
+	)
+
+	var config KeyConfig
+	test.CheckNoErr(t, config.UnmarshalBinary(keyConfig), "unmarshal config")
+	want := []SymmetricAlgorithm{
+		{hpke.KDF_HKDF_SHA256, hpke.AEAD_AES128GCM},
+		{hpke.KDF_HKDF_SHA256, hpke.AEAD_ChaCha20Poly1305},
+	}
+	if config.KeyID != 1 || config.KEM != hpke.KEM_X25519_HKDF_SHA256 ||
+		len(config.Algorithms) != len(want) ||
+		config.Algorithms[0] != want[0] || config.Algorithms[1] != want[1] {
+		test.ReportError(t, config, want)
+	}
+	got, err := config.MarshalBinary()
+	test.CheckNoErr(t, err, "marshal config")
+	if !bytes.Equal(got, keyConfig) {
+		test.ReportError(t, got, keyConfig)
+	}
+
+	sk, err := config.KEM.Scheme().UnmarshalBinaryPrivateKey(skR)
+	test.CheckNoErr(t, err, "unmarshal private key")
+	gateway, err := NewGateway(GatewayKey{config, sk})
+	test.CheckNoErr(t, err, "gateway")
+
+	got, ctx, err := gateway.DecapsulateRequest(encRequest)
+	test.CheckNoErr(t, err, "decapsulate request")
+	if !bytes.Equal(got, request) {
+		test.ReportError(t, got, request)
+	}
+	got, err = ctx.EncapsulateResponse(bytes.NewReader(responseNonce), response)
+	test.CheckNoErr(t, err, "encapsulate response")
+	if !bytes.Equal(got, encResponse) {
+		test.ReportError(t, got, encResponse)
+	}
+}
+
+func TestKeyConfigs(t *testing.T) {
+	algs := []SymmetricAlgorithm{
+		{hpke.KDF(0x7777), hpke.AEAD_AES128GCM},
+		{hpke.KDF_HKDF_SHA384, hpke.AEAD_AES256GCM},
+	}
+	k1, err := GenerateKey(rand.Reader, 1, hpke.KEM_P256_HKDF_SHA256, algs...)
+	test.CheckNoErr(t, err, "keygen")
+	k2, err := GenerateKey(rand.Reader, 2, hpke.KEM_X448_HKDF_SHA512, algs[1])
+	test.CheckNoErr(t, err, "keygen")
+	_, err = GenerateKey(rand.Reader, 3, hpke.KEM_X448_HKDF_SHA512, algs[0])
+	test.CheckIsErr(t, err, "unsupported algorithms must fail")
+
+	gateway, err := NewGateway(*k2, *k1)
+	test.CheckNoErr(t, err, "gateway")
+	_, err = NewGateway(*k1, *k1)
+	test.CheckIsErr(t, err, "duplicate key identifiers must fail")
+
+	raw, err := gateway.KeyConfigs()
+	test.CheckNoErr(t, err, "marshal configs")
+	// Appends a configuration with an unknown KEM, which must be skipped.
+	unknown := []byte{0, 12, 3, 0x77, 0x77, 1, 2, 3, 0, 4, 0, 1, 0, 1}
+	configs, err := UnmarshalKeyConfigs(append(raw, unknown...))
+	test.CheckNoErr(t, err, "unmarshal configs")
+	if len(configs) != 2 {
+		test.ReportError(t, len(configs), 2)
+	}
+	for i, k := range []*GatewayKey{k1, k2} {
+		c := configs[i]
+		if c.KeyID != k.Config.KeyID || c.KEM != k.Config.KEM ||
+			!c.PublicKey.Equal(k.Config.PublicKey) ||
+			len(c.Algorithms) != len(k.Config.Algorithms) {
+			test.ReportError(t, c, k.Config)
+		}
+		suite, err := c.Suite()
+		test.CheckNoErr(t, err, "suite")
+		want := hpke.NewSuite(c.KEM, hpke.KDF_HKDF_SHA384, hpke.AEAD_AES256GCM)
+		if suite != want {
+			test.ReportError(t, suite, want)
+		}
+	}
+
+	_, err = UnmarshalKeyConfigs(raw[:len(raw)-1])
+	test.CheckIsErr(t, err, "truncated configs must fail")
+	var c KeyConfig
+	test.CheckIsErr(t, c.UnmarshalBinary(append(raw[2:], 0)), "trailing data must fail")
+}
+
+func TestEncapsulation(t *testing.T) {
+	for _, kemID := range []hpke.KEM{
+		hpke.KEM_X25519_HKDF_SHA256,
+		hpke.KEM_P384_HKDF_SHA384,
+		hpke.KEM_MLKEM768,
+	} {
+		for _, alg := range []SymmetricAlgorithm{
+			{hpke.KDF_HKDF_SHA256, hpke.AEAD_AES128GCM},
+			{hpke.KDF_HKDF_SHA512, hpke.AEAD_AES256GCM},
+			{hpke.KDF_HKDF_SHA384, hpke.AEAD_ChaCha20Poly1305},
+		} {
+			key, err := GenerateKey(rand.Reader, 7, kemID, alg)
+			test.CheckNoErr(t, err, "keygen")
+			gateway, err := NewGateway(*key)
+			test.CheckNoErr(t, err, "gateway")
+			client, err := NewClient(key.Config)
+			test.CheckNoErr(t, err, "client")
+
+			request, response := []byte("request"), []byte("response")
+			encRequest, clientCtx, err := client.EncapsulateRequest(rand.Reader, request)
+			test.CheckNoErr(t, err, "encapsulate request")
+			got, gatewayCtx, err := gateway.DecapsulateRequest(encRequest)
+			test.CheckNoErr(t, err, "decapsulate request")
+			if !bytes.Equal(got, request) {
+				test.ReportError(t, got, request, kemID, alg)
+			}
+
+			encResponse, err := gatewayCtx.EncapsulateResponse(rand.Reader, response)
+			test.CheckNoErr(t, err, "encapsulate response")
+			got, err = clientCtx.DecapsulateResponse(encResponse)
+			test.CheckNoErr(t, err, "decapsulate response")
+			if !bytes.Equal(got, response) {
+				test.ReportError(t, got, response, kemID, alg)
+			}
+
+			for _, i := range []int{0, 1, 5, headerSize, len(encRequest) - 1} {
+				modified := append([]byte{}, encRequest...)
+				modified[i] ^= 1
+				_, _, err = gateway.DecapsulateRequest(modified)
+				test.CheckIsErr(t, err, "modified request must fail")
+			}
+			_, _, err = gateway.DecapsulateRequest(encRequest[:headerSize+1])
+			test.CheckIsErr(t, err, "truncated request must fail")
+
+			encResponse[len(encResponse)-1] ^= 1
+			_, err = clientCtx.DecapsulateResponse(encResponse)
+			test.CheckIsErr(t, err, "modified response must fail")
+		}
+	}
+}
+
+func TestChunked(t *testing.T) {
+	key, err := GenerateKey(rand.Reader, 1, hpke.KEM_X25519_HKDF_SHA256,
+		SymmetricAlgorithm{hpke.KDF_HKDF_SHA256, hpke.AEAD_AES128GCM})
+	test.CheckNoErr(t, err, "keygen")
+	gateway, err := NewGateway(*key)
+	test.CheckNoErr(t, err, "gateway")
+	client, err := NewClient(key.Config)
+	test.CheckNoErr(t, err, "client")
+
+	chunks := [][]byte{[]byte("GET "), bytes.Repeat([]byte{'a'}, 100), {}, []byte("!")}
+	want := bytes.Join(chunks, nil)
+
+	var encRequest bytes.Buffer
+	w, err := client.EncapsulateChunkedRequest(rand.Reader, &encRequest)
+	test.CheckNoErr(t, err, "encapsulate request")
+	for _, c := range chunks {
+		_, err = w.Write(c)
+		test.CheckNoErr(t, err, "write")
+	}
+	test.CheckNoErr(t, w.Close(), "close")
+	_, err = w.Write([]byte("late"))
+	test.CheckIsErr(t, err, "write after close must fail")
+
+	raw := encRequest.Bytes()
+	r, err := gateway.DecapsulateChunkedRequest(bytes.NewReader(raw))
+	test.CheckNoErr(t, err, "decapsulate request")
+	got, err := io.ReadAll(r)
+	test.CheckNoErr(t, err, "read request")
+	if !bytes.Equal(got, want) {
+		test.ReportError(t, got, want)
+	}
+
+	var encResponse bytes.Buffer
+	rw, err := r.EncapsulateResponse(rand.Reader, &encResponse)
+	test.CheckNoErr(t, err, "encapsulate response")
+	for _, c := range chunks {
+		_, err = rw.Write(c)
+		test.CheckNoErr(t, err, "write")
+	}
+	test.CheckNoErr(t, rw.Close(), "close")
+
+	rr, err := w.DecapsulateResponse(bytes.NewReader(encResponse.Bytes()))
+	test.CheckNoErr(t, err, "decapsulate response")
+	got, err = io.ReadAll(rr)
+	test.CheckNoErr(t, err, "read response")
+	if !bytes.Equal(got, want) {
+		test.ReportError(t, got, want)
+	}
+
+	// The final chunk of the request is an empty AEAD-protected chunk
+	// preceded by a zero length.
+	final := 1 + 16
+	for _, bad := range [][]byte{
+		raw[:len(raw)-final],   // final chunk removed
+		raw[:len(raw)-final+1], // final chunk indicator without chunk
+		raw[:len(raw)-1],       // final chunk truncated
+		append(append([]byte{}, raw[:len(raw)-final]...), 0), // empty final chunk
+	} {
+		r, err := gateway.DecapsulateChunkedRequest(bytes.NewReader(bad))
+		test.CheckNoErr(t, err, "decapsulate request")
+		_, err = io.ReadAll(r)
+		test.CheckIsErr(t, err, "truncated request must fail")
+	}
+
+	// A non-final chunk relabeled as final must fail.
+	hdr := headerSize + 32
+	relabeled := append(append([]byte{}, raw[:hdr]...), 0)
+	relabeled = append(relabeled, raw[hdr+1:hdr+1+4+16]...)
+	r, err = gateway.DecapsulateChunkedRequest(bytes.NewReader(relabeled))
+	test.CheckNoErr(t, err, "decapsulate request")
+	_, err = io.ReadAll(r)
+	test.CheckIsErr(t, err, "relabeled chunk must fail")
+}
+
+func TestVarint(t *testing.T) {
+	for _, v := range []uint64{0, 37, 63, 64, 15293, 16383, 16384, 494878333, 1 << 30, 151288809941952652} {
+		b := appendVarint(nil, v)
+		got, err := readVarint(bytes.NewReader(b))
+		test.CheckNoErr(t, err, "read varint")
+		if got != v {
+			test.ReportError(t, got, v)
+		}
+	}
+	// Examples from RFC 9000, Appendix A.1.
+	want := hexB(t, "c2197c5eff14e88c")
+	if got := appendVarint(nil, 151288809941952652); !bytes.Equal(got, want) {
+		test.ReportError(t, got, want)
+	}
+	want = hexB(t, "7bbd")
+	if got := appendVarint(nil, 15293); !bytes.Equal(got, want) {
+		test.ReportError(t, got, want)
+	}
+}
+
+// TestHTTP runs a client and a gateway over a local HTTP server that stands
+// in for the relay and the gateway.
+func TestHTTP(t *testing.T) {
+	key, err := GenerateKey(rand.Reader, 1, hpke.KEM_X25519_HKDF_SHA256,
+		SymmetricAlgorithm{hpke.KDF_HKDF_SHA256, hpke.AEAD_ChaCha20Poly1305})
+	test.CheckNoErr(t, err, "keygen")
+	gateway, err := NewGateway(*key)
+	test.CheckNoErr(t, err, "gateway")
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ohttp-keys", func(w http.ResponseWriter, r *http.Request) {
+		configs, err := gateway.KeyConfigs()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", KeysMediaType)
+		_, _ = w.Write(configs)
+	})
+	mux.HandleFunc("/gateway", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != RequestMediaType {
+			http.Error(w, "bad media type", http.StatusUnsupportedMediaType)
+			return
+		}
+		encRequest, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		request, ctx, err := gateway.DecapsulateRequest(encRequest)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		encResponse, err := ctx.EncapsulateResponse(rand.Reader,
+			append([]byte("echo: "), request...))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", ResponseMediaType)
+		_, _ = w.Write(encResponse)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/ohttp-keys")
+	test.CheckNoErr(t, err, "get keys")
+	raw, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	test.CheckNoErr(t, err, "read keys")
+	configs, err := UnmarshalKeyConfigs(raw)
+	test.CheckNoErr(t, err, "unmarshal configs")
+	client, err := NewClient(configs[0])
+	test.CheckNoErr(t, err, "client")
+
+	encRequest, ctx, err := client.EncapsulateRequest(rand.Reader, []byte("hello"))
+	test.CheckNoErr(t, err, "encapsulate request")
+	resp, err = http.Post(server.URL+"/gateway", RequestMediaType,
+		bytes.NewReader(encRequest))
+	test.CheckNoErr(t, err, "post request")
+	encResponse, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	test.CheckNoErr(t, err, "read response")
+	if resp.StatusCode != http.StatusOK ||
+		resp.Header.Get("Content-Type") != ResponseMediaType {
+		t.Fatalf("unexpected response: %v", resp.Status)
+	}
+
+	got, err := ctx.DecapsulateResponse(encResponse)
+	test.CheckNoErr(t, err, "decapsulate response")
+	want := []byte("echo: hello")
+	if !bytes.Equal(got, want) {
+		test.ReportError(t, got, want)
+	}
+}
@@ -0,0 +1,227 @@
+package opaque
+
+import (
+	"crypto/subtle"
+	"io"
+
+	"github.com/cloudflare/circl/group"
+	"github.com/cloudflare/circl/oprf"
+)
+
+// Client runs registration and login on behalf of a user.
+type Client struct {
+	conf *Config
+	oprf oprf.Client
+}
+
+// NewClient returns a client for the given configuration.
+func NewClient(conf *Config) (*Client, error) {
+	if err := conf.validate(); err != nil {
+		return nil, err
+	}
+	return &Client{conf, oprf.NewClient(conf.Suite)}, nil
+}
+
+// blind blinds the password with the given blind, and returns the OPRF
+// state and the serialized blinded element.
+func (c *Client) blind(blind group.Scalar, password []byte) (*oprf.FinalizeData, []byte, error) {
+	finData, req, err := c.oprf.DeterministicBlind([][]byte{password}, []oprf.Blind{blind})
+	if err != nil {
+		return nil, nil, err
+	}
+	blinded, err := req.Elements[0].MarshalBinaryCompress()
+	if err != nil {
+		return nil, nil, err
+	}
+	return finData, blinded, nil
+}
+
+// randomizedPassword finalizes the OPRF and returns the randomized password.
+func (c *Client) randomizedPassword(finData *oprf.FinalizeData, evaluated []byte) ([]byte, error) {
+	e, err := c.conf.deserializeElement(evaluated)
+	if err != nil {
+		return nil, err
+	}
+	outputs, err := c.oprf.Finalize(finData, &oprf.Evaluation{Elements: []oprf.Evaluated{e}})
+	if err != nil {
+		return nil, err
+	}
+	return c.conf.randomizedPassword(outputs[0]), nil
+}
+
+// ClientRegistration holds the state of a client between the registration
+// request and the reception of the response.
+type ClientRegistration struct {
+	c       *Client
+	finData *oprf.FinalizeData
+}
+
+// RegistrationInit starts the registration of a password, reading
+// randomness from rnd.
+func (c *Client) RegistrationInit(rnd io.Reader, password []byte) (
+	*RegistrationRequest, *ClientRegistration, error,
+) {
+	return c.registrationInit(c.conf.Suite.Group().RandomNonZeroScalar(rnd), password)
+}
+
+func (c *Client) registrationInit(blind group.Scalar, password []byte) (
+	*RegistrationRequest, *ClientRegistration, error,
+) {
+	finData, blinded, err := c.blind(blind, password)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &RegistrationRequest{blinded}, &ClientRegistration{c, finData}, nil
+}
+
+// Finalize processes the response of the server, reading randomness from
+// rnd. It returns the record to be sent to the server and the export key,
+// which applications can use to protect additional data.
+func (r *ClientRegistration) Finalize(rnd io.Reader, resp *RegistrationResponse, ids Identities) (
+	record *RegistrationRecord, exportKey []byte, err error,
+) {
+	conf := r.c.conf
+	if _, err = conf.deserializeElement(resp.ServerPublicKey); err != nil {
+		return nil, nil, err
+	}
+	randomizedPassword, err := r.c.randomizedPassword(r.finData, resp.EvaluatedMessage)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	nonce, err := conf.random(rnd, nonceSize)
+	if err != nil {
+		return nil, nil, err
+	}
+	maskingKey := conf.expand(randomizedPassword, []byte(labelMaskingKey), conf.hashSize())
+	authKey, exportKey, _, clientPublicKey, err := conf.envelopeKeys(randomizedPassword, nonce)
+	if err != nil {
+		return nil, nil, err
+	}
+	creds, _, _, err := cleartextCredentials(resp.ServerPublicKey, clientPublicKey, ids)
+	if err != nil {
+		return nil, nil, err
+	}
+	authTag := conf.mac(authKey, nonce, creds)
+
+	return &RegistrationRecord{
+		ClientPublicKey: clientPublicKey,
+		MaskingKey:      maskingKey,
+		Envelope:        Envelope{nonce, authTag},
+	}, exportKey, nil
+}
+
+// ClientSession holds the state of a client between KE1 and KE2.
+type ClientSession struct {
+	c        *Client
+	finData  *oprf.FinalizeData
+	secret   group.Scalar
+	ke1      []byte
+	finished bool
+}
+
+// LoginInit starts a login with the password, reading randomness from rnd.
+func (c *Client) LoginInit(rnd io.Reader, password []byte) (*KE1, *ClientSession, error) {
+	return c.loginInit(rnd, c.conf.Suite.Group().RandomNonZeroScalar(rnd), password)
+}
+
+// loginInit starts a login with the given blind, reading the nonce and the
+// seed of the key share from rnd.
+func (c *Client) loginInit(rnd io.Reader, blind group.Scalar, password []byte) (*KE1, *ClientSession, error) {
+	finData, blinded, err := c.blind(blind, password)
+	if err != nil {
+		return nil, nil, err
+	}
+	nonce, err := c.conf.random(rnd, nonceSize)
+	if err != nil {
+		return nil, nil, err
+	}
+	seed, err := c.conf.random(rnd, seedSize)
+	if err != nil {
+		return nil, nil, err
+	}
+	secret, keyshare, err := c.conf.deriveDHKeyPair(seed)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ke1 := &KE1{blinded, nonce, keyshare}
+	raw, err := ke1.MarshalBinary()
+	if err != nil {
+		return nil, nil, err
+	}
+	return ke1, &ClientSession{c: c, finData: finData, secret: secret, ke1: raw}, nil
+}
+
+// Finish processes the answer of the server. It returns the message KE3 to
+// be sent to the server, the session key and the export key. It returns
+// ErrEnvelopeRecovery if the password is wrong or the client is not
+// registered, and ErrServerAuthentication if the server cannot be
+// authenticated.
+func (s *ClientSession) Finish(ke2 *KE2, ids Identities) (
+	ke3 *KE3, sessionKey, exportKey []byte, err error,
+) {
+	if s.finished {
+		return nil, nil, nil, ErrSessionFinished
+	}
+	conf := s.c.conf
+	if len(ke2.MaskingNonce) != nonceSize ||
+		len(ke2.MaskedResponse) != conf.elementSize()+conf.envelopeSize() {
+		return nil, nil, nil, ErrInvalidMessage
+	}
+
+	// Recover the credentials, see draft-irtf-cfrg-opaque, Section 5.3.
+	randomizedPassword, err := s.c.randomizedPassword(s.finData, ke2.EvaluatedMessage)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	maskingKey := conf.expand(randomizedPassword, []byte(labelMaskingKey), conf.hashSize())
+	pad := conf.expand(maskingKey,
+		append(append([]byte{}, ke2.MaskingNonce...), labelCredentialPad...),
+		len(ke2.MaskedResponse))
+	unmasked := make([]byte, len(pad))
+	xorBytes(unmasked, pad, ke2.MaskedResponse)
+	serverPublicKey := unmasked[:conf.elementSize()]
+	nonce := unmasked[conf.elementSize() : conf.elementSize()+nonceSize]
+	authTag := unmasked[conf.elementSize()+nonceSize:]
+
+	authKey, exportKey, clientPrivateKey, clientPublicKey, err := conf.envelopeKeys(randomizedPassword, nonce)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	creds, clientID, serverID, err := cleartextCredentials(serverPublicKey, clientPublicKey, ids)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if subtle.ConstantTimeCompare(authTag, conf.mac(authKey, nonce, creds)) != 1 {
+		return nil, nil, nil, ErrEnvelopeRecovery
+	}
+
+	// Authenticate the server, see draft-irtf-cfrg-opaque, Section 6.4.
+	var ikm []byte
+	for _, dh := range []struct {
+		k   group.Scalar
+		pub []byte
+	}{
+		{s.secret, ke2.ServerPublicKeyshare},
+		{s.secret, serverPublicKey},
+		{clientPrivateKey, ke2.ServerPublicKeyshare},
+	} {
+		shared, err := conf.diffieHellman(dh.k, dh.pub)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		ikm = append(ikm, shared...)
+	}
+	preamble := conf.preamble(clientID, s.ke1, serverID, ke2.credentialResponse(),
+		ke2.ServerNonce, ke2.ServerPublicKeyshare)
+	km2, km3, sessionKey := conf.deriveKeys(ikm, preamble)
+	serverMAC := conf.mac(km2, conf.hash(preamble))
+	if subtle.ConstantTimeCompare(ke2.ServerMAC, serverMAC) != 1 {
+		return nil, nil, nil, ErrServerAuthentication
+	}
+	clientMAC := conf.mac(km3, conf.hash(preamble, serverMAC))
+
+	s.finished = true
+	return &KE3{clientMAC}, sessionKey, exportKey, nil
+}
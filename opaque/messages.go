@@ -0,0 +1,181 @@
+package opaque
+
+import "bytes"
+
+// RegistrationRequest is the first message of registration, sent by the
+// client.
+type RegistrationRequest struct {
+	BlindedMessage []byte
+}
+
+// RegistrationResponse is the answer of the server to a RegistrationRequest.
+type RegistrationResponse struct {
+	EvaluatedMessage []byte
+	ServerPublicKey  []byte
+}
+
+// Envelope holds the data a client needs to recover its private key.
+type Envelope struct {
+	Nonce   []byte
+	AuthTag []byte
+}
+
+// RegistrationRecord is the last message of registration, sent by the client
+// and stored by the server.
+type RegistrationRecord struct {
+	ClientPublicKey []byte
+	MaskingKey      []byte
+	Envelope        Envelope
+}
+
+// KE1 is the first message of login, sent by the client. It contains a
+// CredentialRequest and an AuthRequest.
+type KE1 struct {
+	BlindedMessage       []byte
+	ClientNonce          []byte
+	ClientPublicKeyshare []byte
+}
+
+// KE2 is the answer of the server to a KE1. It contains a CredentialResponse
+// and an AuthResponse.
+type KE2 struct {
+	EvaluatedMessage     []byte
+	MaskingNonce         []byte
+	MaskedResponse       []byte
+	ServerNonce          []byte
+	ServerPublicKeyshare []byte
+	ServerMAC            []byte
+}
+
+// KE3 is the last message of login, sent by the client.
+type KE3 struct {
+	ClientMAC []byte
+}
+
+// MarshalBinary encodes the request.
+func (m *RegistrationRequest) MarshalBinary() ([]byte, error) {
+	return concat(m.BlindedMessage), nil
+}
+
+// UnmarshalBinary decodes a request for the given configuration.
+func (m *RegistrationRequest) UnmarshalBinary(c *Config, data []byte) error {
+	if err := c.validate(); err != nil {
+		return err
+	}
+	return split(data, c.elementSize())(&m.BlindedMessage)
+}
+
+// MarshalBinary encodes the response.
+func (m *RegistrationResponse) MarshalBinary() ([]byte, error) {
+	return concat(m.EvaluatedMessage, m.ServerPublicKey), nil
+}
+
+// UnmarshalBinary decodes a response for the given configuration.
+func (m *RegistrationResponse) UnmarshalBinary(c *Config, data []byte) error {
+	if err := c.validate(); err != nil {
+		return err
+	}
+	return split(data, c.elementSize(), c.elementSize())(
+		&m.EvaluatedMessage, &m.ServerPublicKey)
+}
+
+// MarshalBinary encodes the envelope.
+func (e *Envelope) MarshalBinary() ([]byte, error) {
+	return concat(e.Nonce, e.AuthTag), nil
+}
+
+// UnmarshalBinary decodes an envelope for the given configuration.
+func (e *Envelope) UnmarshalBinary(c *Config, data []byte) error {
+	if err := c.validate(); err != nil {
+		return err
+	}
+	return split(data, nonceSize, c.hashSize())(&e.Nonce, &e.AuthTag)
+}
+
+// MarshalBinary encodes the record.
+func (m *RegistrationRecord) MarshalBinary() ([]byte, error) {
+	return concat(m.ClientPublicKey, m.MaskingKey, m.Envelope.Nonce,
+		m.Envelope.AuthTag), nil
+}
+
+// UnmarshalBinary decodes a record for the given configuration.
+func (m *RegistrationRecord) UnmarshalBinary(c *Config, data []byte) error {
+	if err := c.validate(); err != nil {
+		return err
+	}
+	return split(data, c.elementSize(), c.hashSize(), nonceSize, c.hashSize())(
+		&m.ClientPublicKey, &m.MaskingKey, &m.Envelope.Nonce, &m.Envelope.AuthTag)
+}
+
+// MarshalBinary encodes the message.
+func (m *KE1) MarshalBinary() ([]byte, error) {
+	return concat(m.BlindedMessage, m.ClientNonce, m.ClientPublicKeyshare), nil
+}
+
+// UnmarshalBinary decodes a message for the given configuration.
+func (m *KE1) UnmarshalBinary(c *Config, data []byte) error {
+	if err := c.validate(); err != nil {
+		return err
+	}
+	return split(data, c.elementSize(), nonceSize, c.elementSize())(
+		&m.BlindedMessage, &m.ClientNonce, &m.ClientPublicKeyshare)
+}
+
+// MarshalBinary encodes the message.
+func (m *KE2) MarshalBinary() ([]byte, error) {
+	return concat(m.credentialResponse(), m.ServerNonce,
+		m.ServerPublicKeyshare, m.ServerMAC), nil
+}
+
+// credentialResponse returns the serialized CredentialResponse.
+func (m *KE2) credentialResponse() []byte {
+	return concat(m.EvaluatedMessage, m.MaskingNonce, m.MaskedResponse)
+}
+
+// UnmarshalBinary decodes a message for the given configuration.
+func (m *KE2) UnmarshalBinary(c *Config, data []byte) error {
+	if err := c.validate(); err != nil {
+		return err
+	}
+	return split(data, c.elementSize(), nonceSize,
+		c.elementSize()+c.envelopeSize(), nonceSize, c.elementSize(),
+		c.hashSize())(
+		&m.EvaluatedMessage, &m.MaskingNonce, &m.MaskedResponse,
+		&m.ServerNonce, &m.ServerPublicKeyshare, &m.ServerMAC)
+}
+
+// MarshalBinary encodes the message.
+func (m *KE3) MarshalBinary() ([]byte, error) {
+	return concat(m.ClientMAC), nil
+}
+
+// UnmarshalBinary decodes a message for the given configuration.
+func (m *KE3) UnmarshalBinary(c *Config, data []byte) error {
+	if err := c.validate(); err != nil {
+		return err
+	}
+	return split(data, c.hashSize())(&m.ClientMAC)
+}
+
+func concat(fields ...[]byte) []byte {
+	return bytes.Join(fields, nil)
+}
+
+// split returns a function that copies consecutive chunks of data with the
+// given sizes into its arguments, and fails if data has a different size.
+func split(data []byte, sizes ...int) func(fields ...*[]byte) error {
+	return func(fields ...*[]byte) error {
+		total := 0
+		for _, s := range sizes {
+			total += s
+		}
+		if len(data) != total {
+			return ErrInvalidMessage
+		}
+		for i, s := range sizes {
+			*fields[i] = append([]byte{}, data[:s]...)
+			data = data[s:]
+		}
+		return nil
+	}
+}
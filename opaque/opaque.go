@@ -0,0 +1,401 @@
+// Package opaque implements the OPAQUE asymmetric password-authenticated key
+// exchange.
+//
+// OPAQUE allows a client to register a password with a server, and later to
+// authenticate to the server with the password and agree on a session key,
+// without the server ever learning the password. The password is hardened
+// through an Oblivious Pseudorandom Function (OPRF) evaluated with the
+// server, so an attacker that compromises the server must run an offline
+// dictionary attack for each user.
+//
+// This package implements OPAQUE-3DH as specified in draft-irtf-cfrg-opaque [1].
+// The OPRF is provided by the oprf package and the Diffie-Hellman operations
+// of the authenticated key exchange use the group of the OPRF suite.
+//
+// # Protocol Overview
+//
+// Registration is run once, over an authenticated channel:
+//
+//	Client(password)                              Server(key, credentialID)
+//	=================================================================
+//	req, reg = RegistrationInit(password)
+//
+//	                              req
+//	                          ---------->
+//
+//	                              resp = RegistrationResponse(req)
+//
+//	                              resp
+//	                          <----------
+//
+//	record, exportKey = reg.Finalize(resp)
+//
+//	                             record
+//	                          ---------->
+//
+// Login is run each time the client authenticates:
+//
+//	Client(password)                        Server(key, credentialID, record)
+//	=================================================================
+//	ke1, cs = LoginInit(password)
+//
+//	                              ke1
+//	                          ---------->
+//
+//	                              ke2, ss = LoginInit(ke1, record)
+//
+//	                              ke2
+//	                          <----------
+//
+//	ke3, sessionKey, exportKey = cs.Finish(ke2)
+//
+//	                              ke3
+//	                          ---------->
+//
+//	                              sessionKey = ss.Finish(ke3)
+//
+// # References
+//
+// [1] draft-irtf-cfrg-opaque: https://datatracker.ietf.org/doc/draft-irtf-cfrg-opaque/
+package opaque
+
+import (
+	"crypto/hmac"
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"github.com/cloudflare/circl/group"
+	"github.com/cloudflare/circl/oprf"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/hkdf"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	// nonceSize is the size Nn of the nonces.
+	nonceSize = 32
+	// seedSize is the size Nseed of the seeds used to derive key pairs.
+	seedSize = 32
+
+	labelPrefix            = "OPAQUE-"
+	labelDeriveKeyPair     = "OPAQUE-DeriveKeyPair"
+	labelDeriveDHKeyPair   = "OPAQUE-DeriveDiffieHellmanKeyPair"
+	labelOprfKey           = "OprfKey"
+	labelMaskingKey        = "MaskingKey"
+	labelAuthKey           = "AuthKey"
+	labelExportKey         = "ExportKey"
+	labelPrivateKey        = "PrivateKey"
+	labelCredentialPad     = "CredentialResponsePad"
+	labelHandshakeSecret   = "HandshakeSecret"
+	labelSessionKey        = "SessionKey"
+	labelServerMAC         = "ServerMAC"
+	labelClientMAC         = "ClientMAC"
+	labelPreambleVersion   = "OPAQUEv1-"
+	maxLengthPrefixedBytes = 1<<16 - 1
+)
+
+var (
+	ErrInvalidConfig        = errors.New("opaque: invalid configuration")
+	ErrInvalidMessage       = errors.New("opaque: invalid message")
+	ErrInvalidKey           = errors.New("opaque: invalid key")
+	ErrEnvelopeRecovery     = errors.New("opaque: envelope recovery failed")
+	ErrServerAuthentication = errors.New("opaque: server authentication failed")
+	ErrClientAuthentication = errors.New("opaque: client authentication failed")
+	ErrSessionFinished      = errors.New("opaque: session already finished")
+)
+
+// KSF is a key stretching function that hardens the output of the OPRF
+// against offline dictionary attacks.
+type KSF interface {
+	// Stretch returns length bytes derived from msg.
+	Stretch(msg []byte, length int) []byte
+}
+
+// IdentityKSF is the key stretching function that returns its input
+// unchanged. It must only be used when passwords have high entropy.
+var IdentityKSF KSF = identityKSF{}
+
+type identityKSF struct{}
+
+func (identityKSF) Stretch(msg []byte, _ int) []byte { return append([]byte{}, msg...) }
+
+// Argon2id is the memory-hard key stretching function Argon2id of RFC 9106,
+// with a salt of 16 zero bytes. Memory is given in KiB.
+type Argon2id struct {
+	Time    uint32
+	Memory  uint32
+	Threads uint8
+}
+
+// Argon2idDefault are the parameters recommended by draft-irtf-cfrg-opaque.
+var Argon2idDefault = Argon2id{Time: 1, Memory: 1 << 21, Threads: 4}
+
+func (a Argon2id) Stretch(msg []byte, length int) []byte {
+	var salt [16]byte
+	return argon2.IDKey(msg, salt[:], a.Time, a.Memory, a.Threads, uint32(length))
+}
+
+// Scrypt is the memory-hard key stretching function scrypt of RFC 7914, with
+// a salt of 16 zero bytes.
+type Scrypt struct {
+	N, R, P int
+}
+
+// ScryptDefault are the parameters recommended by draft-irtf-cfrg-opaque.
+var ScryptDefault = Scrypt{N: 32768, R: 8, P: 1}
+
+func (s Scrypt) Stretch(msg []byte, length int) []byte {
+	var salt [16]byte
+	out, err := scrypt.Key(msg, salt[:], s.N, s.R, s.P, length)
+	if err != nil {
+		panic(err)
+	}
+	return out
+}
+
+// Config is an OPAQUE configuration. Client and server must agree on it.
+type Config struct {
+	// Suite is the OPRF suite. Its hash function is also used as the KDF,
+	// MAC and hash of the key exchange, so suites based on an
+	// extendable-output function are not supported.
+	Suite oprf.Suite
+	// KSF is the key stretching function. A nil value is IdentityKSF.
+	KSF KSF
+	// Context is shared information bound to the key exchange, such as an
+	// application identifier.
+	Context []byte
+}
+
+func (c *Config) validate() error {
+	if c == nil || c.Suite == nil || !c.Suite.Hash().Available() {
+		return ErrInvalidConfig
+	}
+	if len(c.Context) > maxLengthPrefixedBytes {
+		return ErrInvalidConfig
+	}
+	return nil
+}
+
+func (c *Config) ksf() KSF {
+	if c.KSF == nil {
+		return IdentityKSF
+	}
+	return c.KSF
+}
+
+// hashSize returns Nh, which is also the size Nm of MACs and the size Nx of
+// the output of Extract.
+func (c *Config) hashSize() int { return c.Suite.Hash().Size() }
+
+// elementSize returns Npk = Noe, the size of serialized group elements.
+func (c *Config) elementSize() int {
+	return int(c.Suite.Group().Params().CompressedElementLength)
+}
+
+// scalarSize returns Nsk, the size of serialized private keys.
+func (c *Config) scalarSize() int {
+	return int(c.Suite.Group().Params().ScalarLength)
+}
+
+// envelopeSize returns the size of a serialized Envelope.
+func (c *Config) envelopeSize() int { return nonceSize + c.hashSize() }
+
+func (c *Config) hash(msgs ...[]byte) []byte {
+	h := c.Suite.Hash().New()
+	for _, m := range msgs {
+		_, _ = h.Write(m)
+	}
+	return h.Sum(nil)
+}
+
+func (c *Config) extract(salt, ikm []byte) []byte {
+	return hkdf.Extract(c.Suite.Hash().New, ikm, salt)
+}
+
+func (c *Config) expand(prk, info []byte, length int) []byte {
+	out := make([]byte, length)
+	if _, err := io.ReadFull(hkdf.Expand(c.Suite.Hash().New, prk, info), out); err != nil {
+		panic(err)
+	}
+	return out
+}
+
+func (c *Config) mac(key []byte, msgs ...[]byte) []byte {
+	m := hmac.New(c.Suite.Hash().New, key)
+	for _, msg := range msgs {
+		_, _ = m.Write(msg)
+	}
+	return m.Sum(nil)
+}
+
+// expandLabel implements Expand-Label, and deriveSecret implements
+// Derive-Secret, see draft-irtf-cfrg-opaque, Section 6.4.2.
+func (c *Config) expandLabel(secret []byte, label string, context []byte, length int) []byte {
+	info := make([]byte, 2, 2+1+len(labelPrefix)+len(label)+1+len(context))
+	binary.BigEndian.PutUint16(info, uint16(length))
+	info = append(info, byte(len(labelPrefix)+len(label)))
+	info = append(append(info, labelPrefix...), label...)
+	info = append(info, byte(len(context)))
+	info = append(info, context...)
+	return c.expand(secret, info, length)
+}
+
+func (c *Config) deriveSecret(secret []byte, label string, transcriptHash []byte) []byte {
+	return c.expandLabel(secret, label, transcriptHash, c.hashSize())
+}
+
+// deriveKeyPair derives a key pair from a seed with the DeriveKeyPair
+// function of the OPRF, and returns the private key and the serialized
+// public key.
+func (c *Config) deriveKeyPair(seed []byte, info string) (group.Scalar, []byte, error) {
+	key, err := oprf.DeriveKey(c.Suite, oprf.BaseMode, seed, []byte(info))
+	if err != nil {
+		return nil, nil, err
+	}
+	raw, err := key.MarshalBinary()
+	if err != nil {
+		return nil, nil, err
+	}
+	sk := c.Suite.Group().NewScalar()
+	if err = sk.UnmarshalBinary(raw); err != nil {
+		return nil, nil, err
+	}
+	pk, err := key.Public().MarshalBinary()
+	if err != nil {
+		return nil, nil, err
+	}
+	return sk, pk, nil
+}
+
+// deriveDHKeyPair implements DeriveDiffieHellmanKeyPair.
+func (c *Config) deriveDHKeyPair(seed []byte) (group.Scalar, []byte, error) {
+	return c.deriveKeyPair(seed, labelDeriveDHKeyPair)
+}
+
+// oprfKey derives the OPRF key of a credential from the OPRF seed of the
+// server.
+func (c *Config) oprfKey(oprfSeed, credentialID []byte) (*oprf.PrivateKey, error) {
+	info := append(append([]byte{}, credentialID...), labelOprfKey...)
+	seed := c.expand(oprfSeed, info, seedSize)
+	return oprf.DeriveKey(c.Suite, oprf.BaseMode, seed, []byte(labelDeriveKeyPair))
+}
+
+// deserializeElement decodes an element, rejecting the identity.
+func (c *Config) deserializeElement(data []byte) (group.Element, error) {
+	e := c.Suite.Group().NewElement()
+	if len(data) != c.elementSize() {
+		return nil, ErrInvalidMessage
+	}
+	if err := e.UnmarshalBinary(data); err != nil {
+		return nil, ErrInvalidMessage
+	}
+	if e.IsIdentity() {
+		return nil, ErrInvalidMessage
+	}
+	return e, nil
+}
+
+// diffieHellman returns the serialization of k*B.
+func (c *Config) diffieHellman(k group.Scalar, pub []byte) ([]byte, error) {
+	B, err := c.deserializeElement(pub)
+	if err != nil {
+		return nil, err
+	}
+	return c.Suite.Group().NewElement().Mul(B, k).MarshalBinaryCompress()
+}
+
+func (c *Config) random(rnd io.Reader, n int) ([]byte, error) {
+	b := make([]byte, n)
+	if _, err := io.ReadFull(rnd, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Identities are the optional identities of client and server. An empty
+// identity is replaced by the public key of the party.
+type Identities struct {
+	Client []byte
+	Server []byte
+}
+
+// cleartextCredentials returns the serialized CleartextCredentials and the
+// identities actually used.
+func cleartextCredentials(serverPublicKey, clientPublicKey []byte, ids Identities) (
+	creds, clientID, serverID []byte, err error,
+) {
+	clientID, serverID = ids.Client, ids.Server
+	if len(clientID) == 0 {
+		clientID = clientPublicKey
+	}
+	if len(serverID) == 0 {
+		serverID = serverPublicKey
+	}
+	if len(clientID) > maxLengthPrefixedBytes || len(serverID) > maxLengthPrefixedBytes {
+		return nil, nil, nil, ErrInvalidMessage
+	}
+	creds = append([]byte{}, serverPublicKey...)
+	creds = appendLengthPrefixed(creds, serverID)
+	creds = appendLengthPrefixed(creds, clientID)
+	return creds, clientID, serverID, nil
+}
+
+func appendLengthPrefixed(b, data []byte) []byte {
+	var l [2]byte
+	binary.BigEndian.PutUint16(l[:], uint16(len(data)))
+	return append(append(b, l[:]...), data...)
+}
+
+// randomizedPassword hardens the output of the OPRF.
+func (c *Config) randomizedPassword(oprfOutput []byte) []byte {
+	stretched := c.ksf().Stretch(oprfOutput, c.hashSize())
+	return c.extract(nil, append(append([]byte{}, oprfOutput...), stretched...))
+}
+
+// envelopeKeys derives the keys protected by an envelope, see
+// draft-irtf-cfrg-opaque, Section 4.1.
+func (c *Config) envelopeKeys(randomizedPassword, nonce []byte) (
+	authKey, exportKey []byte, sk group.Scalar, pk []byte, err error,
+) {
+	info := func(label string) []byte {
+		return append(append([]byte{}, nonce...), label...)
+	}
+	authKey = c.expand(randomizedPassword, info(labelAuthKey), c.hashSize())
+	exportKey = c.expand(randomizedPassword, info(labelExportKey), c.hashSize())
+	seed := c.expand(randomizedPassword, info(labelPrivateKey), seedSize)
+	sk, pk, err = c.deriveDHKeyPair(seed)
+	return authKey, exportKey, sk, pk, err
+}
+
+// preamble returns the transcript of the key exchange, see
+// draft-irtf-cfrg-opaque, Section 6.4.2.
+func (c *Config) preamble(clientID, ke1, serverID, credentialResponse,
+	serverNonce, serverKeyshare []byte,
+) []byte {
+	p := append([]byte{}, labelPreambleVersion...)
+	p = appendLengthPrefixed(p, c.Context)
+	p = appendLengthPrefixed(p, clientID)
+	p = append(p, ke1...)
+	p = appendLengthPrefixed(p, serverID)
+	p = append(p, credentialResponse...)
+	p = append(p, serverNonce...)
+	return append(p, serverKeyshare...)
+}
+
+// deriveKeys returns the MAC keys of server and client and the session key.
+func (c *Config) deriveKeys(ikm, preamble []byte) (km2, km3, sessionKey []byte) {
+	prk := c.extract(nil, ikm)
+	h := c.hash(preamble)
+	handshakeSecret := c.deriveSecret(prk, labelHandshakeSecret, h)
+	sessionKey = c.deriveSecret(prk, labelSessionKey, h)
+	km2 = c.deriveSecret(handshakeSecret, labelServerMAC, nil)
+	km3 = c.deriveSecret(handshakeSecret, labelClientMAC, nil)
+	return km2, km3, sessionKey
+}
+
+func xorBytes(dst, a, b []byte) {
+	for i := range dst {
+		dst[i] = a[i] ^ b[i]
+	}
+}
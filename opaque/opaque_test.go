@@ -0,0 +1,236 @@
+package opaque
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"testing"
+
+	"github.com/cloudflare/circl/internal/test"
+	"github.com/cloudflare/circl/oprf"
+)
+
+type canMarshal interface {
+	MarshalBinary() ([]byte, error)
+	UnmarshalBinary(c *Config, data []byte) error
+}
+
+// roundTrip serializes x and deserializes it into y.
+func roundTrip(t *testing.T, c *Config, x, y canMarshal) {
+	t.Helper()
+	raw, err := x.MarshalBinary()
+	test.CheckNoErr(t, err, "marshal")
+	test.CheckNoErr(t, y.UnmarshalBinary(c, raw), "unmarshal")
+	got, err := y.MarshalBinary()
+	test.CheckNoErr(t, err, "marshal")
+	if !bytes.Equal(got, raw) {
+		test.ReportError(t, got, raw)
+	}
+	test.CheckIsErr(t, y.UnmarshalBinary(c, raw[1:]), "short message must fail")
+}
+
+func register(t *testing.T, client *Client, server *Server, password, credentialID []byte,
+	ids Identities,
+) (*RegistrationRecord, []byte) {
+	t.Helper()
+	conf := client.conf
+
+	req, reg, err := client.RegistrationInit(rand.Reader, password)
+	test.CheckNoErr(t, err, "registration init")
+	var req2 RegistrationRequest
+	roundTrip(t, conf, req, &req2)
+
+	resp, err := server.RegistrationResponse(&req2, credentialID)
+	test.CheckNoErr(t, err, "registration response")
+	var resp2 RegistrationResponse
+	roundTrip(t, conf, resp, &resp2)
+
+	record, exportKey, err := reg.Finalize(rand.Reader, &resp2, ids)
+	test.CheckNoErr(t, err, "registration finalize")
+	var record2 RegistrationRecord
+	roundTrip(t, conf, record, &record2)
+	return &record2, exportKey
+}
+
+// login runs a login and returns the errors of client and server.
+func login(t *testing.T, client *Client, server *Server, password, credentialID []byte,
+	record *RegistrationRecord, clientIDs, serverIDs Identities,
+) (clientErr, serverErr error) {
+	t.Helper()
+	conf := client.conf
+
+	ke1, cs, err := client.LoginInit(rand.Reader, password)
+	test.CheckNoErr(t, err, "login init")
+	var ke1b KE1
+	roundTrip(t, conf, ke1, &ke1b)
+
+	ke2, ss, err := server.LoginInit(rand.Reader, &ke1b, record, credentialID, serverIDs)
+	test.CheckNoErr(t, err, "server login init")
+	var ke2b KE2
+	roundTrip(t, conf, ke2, &ke2b)
+
+	ke3, clientKey, _, err := cs.Finish(&ke2b, clientIDs)
+	if err != nil {
+		return err, nil
+	}
+	var ke3b KE3
+	roundTrip(t, conf, ke3, &ke3b)
+
+	serverKey, err := ss.Finish(&ke3b)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(clientKey, serverKey) {
+		test.ReportError(t, clientKey, serverKey)
+	}
+	return nil, nil
+}
+
+func TestOPAQUE(t *testing.T) {
+	for _, suite := range []oprf.Suite{
+		oprf.SuiteRistretto255,
+		oprf.SuiteP256,
+		oprf.SuiteP384,
+		oprf.SuiteP521,
+	} {
+		t.Run(suite.Identifier(), func(t *testing.T) {
+			testOPAQUE(t, &Config{Suite: suite, Context: []byte("OPAQUE test")})
+		})
+	}
+}
+
+func testOPAQUE(t *testing.T, conf *Config) {
+	password := []byte("correct horse battery staple")
+	credentialID := []byte("alice")
+	ids := Identities{Client: []byte("alice@example.com")}
+
+	key, err := GenerateServerKey(conf, rand.Reader)
+	test.CheckNoErr(t, err, "server key")
+	server, err := NewServer(conf, key)
+	test.CheckNoErr(t, err, "new server")
+	client, err := NewClient(conf)
+	test.CheckNoErr(t, err, "new client")
+
+	record, regExportKey := register(t, client, server, password, credentialID, ids)
+
+	// Successful login, and the export key matches the one of registration.
+	ke1, cs, err := client.LoginInit(rand.Reader, password)
+	test.CheckNoErr(t, err, "login init")
+	ke2, ss, err := server.LoginInit(rand.Reader, ke1, record, credentialID, ids)
+	test.CheckNoErr(t, err, "server login init")
+	ke3, clientKey, exportKey, err := cs.Finish(ke2, ids)
+	test.CheckNoErr(t, err, "client finish")
+	if !bytes.Equal(exportKey, regExportKey) {
+		test.ReportError(t, exportKey, regExportKey)
+	}
+	serverKey, err := ss.Finish(ke3)
+	test.CheckNoErr(t, err, "server finish")
+	if !bytes.Equal(clientKey, serverKey) || len(clientKey) != conf.hashSize() {
+		test.ReportError(t, clientKey, serverKey)
+	}
+	_, _, _, err = cs.Finish(ke2, ids)
+	test.CheckIsErr(t, err, "session must not be reused")
+	_, err = ss.Finish(ke3)
+	test.CheckIsErr(t, err, "session must not be reused")
+
+	clientErr, serverErr := login(t, client, server, password, credentialID, record, ids, ids)
+	test.CheckNoErr(t, clientErr, "client login")
+	test.CheckNoErr(t, serverErr, "server login")
+
+	// Wrong password, credential identifier or identities.
+	clientErr, _ = login(t, client, server, []byte("wrong"), credentialID, record, ids, ids)
+	if clientErr != ErrEnvelopeRecovery {
+		test.ReportError(t, clientErr, ErrEnvelopeRecovery)
+	}
+	clientErr, _ = login(t, client, server, password, []byte("bob"), record, ids, ids)
+	if clientErr != ErrEnvelopeRecovery {
+		test.ReportError(t, clientErr, ErrEnvelopeRecovery)
+	}
+	clientErr, _ = login(t, client, server, password, credentialID, record, Identities{}, ids)
+	if clientErr != ErrEnvelopeRecovery {
+		test.ReportError(t, clientErr, ErrEnvelopeRecovery)
+	}
+	clientErr, _ = login(t, client, server, password, credentialID, record,
+		ids, Identities{Client: ids.Client, Server: []byte("other server")})
+	if clientErr != ErrServerAuthentication {
+		test.ReportError(t, clientErr, ErrServerAuthentication)
+	}
+
+	// Unregistered client.
+	fake, err := server.FakeRecord(rand.Reader)
+	test.CheckNoErr(t, err, "fake record")
+	clientErr, _ = login(t, client, server, password, []byte("mallory"), fake, ids, ids)
+	if clientErr != ErrEnvelopeRecovery {
+		test.ReportError(t, clientErr, ErrEnvelopeRecovery)
+	}
+
+	// Tampered messages.
+	ke1, cs, err = client.LoginInit(rand.Reader, password)
+	test.CheckNoErr(t, err, "login init")
+	ke2, ss, err = server.LoginInit(rand.Reader, ke1, record, credentialID, ids)
+	test.CheckNoErr(t, err, "server login init")
+	ke2.ServerNonce[0] ^= 1
+	_, _, _, err = cs.Finish(ke2, ids)
+	if err != ErrServerAuthentication {
+		test.ReportError(t, err, ErrServerAuthentication)
+	}
+	_, err = ss.Finish(&KE3{make([]byte, conf.hashSize())})
+	if err != ErrClientAuthentication {
+		test.ReportError(t, err, ErrClientAuthentication)
+	}
+}
+
+func TestKSF(t *testing.T) {
+	for _, ksf := range []KSF{
+		Argon2id{Time: 1, Memory: 64, Threads: 1},
+		Scrypt{N: 16, R: 8, P: 1},
+	} {
+		t.Run(fmt.Sprintf("%T", ksf), func(t *testing.T) {
+			conf := &Config{Suite: oprf.SuiteRistretto255, KSF: ksf}
+			key, err := GenerateServerKey(conf, rand.Reader)
+			test.CheckNoErr(t, err, "server key")
+			server, err := NewServer(conf, key)
+			test.CheckNoErr(t, err, "new server")
+			client, err := NewClient(conf)
+			test.CheckNoErr(t, err, "new client")
+
+			password, credentialID := []byte("password"), []byte("id")
+			record, _ := register(t, client, server, password, credentialID, Identities{})
+			clientErr, serverErr := login(t, client, server, password, credentialID,
+				record, Identities{}, Identities{})
+			test.CheckNoErr(t, clientErr, "client login")
+			test.CheckNoErr(t, serverErr, "server login")
+
+			// A client with another KSF cannot recover its envelope.
+			other, err := NewClient(&Config{Suite: oprf.SuiteRistretto255})
+			test.CheckNoErr(t, err, "new client")
+			clientErr, _ = login(t, other, server, password, credentialID,
+				record, Identities{}, Identities{})
+			if clientErr != ErrEnvelopeRecovery {
+				test.ReportError(t, clientErr, ErrEnvelopeRecovery)
+			}
+		})
+	}
+}
+
+func TestConfig(t *testing.T) {
+	for _, conf := range []*Config{
+		nil,
+		{},
+		{Suite: oprf.SuiteDecaf448},
+	} {
+		_, err := NewClient(conf)
+		test.CheckIsErr(t, err, "invalid config must fail")
+		_, err = GenerateServerKey(conf, rand.Reader)
+		test.CheckIsErr(t, err, "invalid config must fail")
+	}
+
+	conf := &Config{Suite: oprf.SuiteP256}
+	key, err := GenerateServerKey(conf, rand.Reader)
+	test.CheckNoErr(t, err, "server key")
+	bad := *key
+	bad.PublicKey = append([]byte{}, key.PublicKey...)
+	bad.PublicKey[1] ^= 1
+	_, err = NewServer(conf, &bad)
+	test.CheckIsErr(t, err, "mismatched key pair must fail")
+}
@@ -0,0 +1,240 @@
+package opaque
+
+import (
+	"crypto/subtle"
+	"io"
+
+	"github.com/cloudflare/circl/group"
+	"github.com/cloudflare/circl/oprf"
+)
+
+// ServerKey holds the long-term secrets of a server: the seed from which
+// OPRF keys of credentials are derived, and the key pair of the server.
+type ServerKey struct {
+	OPRFSeed   []byte
+	PrivateKey []byte
+	PublicKey  []byte
+}
+
+// GenerateServerKey generates the long-term secrets of a server, reading
+// randomness from rnd.
+func GenerateServerKey(conf *Config, rnd io.Reader) (*ServerKey, error) {
+	if err := conf.validate(); err != nil {
+		return nil, err
+	}
+	oprfSeed, err := conf.random(rnd, conf.hashSize())
+	if err != nil {
+		return nil, err
+	}
+	seed, err := conf.random(rnd, seedSize)
+	if err != nil {
+		return nil, err
+	}
+	sk, pk, err := conf.deriveDHKeyPair(seed)
+	if err != nil {
+		return nil, err
+	}
+	rawSk, err := sk.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return &ServerKey{oprfSeed, rawSk, pk}, nil
+}
+
+// Server runs registration and login on behalf of a server.
+type Server struct {
+	conf       *Config
+	key        ServerKey
+	privateKey group.Scalar
+}
+
+// NewServer returns a server for the given configuration and key.
+func NewServer(conf *Config, key *ServerKey) (*Server, error) {
+	if err := conf.validate(); err != nil {
+		return nil, err
+	}
+	if len(key.OPRFSeed) != conf.hashSize() || len(key.PrivateKey) != conf.scalarSize() {
+		return nil, ErrInvalidKey
+	}
+	sk := conf.Suite.Group().NewScalar()
+	if err := sk.UnmarshalBinary(key.PrivateKey); err != nil || sk.IsZero() {
+		return nil, ErrInvalidKey
+	}
+	pk, err := conf.Suite.Group().NewElement().MulGen(sk).MarshalBinaryCompress()
+	if err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare(pk, key.PublicKey) != 1 {
+		return nil, ErrInvalidKey
+	}
+	return &Server{conf, *key, sk}, nil
+}
+
+// evaluate evaluates the OPRF of the credential on a blinded element.
+func (s *Server) evaluate(blinded, credentialID []byte) ([]byte, error) {
+	e, err := s.conf.deserializeElement(blinded)
+	if err != nil {
+		return nil, err
+	}
+	key, err := s.conf.oprfKey(s.key.OPRFSeed, credentialID)
+	if err != nil {
+		return nil, err
+	}
+	eval, err := oprf.NewServer(s.conf.Suite, key).Evaluate(
+		&oprf.EvaluationRequest{Elements: []oprf.Blinded{e}})
+	if err != nil {
+		return nil, err
+	}
+	return eval.Elements[0].MarshalBinaryCompress()
+}
+
+// RegistrationResponse answers the registration request of the credential
+// with the given identifier, which is unique per client, such as the
+// hash of a user name.
+func (s *Server) RegistrationResponse(req *RegistrationRequest, credentialID []byte) (
+	*RegistrationResponse, error,
+) {
+	evaluated, err := s.evaluate(req.BlindedMessage, credentialID)
+	if err != nil {
+		return nil, err
+	}
+	return &RegistrationResponse{evaluated, append([]byte{}, s.key.PublicKey...)}, nil
+}
+
+// FakeRecord returns a record to be used in LoginInit for clients that are
+// not registered, so that they cannot be distinguished from registered
+// clients. The server should store the record and use it for all the login
+// attempts of an unregistered credential.
+func (s *Server) FakeRecord(rnd io.Reader) (*RegistrationRecord, error) {
+	seed, err := s.conf.random(rnd, seedSize)
+	if err != nil {
+		return nil, err
+	}
+	_, pk, err := s.conf.deriveDHKeyPair(seed)
+	if err != nil {
+		return nil, err
+	}
+	maskingKey, err := s.conf.random(rnd, s.conf.hashSize())
+	if err != nil {
+		return nil, err
+	}
+	return &RegistrationRecord{
+		ClientPublicKey: pk,
+		MaskingKey:      maskingKey,
+		Envelope: Envelope{
+			Nonce:   make([]byte, nonceSize),
+			AuthTag: make([]byte, s.conf.hashSize()),
+		},
+	}, nil
+}
+
+// ServerSession holds the state of a server between KE2 and KE3.
+type ServerSession struct {
+	expectedClientMAC []byte
+	sessionKey        []byte
+	finished          bool
+}
+
+// LoginInit answers the KE1 message of the client with the given credential
+// identifier and record, reading randomness from rnd.
+func (s *Server) LoginInit(
+	rnd io.Reader, ke1 *KE1, record *RegistrationRecord, credentialID []byte,
+	ids Identities,
+) (*KE2, *ServerSession, error) {
+	conf := s.conf
+	if len(ke1.ClientNonce) != nonceSize ||
+		len(record.MaskingKey) != conf.hashSize() ||
+		len(record.Envelope.Nonce) != nonceSize ||
+		len(record.Envelope.AuthTag) != conf.hashSize() {
+		return nil, nil, ErrInvalidMessage
+	}
+
+	// Create the credential response, see draft-irtf-cfrg-opaque, Section 5.3.
+	evaluated, err := s.evaluate(ke1.BlindedMessage, credentialID)
+	if err != nil {
+		return nil, nil, err
+	}
+	maskingNonce, err := conf.random(rnd, nonceSize)
+	if err != nil {
+		return nil, nil, err
+	}
+	envelope, err := record.Envelope.MarshalBinary()
+	if err != nil {
+		return nil, nil, err
+	}
+	clear := concat(s.key.PublicKey, envelope)
+	pad := conf.expand(record.MaskingKey,
+		append(append([]byte{}, maskingNonce...), labelCredentialPad...),
+		len(clear))
+	masked := make([]byte, len(clear))
+	xorBytes(masked, pad, clear)
+
+	// Respond to the authentication request, see draft-irtf-cfrg-opaque,
+	// Section 6.4.
+	serverNonce, err := conf.random(rnd, nonceSize)
+	if err != nil {
+		return nil, nil, err
+	}
+	seed, err := conf.random(rnd, seedSize)
+	if err != nil {
+		return nil, nil, err
+	}
+	secret, keyshare, err := conf.deriveDHKeyPair(seed)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var ikm []byte
+	for _, dh := range []struct {
+		k   group.Scalar
+		pub []byte
+	}{
+		{secret, ke1.ClientPublicKeyshare},
+		{s.privateKey, ke1.ClientPublicKeyshare},
+		{secret, record.ClientPublicKey},
+	} {
+		shared, err := conf.diffieHellman(dh.k, dh.pub)
+		if err != nil {
+			return nil, nil, err
+		}
+		ikm = append(ikm, shared...)
+	}
+
+	_, clientID, serverID, err := cleartextCredentials(s.key.PublicKey, record.ClientPublicKey, ids)
+	if err != nil {
+		return nil, nil, err
+	}
+	rawKE1, err := ke1.MarshalBinary()
+	if err != nil {
+		return nil, nil, err
+	}
+	ke2 := &KE2{
+		EvaluatedMessage:     evaluated,
+		MaskingNonce:         maskingNonce,
+		MaskedResponse:       masked,
+		ServerNonce:          serverNonce,
+		ServerPublicKeyshare: keyshare,
+	}
+	preamble := conf.preamble(clientID, rawKE1, serverID, ke2.credentialResponse(),
+		serverNonce, keyshare)
+	km2, km3, sessionKey := conf.deriveKeys(ikm, preamble)
+	ke2.ServerMAC = conf.mac(km2, conf.hash(preamble))
+
+	return ke2, &ServerSession{
+		expectedClientMAC: conf.mac(km3, conf.hash(preamble, ke2.ServerMAC)),
+		sessionKey:        sessionKey,
+	}, nil
+}
+
+// Finish authenticates the client with the KE3 message and returns the
+// session key. It returns ErrClientAuthentication if authentication fails.
+func (s *ServerSession) Finish(ke3 *KE3) ([]byte, error) {
+	if s.finished {
+		return nil, ErrSessionFinished
+	}
+	s.finished = true
+	if subtle.ConstantTimeCompare(ke3.ClientMAC, s.expectedClientMAC) != 1 {
+		return nil, ErrClientAuthentication
+	}
+	return s.sessionKey, nil
+}
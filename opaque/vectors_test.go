@@ -0,0 +1,137 @@
+package opaque
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/cloudflare/circl/internal/test"
+	"github.com/cloudflare/circl/oprf"
+)
+
+type vector struct {
+	Name                 string `json:"name"`
+	OPRF                 string `json:"oprf"`
+	KSF                  string `json:"ksf"`
+	Context              string `json:"context"`
+	OPRFSeed             string `json:"oprf_seed"`
+	CredentialIdentifier string `json:"credential_identifier"`
+	Password             string `json:"password"`
+	EnvelopeNonce        string `json:"envelope_nonce"`
+	MaskingNonce         string `json:"masking_nonce"`
+	ServerPrivateKey     string `json:"server_private_key"`
+	ServerPublicKey      string `json:"server_public_key"`
+	ServerNonce          string `json:"server_nonce"`
+	ClientNonce          string `json:"client_nonce"`
+	ClientKeyshareSeed   string `json:"client_keyshare_seed"`
+	ServerKeyshareSeed   string `json:"server_keyshare_seed"`
+	BlindRegistration    string `json:"blind_registration"`
+	BlindLogin           string `json:"blind_login"`
+	RegistrationRequest  string `json:"registration_request"`
+	RegistrationResponse string `json:"registration_response"`
+	RegistrationUpload   string `json:"registration_upload"`
+	KE1                  string `json:"KE1"`
+	KE2                  string `json:"KE2"`
+	KE3                  string `json:"KE3"`
+	ExportKey            string `json:"export_key"`
+	SessionKey           string `json:"session_key"`
+}
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	test.CheckNoErr(t, err, "decoding hex")
+	return b
+}
+
+func checkMessage(t *testing.T, m canMarshal, want []byte, name string) {
+	t.Helper()
+	got, err := m.MarshalBinary()
+	test.CheckNoErr(t, err, "marshal "+name)
+	if !bytes.Equal(got, want) {
+		test.ReportError(t, got, want, name)
+	}
+}
+
+func checkBytes(t *testing.T, got, want []byte, name string) {
+	t.Helper()
+	if !bytes.Equal(got, want) {
+		test.ReportError(t, got, want, name)
+	}
+}
+
+// TestVectors checks the test vectors of RFC 9807, Appendix C.
+func TestVectors(t *testing.T) {
+	data, err := os.ReadFile("testdata/rfc9807.json")
+	test.CheckNoErr(t, err, "reading vectors")
+	var vectors []vector
+	test.CheckNoErr(t, json.Unmarshal(data, &vectors), "parsing vectors")
+
+	for i := range vectors {
+		v := &vectors[i]
+		t.Run(v.Name, func(t *testing.T) { testVector(t, v) })
+	}
+}
+
+func testVector(t *testing.T, v *vector) {
+	if v.OPRF != oprf.SuiteRistretto255.Identifier() || v.KSF != "Identity" {
+		t.Skipf("unsupported configuration %v, %v", v.OPRF, v.KSF)
+	}
+	conf := &Config{Suite: oprf.SuiteRistretto255, Context: mustHex(t, v.Context)}
+	credentialID := mustHex(t, v.CredentialIdentifier)
+	password := mustHex(t, v.Password)
+	blind := func(s string) oprf.Blind {
+		k := conf.Suite.Group().NewScalar()
+		test.CheckNoErr(t, k.UnmarshalBinary(mustHex(t, s)), "decoding blind")
+		return k
+	}
+
+	client, err := NewClient(conf)
+	test.CheckNoErr(t, err, "new client")
+	server, err := NewServer(conf, &ServerKey{
+		OPRFSeed:   mustHex(t, v.OPRFSeed),
+		PrivateKey: mustHex(t, v.ServerPrivateKey),
+		PublicKey:  mustHex(t, v.ServerPublicKey),
+	})
+	test.CheckNoErr(t, err, "new server")
+
+	// The blinds cannot be read from a reader, as the ristretto255 group
+	// draws its scalars from its own source of randomness.
+	req, reg, err := client.registrationInit(blind(v.BlindRegistration), password)
+	test.CheckNoErr(t, err, "registration init")
+	checkMessage(t, req, mustHex(t, v.RegistrationRequest), "registration request")
+
+	resp, err := server.RegistrationResponse(req, credentialID)
+	test.CheckNoErr(t, err, "registration response")
+	checkMessage(t, resp, mustHex(t, v.RegistrationResponse), "registration response")
+
+	record, exportKey, err := reg.Finalize(
+		bytes.NewReader(mustHex(t, v.EnvelopeNonce)), resp, Identities{})
+	test.CheckNoErr(t, err, "registration finalize")
+	checkMessage(t, record, mustHex(t, v.RegistrationUpload), "registration upload")
+	checkBytes(t, exportKey, mustHex(t, v.ExportKey), "export key")
+
+	ke1, cs, err := client.loginInit(
+		bytes.NewReader(mustHex(t, v.ClientNonce+v.ClientKeyshareSeed)),
+		blind(v.BlindLogin), password)
+	test.CheckNoErr(t, err, "client login init")
+	checkMessage(t, ke1, mustHex(t, v.KE1), "KE1")
+
+	ke2, ss, err := server.LoginInit(
+		bytes.NewReader(mustHex(t, v.MaskingNonce+v.ServerNonce+v.ServerKeyshareSeed)),
+		ke1, record, credentialID, Identities{})
+	test.CheckNoErr(t, err, "server login init")
+	checkMessage(t, ke2, mustHex(t, v.KE2), "KE2")
+
+	ke3, sessionKey, exportKey, err := cs.Finish(ke2, Identities{})
+	test.CheckNoErr(t, err, "client finish")
+	checkMessage(t, ke3, mustHex(t, v.KE3), "KE3")
+	checkBytes(t, sessionKey, mustHex(t, v.SessionKey), "client session key")
+	checkBytes(t, exportKey, mustHex(t, v.ExportKey), "export key")
+
+	sessionKey, err = ss.Finish(ke3)
+	test.CheckNoErr(t, err, "server finish")
+	checkBytes(t, sessionKey, mustHex(t, v.SessionKey), "server session key")
+}
@@ -95,7 +95,7 @@ func (c client) finalize(f *FinalizeData, e *Evaluation, info []byte) ([][]byte,
 		return nil, err
 	}
 
-	h := c.params.hash.New()
+	h := c.params.newHash()
 	outputs := make([][]byte, len(f.inputs))
 	for i := range f.inputs {
 		outputs[i] = c.params.finalizeHash(h, f.inputs[i], info, unblindedElements[i])
@@ -0,0 +1,198 @@
+package oprf
+
+import (
+	"math"
+
+	"github.com/cloudflare/circl/group"
+	"github.com/cloudflare/circl/zk/dleq"
+	"golang.org/x/crypto/cryptobyte"
+)
+
+// MarshalBinary encodes the blinded elements of the request, prefixed by
+// their number as a two-byte integer.
+func (r *EvaluationRequest) MarshalBinary() ([]byte, error) {
+	var b cryptobyte.Builder
+	addElements(&b, r.Elements)
+	return b.Bytes()
+}
+
+// UnmarshalBinary decodes a request encoded by MarshalBinary.
+func (r *EvaluationRequest) UnmarshalBinary(s Suite, data []byte) error {
+	p, ok := s.(params)
+	if !ok {
+		return ErrInvalidSuite
+	}
+	str := cryptobyte.String(data)
+	elements, err := readElements(&str, p.group)
+	if err != nil {
+		return err
+	}
+	if !str.Empty() {
+		return ErrInvalidInput
+	}
+	r.Elements = elements
+	return nil
+}
+
+// MarshalBinary encodes the evaluated elements, prefixed by their number as
+// a two-byte integer, followed by the proof in verifiable modes.
+func (e *Evaluation) MarshalBinary() ([]byte, error) {
+	var b cryptobyte.Builder
+	addElements(&b, e.Elements)
+	if e.Proof != nil {
+		proof, err := e.Proof.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		b.AddBytes(proof)
+	}
+	return b.Bytes()
+}
+
+// UnmarshalBinary decodes an evaluation encoded by MarshalBinary. The proof
+// is only set if present in data.
+func (e *Evaluation) UnmarshalBinary(s Suite, data []byte) error {
+	p, ok := s.(params)
+	if !ok {
+		return ErrInvalidSuite
+	}
+	str := cryptobyte.String(data)
+	elements, err := readElements(&str, p.group)
+	if err != nil {
+		return err
+	}
+
+	var proof *dleq.Proof
+	switch len(str) {
+	case 0:
+	case 2 * int(p.group.Params().ScalarLength):
+		proof = new(dleq.Proof)
+		if err = proof.UnmarshalBinary(p.group, str); err != nil {
+			return err
+		}
+	default:
+		return ErrInvalidInput
+	}
+
+	e.Elements = elements
+	e.Proof = proof
+	return nil
+}
+
+// MarshalBinary encodes the inputs, the blinds and the blinded elements
+// kept by the client. The encoding contains secret data, so it must be
+// stored accordingly.
+func (f *FinalizeData) MarshalBinary() ([]byte, error) {
+	if len(f.inputs) > math.MaxUint16 || len(f.blinds) != len(f.inputs) ||
+		f.evalReq == nil || len(f.evalReq.Elements) != len(f.inputs) {
+		return nil, ErrInvalidInput
+	}
+
+	var b cryptobyte.Builder
+	b.AddUint16(uint16(len(f.inputs)))
+	for _, in := range f.inputs {
+		b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+			b.AddBytes(in)
+		})
+	}
+	for _, k := range f.blinds {
+		raw, err := k.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		b.AddBytes(raw)
+	}
+	addElements(&b, f.evalReq.Elements)
+	return b.Bytes()
+}
+
+// UnmarshalBinary decodes the data encoded by MarshalBinary.
+func (f *FinalizeData) UnmarshalBinary(s Suite, data []byte) error {
+	p, ok := s.(params)
+	if !ok {
+		return ErrInvalidSuite
+	}
+
+	var n uint16
+	str := cryptobyte.String(data)
+	if !str.ReadUint16(&n) || n == 0 {
+		return ErrInvalidInput
+	}
+
+	inputs := make([][]byte, n)
+	for i := range inputs {
+		var in cryptobyte.String
+		if !str.ReadUint16LengthPrefixed(&in) {
+			return ErrInvalidInput
+		}
+		inputs[i] = append([]byte{}, in...)
+	}
+
+	scalarLen := int(p.group.Params().ScalarLength)
+	blinds := make([]Blind, n)
+	for i := range blinds {
+		var raw []byte
+		if !str.ReadBytes(&raw, scalarLen) {
+			return ErrInvalidInput
+		}
+		blinds[i] = p.group.NewScalar()
+		if err := blinds[i].UnmarshalBinary(raw); err != nil {
+			return err
+		}
+	}
+
+	elements, err := readElements(&str, p.group)
+	if err != nil {
+		return err
+	}
+	if len(elements) != int(n) || !str.Empty() {
+		return ErrInvalidInput
+	}
+
+	f.inputs = inputs
+	f.blinds = blinds
+	f.evalReq = &EvaluationRequest{elements}
+	return nil
+}
+
+func addElements(b *cryptobyte.Builder, elements []group.Element) {
+	if len(elements) > math.MaxUint16 {
+		b.SetError(ErrInvalidInput)
+		return
+	}
+	b.AddUint16(uint16(len(elements)))
+	for _, e := range elements {
+		enc, err := e.MarshalBinaryCompress()
+		if err != nil {
+			b.SetError(err)
+			return
+		}
+		b.AddBytes(enc)
+	}
+}
+
+// readElements reads a list of elements prefixed by their number, and
+// rejects the identity element.
+func readElements(s *cryptobyte.String, g group.Group) ([]group.Element, error) {
+	var n uint16
+	if !s.ReadUint16(&n) || n == 0 {
+		return nil, ErrInvalidInput
+	}
+
+	elemLen := int(g.Params().CompressedElementLength)
+	elements := make([]group.Element, n)
+	for i := range elements {
+		var raw []byte
+		if !s.ReadBytes(&raw, elemLen) {
+			return nil, ErrInvalidInput
+		}
+		elements[i] = g.NewElement()
+		if err := elements[i].UnmarshalBinary(raw); err != nil {
+			return nil, err
+		}
+		if elements[i].IsIdentity() {
+			return nil, ErrInvalidInput
+		}
+	}
+	return elements, nil
+}
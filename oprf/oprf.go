@@ -4,7 +4,7 @@
 // computing the output of a PRF. One party (the server) holds the PRF secret
 // key, and the other (the client) holds the PRF input.
 //
-// This package is compatible with the OPRF specification at draft-irtf-cfrg-voprf [1].
+// This package is compatible with the OPRF specification at RFC-9497 [1].
 //
 // # Protocol Overview
 //
@@ -41,26 +41,36 @@
 // All three modes can perform batches of PRF evaluations, so passing an array
 // of inputs will produce an array of outputs.
 //
+// # Threshold Evaluation
+//
+// The private key can be split with SplitKey among n servers, so that any t+1
+// of them can evaluate the PRF while t of them learn nothing about the key.
+// Each ThresholdServer evaluates the request with its share and proves the
+// evaluation with a DLEQ proof against the public key of its share. The
+// ThresholdClient verifies the proofs and combines t+1 partial evaluations
+// with Lagrange interpolation in the exponent, producing the same outputs as
+// a Server holding the full key.
+//
 // # References
 //
-// [1] draft-irtf-cfrg-voprf: https://datatracker.ietf.org/doc/draft-irtf-cfrg-voprf
+// [1] RFC-9497: https://www.rfc-editor.org/info/rfc9497
 package oprf
 
 import (
 	"crypto"
 	"encoding/binary"
 	"errors"
-	"fmt"
 	"hash"
 	"io"
 	"math"
 
 	"github.com/cloudflare/circl/group"
+	"github.com/cloudflare/circl/xof"
 	"github.com/cloudflare/circl/zk/dleq"
 )
 
 const (
-	version          = "VOPRF10-"
+	version          = "OPRFV1-"
 	finalizeDST      = "Finalize"
 	hashToGroupDST   = "HashToGroup-"
 	hashToScalarDST  = "HashToScalar-"
@@ -81,37 +91,34 @@ func isValidMode(m Mode) bool {
 }
 
 type Suite interface {
-	ID() int
+	Identifier() string
 	Group() group.Group
+	// Hash returns the hash function of the suite, or zero for suites based
+	// on an extendable-output function, such as SuiteDecaf448.
 	Hash() crypto.Hash
-	Name() string
 	cannotBeImplementedExternally()
 }
 
 var (
 	// SuiteRistretto255 represents the OPRF with Ristretto255 and SHA-512
-	SuiteRistretto255 Suite = params{id: 1, group: group.Ristretto255, hash: crypto.SHA512, name: "OPRF(ristretto255, SHA-512)"}
+	SuiteRistretto255 Suite = params{identifier: "ristretto255-SHA512", group: group.Ristretto255, hash: crypto.SHA512}
 	// SuiteP256 represents the OPRF with P-256 and SHA-256.
-	SuiteP256 Suite = params{id: 3, group: group.P256, hash: crypto.SHA256, name: "OPRF(P-256, SHA-256)"}
+	SuiteP256 Suite = params{identifier: "P256-SHA256", group: group.P256, hash: crypto.SHA256}
 	// SuiteP384 represents the OPRF with P-384 and SHA-384.
-	SuiteP384 Suite = params{id: 4, group: group.P384, hash: crypto.SHA384, name: "OPRF(P-384, SHA-384)"}
+	SuiteP384 Suite = params{identifier: "P384-SHA384", group: group.P384, hash: crypto.SHA384}
 	// SuiteP521 represents the OPRF with P-521 and SHA-512.
-	SuiteP521 Suite = params{id: 5, group: group.P521, hash: crypto.SHA512, name: "OPRF(P-521, SHA-512)"}
+	SuiteP521 Suite = params{identifier: "P521-SHA512", group: group.P521, hash: crypto.SHA512}
+	// SuiteDecaf448 represents the OPRF with Decaf448 and SHAKE-256.
+	SuiteDecaf448 Suite = params{identifier: "decaf448-SHAKE256", group: group.Decaf448, xof: xof.SHAKE256}
 )
 
-func GetSuite(id int) (Suite, error) {
-	switch uint16(id) {
-	case SuiteRistretto255.(params).id:
-		return SuiteRistretto255, nil
-	case SuiteP256.(params).id:
-		return SuiteP256, nil
-	case SuiteP384.(params).id:
-		return SuiteP384, nil
-	case SuiteP521.(params).id:
-		return SuiteP521, nil
-	default:
-		return nil, ErrInvalidSuite
+func GetSuite(identifier string) (Suite, error) {
+	for _, suite := range []Suite{SuiteRistretto255, SuiteP256, SuiteP384, SuiteP521, SuiteDecaf448} {
+		if suite.Identifier() == identifier {
+			return suite, nil
+		}
 	}
+	return nil, ErrInvalidSuite
 }
 
 func NewClient(s Suite) Client {
@@ -172,26 +179,51 @@ func NewPartialObliviousServer(s Suite, key *PrivateKey) PartialObliviousServer
 }
 
 type params struct {
-	id    uint16
-	m     Mode
-	group group.Group
-	hash  crypto.Hash
-	name  string
+	m          Mode
+	group      group.Group
+	hash       crypto.Hash
+	xof        xof.ID
+	identifier string
 }
 
 func (p params) cannotBeImplementedExternally() {}
 
-func (p params) String() string     { return fmt.Sprintf("Suite%v", p.group) }
-func (p params) ID() int            { return int(p.id) }
+func (p params) String() string     { return p.Identifier() }
 func (p params) Group() group.Group { return p.group }
 func (p params) Hash() crypto.Hash  { return p.hash }
-func (p params) Name() string       { return p.name }
+func (p params) Identifier() string { return p.identifier }
+
+// newHash returns the hash function of the suite. For suites based on an
+// extendable-output function, the output is truncated to 64 bytes.
+func (p params) newHash() hash.Hash {
+	if p.xof != 0 {
+		return &xofHash{p.xof.New()}
+	}
+	return p.hash.New()
+}
+
+// xofHash wraps an extendable-output function as a hash.Hash.
+type xofHash struct{ x xof.XOF }
+
+func (h *xofHash) Write(b []byte) (int, error) { return h.x.Write(b) }
+func (h *xofHash) Reset()                      { h.x.Reset() }
+func (h *xofHash) Size() int                   { return 64 }
+func (h *xofHash) BlockSize() int              { return 136 }
+func (h *xofHash) Sum(b []byte) []byte {
+	out := make([]byte, h.Size())
+	if _, err := io.ReadFull(h.x.Clone(), out); err != nil {
+		panic(err)
+	}
+	return append(b, out...)
+}
 
 func (p params) getDST(name string) []byte {
-	return append(append(append([]byte{},
+	return append(append(append(append(
+		[]byte{},
 		[]byte(name)...),
 		[]byte(version)...),
-		[]byte{p.m, 0, byte(p.id)}...)
+		[]byte{p.m, byte('-')}...),
+		[]byte(p.identifier)...)
 }
 
 func (p params) scalarFromInfo(info []byte) (group.Scalar, error) {
@@ -234,6 +266,7 @@ func (p params) finalizeHash(h hash.Hash, input, info, element []byte) []byte {
 func (p params) getDLEQParams() (out dleq.Params) {
 	out.G = p.group
 	out.H = p.hash
+	out.X = p.xof
 	out.DST = p.getDST("")
 
 	return
@@ -245,19 +278,21 @@ func mustWrite(h io.Writer, bytes []byte) {
 		panic(err)
 	}
 	if len(bytes) != bytesLen {
-		panic("failed to write")
+		panic("oprf: failed to write")
 	}
 }
 
 var (
-	ErrInvalidSuite       = errors.New("invalid suite")
-	ErrInvalidMode        = errors.New("invalid mode")
-	ErrDeriveKeyPairError = errors.New("key pair derivation failed")
-	ErrInvalidInput       = errors.New("invalid input")
-	ErrInvalidInfo        = errors.New("invalid info")
-	ErrInvalidProof       = errors.New("proof verification failed")
-	ErrInverseZero        = errors.New("inverting a zero value")
-	ErrNoKey              = errors.New("must provide a key")
+	ErrInvalidSuite       = errors.New("oprf: invalid suite")
+	ErrInvalidMode        = errors.New("oprf: invalid mode")
+	ErrDeriveKeyPairError = errors.New("oprf: key pair derivation failed")
+	ErrInvalidInput       = errors.New("oprf: invalid input")
+	ErrInvalidSeed        = errors.New("oprf: invalid seed size")
+	ErrInvalidInfo        = errors.New("oprf: invalid info")
+	ErrInvalidProof       = errors.New("oprf: proof verification failed")
+	ErrInverseZero        = errors.New("oprf: inverting a zero value")
+	ErrNoKey              = errors.New("oprf: must provide a key")
+	ErrInvalidShares      = errors.New("oprf: invalid key shares")
 )
 
 type (
@@ -273,7 +308,7 @@ type FinalizeData struct {
 	evalReq *EvaluationRequest
 }
 
-// CopyBlinds copies the serialized blinds to use when determinstically
+// CopyBlinds copies the serialized blinds to use when deterministically
 // invoking DeterministicBlind.
 func (f FinalizeData) CopyBlinds() []Blind {
 	out := make([]Blind, len(f.blinds))
@@ -104,9 +104,33 @@ func testAPI(t *testing.T, server commonServer, client commonClient) {
 		test.CheckOk(evalReq.Elements[i].IsEqual(detEvalReq.Elements[i]), "invalid blinded element mismatch", t)
 	}
 
+	suite := server.PublicKey().p
+	testMarshal(t, suite, evalReq, new(EvaluationRequest), "EvaluationRequest")
+	testMarshal(t, suite, finData, new(FinalizeData), "FinalizeData")
+
 	eval, err := server.Evaluate(evalReq)
 	test.CheckNoErr(t, err, "invalid evaluation of server")
 	test.CheckOk(eval != nil, "invalid evaluation of server: no evaluation", t)
+	testMarshal(t, suite, eval, new(Evaluation), "Evaluation")
+
+	// Run the protocol with messages that went through serialization.
+	rawReq, err := evalReq.MarshalBinary()
+	test.CheckNoErr(t, err, "error on marshaling EvaluationRequest")
+	evalReq = new(EvaluationRequest)
+	test.CheckNoErr(t, evalReq.UnmarshalBinary(suite, rawReq), "error on unmarshaling EvaluationRequest")
+	eval, err = server.Evaluate(evalReq)
+	test.CheckNoErr(t, err, "invalid evaluation of server")
+
+	rawEval, err := eval.MarshalBinary()
+	test.CheckNoErr(t, err, "error on marshaling Evaluation")
+	eval = new(Evaluation)
+	test.CheckNoErr(t, eval.UnmarshalBinary(suite, rawEval), "error on unmarshaling Evaluation")
+	test.CheckIsErr(t, new(Evaluation).UnmarshalBinary(suite, rawEval[:len(rawEval)-1]), "truncated Evaluation must fail")
+
+	rawFinData, err := finData.MarshalBinary()
+	test.CheckNoErr(t, err, "error on marshaling FinalizeData")
+	finData = new(FinalizeData)
+	test.CheckNoErr(t, finData.UnmarshalBinary(suite, rawFinData), "error on unmarshaling FinalizeData")
 
 	clientOutputs, err := client.Finalize(finData, eval)
 	test.CheckNoErr(t, err, "invalid finalize of client")
@@ -133,6 +157,7 @@ func TestAPI(t *testing.T) {
 		SuiteP256,
 		SuiteP384,
 		SuiteP521,
+		SuiteDecaf448,
 	} {
 		t.Run(suite.(fmt.Stringer).String(), func(t *testing.T) {
 			private, err := GenerateKey(suite, rand.Reader)
@@ -281,23 +306,24 @@ func BenchmarkAPI(b *testing.B) {
 		SuiteP256,
 		SuiteP384,
 		SuiteP521,
+		SuiteDecaf448,
 	} {
 		key, err := GenerateKey(suite, rand.Reader)
 		test.CheckNoErr(b, err, "failed key generation")
 
-		b.Run("OPRF/"+suite.Name(), func(b *testing.B) {
+		b.Run("OPRF/"+suite.Identifier(), func(b *testing.B) {
 			s := NewServer(suite, key)
 			c := NewClient(suite)
 			benchAPI(b, s, c)
 		})
 
-		b.Run("VOPRF/"+suite.Name(), func(b *testing.B) {
+		b.Run("VOPRF/"+suite.Identifier(), func(b *testing.B) {
 			s := NewVerifiableServer(suite, key)
 			c := NewVerifiableClient(suite, s.PublicKey())
 			benchAPI(b, s, c)
 		})
 
-		b.Run("POPRF/"+suite.Name(), func(b *testing.B) {
+		b.Run("POPRF/"+suite.Identifier(), func(b *testing.B) {
 			info := []byte("shared info")
 			s := &s1{NewPartialObliviousServer(suite, key), info}
 			c := &c1{NewPartialObliviousClient(suite, s.PublicKey()), info}
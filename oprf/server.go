@@ -109,7 +109,7 @@ func (s server) fullEvaluate(input, info []byte) ([]byte, error) {
 		return nil, err
 	}
 
-	return s.finalizeHash(s.params.hash.New(), input, info, serEval), nil
+	return s.finalizeHash(s.params.newHash(), input, info, serEval), nil
 }
 
 func (s Server) FullEvaluate(input []byte) (output []byte, err error) {
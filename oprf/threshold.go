@@ -0,0 +1,239 @@
+package oprf
+
+import (
+	"crypto/rand"
+	"io"
+
+	"github.com/cloudflare/circl/group"
+	"github.com/cloudflare/circl/math/polynomial"
+	"github.com/cloudflare/circl/zk/dleq"
+)
+
+// KeyShare is the share of a private key held by one server.
+type KeyShare struct {
+	// ID is the non-zero point at which the sharing polynomial is evaluated.
+	ID  uint
+	Key *PrivateKey
+}
+
+// PublicKeyShare is the public key of a KeyShare.
+type PublicKeyShare struct {
+	ID  uint
+	Key *PublicKey
+}
+
+// Public returns the public key of the share.
+func (s *KeyShare) Public() PublicKeyShare {
+	return PublicKeyShare{s.ID, s.Key.Public()}
+}
+
+// SplitKey splits the private key into n shares, such that any t+1 of them
+// can evaluate the PRF. Randomness is read from rnd.
+func SplitKey(key *PrivateKey, t, n uint, rnd io.Reader) ([]KeyShare, error) {
+	if rnd == nil {
+		return nil, io.ErrNoProgress
+	}
+	if key == nil {
+		return nil, ErrNoKey
+	}
+	if n == 0 || t >= n {
+		return nil, ErrInvalidShares
+	}
+
+	g := key.p.group
+	coeffs := make([]group.Scalar, t+1)
+	coeffs[0] = key.k
+	for i := uint(1); i <= t; i++ {
+		coeffs[i] = g.RandomScalar(rnd)
+	}
+	poly := polynomial.New(coeffs)
+
+	shares := make([]KeyShare, n)
+	x := g.NewScalar()
+	for i := range shares {
+		id := uint(i + 1)
+		x.SetUint64(uint64(id))
+		shares[i] = KeyShare{id, &PrivateKey{key.p, poly.Evaluate(x), nil}}
+	}
+
+	return shares, nil
+}
+
+// GenerateThresholdKey generates a private key compatible with the suite,
+// and returns its public key and its split into n shares such that any t+1
+// of them can evaluate the PRF. The private key itself is not kept.
+func GenerateThresholdKey(s Suite, t, n uint, rnd io.Reader) (*PublicKey, []KeyShare, error) {
+	key, err := GenerateKey(s, rnd)
+	if err != nil {
+		return nil, nil, err
+	}
+	shares, err := SplitKey(key, t, n, rnd)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return key.Public(), shares, nil
+}
+
+// PartialEvaluation is the evaluation of a request by one server with its
+// key share.
+type PartialEvaluation struct {
+	ID uint
+	Evaluation
+}
+
+type ThresholdServer struct {
+	server
+	id uint
+}
+
+func NewThresholdServer(s Suite, share *KeyShare) ThresholdServer {
+	p, ok := s.(params)
+	if !ok || share == nil || share.Key == nil {
+		panic(ErrNoKey)
+	}
+	p.m = BaseMode
+
+	return ThresholdServer{server{p, share.Key}, share.ID}
+}
+
+// Evaluate evaluates the request with the key share, and proves that the
+// evaluation is consistent with the public key of the share.
+func (s ThresholdServer) Evaluate(req *EvaluationRequest) (*PartialEvaluation, error) {
+	evaluations := s.server.evaluate(req.Elements, s.privateKey.k)
+
+	proof, err := dleq.Prover{Params: s.getDLEQParams()}.ProveBatch(
+		s.privateKey.k,
+		s.params.group.Generator(),
+		s.PublicKey().e,
+		req.Elements,
+		evaluations,
+		rand.Reader,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PartialEvaluation{s.id, Evaluation{evaluations, proof}}, nil
+}
+
+type ThresholdClient struct {
+	client
+	t      uint
+	shares map[uint]*PublicKey
+}
+
+// NewThresholdClient returns a client for a key shared with threshold t.
+// It checks that the public keys of the shares lie on a polynomial of
+// degree t whose constant term is the public key pkS.
+func NewThresholdClient(s Suite, t uint, pkS *PublicKey, shares []PublicKeyShare) (ThresholdClient, error) {
+	p, ok := s.(params)
+	if !ok || pkS == nil {
+		panic(ErrNoKey)
+	}
+	p.m = BaseMode
+
+	if uint(len(shares)) <= t {
+		return ThresholdClient{}, ErrInvalidShares
+	}
+	ids := make([]uint, len(shares))
+	keys := make(map[uint]*PublicKey, len(shares))
+	for i := range shares {
+		id, key := shares[i].ID, shares[i].Key
+		if _, exists := keys[id]; exists || id == 0 || key == nil {
+			return ThresholdClient{}, ErrInvalidShares
+		}
+		ids[i] = id
+		keys[id] = key
+	}
+
+	base := make([]group.Element, t+1)
+	for i := range base {
+		base[i] = keys[ids[i]].e
+	}
+	x := p.group.NewScalar()
+	if !p.interpolate(ids[:t+1], base, x).IsEqual(pkS.e) {
+		return ThresholdClient{}, ErrInvalidShares
+	}
+	for _, id := range ids[t+1:] {
+		x.SetUint64(uint64(id))
+		if !p.interpolate(ids[:t+1], base, x).IsEqual(keys[id].e) {
+			return ThresholdClient{}, ErrInvalidShares
+		}
+	}
+
+	return ThresholdClient{client{p}, t, keys}, nil
+}
+
+// Finalize verifies the partial evaluations and combines t+1 of them to
+// produce the outputs. Partial evaluations with an unknown or repeated
+// identifier, or an invalid proof, are ignored; it fails if fewer than t+1
+// partial evaluations are valid.
+func (c ThresholdClient) Finalize(f *FinalizeData, evals []*PartialEvaluation) (outputs [][]byte, err error) {
+	verifier := dleq.Verifier{Params: c.getDLEQParams()}
+	ids := make([]uint, 0, c.t+1)
+	valid := make([]*PartialEvaluation, 0, c.t+1)
+	for _, e := range evals {
+		if uint(len(valid)) > c.t {
+			break
+		}
+		key, ok := c.shares[e.ID]
+		if !ok || contains(ids, e.ID) || c.validate(f, &e.Evaluation) != nil || e.Proof == nil {
+			continue
+		}
+		if !verifier.VerifyBatch(
+			c.params.group.Generator(),
+			key.e,
+			f.evalReq.Elements,
+			e.Elements,
+			e.Proof,
+		) {
+			continue
+		}
+		ids = append(ids, e.ID)
+		valid = append(valid, e)
+	}
+	if uint(len(valid)) <= c.t {
+		return nil, ErrInvalidShares
+	}
+
+	combined := make([]Evaluated, len(f.blinds))
+	points := make([]group.Element, len(valid))
+	zero := c.params.group.NewScalar()
+	for j := range combined {
+		for i := range valid {
+			points[i] = valid[i].Elements[j]
+		}
+		combined[j] = c.params.interpolate(ids, points, zero)
+	}
+
+	return c.client.finalize(f, &Evaluation{combined, nil}, nil)
+}
+
+// interpolate evaluates at x the polynomial in the exponent that takes the
+// given points at the given identifiers.
+func (p params) interpolate(ids []uint, points []group.Element, x group.Scalar) group.Element {
+	xi := make([]group.Scalar, len(ids))
+	for i := range ids {
+		xi[i] = p.group.NewScalar()
+		xi[i].SetUint64(uint64(ids[i]))
+	}
+
+	result := p.group.Identity()
+	term := p.group.NewElement()
+	for i := range points {
+		term.Mul(points[i], polynomial.LagrangeBase(uint(i), xi, x))
+		result.Add(result, term)
+	}
+
+	return result
+}
+
+func contains(ids []uint, id uint) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+	return false
+}
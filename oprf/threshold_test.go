@@ -0,0 +1,92 @@
+package oprf
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"testing"
+
+	"github.com/cloudflare/circl/internal/test"
+)
+
+func TestThreshold(t *testing.T) {
+	const threshold, n = 2, 5
+	inputs := [][]byte{[]byte("first input"), []byte("second input")}
+
+	for _, suite := range []Suite{
+		SuiteRistretto255,
+		SuiteP256,
+		SuiteDecaf448,
+	} {
+		t.Run(suite.(fmt.Stringer).String(), func(t *testing.T) {
+			private, err := GenerateKey(suite, rand.Reader)
+			test.CheckNoErr(t, err, "failed private key generation")
+			shares, err := SplitKey(private, threshold, n, rand.Reader)
+			test.CheckNoErr(t, err, "failed key splitting")
+
+			publicShares := make([]PublicKeyShare, n)
+			servers := make([]ThresholdServer, n)
+			for i := range shares {
+				publicShares[i] = shares[i].Public()
+				servers[i] = NewThresholdServer(suite, &shares[i])
+			}
+			client, err := NewThresholdClient(suite, threshold, private.Public(), publicShares)
+			test.CheckNoErr(t, err, "failed client creation")
+
+			finData, evalReq, err := client.Blind(inputs)
+			test.CheckNoErr(t, err, "failed blind")
+			evals := make([]*PartialEvaluation, n)
+			for i := range servers {
+				evals[i], err = servers[i].Evaluate(evalReq)
+				test.CheckNoErr(t, err, "failed evaluation")
+			}
+
+			single := NewServer(suite, private)
+			check := func(outputs [][]byte) {
+				t.Helper()
+				for i := range inputs {
+					want, err := single.FullEvaluate(inputs[i])
+					test.CheckNoErr(t, err, "failed full evaluation")
+					if !bytes.Equal(outputs[i], want) {
+						test.ReportError(t, outputs[i], want)
+					}
+				}
+			}
+
+			// Any t+1 partial evaluations give the output of the full key.
+			outputs, err := client.Finalize(finData, evals[:threshold+1])
+			test.CheckNoErr(t, err, "failed finalize")
+			check(outputs)
+			outputs, err = client.Finalize(finData, evals[n-threshold-1:])
+			test.CheckNoErr(t, err, "failed finalize")
+			check(outputs)
+
+			// Invalid partial evaluations are ignored.
+			bad := *evals[0]
+			bad.Elements = []Evaluated{evals[1].Elements[0], evals[0].Elements[1]}
+			outputs, err = client.Finalize(finData, append([]*PartialEvaluation{&bad}, evals[1:]...))
+			test.CheckNoErr(t, err, "failed finalize")
+			check(outputs)
+
+			_, err = client.Finalize(finData, evals[:threshold])
+			test.CheckIsErr(t, err, "not enough partial evaluations must fail")
+			_, err = client.Finalize(finData, []*PartialEvaluation{&bad, evals[1], evals[1], evals[2]})
+			test.CheckIsErr(t, err, "not enough valid partial evaluations must fail")
+
+			// Shares that do not match the public key are rejected.
+			other, err := GenerateKey(suite, rand.Reader)
+			test.CheckNoErr(t, err, "failed private key generation")
+			_, err = NewThresholdClient(suite, threshold, other.Public(), publicShares)
+			test.CheckIsErr(t, err, "wrong public key must fail")
+			wrong := append([]PublicKeyShare{}, publicShares...)
+			wrong[n-1].Key = other.Public()
+			_, err = NewThresholdClient(suite, threshold, private.Public(), wrong)
+			test.CheckIsErr(t, err, "inconsistent shares must fail")
+			_, err = NewThresholdClient(suite, threshold, private.Public(), publicShares[:threshold])
+			test.CheckIsErr(t, err, "not enough shares must fail")
+		})
+	}
+
+	_, err := SplitKey(new(PrivateKey), n, n, rand.Reader)
+	test.CheckIsErr(t, err, "threshold must be smaller than the number of shares")
+}
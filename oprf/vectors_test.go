@@ -18,16 +18,15 @@ import (
 )
 
 type vector struct {
-	ID       int    `json:"suiteID"`
-	Name     string `json:"suiteName"`
-	Mode     Mode   `json:"mode"`
-	Hash     string `json:"hash"`
-	PkSm     string `json:"pkSm"`
-	SkSm     string `json:"skSm"`
-	Seed     string `json:"seed"`
-	KeyInfo  string `json:"keyInfo"`
-	GroupDST string `json:"groupDST"`
-	Vectors  []struct {
+	Identifier string `json:"identifier"`
+	Mode       Mode   `json:"mode"`
+	Hash       string `json:"hash"`
+	PkSm       string `json:"pkSm"`
+	SkSm       string `json:"skSm"`
+	Seed       string `json:"seed"`
+	KeyInfo    string `json:"keyInfo"`
+	GroupDST   string `json:"groupDST"`
+	Vectors    []struct {
 		Batch             int    `json:"Batch"`
 		Blind             string `json:"Blind"`
 		Info              string `json:"Info"`
@@ -105,9 +104,10 @@ func readFile(t *testing.T, fileName string) []vector {
 }
 
 func (v *vector) SetUpParties(t *testing.T) (id params, s commonServer, c commonClient) {
-	suite, err := GetSuite(v.ID)
+	suite, err := GetSuite(v.Identifier)
 	test.CheckNoErr(t, err, "suite id")
 	seed := toBytes(t, v.Seed, "seed for key derivation")
+	test.CheckOk(len(seed) == 32, ErrInvalidSeed.Error(), t)
 	keyInfo := toBytes(t, v.KeyInfo, "info for key derivation")
 	privateKey, err := DeriveKey(suite, v.Mode, seed, keyInfo)
 	test.CheckNoErr(t, err, "deriving key")
@@ -137,7 +137,7 @@ func (v *vector) compareLists(t *testing.T, got, want [][]byte) {
 	t.Helper()
 	for i := range got {
 		if !bytes.Equal(got[i], want[i]) {
-			test.ReportError(t, got[i], want[i], v.Name, v.Mode, i)
+			test.ReportError(t, got[i], want[i], v.Identifier, v.Mode, i)
 		}
 	}
 }
@@ -145,7 +145,7 @@ func (v *vector) compareLists(t *testing.T, got, want [][]byte) {
 func (v *vector) compareBytes(t *testing.T, got, want []byte) {
 	t.Helper()
 	if !bytes.Equal(got, want) {
-		test.ReportError(t, got, want, v.Name, v.Mode)
+		test.ReportError(t, got, want, v.Identifier, v.Mode)
 	}
 }
 
@@ -229,7 +229,7 @@ func (v *vector) test(t *testing.T) {
 			got := output
 			want := expectedOutputs[j]
 			if !bytes.Equal(got, want) {
-				test.ReportError(t, got, want, v.Name, v.Mode, i, j)
+				test.ReportError(t, got, want, v.Identifier, v.Mode, i, j)
 			}
 
 			test.CheckOk(server.VerifyFinalize(inputs[j], output), "verify finalize", t)
@@ -238,15 +238,14 @@ func (v *vector) test(t *testing.T) {
 }
 
 func TestVectors(t *testing.T) {
-	// Draft published at https://datatracker.ietf.org/doc/html/draft-irtf-cfrg-voprf-10
+	// RFC-9497 published at https://www.rfc-editor.org/info/rfc9497
 	// Test vectors at https://github.com/cfrg/draft-irtf-cfrg-voprf
-	// Version supported: v10
-	v := readFile(t, "testdata/allVectors.json")
+	v := readFile(t, "testdata/rfc9497.json")
 
 	for i := range v {
-		suite, err := GetSuite(v[i].ID)
+		suite, err := GetSuite(v[i].Identifier)
 		if err != nil {
-			t.Logf(v[i].Name + " not supported yet")
+			t.Log(v[i].Identifier + " not supported yet")
 			continue
 		}
 		t.Run(fmt.Sprintf("%v/Mode%v", suite, v[i].Mode), v[i].test)
@@ -0,0 +1,49 @@
+package otext
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// bmul64 returns the 64 lower bits of the carry-less product of x and y, in
+// constant time. The integer multiplications leave holes of three bits
+// between the bits that are added, so the carries do not spread.
+func bmul64(x, y uint64) uint64 {
+	const (
+		m0 = 0x1111111111111111
+		m1 = 0x2222222222222222
+		m2 = 0x4444444444444444
+		m3 = 0x8888888888888888
+	)
+	x0, x1, x2, x3 := x&m0, x&m1, x&m2, x&m3
+	y0, y1, y2, y3 := y&m0, y&m1, y&m2, y&m3
+	z0 := (x0 * y0) ^ (x1 * y3) ^ (x2 * y2) ^ (x3 * y1)
+	z1 := (x0 * y1) ^ (x1 * y0) ^ (x2 * y3) ^ (x3 * y2)
+	z2 := (x0 * y2) ^ (x1 * y1) ^ (x2 * y0) ^ (x3 * y3)
+	z3 := (x0 * y3) ^ (x1 * y2) ^ (x2 * y1) ^ (x3 * y0)
+	return (z0 & m0) | (z1 & m1) | (z2 & m2) | (z3 & m3)
+}
+
+// clmul64 returns the carry-less product of x and y.
+func clmul64(x, y uint64) (lo, hi uint64) {
+	lo = bmul64(x, y)
+	hi = bits.Reverse64(bmul64(bits.Reverse64(x), bits.Reverse64(y))) >> 1
+	return
+}
+
+// mulAdd adds to z the carry-less product of a and b, as polynomials of
+// GF(2)[X]. The product is not reduced, so z holds 256 bits.
+func mulAdd(z *[4]uint64, a, b *block) {
+	a0, a1 := binary.LittleEndian.Uint64(a[:8]), binary.LittleEndian.Uint64(a[8:])
+	b0, b1 := binary.LittleEndian.Uint64(b[:8]), binary.LittleEndian.Uint64(b[8:])
+	// Karatsuba multiplication.
+	l0, l1 := clmul64(a0, b0)
+	h0, h1 := clmul64(a1, b1)
+	m0, m1 := clmul64(a0^a1, b0^b1)
+	m0 ^= l0 ^ h0
+	m1 ^= l1 ^ h1
+	z[0] ^= l0
+	z[1] ^= l1 ^ m0
+	z[2] ^= h0 ^ m1
+	z[3] ^= h1
+}
@@ -0,0 +1,26 @@
+package otext
+
+// Correction is sent by the receiver to extend a batch of OTs. Column j of
+// the matrix is the XOR of the expansions of both seeds of the base OT j and
+// of the choice bits.
+type Correction struct {
+	// Batch is the index of the batch, starting from zero.
+	Batch uint64
+	// N is the number of OTs of the batch.
+	N int
+	// U holds the columns of the matrix.
+	U [Kappa][]byte
+}
+
+// Challenge is sent by the sender to check the consistency of a Correction.
+type Challenge struct {
+	Seed [16]byte
+}
+
+// Response is sent by the receiver to answer a Challenge.
+type Response struct {
+	// X is the sum of the challenges weighted by the choice bits.
+	X [Kappa / 8]byte
+	// T is the sum of the products of the challenges and rows of the matrix.
+	T [4]uint64
+}
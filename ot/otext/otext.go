@@ -0,0 +1,147 @@
+// Package otext implements oblivious transfer extension.
+//
+// A Sender and a Receiver first run Kappa base OTs with the Simplest OT of
+// package simot, in reversed roles. They then extend them to batches of any
+// size of 1-out-of-2 OTs using the IKNP construction, which only needs
+// symmetric-key operations. The consistency check of Keller, Orsini and
+// Scholl makes the extension secure against a malicious receiver.
+//
+// Each extended OT is a random OT: the sender gets two random keys, and the
+// receiver gets the one of them selected by its choice bit. Random OTs are
+// turned into chosen-message OTs with Sender.Encrypt and Receiver.Decrypt.
+//
+// The correlation-robust hash is the tweakable construction
+// H(i, x) = π(π(x) ⊕ i) ⊕ π(x) of Guo et al., where π is AES-128 with a
+// fixed key, and the pseudo-random generator is AES-128 in counter mode.
+//
+// References:
+//   - IKNP: https://www.iacr.org/archive/crypto2003/27290145/27290145.pdf
+//   - KOS: https://eprint.iacr.org/2015/546
+//   - Fixed-key AES hashing: https://eprint.iacr.org/2019/074
+package otext
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+
+	"golang.org/x/crypto/sha3"
+)
+
+const (
+	// Kappa is the number of base OTs, which is the computational security
+	// parameter.
+	Kappa = 128
+	// KeySize is the size in bytes of the keys of random OTs.
+	KeySize = 16
+	// padding is the number of random OTs added to each batch to hide the
+	// choice bits during the consistency check. It is Kappa plus the
+	// statistical security parameter.
+	padding = Kappa + 64
+)
+
+var (
+	ErrInvalidRound   = errors.New("otext: invalid round")
+	ErrInvalidMessage = errors.New("otext: invalid message")
+	ErrInvalidLength  = errors.New("otext: invalid length")
+	ErrBaseOT         = errors.New("otext: base OT failed")
+	ErrCheckFailed    = errors.New("otext: consistency check failed")
+)
+
+// block holds 128 bits: a row of the extension matrix, a key, or an element
+// of GF(2)[X] of degree lower than 128.
+type block [Kappa / 8]byte
+
+func (b *block) xor(x, y *block) {
+	for i := range b {
+		b[i] = x[i] ^ y[i]
+	}
+}
+
+// fixedKey is the key of the permutation π of the hash.
+var fixedKey = func() cipher.Block {
+	var key [16]byte
+	s := sha3.NewShake128()
+	_, _ = s.Write([]byte("CIRCL-OTExtension-FixedKey"))
+	_, _ = s.Read(key[:])
+	c, err := aes.NewCipher(key[:])
+	if err != nil {
+		panic(err)
+	}
+	return c
+}()
+
+// hash returns H((batch, i), x).
+func hash(x *block, batch, i uint64) (out [KeySize]byte) {
+	var y, z block
+	fixedKey.Encrypt(y[:], x[:])
+	binary.BigEndian.PutUint64(z[:8], batch)
+	binary.BigEndian.PutUint64(z[8:], i)
+	z.xor(&z, &y)
+	fixedKey.Encrypt(z[:], z[:])
+	z.xor(&z, &y)
+	return z
+}
+
+// prg returns a stream of pseudo-random bytes expanded from the seed. The
+// stream for each batch is distinct.
+func prg(seed []byte, batch uint64) cipher.Stream {
+	c, err := aes.NewCipher(seed)
+	if err != nil {
+		panic(err)
+	}
+	var iv [aes.BlockSize]byte
+	binary.BigEndian.PutUint64(iv[:8], batch)
+	return cipher.NewCTR(c, iv[:])
+}
+
+// expand fills out with the stream expanded from the seed.
+func expand(seed []byte, batch uint64, out []byte) {
+	for i := range out {
+		out[i] = 0
+	}
+	prg(seed, batch).XORKeyStream(out, out)
+}
+
+// columns returns the number of bytes of a column of the extension matrix
+// for a batch of n OTs.
+func columns(n int) int { return (n+7)/8 + padding/8 }
+
+// transpose returns the rows of the matrix given by its Kappa columns, where
+// row i of column j is the bit i%8 of cols[j][i/8].
+func transpose(cols *[Kappa][]byte) []block {
+	rows := make([]block, 8*len(cols[0]))
+	for b := range cols[0] {
+		for j := 0; j < Kappa; j += 8 {
+			var x uint64
+			for k := 0; k < 8; k++ {
+				x |= uint64(cols[j+k][b]) << (8 * k)
+			}
+			x = transpose8(x)
+			for l := 0; l < 8; l++ {
+				rows[8*b+l][j/8] = byte(x >> (8 * l))
+			}
+		}
+	}
+	return rows
+}
+
+// transpose8 transposes the 8x8 bit matrix whose row k is the byte k of x.
+func transpose8(x uint64) uint64 {
+	t := (x ^ (x >> 7)) & 0x00AA00AA00AA00AA
+	x ^= t ^ (t << 7)
+	t = (x ^ (x >> 14)) & 0x0000CCCC0000CCCC
+	x ^= t ^ (t << 14)
+	t = (x ^ (x >> 28)) & 0x00000000F0F0F0F0
+	x ^= t ^ (t << 28)
+	return x
+}
+
+// bit returns the bit i of v as 0 or 1.
+func bit(v []byte, i int) byte { return (v[i/8] >> (i % 8)) & 1 }
+
+// pad encrypts msg with the key of an OT into dst.
+func pad(dst, msg []byte, key *[KeySize]byte) {
+	prg(key[:], 0).XORKeyStream(dst, msg)
+}
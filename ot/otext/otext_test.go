@@ -0,0 +1,187 @@
+package otext
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"testing"
+
+	"github.com/cloudflare/circl/group"
+	"github.com/cloudflare/circl/internal/test"
+)
+
+func setup(t testing.TB, g group.Group) (*Sender, *Receiver) {
+	s, r := NewSender(g), NewReceiver(g)
+	A, err := r.BaseRound1(rand.Reader)
+	test.CheckNoErr(t, err, "base round 1")
+	B, err := s.BaseRound2(rand.Reader, A)
+	test.CheckNoErr(t, err, "base round 2")
+	e0, e1, err := r.BaseRound3(B)
+	test.CheckNoErr(t, err, "base round 3")
+	test.CheckNoErr(t, s.BaseRound4(e0, e1), "base round 4")
+	return s, r
+}
+
+func extend(t testing.TB, s *Sender, r *Receiver, choices []byte, n int) error {
+	c, err := r.Extend(rand.Reader, choices, n)
+	test.CheckNoErr(t, err, "receiver extend")
+	ch, err := s.Extend(rand.Reader, c)
+	test.CheckNoErr(t, err, "sender extend")
+	resp, err := r.Respond(ch)
+	test.CheckNoErr(t, err, "receiver respond")
+	return s.Check(resp)
+}
+
+func randomChoices(t testing.TB, n int) []byte {
+	choices := make([]byte, (n+7)/8)
+	_, err := rand.Read(choices)
+	test.CheckNoErr(t, err, "random choices")
+	return choices
+}
+
+func TestOTExtension(t *testing.T) {
+	s, r := setup(t, group.P256)
+	for _, n := range []int{1, 13, 128, 1000} {
+		choices := randomChoices(t, n)
+		test.CheckNoErr(t, extend(t, s, r, choices, n), "check")
+
+		sk, rk := s.Keys(), r.Keys()
+		if len(sk) != n || len(rk) != n {
+			test.ReportError(t, len(rk), n)
+		}
+		for i := range rk {
+			c := bit(choices, i)
+			test.CheckOk(rk[i] == sk[i][c], "receiver must get the chosen key", t)
+			test.CheckOk(rk[i] != sk[i][1-c], "receiver must not get the other key", t)
+		}
+
+		m0, m1 := make([][]byte, n), make([][]byte, n)
+		for i := range m0 {
+			m0[i], m1[i] = make([]byte, i%40), make([]byte, i%40)
+			_, _ = rand.Read(m0[i])
+			_, _ = rand.Read(m1[i])
+		}
+		e0, e1, err := s.Encrypt(m0, m1)
+		test.CheckNoErr(t, err, "encrypt")
+		m, err := r.Decrypt(e0, e1)
+		test.CheckNoErr(t, err, "decrypt")
+		for i := range m {
+			want := m0[i]
+			if bit(choices, i) == 1 {
+				want = m1[i]
+			}
+			if !bytes.Equal(m[i], want) {
+				test.ReportError(t, m[i], want, i)
+			}
+		}
+	}
+}
+
+func TestMaliciousReceiver(t *testing.T) {
+	s, r := setup(t, group.Ristretto255)
+	const n = 100
+	c, err := r.Extend(rand.Reader, randomChoices(t, n), n)
+	test.CheckNoErr(t, err, "receiver extend")
+	// Uses a different choice bit of row 5 in half of the columns, to learn
+	// bits of delta.
+	for j := 0; j < Kappa/2; j++ {
+		c.U[j][0] ^= 1 << 5
+	}
+	ch, err := s.Extend(rand.Reader, c)
+	test.CheckNoErr(t, err, "sender extend")
+	resp, err := r.Respond(ch)
+	test.CheckNoErr(t, err, "receiver respond")
+	if err = s.Check(resp); err != ErrCheckFailed {
+		test.ReportError(t, err, ErrCheckFailed)
+	}
+
+	// The sender rejects any further batch.
+	c, err = r.Extend(rand.Reader, randomChoices(t, n), n)
+	test.CheckNoErr(t, err, "receiver extend")
+	if _, err = s.Extend(rand.Reader, c); err != ErrCheckFailed {
+		test.ReportError(t, err, ErrCheckFailed)
+	}
+}
+
+func TestInvalid(t *testing.T) {
+	g := group.P256
+	s, r := NewSender(g), NewReceiver(g)
+	if _, err := r.Extend(rand.Reader, []byte{0}, 8); err != ErrInvalidRound {
+		test.ReportError(t, err, ErrInvalidRound)
+	}
+	if err := s.BaseRound4(nil, nil); err != ErrInvalidRound {
+		test.ReportError(t, err, ErrInvalidRound)
+	}
+
+	s, r = setup(t, g)
+	if _, err := r.Extend(rand.Reader, []byte{0, 0}, 8); err != ErrInvalidLength {
+		test.ReportError(t, err, ErrInvalidLength)
+	}
+	c, err := r.Extend(rand.Reader, []byte{0}, 8)
+	test.CheckNoErr(t, err, "receiver extend")
+	c.Batch++
+	if _, err = s.Extend(rand.Reader, c); err != ErrInvalidMessage {
+		test.ReportError(t, err, ErrInvalidMessage)
+	}
+}
+
+func TestTranspose(t *testing.T) {
+	var cols [Kappa][]byte
+	for j := range cols {
+		cols[j] = make([]byte, 24)
+		_, _ = rand.Read(cols[j])
+	}
+	rows := transpose(&cols)
+	for i := range rows {
+		for j := 0; j < Kappa; j++ {
+			if bit(rows[i][:], j) != bit(cols[j], i) {
+				test.ReportError(t, bit(rows[i][:], j), bit(cols[j], i), i, j)
+			}
+		}
+	}
+}
+
+func TestMulAdd(t *testing.T) {
+	for k := 0; k < 100; k++ {
+		var a, b block
+		_, _ = rand.Read(a[:])
+		_, _ = rand.Read(b[:])
+		var got, want [4]uint64
+		mulAdd(&got, &a, &b)
+		for i := 0; i < Kappa; i++ {
+			if bit(b[:], i) == 1 {
+				for j := 0; j < Kappa; j++ {
+					want[(i+j)/64] ^= uint64(bit(a[:], j)) << ((i + j) % 64)
+				}
+			}
+		}
+		if got != want {
+			test.ReportError(t, got, want, a, b)
+		}
+	}
+	var z [4]uint64
+	a, b := block{}, block{}
+	binary.LittleEndian.PutUint64(a[8:], 1<<63)
+	binary.LittleEndian.PutUint64(b[8:], 1<<63)
+	mulAdd(&z, &a, &b)
+	test.CheckOk(z == [4]uint64{0, 0, 0, 1 << 62}, "wrong product of X^127 by X^127", t)
+}
+
+func BenchmarkOTExtension(b *testing.B) {
+	const n = 1 << 16
+	s, r := setup(b, group.P256)
+	choices := randomChoices(b, n)
+	b.Run("Base", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			setup(b, group.P256)
+		}
+	})
+	b.Run("Extend", func(b *testing.B) {
+		b.SetBytes(n)
+		for i := 0; i < b.N; i++ {
+			if err := extend(b, s, r, choices, n); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,169 @@
+package otext
+
+import (
+	"crypto/subtle"
+	"io"
+
+	"github.com/cloudflare/circl/group"
+	"github.com/cloudflare/circl/ot/simot"
+)
+
+const (
+	receiverBase = iota
+	receiverBaseOT
+	receiverReady
+	receiverCheck
+)
+
+// Receiver is the party of the OT extension that gets one of the two keys of
+// each OT, selected by its choice bits. It acts as the sender of the base
+// OTs.
+type Receiver struct {
+	g       group.Group
+	state   int
+	base    [Kappa]simot.Sender
+	seeds   [Kappa][2][]byte // The seeds sent with the base OTs
+	batch   uint64           // The index of the current batch
+	n       int              // The number of OTs of the current batch
+	choices []byte           // The padded choice bits of the current batch
+	t       []block          // The rows of the current batch
+	keys    [][KeySize]byte  // The keys of the last checked batch
+	chosen  []byte           // The choice bits of the last checked batch
+}
+
+// NewReceiver returns a Receiver running the base OTs in the group g.
+func NewReceiver(g group.Group) *Receiver { return &Receiver{g: g} }
+
+// BaseRound1 starts the base OTs by sampling their random seeds.
+// Output: the elements A of the base OTs, to be sent to the Sender.
+func (r *Receiver) BaseRound1(rnd io.Reader) ([]group.Element, error) {
+	if r.state != receiverBase {
+		return nil, ErrInvalidRound
+	}
+	A := make([]group.Element, Kappa)
+	for j := range r.base {
+		for b := range r.seeds[j] {
+			r.seeds[j][b] = make([]byte, KeySize)
+			if _, err := io.ReadFull(rnd, r.seeds[j][b]); err != nil {
+				return nil, err
+			}
+		}
+		A[j] = r.base[j].InitSender(r.g, r.seeds[j][0], r.seeds[j][1], j)
+	}
+	r.state = receiverBaseOT
+	return A, nil
+}
+
+// BaseRound3 encrypts the seeds of the base OTs.
+// Input: B, the elements of the base OTs from the Sender.
+// Output: e0, e1, the encryptions of the seeds, to be sent to the Sender.
+func (r *Receiver) BaseRound3(B []group.Element) (e0, e1 [][]byte, err error) {
+	if r.state != receiverBaseOT {
+		return nil, nil, ErrInvalidRound
+	}
+	if len(B) != Kappa {
+		return nil, nil, ErrInvalidMessage
+	}
+	e0, e1 = make([][]byte, Kappa), make([][]byte, Kappa)
+	for j := range r.base {
+		e0[j], e1[j] = r.base[j].Round2Sender(B[j])
+	}
+	r.state = receiverReady
+	return e0, e1, nil
+}
+
+// Extend starts a batch of n OTs. The choice bit of OT i is the bit i%8 of
+// choices[i/8], and choices must have (n+7)/8 bytes.
+// Output: the Correction to be sent to the Sender.
+func (r *Receiver) Extend(rnd io.Reader, choices []byte, n int) (*Correction, error) {
+	if r.state != receiverReady {
+		return nil, ErrInvalidRound
+	}
+	if n <= 0 || len(choices) != (n+7)/8 {
+		return nil, ErrInvalidLength
+	}
+
+	// The choice bits are padded with random ones.
+	size := columns(n)
+	r.choices = make([]byte, size+1)
+	if _, err := io.ReadFull(rnd, r.choices[len(choices)-1:]); err != nil {
+		return nil, err
+	}
+	last := r.choices[len(choices)-1]
+	copy(r.choices, choices)
+	if n%8 != 0 {
+		mask := byte(1)<<(n%8) - 1
+		r.choices[len(choices)-1] = (choices[len(choices)-1] & mask) | (last &^ mask)
+	}
+	r.choices = r.choices[:size]
+
+	c := &Correction{Batch: r.batch, N: n}
+	var t [Kappa][]byte
+	for j := range t {
+		t[j] = make([]byte, size)
+		expand(r.seeds[j][0], r.batch, t[j])
+		c.U[j] = make([]byte, size)
+		expand(r.seeds[j][1], r.batch, c.U[j])
+		for i := range c.U[j] {
+			c.U[j][i] ^= t[j][i] ^ r.choices[i]
+		}
+	}
+	r.t = transpose(&t)
+	r.n = n
+	r.state = receiverCheck
+	return c, nil
+}
+
+// Respond answers the consistency check of the current batch, and derives
+// the keys of its OTs.
+// Output: the Response to be sent to the Sender.
+func (r *Receiver) Respond(ch *Challenge) (*Response, error) {
+	if r.state != receiverCheck {
+		return nil, ErrInvalidRound
+	}
+
+	resp := new(Response)
+	chi := prg(ch.Seed[:], r.batch)
+	var x, zero block
+	for i := range r.t {
+		chi.XORKeyStream(x[:], zero[:])
+		mulAdd(&resp.T, &r.t[i], &x)
+		m := -bit(r.choices, i)
+		for k := range x {
+			resp.X[k] ^= x[k] & m
+		}
+	}
+
+	r.keys = make([][KeySize]byte, r.n)
+	for i := range r.keys {
+		r.keys[i] = hash(&r.t[i], r.batch, uint64(i))
+	}
+	r.chosen, r.choices, r.t = r.choices, nil, nil
+	r.batch++
+	r.state = receiverReady
+	return resp, nil
+}
+
+// Keys returns the keys of the OTs of the last batch. Key i is the key k_c
+// of the Sender, where c is the choice bit of OT i.
+func (r *Receiver) Keys() [][KeySize]byte { return r.keys }
+
+// Decrypt returns the messages of the chosen-message OTs of the last batch.
+// Input: e0, e1, the encryptions of the messages from Sender.Encrypt.
+func (r *Receiver) Decrypt(e0, e1 [][]byte) ([][]byte, error) {
+	if len(e0) != len(r.keys) || len(e1) != len(r.keys) {
+		return nil, ErrInvalidLength
+	}
+	m := make([][]byte, len(r.keys))
+	for i := range m {
+		if len(e0[i]) != len(e1[i]) {
+			return nil, ErrInvalidLength
+		}
+		c := int(bit(r.chosen, i))
+		m[i] = make([]byte, len(e0[i]))
+		subtle.ConstantTimeCopy(1-c, m[i], e0[i])
+		subtle.ConstantTimeCopy(c, m[i], e1[i])
+		pad(m[i], m[i], &r.keys[i])
+	}
+	return m, nil
+}
@@ -0,0 +1,183 @@
+package otext
+
+import (
+	"io"
+
+	"github.com/cloudflare/circl/group"
+	"github.com/cloudflare/circl/ot/simot"
+)
+
+const (
+	senderBase = iota
+	senderBaseOT
+	senderReady
+	senderCheck
+	senderAborted
+)
+
+// Sender is the party of the OT extension that gets both keys of each OT.
+// It acts as the receiver of the base OTs, with the bits of a secret delta
+// as choice bits.
+type Sender struct {
+	g     group.Group
+	state int
+	base  [Kappa]simot.Receiver
+	delta block              // The choice bits of the base OTs
+	seeds [Kappa][]byte      // The seeds received with the base OTs
+	batch uint64             // The index of the current batch
+	n     int                // The number of OTs of the current batch
+	seed  [16]byte           // The seed of the challenge of the current batch
+	q     []block            // The rows of the current batch
+	keys  [][2][KeySize]byte // The keys of the last checked batch
+}
+
+// NewSender returns a Sender running the base OTs in the group g.
+func NewSender(g group.Group) *Sender { return &Sender{g: g} }
+
+// BaseRound2 chooses the secret delta, which is used as the choice bits of
+// the base OTs.
+// Input: A, the elements of the base OTs from the Receiver.
+// Output: the elements B of the base OTs, to be sent to the Receiver.
+func (s *Sender) BaseRound2(rnd io.Reader, A []group.Element) ([]group.Element, error) {
+	if s.state != senderBase {
+		return nil, ErrInvalidRound
+	}
+	if len(A) != Kappa {
+		return nil, ErrInvalidMessage
+	}
+	if _, err := io.ReadFull(rnd, s.delta[:]); err != nil {
+		return nil, err
+	}
+	B := make([]group.Element, Kappa)
+	for j := range s.base {
+		B[j] = s.base[j].Round1Receiver(s.g, int(bit(s.delta[:], j)), j, A[j])
+	}
+	s.state = senderBaseOT
+	return B, nil
+}
+
+// BaseRound4 decrypts the seeds of the base OTs.
+// Input: e0, e1, the encryptions of the seeds from the Receiver.
+func (s *Sender) BaseRound4(e0, e1 [][]byte) error {
+	if s.state != senderBaseOT {
+		return ErrInvalidRound
+	}
+	if len(e0) != Kappa || len(e1) != Kappa {
+		return ErrInvalidMessage
+	}
+	for j := range s.base {
+		if s.base[j].Round3Receiver(e0[j], e1[j], int(bit(s.delta[:], j))) != nil {
+			s.state = senderAborted
+			return ErrBaseOT
+		}
+		s.seeds[j] = s.base[j].Returnmc()
+		if len(s.seeds[j]) != KeySize {
+			s.state = senderAborted
+			return ErrBaseOT
+		}
+	}
+	s.state = senderReady
+	return nil
+}
+
+// Extend receives a batch of OTs, and challenges the Receiver to prove that
+// its Correction is consistent.
+// Output: the Challenge to be sent to the Receiver.
+func (s *Sender) Extend(rnd io.Reader, c *Correction) (*Challenge, error) {
+	if s.state == senderAborted {
+		return nil, ErrCheckFailed
+	}
+	if s.state != senderReady {
+		return nil, ErrInvalidRound
+	}
+	if c.Batch != s.batch || c.N <= 0 {
+		return nil, ErrInvalidMessage
+	}
+	size := columns(c.N)
+	var q [Kappa][]byte
+	for j := range q {
+		if len(c.U[j]) != size {
+			return nil, ErrInvalidMessage
+		}
+		q[j] = make([]byte, size)
+		expand(s.seeds[j], s.batch, q[j])
+		m := -bit(s.delta[:], j)
+		for i := range q[j] {
+			q[j][i] ^= c.U[j][i] & m
+		}
+	}
+
+	ch := new(Challenge)
+	if _, err := io.ReadFull(rnd, ch.Seed[:]); err != nil {
+		return nil, err
+	}
+	s.q, s.n, s.seed = transpose(&q), c.N, ch.Seed
+	s.state = senderCheck
+	return ch, nil
+}
+
+// Check verifies the Response of the Receiver, and derives the keys of the
+// OTs of the current batch. If the check fails, the Sender aborts, and
+// rejects any further batch.
+func (s *Sender) Check(resp *Response) error {
+	if s.state == senderAborted {
+		return ErrCheckFailed
+	}
+	if s.state != senderCheck {
+		return ErrInvalidRound
+	}
+
+	// Checks that sum(chi_i*q_i) = sum(chi_i*t_i) + sum(chi_i*r_i)*delta.
+	var got [4]uint64
+	want := resp.T
+	mulAdd(&want, (*block)(&resp.X), &s.delta)
+	chi := prg(s.seed[:], s.batch)
+	var x, zero block
+	for i := range s.q {
+		chi.XORKeyStream(x[:], zero[:])
+		mulAdd(&got, &s.q[i], &x)
+	}
+	var diff uint64
+	for k := range got {
+		diff |= got[k] ^ want[k]
+	}
+	if diff != 0 {
+		s.q, s.state = nil, senderAborted
+		return ErrCheckFailed
+	}
+
+	s.keys = make([][2][KeySize]byte, s.n)
+	var q1 block
+	for i := range s.keys {
+		q1.xor(&s.q[i], &s.delta)
+		s.keys[i][0] = hash(&s.q[i], s.batch, uint64(i))
+		s.keys[i][1] = hash(&q1, s.batch, uint64(i))
+	}
+	s.q = nil
+	s.batch++
+	s.state = senderReady
+	return nil
+}
+
+// Keys returns the keys k_0 and k_1 of the OTs of the last batch.
+func (s *Sender) Keys() [][2][KeySize]byte { return s.keys }
+
+// Encrypt turns the OTs of the last batch into chosen-message OTs, where
+// the messages m0[i] and m1[i] must have the same length.
+// Output: e0, e1, the encryptions of the messages, to be sent to the
+// Receiver.
+func (s *Sender) Encrypt(m0, m1 [][]byte) (e0, e1 [][]byte, err error) {
+	if len(m0) != len(s.keys) || len(m1) != len(s.keys) {
+		return nil, nil, ErrInvalidLength
+	}
+	e0, e1 = make([][]byte, len(s.keys)), make([][]byte, len(s.keys))
+	for i := range s.keys {
+		if len(m0[i]) != len(m1[i]) {
+			return nil, nil, ErrInvalidLength
+		}
+		e0[i], e1[i] = make([]byte, len(m0[i])), make([]byte, len(m1[i]))
+		pad(e0[i], m0[i], &s.keys[i][0])
+		pad(e1[i], m1[i], &s.keys[i][1])
+	}
+	return e0, e1, nil
+}
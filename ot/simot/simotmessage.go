@@ -0,0 +1,220 @@
+package simot
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/cloudflare/circl/group"
+)
+
+var (
+	ErrInvalidMessage = errors.New("simot: invalid message")
+	ErrInvalidBatch   = errors.New("simot: invalid batch")
+)
+
+// InitMessage is sent by the sender with the elements A of a batch of OTs.
+type InitMessage struct {
+	A []group.Element
+}
+
+// ChoiceMessage is sent by the receiver with the elements B of a batch of
+// OTs, which hide its choice bits.
+type ChoiceMessage struct {
+	B []group.Element
+}
+
+// CiphertextMessage is sent by the sender with the encryptions e0 and e1 of
+// the messages of a batch of OTs.
+type CiphertextMessage struct {
+	E0, E1 [][]byte
+}
+
+func (m *InitMessage) MarshalBinary() ([]byte, error) { return marshalElements(m.A) }
+
+func (m *InitMessage) UnmarshalBinary(g group.Group, data []byte) error {
+	elts, err := unmarshalElements(g, data)
+	if err != nil {
+		return err
+	}
+	m.A = elts
+	return nil
+}
+
+func (m *ChoiceMessage) MarshalBinary() ([]byte, error) { return marshalElements(m.B) }
+
+func (m *ChoiceMessage) UnmarshalBinary(g group.Group, data []byte) error {
+	elts, err := unmarshalElements(g, data)
+	if err != nil {
+		return err
+	}
+	m.B = elts
+	return nil
+}
+
+// MarshalBinary encodes the number of OTs, followed by e0 and e1 of each OT
+// prefixed by their length.
+func (m *CiphertextMessage) MarshalBinary() ([]byte, error) {
+	if len(m.E0) != len(m.E1) {
+		return nil, ErrInvalidMessage
+	}
+	b := appendUint32(nil, len(m.E0))
+	for i := range m.E0 {
+		for _, e := range [][]byte{m.E0[i], m.E1[i]} {
+			b = append(appendUint32(b, len(e)), e...)
+		}
+	}
+	return b, nil
+}
+
+func (m *CiphertextMessage) UnmarshalBinary(data []byte) error {
+	if len(data) < 4 {
+		return ErrInvalidMessage
+	}
+	n := binary.BigEndian.Uint32(data)
+	data = data[4:]
+	// Each OT takes at least eight bytes.
+	if uint64(n) > uint64(len(data)/8) {
+		return ErrInvalidMessage
+	}
+	e := make([][]byte, 2*n)
+	for i := range e {
+		if len(data) < 4 {
+			return ErrInvalidMessage
+		}
+		l := binary.BigEndian.Uint32(data)
+		data = data[4:]
+		if uint64(l) > uint64(len(data)) {
+			return ErrInvalidMessage
+		}
+		e[i] = append([]byte{}, data[:l]...)
+		data = data[l:]
+	}
+	if len(data) != 0 {
+		return ErrInvalidMessage
+	}
+	m.E0, m.E1 = make([][]byte, n), make([][]byte, n)
+	for i := range m.E0 {
+		m.E0[i], m.E1[i] = e[2*i], e[2*i+1]
+	}
+	return nil
+}
+
+// marshalElements encodes the number of elements followed by their
+// compressed encodings. The identity is rejected, as it has no encoding of
+// the same size.
+func marshalElements(elts []group.Element) ([]byte, error) {
+	b := appendUint32(nil, len(elts))
+	for _, e := range elts {
+		if e.IsIdentity() {
+			return nil, ErrInvalidMessage
+		}
+		enc, err := e.MarshalBinaryCompress()
+		if err != nil {
+			return nil, err
+		}
+		b = append(b, enc...)
+	}
+	return b, nil
+}
+
+func unmarshalElements(g group.Group, data []byte) ([]group.Element, error) {
+	size := int(g.Params().CompressedElementLength)
+	if len(data) < 4 || (len(data)-4)%size != 0 {
+		return nil, ErrInvalidMessage
+	}
+	n := binary.BigEndian.Uint32(data)
+	data = data[4:]
+	if uint64(n) != uint64(len(data)/size) {
+		return nil, ErrInvalidMessage
+	}
+	elts := make([]group.Element, n)
+	for i := range elts {
+		elts[i] = g.NewElement()
+		if elts[i].UnmarshalBinary(data[i*size:(i+1)*size]) != nil || elts[i].IsIdentity() {
+			return nil, ErrInvalidMessage
+		}
+	}
+	return elts, nil
+}
+
+func appendUint32(b []byte, n int) []byte {
+	var buf [4]byte
+	binary.BigEndian.PutUint32(buf[:], uint32(n))
+	return append(b, buf[:]...)
+}
+
+// BatchSender runs the sender of a batch of OTs.
+type BatchSender struct {
+	senders []Sender
+}
+
+// BatchReceiver runs the receiver of a batch of OTs.
+type BatchReceiver struct {
+	receivers []Receiver
+	choices   []int
+}
+
+// Init starts a batch of OTs, where OT i transfers m0[i] or m1[i].
+// Output: the InitMessage to be sent to the receiver.
+func (b *BatchSender) Init(myGroup group.Group, m0, m1 [][]byte) (*InitMessage, error) {
+	if len(m0) == 0 || len(m0) != len(m1) {
+		return nil, ErrInvalidBatch
+	}
+	b.senders = make([]Sender, len(m0))
+	msg := &InitMessage{A: make([]group.Element, len(m0))}
+	for i := range b.senders {
+		msg.A[i] = b.senders[i].InitSender(myGroup, m0[i], m1[i], i)
+	}
+	return msg, nil
+}
+
+// Round1 hides the choice bits, which must be 0 or 1, of a batch of OTs.
+// Output: the ChoiceMessage to be sent to the sender.
+func (b *BatchReceiver) Round1(myGroup group.Group, choices []int, msg *InitMessage) (*ChoiceMessage, error) {
+	if len(choices) == 0 || len(choices) != len(msg.A) {
+		return nil, ErrInvalidBatch
+	}
+	for _, c := range choices {
+		if c != 0 && c != 1 {
+			return nil, ErrInvalidBatch
+		}
+	}
+	b.receivers = make([]Receiver, len(choices))
+	b.choices = append([]int{}, choices...)
+	out := &ChoiceMessage{B: make([]group.Element, len(choices))}
+	for i := range b.receivers {
+		out.B[i] = b.receivers[i].Round1Receiver(myGroup, choices[i], i, msg.A[i])
+	}
+	return out, nil
+}
+
+// Round2 encrypts the messages of the batch of OTs.
+// Output: the CiphertextMessage to be sent to the receiver.
+func (b *BatchSender) Round2(msg *ChoiceMessage) (*CiphertextMessage, error) {
+	if len(b.senders) == 0 || len(msg.B) != len(b.senders) {
+		return nil, ErrInvalidBatch
+	}
+	out := &CiphertextMessage{E0: make([][]byte, len(b.senders)), E1: make([][]byte, len(b.senders))}
+	for i := range b.senders {
+		out.E0[i], out.E1[i] = b.senders[i].Round2Sender(msg.B[i])
+	}
+	return out, nil
+}
+
+// Round3 decrypts the chosen messages of the batch of OTs.
+func (b *BatchReceiver) Round3(msg *CiphertextMessage) ([][]byte, error) {
+	if len(b.receivers) == 0 || len(msg.E0) != len(b.receivers) || len(msg.E1) != len(b.receivers) {
+		return nil, ErrInvalidBatch
+	}
+	mc := make([][]byte, len(b.receivers))
+	for i := range b.receivers {
+		if len(msg.E0[i]) != len(msg.E1[i]) {
+			return nil, ErrInvalidMessage
+		}
+		if err := b.receivers[i].Round3Receiver(msg.E0[i], msg.E1[i], b.choices[i]); err != nil {
+			return nil, err
+		}
+		mc[i] = b.receivers[i].Returnmc()
+	}
+	return mc, nil
+}
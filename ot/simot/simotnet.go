@@ -0,0 +1,101 @@
+package simot
+
+import (
+	"encoding"
+	"encoding/binary"
+	"io"
+	"net"
+
+	"github.com/cloudflare/circl/group"
+)
+
+// maxMessageSize bounds the size of the messages read from a connection.
+const maxMessageSize = 1 << 28
+
+// RunSender runs the sender of a batch of OTs with the receiver at the other
+// end of conn, where OT i transfers m0[i] or m1[i].
+func RunSender(conn net.Conn, myGroup group.Group, m0, m1 [][]byte) error {
+	var b BatchSender
+	init, err := b.Init(myGroup, m0, m1)
+	if err != nil {
+		return err
+	}
+	if err = writeMessage(conn, init); err != nil {
+		return err
+	}
+
+	data, err := readMessage(conn)
+	if err != nil {
+		return err
+	}
+	var choice ChoiceMessage
+	if err = choice.UnmarshalBinary(myGroup, data); err != nil {
+		return err
+	}
+	ct, err := b.Round2(&choice)
+	if err != nil {
+		return err
+	}
+	return writeMessage(conn, ct)
+}
+
+// RunReceiver runs the receiver of a batch of OTs with the sender at the
+// other end of conn, and returns the messages selected by the choice bits.
+func RunReceiver(conn net.Conn, myGroup group.Group, choices []int) ([][]byte, error) {
+	var b BatchReceiver
+	data, err := readMessage(conn)
+	if err != nil {
+		return nil, err
+	}
+	var init InitMessage
+	if err = init.UnmarshalBinary(myGroup, data); err != nil {
+		return nil, err
+	}
+	choice, err := b.Round1(myGroup, choices, &init)
+	if err != nil {
+		return nil, err
+	}
+	if err = writeMessage(conn, choice); err != nil {
+		return nil, err
+	}
+
+	data, err = readMessage(conn)
+	if err != nil {
+		return nil, err
+	}
+	var ct CiphertextMessage
+	if err = ct.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return b.Round3(&ct)
+}
+
+// writeMessage writes the encoding of m prefixed by its length.
+func writeMessage(w io.Writer, m encoding.BinaryMarshaler) error {
+	data, err := m.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	if len(data) > maxMessageSize {
+		return ErrInvalidMessage
+	}
+	_, err = w.Write(append(appendUint32(nil, len(data)), data...))
+	return err
+}
+
+// readMessage reads a message prefixed by its length.
+func readMessage(r io.Reader) ([]byte, error) {
+	var l [4]byte
+	if _, err := io.ReadFull(r, l[:]); err != nil {
+		return nil, err
+	}
+	n := binary.BigEndian.Uint32(l[:])
+	if n > maxMessageSize {
+		return nil, ErrInvalidMessage
+	}
+	data := make([]byte, n)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
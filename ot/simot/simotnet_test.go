@@ -0,0 +1,123 @@
+package simot
+
+import (
+	"bytes"
+	"crypto/rand"
+	"net"
+	"testing"
+
+	"github.com/cloudflare/circl/group"
+	"github.com/cloudflare/circl/internal/test"
+)
+
+func randomMessages(t *testing.T, n, size int) [][]byte {
+	m := make([][]byte, n)
+	for i := range m {
+		m[i] = make([]byte, size)
+		_, err := rand.Read(m[i])
+		test.CheckNoErr(t, err, "random message")
+	}
+	return m
+}
+
+func TestRunOverPipe(t *testing.T) {
+	for _, g := range []group.Group{group.P256, group.Ristretto255} {
+		const n = 20
+		m0, m1 := randomMessages(t, n, 32), randomMessages(t, n, 32)
+		choices := make([]int, n)
+		for i := range choices {
+			choices[i] = (i / 3) % 2
+		}
+
+		sc, rc := net.Pipe()
+		errCh := make(chan error, 1)
+		go func() {
+			defer sc.Close()
+			errCh <- RunSender(sc, g, m0, m1)
+		}()
+		mc, err := RunReceiver(rc, g, choices)
+		test.CheckNoErr(t, err, "receiver")
+		test.CheckNoErr(t, <-errCh, "sender")
+		rc.Close()
+
+		for i := range mc {
+			want := m0[i]
+			if choices[i] == 1 {
+				want = m1[i]
+			}
+			if !bytes.Equal(mc[i], want) {
+				test.ReportError(t, mc[i], want, g, i)
+			}
+		}
+	}
+}
+
+func TestMessages(t *testing.T) {
+	g := group.P384
+	const n = 5
+	var s BatchSender
+	var r BatchReceiver
+	m0, m1 := randomMessages(t, n, 16), randomMessages(t, n, 16)
+	choices := []int{0, 1, 1, 0, 1}
+
+	init, err := s.Init(g, m0, m1)
+	test.CheckNoErr(t, err, "init")
+	enc, err := init.MarshalBinary()
+	test.CheckNoErr(t, err, "marshal init")
+	init = new(InitMessage)
+	test.CheckNoErr(t, init.UnmarshalBinary(g, enc), "unmarshal init")
+	test.CheckIsErr(t, init.UnmarshalBinary(g, enc[:len(enc)-1]), "must fail on truncated message")
+
+	choice, err := r.Round1(g, choices, init)
+	test.CheckNoErr(t, err, "round 1")
+	enc, err = choice.MarshalBinary()
+	test.CheckNoErr(t, err, "marshal choice")
+	choice = new(ChoiceMessage)
+	test.CheckNoErr(t, choice.UnmarshalBinary(g, enc), "unmarshal choice")
+
+	ct, err := s.Round2(choice)
+	test.CheckNoErr(t, err, "round 2")
+	enc, err = ct.MarshalBinary()
+	test.CheckNoErr(t, err, "marshal ciphertexts")
+	ct = new(CiphertextMessage)
+	test.CheckNoErr(t, ct.UnmarshalBinary(enc), "unmarshal ciphertexts")
+	test.CheckIsErr(t, ct.UnmarshalBinary(append(enc, 0)), "must fail on trailing bytes")
+
+	mc, err := r.Round3(ct)
+	test.CheckNoErr(t, err, "round 3")
+	for i := range mc {
+		want := m0[i]
+		if choices[i] == 1 {
+			want = m1[i]
+		}
+		if !bytes.Equal(mc[i], want) {
+			test.ReportError(t, mc[i], want, i)
+		}
+	}
+
+	// Tampered ciphertexts fail to decrypt.
+	ct.E1[1][0] ^= 1
+	_, err = r.Round3(ct)
+	test.CheckIsErr(t, err, "must fail on tampered ciphertext")
+}
+
+func TestInvalidBatch(t *testing.T) {
+	g := group.P256
+	var s BatchSender
+	var r BatchReceiver
+	if _, err := s.Init(g, randomMessages(t, 2, 16), randomMessages(t, 3, 16)); err != ErrInvalidBatch {
+		test.ReportError(t, err, ErrInvalidBatch)
+	}
+	init, err := s.Init(g, randomMessages(t, 2, 16), randomMessages(t, 2, 16))
+	test.CheckNoErr(t, err, "init")
+	if _, err = r.Round1(g, []int{0, 2}, init); err != ErrInvalidBatch {
+		test.ReportError(t, err, ErrInvalidBatch)
+	}
+	if _, err = r.Round1(g, []int{0}, init); err != ErrInvalidBatch {
+		test.ReportError(t, err, ErrInvalidBatch)
+	}
+	init.A[0] = g.Identity()
+	if _, err = init.MarshalBinary(); err != ErrInvalidMessage {
+		test.ReportError(t, err, ErrInvalidMessage)
+	}
+}
@@ -0,0 +1,130 @@
+package simotn
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"testing"
+
+	"github.com/cloudflare/circl/group"
+	"github.com/cloudflare/circl/internal/test"
+)
+
+var allGroups = []group.Group{group.P256, group.P384, group.P521, group.Ristretto255}
+
+func randomMessages(t testing.TB, n int, myGroup group.Group) [][]byte {
+	m := make([][]byte, n)
+	for j := range m {
+		m[j] = make([]byte, myGroup.Params().ScalarLength)
+		_, err := rand.Read(m[j])
+		test.CheckNoErr(t, err, "random message")
+	}
+	return m
+}
+
+func simOTN(myGroup group.Group, sender *Sender, receiver *Receiver, m [][]byte, choice, index int) error {
+	A := sender.InitSender(myGroup, m, index)
+	B := receiver.Round1Receiver(myGroup, choice, index, A)
+	e := sender.Round2Sender(B)
+	return receiver.Round3Receiver(e, choice)
+}
+
+func TestSimOTN(t *testing.T) {
+	const n = 7
+	for _, g := range allGroups {
+		m := randomMessages(t, n, g)
+		for choice := 0; choice < n; choice++ {
+			var sender Sender
+			var receiver Receiver
+			test.CheckNoErr(t, simOTN(g, &sender, &receiver, m, choice, choice), "OT failed")
+			if !bytes.Equal(receiver.Returnmc(), m[choice]) {
+				test.ReportError(t, receiver.Returnmc(), m[choice], g, choice)
+			}
+		}
+	}
+}
+
+func TestSimOTNNegative(t *testing.T) {
+	const n = 5
+	g := group.P256
+	m := randomMessages(t, n, g)
+	for choice := 0; choice < n; choice++ {
+		var sender Sender
+		var receiver Receiver
+		A := sender.InitSender(g, m, 0)
+		B := receiver.Round1Receiver(g, choice, 0, A)
+		e := sender.Round2Sender(B)
+
+		// The receiver cannot decrypt any message but the chosen one.
+		for other := 0; other < n; other++ {
+			if other != choice {
+				test.CheckIsErr(t, receiver.Round3Receiver(e, other), "decryption of another message must fail")
+				test.CheckOk(receiver.Returnmc() == nil, "receiver must not learn another message", t)
+			}
+		}
+		if err := receiver.Round3Receiver(e, n); err != ErrInvalidChoice {
+			test.ReportError(t, err, ErrInvalidChoice)
+		}
+		e[0] = e[0][1:]
+		if err := receiver.Round3Receiver(e, choice); err != ErrInvalidCiphertext {
+			test.ReportError(t, err, ErrInvalidCiphertext)
+		}
+	}
+}
+
+func TestBatch(t *testing.T) {
+	const n, k = 10, 4
+	g := group.Ristretto255
+	m := randomMessages(t, n, g)
+	choices := []int{9, 0, 3, 4}
+
+	var sender BatchSender
+	var receiver BatchReceiver
+	A, err := sender.InitSender(g, m, k)
+	test.CheckNoErr(t, err, "init")
+	B, err := receiver.Round1Receiver(g, choices, n, A)
+	test.CheckNoErr(t, err, "round 1")
+	e, err := sender.Round2Sender(B)
+	test.CheckNoErr(t, err, "round 2")
+	mc, err := receiver.Round3Receiver(e)
+	test.CheckNoErr(t, err, "round 3")
+	for i, c := range choices {
+		if !bytes.Equal(mc[i], m[c]) {
+			test.ReportError(t, mc[i], m[c], i, c)
+		}
+	}
+
+	// The key of an OT does not decrypt the messages of another OT.
+	e[0], e[1] = e[1], e[0]
+	_, err = receiver.Round3Receiver(e)
+	test.CheckIsErr(t, err, "must fail on swapped ciphertexts")
+
+	if _, err = sender.InitSender(g, m, n+1); err != ErrInvalidBatch {
+		test.ReportError(t, err, ErrInvalidBatch)
+	}
+	for _, c := range [][]int{{1, 2, 1, 3}, {1, 2, 3, n}, {-1, 0, 1, 2}} {
+		if _, err = receiver.Round1Receiver(g, c, n, A); err != ErrInvalidChoice {
+			test.ReportError(t, err, ErrInvalidChoice, c)
+		}
+	}
+	if _, err = receiver.Round1Receiver(g, choices[:2], n, A); err != ErrInvalidBatch {
+		test.ReportError(t, err, ErrInvalidBatch)
+	}
+}
+
+func BenchmarkSimOTN(b *testing.B) {
+	for _, g := range allGroups {
+		for _, n := range []int{2, 16, 256} {
+			m := randomMessages(b, n, g)
+			b.Run(fmt.Sprintf("%v/N=%v", g, n), func(b *testing.B) {
+				var sender Sender
+				var receiver Receiver
+				for i := 0; i < b.N; i++ {
+					if err := simOTN(g, &sender, &receiver, m, i%n, 0); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
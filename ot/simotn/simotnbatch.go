@@ -0,0 +1,99 @@
+package simotn
+
+import (
+	"errors"
+
+	"github.com/cloudflare/circl/group"
+)
+
+var ErrInvalidBatch = errors.New("simotn: invalid batch")
+
+// BatchSender runs the sender of a k-out-of-N OT, made of k 1-out-of-N OTs
+// of the same N messages.
+type BatchSender struct {
+	senders []Sender
+}
+
+// BatchReceiver runs the receiver of a k-out-of-N OT.
+type BatchReceiver struct {
+	receivers []Receiver
+	choices   []int
+}
+
+// Input: myGroup, the group we operate in
+// Input: m, the N messages of the sender, which must have the same length
+// Input: k, the number of messages the receiver gets
+// Output: A, one element for each of the k OTs
+func (b *BatchSender) InitSender(myGroup group.Group, m [][]byte, k int) ([]group.Element, error) {
+	if k <= 0 || k > len(m) {
+		return nil, ErrInvalidBatch
+	}
+	for j := range m {
+		if len(m[j]) != len(m[0]) {
+			return nil, ErrInvalidBatch
+		}
+	}
+	b.senders = make([]Sender, k)
+	A := make([]group.Element, k)
+	for i := range b.senders {
+		A[i] = b.senders[i].InitSender(myGroup, m, i)
+	}
+	return A, nil
+}
+
+// Input: myGroup, the group we operate in
+// Input: choices, k distinct choices in [0, N)
+// Input: n, the number N of messages
+// Input: A, from sender
+// Output: B, one element for each of the k OTs
+func (b *BatchReceiver) Round1Receiver(myGroup group.Group, choices []int, n int, A []group.Element) ([]group.Element, error) {
+	if len(choices) == 0 || len(choices) != len(A) {
+		return nil, ErrInvalidBatch
+	}
+	for i, c := range choices {
+		if c < 0 || c >= n {
+			return nil, ErrInvalidChoice
+		}
+		for _, d := range choices[:i] {
+			if c == d {
+				return nil, ErrInvalidChoice
+			}
+		}
+	}
+	b.receivers = make([]Receiver, len(choices))
+	b.choices = append([]int{}, choices...)
+	B := make([]group.Element, len(choices))
+	for i := range b.receivers {
+		B[i] = b.receivers[i].Round1Receiver(myGroup, choices[i], i, A[i])
+	}
+	return B, nil
+}
+
+// Input: B from the receiver
+// Output: e, the encryptions of the N messages for each of the k OTs
+func (b *BatchSender) Round2Sender(B []group.Element) ([][][]byte, error) {
+	if len(b.senders) == 0 || len(B) != len(b.senders) {
+		return nil, ErrInvalidBatch
+	}
+	e := make([][][]byte, len(b.senders))
+	for i := range b.senders {
+		e[i] = b.senders[i].Round2Sender(B[i])
+	}
+	return e, nil
+}
+
+// Input: e, the encryptions of the messages from the sender
+// Output: the k chosen messages, in the order of the choices
+func (b *BatchReceiver) Round3Receiver(e [][][]byte) ([][]byte, error) {
+	if len(b.receivers) == 0 || len(e) != len(b.receivers) {
+		return nil, ErrInvalidBatch
+	}
+	mc := make([][]byte, len(b.receivers))
+	for i := range b.receivers {
+		if err := b.receivers[i].Round3Receiver(e[i], b.choices[i]); err != nil {
+			return nil, err
+		}
+		mc[i] = b.receivers[i].Returnmc()
+	}
+	return mc, nil
+}
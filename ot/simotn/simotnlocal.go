@@ -0,0 +1,211 @@
+package simotn
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"github.com/cloudflare/circl/group"
+	"golang.org/x/crypto/sha3"
+)
+
+const keyLength = 16
+
+var (
+	ErrInvalidChoice     = errors.New("simotn: invalid choice")
+	ErrInvalidCiphertext = errors.New("simotn: invalid ciphertexts")
+)
+
+// AES GCM encryption, we don't need to pad because our input is fixed length
+// Need to use authenticated encryption to defend against tampering on ciphertext
+// Input: key, plaintext message
+// Output: ciphertext
+func aesEncGCM(key, plaintext []byte) []byte {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		panic(err)
+	}
+
+	aesgcm, err := cipher.NewGCM(block)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	nonce := make([]byte, aesgcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		panic(err)
+	}
+
+	ciphertext := aesgcm.Seal(nonce, nonce, plaintext, nil)
+	return ciphertext
+}
+
+// AES GCM decryption
+// Input: key, ciphertext message
+// Output: plaintext
+func aesDecGCM(key, ciphertext []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		panic(err)
+	}
+	aesgcm, err := cipher.NewGCM(block)
+	if err != nil {
+		panic(err.Error())
+	}
+	nonceSize := aesgcm.NonceSize()
+	if len(ciphertext) < nonceSize {
+		return nil, errors.New("ciphertext too short")
+	}
+
+	nonce, encryptedMessage := ciphertext[:nonceSize], ciphertext[nonceSize:]
+
+	plaintext, err := aesgcm.Open(nil, nonce, encryptedMessage, nil)
+
+	return plaintext, err
+}
+
+// deriveKey hashes the transcript A|B|P of the OT with the given index, and
+// the index j of the message, into a key.
+func deriveKey(index, j int, A, B, P group.Element) []byte {
+	s := sha3.NewShake128()
+	var buf [8]byte
+	binary.BigEndian.PutUint32(buf[:4], uint32(index))
+	binary.BigEndian.PutUint32(buf[4:], uint32(j))
+	_, errWrite := s.Write(buf[:])
+	if errWrite != nil {
+		panic(errWrite)
+	}
+	for _, e := range []group.Element{A, B, P} {
+		eByte, errByte := e.MarshalBinary()
+		if errByte != nil {
+			panic(errByte)
+		}
+		_, errWrite = s.Write(eByte)
+		if errWrite != nil {
+			panic(errWrite)
+		}
+	}
+	key := make([]byte, keyLength)
+	_, errRead := s.Read(key)
+	if errRead != nil {
+		panic(errRead)
+	}
+	return key
+}
+
+// Initialization
+
+// Input: myGroup, the group we operate in
+// Input: m, the N messages of the sender, which must have the same length
+// Input: index, the index of this OT
+// Output: A = [a]G, a the sender randomness
+func (sender *Sender) InitSender(myGroup group.Group, m [][]byte, index int) group.Element {
+	sender.a = myGroup.RandomNonZeroScalar(rand.Reader)
+	sender.m = m
+	sender.index = index
+	sender.A = myGroup.NewElement()
+	sender.A.MulGen(sender.a)
+	sender.myGroup = myGroup
+	return sender.A.Copy()
+}
+
+// Round 1
+
+// ---- sender should send A to receiver ----
+
+// Input: myGroup, the group we operate in
+// Input: choice, the receiver choice in [0, N)
+// Input: index, the index of this OT
+// Input: A, from sender
+// Output: B = [b]G + [choice]A, b the receiver randomness
+func (receiver *Receiver) Round1Receiver(myGroup group.Group, choice int, index int, A group.Element) group.Element {
+	receiver.b = myGroup.RandomNonZeroScalar(rand.Reader)
+	receiver.c = choice
+	receiver.index = index
+	receiver.A = A
+	receiver.myGroup = myGroup
+
+	cA := myGroup.NewElement()
+	cA.Mul(A, myGroup.NewScalar().SetUint64(uint64(choice)))
+	receiver.B = myGroup.NewElement()
+	receiver.B.MulGen(receiver.b)
+	receiver.B.Add(receiver.B, cA)
+
+	return receiver.B.Copy()
+}
+
+// Round 2
+
+// ---- receiver should send B to sender ----
+
+// Input: B from the receiver
+// Output: e, the encryptions of the messages m_j under the keys k_j
+// derived from [a](B - [j]A)
+func (sender *Sender) Round2Sender(B group.Element) [][]byte {
+	sender.B = B
+
+	// P_0 = [a]B, and P_{j+1} = P_j - [a]A.
+	maA := sender.myGroup.NewElement()
+	maA.Mul(sender.A, sender.a)
+	maA.Neg(maA)
+	P := sender.myGroup.NewElement()
+	P.Mul(sender.B, sender.a)
+
+	sender.k = make([][]byte, len(sender.m))
+	sender.e = make([][]byte, len(sender.m))
+	for j := range sender.m {
+		sender.k[j] = deriveKey(sender.index, j, sender.A, sender.B, P)
+		sender.e[j] = aesEncGCM(sender.k[j], sender.m[j])
+		P.Add(P, maA)
+	}
+
+	return sender.e
+}
+
+// Round 3
+
+// ---- sender should send e to receiver ----
+
+// Input: e, the encryptions of the messages from the sender, which must
+// have the same length
+// Input: choice, choice of receiver
+// Choose e_choice in constant time, and decrypt it
+func (receiver *Receiver) Round3Receiver(e [][]byte, choice int) error {
+	if choice < 0 || choice >= len(e) {
+		return ErrInvalidChoice
+	}
+	receiver.ec = make([]byte, len(e[0]))
+	for j := range e {
+		if len(e[j]) != len(receiver.ec) {
+			return ErrInvalidCiphertext
+		}
+		subtle.ConstantTimeCopy(subtle.ConstantTimeEq(int32(j), int32(choice)), receiver.ec, e[j])
+	}
+
+	bA := receiver.myGroup.NewElement()
+	bA.Mul(receiver.A, receiver.b)
+	// kR, decryption key of mc
+	receiver.kR = deriveKey(receiver.index, choice, receiver.A, receiver.B, bA)
+	mc, errDec := aesDecGCM(receiver.kR, receiver.ec)
+	if errDec != nil {
+		return errDec
+	}
+	receiver.mc = mc
+	return nil
+}
+
+func (receiver *Receiver) Returnmc() []byte {
+	return receiver.mc
+}
+
+func (sender *Sender) Returne() [][]byte {
+	return sender.e
+}
+
+func (sender *Sender) Returnm() [][]byte {
+	return sender.m
+}
@@ -0,0 +1,34 @@
+// Package simotn provides 1-out-of-N oblivious transfer, which generalizes
+// the Simplest OT of package simot to N messages.
+//
+// The sender sends A = [a]G, and the receiver with choice c in [0, N) answers
+// B = [b]G + [c]A. The key of message j is derived from [a](B - [j]A), which
+// the receiver computes as [b]A only for j = c.
+//
+// Reference: https://eprint.iacr.org/2015/267.pdf
+package simotn
+
+import "github.com/cloudflare/circl/group"
+
+type Sender struct {
+	index   int           // Indicate which OT
+	m       [][]byte      // The N messages from sender
+	a       group.Scalar  // The randomness of the sender
+	A       group.Element // [a]G
+	B       group.Element // The random group element from the receiver
+	k       [][]byte      // The encryption keys of the messages
+	e       [][]byte      // The encryptions of the messages
+	myGroup group.Group   // The elliptic curve we operate in
+}
+
+type Receiver struct {
+	index   int           // Indicate which OT
+	c       int           // The choice of the receiver
+	A       group.Element // The random group element from the sender
+	b       group.Scalar  // The randomness of the receiver
+	B       group.Element // B = [b]G + [c]A
+	kR      []byte        // The decryption key of receiver
+	ec      []byte        // The encryption of mc
+	mc      []byte        // The decrypted message from sender
+	myGroup group.Group   // The elliptic curve we operate in
+}
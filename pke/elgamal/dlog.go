@@ -0,0 +1,58 @@
+package elgamal
+
+import (
+	"github.com/cloudflare/circl/group"
+)
+
+// LogTable computes discrete logarithms to the base G in the range [0, max)
+// with the baby-step giant-step algorithm. It stores about sqrt(max)
+// elements, and each logarithm takes about sqrt(max) group additions.
+type LogTable struct {
+	g    group.Group
+	max  uint64
+	step uint64
+	// baby maps the encoding of j*G to j, for j in [0, step).
+	baby map[string]uint64
+	// giant is -step*G.
+	giant group.Element
+}
+
+// NewLogTable returns a table for the logarithms in [0, max).
+func NewLogTable(g group.Group, max uint64) *LogTable {
+	step := uint64(1)
+	for step*step < max && step < 1<<32 {
+		step++
+	}
+	t := &LogTable{g: g, max: max, step: step, baby: make(map[string]uint64, step)}
+	e := g.Identity()
+	for j := uint64(0); j < step; j++ {
+		t.baby[encode(e)] = j
+		e.Add(e, g.Generator())
+	}
+	t.giant = g.NewElement().Neg(e)
+	return t
+}
+
+// Log returns the integer m in [0, max) such that e = m*G, or
+// ErrMessageNotFound if there is none.
+func (t *LogTable) Log(e group.Element) (uint64, error) {
+	e = e.Copy()
+	for i := uint64(0); i*t.step < t.max; i++ {
+		if j, ok := t.baby[encode(e)]; ok {
+			if m := i*t.step + j; m < t.max {
+				return m, nil
+			}
+			return 0, ErrMessageNotFound
+		}
+		e.Add(e, t.giant)
+	}
+	return 0, ErrMessageNotFound
+}
+
+func encode(e group.Element) string {
+	b, err := e.MarshalBinaryCompress()
+	if err != nil {
+		panic(err)
+	}
+	return string(b)
+}
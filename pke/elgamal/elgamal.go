@@ -0,0 +1,249 @@
+// Package elgamal provides ElGamal encryption and Pedersen commitments over
+// prime-order groups.
+//
+// A ciphertext of an element M under the public key Y = x*G is the pair
+// (r*G, M + r*Y) for a random scalar r. Ciphertexts are homomorphic: the
+// sum of two ciphertexts is a ciphertext of the sum of their elements, and
+// ciphertexts can be re-randomized. Exponential ElGamal encrypts a small
+// integer m as the element m*G, so that sums of ciphertexts decrypt to the
+// sums of the integers, which are recovered with a LogTable. The holder of
+// the private key can prove that a decryption is correct with a DLEQ proof
+// of package zk/dleq.
+//
+// A Pedersen commitment to a scalar m is m*G + r*H for a random scalar r,
+// where nobody knows the discrete logarithm of H to the base G. Commitments
+// are hiding, binding, and homomorphic.
+//
+// References:
+//   - ElGamal, A public key cryptosystem and a signature scheme based on discrete logarithms. https://doi.org/10.1109/TIT.1985.1057074
+//   - Pedersen, Non-interactive and information-theoretic secure verifiable secret sharing. https://doi.org/10.1007/3-540-46766-1_9
+package elgamal
+
+import (
+	"crypto"
+	"errors"
+	"io"
+
+	"github.com/cloudflare/circl/group"
+	"github.com/cloudflare/circl/zk/dleq"
+)
+
+var (
+	// ErrInvalidKey is returned when a key cannot be decoded.
+	ErrInvalidKey = errors.New("elgamal: invalid key")
+	// ErrInvalidCiphertext is returned when a ciphertext cannot be decoded.
+	ErrInvalidCiphertext = errors.New("elgamal: invalid ciphertext")
+	// ErrInvalidCommitment is returned when a commitment cannot be decoded.
+	ErrInvalidCommitment = errors.New("elgamal: invalid commitment")
+	// ErrMessageNotFound is returned when a decrypted message is not in the
+	// range of a LogTable.
+	ErrMessageNotFound = errors.New("elgamal: message out of range")
+	// ErrInvalidProof is returned when a proof of decryption cannot be
+	// decoded.
+	ErrInvalidProof = errors.New("elgamal: invalid proof")
+)
+
+// PrivateKey is an ElGamal private key.
+type PrivateKey struct {
+	g   group.Group
+	x   group.Scalar
+	pub *PublicKey
+}
+
+// PublicKey is an ElGamal public key.
+type PublicKey struct {
+	g group.Group
+	y group.Element
+}
+
+// GenerateKey generates a private key in the group.
+func GenerateKey(g group.Group, rnd io.Reader) (*PrivateKey, error) {
+	if rnd == nil {
+		return nil, io.ErrNoProgress
+	}
+	return &PrivateKey{g: g, x: g.RandomNonZeroScalar(rnd)}, nil
+}
+
+func (k *PrivateKey) Public() *PublicKey {
+	if k.pub == nil {
+		k.pub = &PublicKey{k.g, k.g.NewElement().MulGen(k.x)}
+	}
+	return k.pub
+}
+
+func (k *PrivateKey) MarshalBinary() ([]byte, error) { return k.x.MarshalBinary() }
+
+func (k *PrivateKey) UnmarshalBinary(g group.Group, data []byte) error {
+	x := g.NewScalar()
+	if len(data) != int(g.Params().ScalarLength) || x.UnmarshalBinary(data) != nil || x.IsZero() {
+		return ErrInvalidKey
+	}
+	*k = PrivateKey{g: g, x: x}
+	return nil
+}
+
+func (k *PublicKey) MarshalBinary() ([]byte, error) { return k.y.MarshalBinaryCompress() }
+
+func (k *PublicKey) UnmarshalBinary(g group.Group, data []byte) error {
+	y, err := unmarshalElement(g, data)
+	if err != nil || y.IsIdentity() {
+		return ErrInvalidKey
+	}
+	*k = PublicKey{g, y}
+	return nil
+}
+
+func unmarshalElement(g group.Group, data []byte) (group.Element, error) {
+	e := g.NewElement()
+	if len(data) != int(g.Params().CompressedElementLength) || e.UnmarshalBinary(data) != nil {
+		return nil, group.ErrUnmarshal
+	}
+	return e, nil
+}
+
+// Ciphertext is an ElGamal ciphertext (r*G, M + r*Y).
+type Ciphertext struct {
+	c1, c2 group.Element
+}
+
+// Encrypt returns a ciphertext of the element.
+func (k *PublicKey) Encrypt(m group.Element, rnd io.Reader) (*Ciphertext, error) {
+	if rnd == nil {
+		return nil, io.ErrNoProgress
+	}
+	ct := &Ciphertext{k.g.Identity(), m.Copy()}
+	return k.rerandomize(ct, k.g.RandomScalar(rnd)), nil
+}
+
+// EncryptExp returns a ciphertext of the integer m, encoded as m*G. Only
+// small integers can be decrypted.
+func (k *PublicKey) EncryptExp(m uint64, rnd io.Reader) (*Ciphertext, error) {
+	return k.Encrypt(k.g.NewElement().MulGen(k.g.NewScalar().SetUint64(m)), rnd)
+}
+
+// Rerandomize returns a fresh ciphertext of the same element as ct.
+func (k *PublicKey) Rerandomize(ct *Ciphertext, rnd io.Reader) (*Ciphertext, error) {
+	if rnd == nil {
+		return nil, io.ErrNoProgress
+	}
+	return k.rerandomize(ct, k.g.RandomScalar(rnd)), nil
+}
+
+// rerandomize returns ct + (r*G, r*Y).
+func (k *PublicKey) rerandomize(ct *Ciphertext, r group.Scalar) *Ciphertext {
+	c1 := k.g.NewElement().MulGen(r)
+	c2 := k.g.NewElement().Mul(k.y, r)
+	return &Ciphertext{c1.Add(c1, ct.c1), c2.Add(c2, ct.c2)}
+}
+
+// Add sets the receiver to a ciphertext of the sum of the elements of a
+// and b, and returns the receiver.
+func (ct *Ciphertext) Add(a, b *Ciphertext) *Ciphertext {
+	g := a.c1.Group()
+	ct.c1 = g.NewElement().Add(a.c1, b.c1)
+	ct.c2 = g.NewElement().Add(a.c2, b.c2)
+	return ct
+}
+
+// Mul sets the receiver to a ciphertext of s times the element of a, and
+// returns the receiver.
+func (ct *Ciphertext) Mul(a *Ciphertext, s group.Scalar) *Ciphertext {
+	g := a.c1.Group()
+	ct.c1 = g.NewElement().Mul(a.c1, s)
+	ct.c2 = g.NewElement().Mul(a.c2, s)
+	return ct
+}
+
+func (ct *Ciphertext) MarshalBinary() ([]byte, error) {
+	c1, err := ct.c1.MarshalBinaryCompress()
+	if err != nil {
+		return nil, err
+	}
+	c2, err := ct.c2.MarshalBinaryCompress()
+	if err != nil {
+		return nil, err
+	}
+	return append(c1, c2...), nil
+}
+
+func (ct *Ciphertext) UnmarshalBinary(g group.Group, data []byte) error {
+	size := int(g.Params().CompressedElementLength)
+	if len(data) != 2*size {
+		return ErrInvalidCiphertext
+	}
+	c1, err := unmarshalElement(g, data[:size])
+	if err != nil {
+		return ErrInvalidCiphertext
+	}
+	c2, err := unmarshalElement(g, data[size:])
+	if err != nil {
+		return ErrInvalidCiphertext
+	}
+	*ct = Ciphertext{c1, c2}
+	return nil
+}
+
+// Decrypt returns the element M = c2 - x*c1 of the ciphertext.
+func (k *PrivateKey) Decrypt(ct *Ciphertext) group.Element {
+	d := k.g.NewElement().Mul(ct.c1, k.x)
+	return d.Neg(d).Add(d, ct.c2)
+}
+
+// DecryptExp returns the integer of a ciphertext made by EncryptExp, or by
+// adding such ciphertexts. It fails with ErrMessageNotFound if the integer
+// is not in the range of the table.
+func (k *PrivateKey) DecryptExp(ct *Ciphertext, t *LogTable) (uint64, error) {
+	return t.Log(k.Decrypt(ct))
+}
+
+// DecryptionProof proves that a ciphertext decrypts to an element under a
+// public key.
+type DecryptionProof struct {
+	proof *dleq.Proof
+}
+
+func dleqParams(g group.Group) dleq.Params {
+	return dleq.Params{G: g, H: crypto.SHA512, DST: []byte("CIRCL-ElGamal-Decryption")}
+}
+
+// ProveDecryption returns the element of the ciphertext and a proof that
+// the decryption is correct, which shows that log_G(Y) = log_c1(c2 - M)
+// without revealing the private key.
+func (k *PrivateKey) ProveDecryption(ct *Ciphertext, rnd io.Reader) (group.Element, *DecryptionProof, error) {
+	if rnd == nil {
+		return nil, nil, io.ErrNoProgress
+	}
+	d := k.g.NewElement().Mul(ct.c1, k.x)
+	prover := dleq.Prover{Params: dleqParams(k.g)}
+	proof, err := prover.Prove(k.x, k.g.Generator(), k.Public().y, ct.c1, d, rnd)
+	if err != nil {
+		return nil, nil, err
+	}
+	m := k.g.NewElement().Neg(d)
+	return m.Add(m, ct.c2), &DecryptionProof{proof}, nil
+}
+
+// VerifyDecryption checks that the ciphertext decrypts to the element.
+func (k *PublicKey) VerifyDecryption(ct *Ciphertext, m group.Element, proof *DecryptionProof) bool {
+	if proof == nil || proof.proof == nil {
+		return false
+	}
+	d := k.g.NewElement().Neg(m)
+	d.Add(d, ct.c2)
+	verifier := dleq.Verifier{Params: dleqParams(k.g)}
+	return verifier.Verify(k.g.Generator(), k.y, ct.c1, d, proof.proof)
+}
+
+func (p *DecryptionProof) MarshalBinary() ([]byte, error) { return p.proof.MarshalBinary() }
+
+func (p *DecryptionProof) UnmarshalBinary(g group.Group, data []byte) error {
+	if len(data) != 2*int(g.Params().ScalarLength) {
+		return ErrInvalidProof
+	}
+	proof := new(dleq.Proof)
+	if proof.UnmarshalBinary(g, data) != nil {
+		return ErrInvalidProof
+	}
+	p.proof = proof
+	return nil
+}
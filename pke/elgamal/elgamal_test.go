@@ -0,0 +1,138 @@
+package elgamal_test
+
+import (
+	"crypto/rand"
+	"fmt"
+	"testing"
+
+	"github.com/cloudflare/circl/group"
+	"github.com/cloudflare/circl/internal/test"
+	"github.com/cloudflare/circl/pke/elgamal"
+)
+
+var groups = []group.Group{group.P256, group.P384, group.Ristretto255, group.Decaf448}
+
+func TestElGamal(t *testing.T) {
+	for _, g := range groups {
+		t.Run(g.(fmt.Stringer).String(), func(t *testing.T) {
+			sk, err := elgamal.GenerateKey(g, rand.Reader)
+			test.CheckNoErr(t, err, "key generation")
+			enc, err := sk.MarshalBinary()
+			test.CheckNoErr(t, err, "marshal private key")
+			sk = new(elgamal.PrivateKey)
+			test.CheckNoErr(t, sk.UnmarshalBinary(g, enc), "unmarshal private key")
+			enc, err = sk.Public().MarshalBinary()
+			test.CheckNoErr(t, err, "marshal public key")
+			pk := new(elgamal.PublicKey)
+			test.CheckNoErr(t, pk.UnmarshalBinary(g, enc), "unmarshal public key")
+
+			m := g.RandomElement(rand.Reader)
+			ct, err := pk.Encrypt(m, rand.Reader)
+			test.CheckNoErr(t, err, "encrypt")
+			enc, err = ct.MarshalBinary()
+			test.CheckNoErr(t, err, "marshal ciphertext")
+			ct = new(elgamal.Ciphertext)
+			test.CheckNoErr(t, ct.UnmarshalBinary(g, enc), "unmarshal ciphertext")
+			test.CheckOk(sk.Decrypt(ct).IsEqual(m), "decryption failed", t)
+
+			ct2, err := pk.Rerandomize(ct, rand.Reader)
+			test.CheckNoErr(t, err, "rerandomize")
+			enc2, err := ct2.MarshalBinary()
+			test.CheckNoErr(t, err, "marshal ciphertext")
+			test.CheckOk(string(enc) != string(enc2), "rerandomized ciphertext must differ", t)
+			test.CheckOk(sk.Decrypt(ct2).IsEqual(m), "decryption of rerandomized ciphertext failed", t)
+
+			got, proof, err := sk.ProveDecryption(ct2, rand.Reader)
+			test.CheckNoErr(t, err, "prove decryption")
+			test.CheckOk(got.IsEqual(m), "proven decryption failed", t)
+			enc, err = proof.MarshalBinary()
+			test.CheckNoErr(t, err, "marshal proof")
+			proof = new(elgamal.DecryptionProof)
+			test.CheckNoErr(t, proof.UnmarshalBinary(g, enc), "unmarshal proof")
+			test.CheckOk(pk.VerifyDecryption(ct2, m, proof), "proof must verify", t)
+			test.CheckOk(!pk.VerifyDecryption(ct2, g.RandomElement(rand.Reader), proof), "wrong message must fail", t)
+			test.CheckOk(!pk.VerifyDecryption(ct, m, proof), "other ciphertext must fail", t)
+		})
+	}
+}
+
+func TestExponential(t *testing.T) {
+	g := group.Ristretto255
+	sk, err := elgamal.GenerateKey(g, rand.Reader)
+	test.CheckNoErr(t, err, "key generation")
+	pk := sk.Public()
+	table := elgamal.NewLogTable(g, 1000)
+
+	sum, err := pk.EncryptExp(0, rand.Reader)
+	test.CheckNoErr(t, err, "encrypt")
+	for _, m := range []uint64{1, 20, 300} {
+		ct, err := pk.EncryptExp(m, rand.Reader)
+		test.CheckNoErr(t, err, "encrypt")
+		got, err := sk.DecryptExp(ct, table)
+		test.CheckNoErr(t, err, "decrypt")
+		if got != m {
+			test.ReportError(t, got, m)
+		}
+		sum.Add(sum, ct)
+	}
+	got, err := sk.DecryptExp(sum, table)
+	test.CheckNoErr(t, err, "decrypt sum")
+	if got != 321 {
+		test.ReportError(t, got, 321)
+	}
+	got, err = sk.DecryptExp(sum.Mul(sum, g.NewScalar().SetUint64(3)), table)
+	test.CheckNoErr(t, err, "decrypt product")
+	if got != 963 {
+		test.ReportError(t, got, 963)
+	}
+
+	for _, m := range []uint64{1000, 1 << 40} {
+		ct, err := pk.EncryptExp(m, rand.Reader)
+		test.CheckNoErr(t, err, "encrypt")
+		_, err = sk.DecryptExp(ct, table)
+		if err != elgamal.ErrMessageNotFound {
+			test.ReportError(t, err, elgamal.ErrMessageNotFound, m)
+		}
+	}
+
+	for _, max := range []uint64{1, 2, 17, 64} {
+		table := elgamal.NewLogTable(g, max)
+		for m := uint64(0); m < max; m++ {
+			got, err := table.Log(g.NewElement().MulGen(g.NewScalar().SetUint64(m)))
+			test.CheckNoErr(t, err, "log")
+			if got != m {
+				test.ReportError(t, got, m, max)
+			}
+		}
+	}
+}
+
+func TestPedersen(t *testing.T) {
+	for _, g := range groups {
+		t.Run(g.(fmt.Stringer).String(), func(t *testing.T) {
+			key := elgamal.NewCommitmentKey(g, []byte("test"))
+			m1, m2 := g.RandomScalar(rand.Reader), g.RandomScalar(rand.Reader)
+			c1, r1, err := key.Commit(m1, rand.Reader)
+			test.CheckNoErr(t, err, "commit")
+			c2, r2, err := key.Commit(m2, rand.Reader)
+			test.CheckNoErr(t, err, "commit")
+			test.CheckOk(key.Open(c1, m1, r1), "commitment must open", t)
+			test.CheckOk(!key.Open(c1, m2, r1), "wrong message must fail", t)
+			test.CheckOk(!key.Open(c1, m1, r2), "wrong blinding must fail", t)
+
+			enc, err := c1.MarshalBinary()
+			test.CheckNoErr(t, err, "marshal commitment")
+			c := new(elgamal.Commitment)
+			test.CheckNoErr(t, c.UnmarshalBinary(g, enc), "unmarshal commitment")
+			test.CheckOk(key.Open(c, m1, r1), "decoded commitment must open", t)
+
+			c.Add(c1, c2)
+			m := g.NewScalar().Add(m1, m2)
+			r := g.NewScalar().Add(r1, r2)
+			test.CheckOk(key.Open(c, m, r), "sum of commitments must open", t)
+
+			c.Mul(c1, m2)
+			test.CheckOk(key.Open(c, m.Mul(m1, m2), r.Mul(r1, m2)), "multiple of commitment must open", t)
+		})
+	}
+}
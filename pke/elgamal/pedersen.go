@@ -0,0 +1,77 @@
+package elgamal
+
+import (
+	"io"
+
+	"github.com/cloudflare/circl/group"
+)
+
+// CommitmentKey holds the bases G and H of Pedersen commitments.
+type CommitmentKey struct {
+	g group.Group
+	h group.Element
+}
+
+// NewCommitmentKey returns a commitment key whose base H is derived from the
+// domain separation tag, so that nobody knows its discrete logarithm.
+func NewCommitmentKey(g group.Group, dst []byte) *CommitmentKey {
+	return &CommitmentKey{g, g.HashToElement([]byte("PedersenH"), dst)}
+}
+
+// H returns the base of the blindings.
+func (k *CommitmentKey) H() group.Element { return k.h.Copy() }
+
+// Commitment is a Pedersen commitment m*G + r*H.
+type Commitment struct {
+	c group.Element
+}
+
+// Commit returns a commitment to the scalar and its random blinding.
+func (k *CommitmentKey) Commit(m group.Scalar, rnd io.Reader) (*Commitment, group.Scalar, error) {
+	if rnd == nil {
+		return nil, nil, io.ErrNoProgress
+	}
+	r := k.g.RandomScalar(rnd)
+	return k.CommitWithBlinding(m, r), r, nil
+}
+
+// CommitWithBlinding returns the commitment m*G + r*H.
+func (k *CommitmentKey) CommitWithBlinding(m, r group.Scalar) *Commitment {
+	c := k.g.NewElement().MulGen(m)
+	return &Commitment{c.Add(c, k.g.NewElement().Mul(k.h, r))}
+}
+
+// Open reports whether the commitment opens to the scalar with the
+// blinding.
+func (k *CommitmentKey) Open(c *Commitment, m, r group.Scalar) bool {
+	return k.CommitWithBlinding(m, r).c.IsEqual(c.c)
+}
+
+// Add sets the receiver to a + b, which is a commitment to the sum of the
+// scalars of a and b with the sum of their blindings, and returns the
+// receiver.
+func (c *Commitment) Add(a, b *Commitment) *Commitment {
+	c.c = a.c.Group().NewElement().Add(a.c, b.c)
+	return c
+}
+
+// Mul sets the receiver to s*a, which is a commitment to s times the scalar
+// of a, and returns the receiver.
+func (c *Commitment) Mul(a *Commitment, s group.Scalar) *Commitment {
+	c.c = a.c.Group().NewElement().Mul(a.c, s)
+	return c
+}
+
+// Element returns the element of the commitment.
+func (c *Commitment) Element() group.Element { return c.c.Copy() }
+
+func (c *Commitment) MarshalBinary() ([]byte, error) { return c.c.MarshalBinaryCompress() }
+
+func (c *Commitment) UnmarshalBinary(g group.Group, data []byte) error {
+	e, err := unmarshalElement(g, data)
+	if err != nil {
+		return ErrInvalidCommitment
+	}
+	c.c = e
+	return nil
+}
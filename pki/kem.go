@@ -0,0 +1,187 @@
+package pki
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"strings"
+
+	"github.com/cloudflare/circl/kem"
+	kemSchemes "github.com/cloudflare/circl/kem/schemes"
+)
+
+var allKEMSchemesByOID map[string]kem.Scheme
+
+func init() {
+	allKEMSchemesByOID = make(map[string]kem.Scheme)
+	for _, scheme := range kemSchemes.All() {
+		if cert, ok := scheme.(KEMCertificateScheme); ok && len(cert.Oid()) != 0 {
+			allKEMSchemesByOID[cert.Oid().String()] = scheme
+		}
+	}
+}
+
+// Additional methods when the KEM scheme is supported in X509.
+type KEMCertificateScheme interface {
+	// Return the OID of the algorithm used for public and private keys of
+	// this instance, or nil if it has none.
+	Oid() asn1.ObjectIdentifier
+}
+
+func KEMSchemeByOid(oid asn1.ObjectIdentifier) kem.Scheme {
+	return allKEMSchemesByOID[oid.String()]
+}
+
+func kemOid(scheme kem.Scheme) (asn1.ObjectIdentifier, error) {
+	cert, ok := scheme.(KEMCertificateScheme)
+	if !ok || len(cert.Oid()) == 0 {
+		return nil, errors.New("unsupported key encapsulation algorithm")
+	}
+	return cert.Oid(), nil
+}
+
+func UnmarshalPEMKEMPublicKey(data []byte) (kem.PublicKey, error) {
+	block, rest := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no pem block found")
+	}
+	if len(rest) != 0 {
+		return nil, errors.New("trailing data")
+	}
+	if !strings.HasSuffix(block.Type, "PUBLIC KEY") {
+		return nil, errors.New("pem block type is not public key")
+	}
+
+	return UnmarshalPKIXKEMPublicKey(block.Bytes)
+}
+
+func UnmarshalPKIXKEMPublicKey(data []byte) (kem.PublicKey, error) {
+	var pkix struct {
+		Raw       asn1.RawContent
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+	if rest, err := asn1.Unmarshal(data, &pkix); err != nil {
+		return nil, err
+	} else if len(rest) != 0 {
+		return nil, errors.New("trailing data")
+	}
+	scheme := KEMSchemeByOid(pkix.Algorithm.Algorithm)
+	if scheme == nil {
+		return nil, errors.New("unsupported public key algorithm")
+	}
+	return scheme.UnmarshalBinaryPublicKey(pkix.PublicKey.RightAlign())
+}
+
+func UnmarshalPEMKEMPrivateKey(data []byte) (kem.PrivateKey, error) {
+	block, rest := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no pem block found")
+	}
+	if len(rest) != 0 {
+		return nil, errors.New("trailing data")
+	}
+	if !strings.HasSuffix(block.Type, "PRIVATE KEY") {
+		return nil, errors.New("pem block type is not private key")
+	}
+
+	return UnmarshalPKCS8KEMPrivateKey(block.Bytes)
+}
+
+// UnmarshalPKCS8KEMPrivateKey parses a private key in PKCS #8, ASN.1 DER
+// form, whose key is wrapped in an OCTET STRING as done in RFC 8410.
+func UnmarshalPKCS8KEMPrivateKey(data []byte) (kem.PrivateKey, error) {
+	var pkix pkixPrivKey
+	if rest, err := asn1.Unmarshal(data, &pkix); err != nil {
+		return nil, err
+	} else if len(rest) != 0 {
+		return nil, errors.New("trailing data")
+	}
+	scheme := KEMSchemeByOid(pkix.Algorithm.Algorithm)
+	if scheme == nil {
+		return nil, errors.New("unsupported private key algorithm")
+	}
+	var sk []byte
+	if rest, err := asn1.Unmarshal(pkix.PrivateKey, &sk); err != nil {
+		return nil, err
+	} else if len(rest) > 0 {
+		return nil, errors.New("trailing data")
+	}
+	return scheme.UnmarshalBinaryPrivateKey(sk)
+}
+
+func MarshalPEMKEMPublicKey(pk kem.PublicKey) ([]byte, error) {
+	data, err := MarshalPKIXKEMPublicKey(pk)
+	if err != nil {
+		return nil, err
+	}
+	str := pem.EncodeToMemory(&pem.Block{
+		Type:  "PUBLIC KEY",
+		Bytes: data,
+	})
+	return str, nil
+}
+
+func MarshalPKIXKEMPublicKey(pk kem.PublicKey) ([]byte, error) {
+	oid, err := kemOid(pk.Scheme())
+	if err != nil {
+		return nil, err
+	}
+	data, err := pk.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	return asn1.Marshal(struct {
+		pkix.AlgorithmIdentifier
+		asn1.BitString
+	}{
+		pkix.AlgorithmIdentifier{
+			Algorithm: oid,
+		},
+		asn1.BitString{
+			Bytes:     data,
+			BitLength: len(data) * 8,
+		},
+	})
+}
+
+func MarshalPEMKEMPrivateKey(sk kem.PrivateKey) ([]byte, error) {
+	data, err := MarshalPKCS8KEMPrivateKey(sk)
+	if err != nil {
+		return nil, err
+	}
+	str := pem.EncodeToMemory(&pem.Block{
+		Type:  sk.Scheme().Name() + " PRIVATE KEY",
+		Bytes: data,
+	},
+	)
+	return str, nil
+}
+
+// MarshalPKCS8KEMPrivateKey converts a private key to PKCS #8, ASN.1 DER
+// form, wrapping the key in an OCTET STRING as done in RFC 8410.
+func MarshalPKCS8KEMPrivateKey(sk kem.PrivateKey) ([]byte, error) {
+	oid, err := kemOid(sk.Scheme())
+	if err != nil {
+		return nil, err
+	}
+	data, err := sk.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err = asn1.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return asn1.Marshal(pkixPrivKey{
+		0,
+		pkix.AlgorithmIdentifier{
+			Algorithm: oid,
+		},
+		data,
+	})
+}
@@ -0,0 +1,263 @@
+package privacypass
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha512"
+	"crypto/x509"
+	encoding_asn1 "encoding/asn1"
+	"io"
+
+	"github.com/cloudflare/circl/blindsign/blindrsa"
+	"golang.org/x/crypto/cryptobyte"
+	"golang.org/x/crypto/cryptobyte/asn1"
+)
+
+const (
+	// rsaModulusSize is the size Nk of the modulus of issuer keys.
+	rsaModulusSize = 256
+	// rsaSaltSize is the size of the PSS salt.
+	rsaSaltSize = sha512.Size384
+)
+
+var (
+	oidRSAPSS = encoding_asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 10}
+	oidRSA    = encoding_asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	oidMGF1   = encoding_asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 8}
+	oidSHA384 = encoding_asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 2}
+)
+
+// MarshalTokenKey encodes an issuer public key of type TokenTypeBlindRSA as
+// a SubjectPublicKeyInfo with the RSASSA-PSS object identifier, the form
+// from which token key IDs are computed.
+func MarshalTokenKey(pk *rsa.PublicKey) ([]byte, error) {
+	if err := checkRSAKey(pk); err != nil {
+		return nil, err
+	}
+	var b cryptobyte.Builder
+	b.AddASN1(asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+		b.AddASN1(asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+			b.AddASN1ObjectIdentifier(oidRSAPSS)
+			b.AddASN1(asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+				b.AddASN1(asn1.Tag(0).ContextSpecific().Constructed(), func(b *cryptobyte.Builder) {
+					b.AddASN1(asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+						b.AddASN1ObjectIdentifier(oidSHA384)
+					})
+				})
+				b.AddASN1(asn1.Tag(1).ContextSpecific().Constructed(), func(b *cryptobyte.Builder) {
+					b.AddASN1(asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+						b.AddASN1ObjectIdentifier(oidMGF1)
+						b.AddASN1(asn1.SEQUENCE, func(b *cryptobyte.Builder) {
+							b.AddASN1ObjectIdentifier(oidSHA384)
+						})
+					})
+				})
+				b.AddASN1(asn1.Tag(2).ContextSpecific().Constructed(), func(b *cryptobyte.Builder) {
+					b.AddASN1Int64(rsaSaltSize)
+				})
+			})
+		})
+		b.AddASN1BitString(x509.MarshalPKCS1PublicKey(pk))
+	})
+	return b.Bytes()
+}
+
+// ParseTokenKey decodes an issuer public key of type TokenTypeBlindRSA
+// encoded as a SubjectPublicKeyInfo with either the RSASSA-PSS or the
+// rsaEncryption object identifier.
+func ParseTokenKey(data []byte) (*rsa.PublicKey, error) {
+	s := cryptobyte.String(data)
+	var spki, alg cryptobyte.String
+	var oid encoding_asn1.ObjectIdentifier
+	var key encoding_asn1.BitString
+	if !s.ReadASN1(&spki, asn1.SEQUENCE) || !s.Empty() ||
+		!spki.ReadASN1(&alg, asn1.SEQUENCE) ||
+		!alg.ReadASN1ObjectIdentifier(&oid) ||
+		!spki.ReadASN1BitString(&key) || !spki.Empty() ||
+		key.BitLength%8 != 0 {
+		return nil, ErrInvalidKey
+	}
+	if !oid.Equal(oidRSAPSS) && !oid.Equal(oidRSA) {
+		return nil, ErrInvalidKey
+	}
+	pk, err := x509.ParsePKCS1PublicKey(key.Bytes)
+	if err != nil {
+		return nil, ErrInvalidKey
+	}
+	if err := checkRSAKey(pk); err != nil {
+		return nil, err
+	}
+	return pk, nil
+}
+
+// checkRSAKey checks that the modulus of the key has the expected size.
+func checkRSAKey(pk *rsa.PublicKey) error {
+	if pk == nil || pk.N == nil || pk.Size() != rsaModulusSize {
+		return ErrInvalidKey
+	}
+	return nil
+}
+
+// rsaKeyID returns the token key ID of a public key.
+func rsaKeyID(pk *rsa.PublicKey) ([]byte, error) {
+	raw, err := MarshalTokenKey(pk)
+	if err != nil {
+		return nil, err
+	}
+	return tokenKeyID(raw), nil
+}
+
+// BlindRSATokenResponse is the answer of an issuer to a TokenRequest of
+// type TokenTypeBlindRSA.
+type BlindRSATokenResponse struct {
+	BlindSig []byte
+}
+
+// MarshalBinary encodes the response.
+func (r *BlindRSATokenResponse) MarshalBinary() ([]byte, error) {
+	if len(r.BlindSig) != rsaModulusSize {
+		return nil, ErrInvalidMessage
+	}
+	return append([]byte{}, r.BlindSig...), nil
+}
+
+// UnmarshalBinary decodes a response.
+func (r *BlindRSATokenResponse) UnmarshalBinary(data []byte) error {
+	if len(data) != rsaModulusSize {
+		return ErrInvalidMessage
+	}
+	r.BlindSig = append([]byte{}, data...)
+	return nil
+}
+
+// BlindRSAVerifier verifies tokens of type TokenTypeBlindRSA. Since these
+// tokens are publicly verifiable, it only needs the public key of the
+// issuer.
+type BlindRSAVerifier struct {
+	pk    *rsa.PublicKey
+	keyID []byte
+}
+
+// NewBlindRSAVerifier returns a verifier for the issuer with the given
+// public key.
+func NewBlindRSAVerifier(pk *rsa.PublicKey) (*BlindRSAVerifier, error) {
+	keyID, err := rsaKeyID(pk)
+	if err != nil {
+		return nil, err
+	}
+	return &BlindRSAVerifier{pk, keyID}, nil
+}
+
+// TokenType returns TokenTypeBlindRSA.
+func (v *BlindRSAVerifier) TokenType() TokenType { return TokenTypeBlindRSA }
+
+// Verify checks that the token was signed with the key of the issuer.
+func (v *BlindRSAVerifier) Verify(t *Token) error {
+	if err := checkToken(t, TokenTypeBlindRSA, v.keyID); err != nil {
+		return err
+	}
+	digest := sha512.Sum384(t.input())
+	err := rsa.VerifyPSS(v.pk, crypto.SHA384, digest[:], t.Authenticator,
+		&rsa.PSSOptions{SaltLength: rsaSaltSize, Hash: crypto.SHA384})
+	if err != nil {
+		return ErrInvalidToken
+	}
+	return nil
+}
+
+// BlindRSAIssuer issues and verifies tokens of type TokenTypeBlindRSA.
+type BlindRSAIssuer struct {
+	BlindRSAVerifier
+	signer blindrsa.Signer
+}
+
+// NewBlindRSAIssuer returns an issuer using a 2048-bit RSA private key.
+func NewBlindRSAIssuer(sk *rsa.PrivateKey) (*BlindRSAIssuer, error) {
+	v, err := NewBlindRSAVerifier(&sk.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	return &BlindRSAIssuer{*v, blindrsa.NewSigner(sk)}, nil
+}
+
+// PublicKey returns the public key of the issuer, to be given to clients.
+func (i *BlindRSAIssuer) PublicKey() *rsa.PublicKey { return i.pk }
+
+// TokenKeyID returns the key ID of the issuer.
+func (i *BlindRSAIssuer) TokenKeyID() []byte { return append([]byte{}, i.keyID...) }
+
+// Evaluate answers a token request.
+func (i *BlindRSAIssuer) Evaluate(req *TokenRequest) (*BlindRSATokenResponse, error) {
+	if req.TokenType != TokenTypeBlindRSA {
+		return nil, ErrInvalidTokenType
+	}
+	if req.TruncatedTokenKeyID != truncate(i.keyID) {
+		return nil, ErrInvalidKeyID
+	}
+	if len(req.BlindedMsg) != rsaModulusSize {
+		return nil, ErrInvalidMessage
+	}
+	sig, err := i.signer.BlindSign(req.BlindedMsg)
+	if err != nil {
+		return nil, err
+	}
+	return &BlindRSATokenResponse{sig}, nil
+}
+
+// BlindRSAClient requests tokens of type TokenTypeBlindRSA.
+type BlindRSAClient struct {
+	client blindrsa.Client
+	keyID  []byte
+}
+
+// NewBlindRSAClient returns a client for the issuer with the given public
+// key.
+func NewBlindRSAClient(pk *rsa.PublicKey) (*BlindRSAClient, error) {
+	keyID, err := rsaKeyID(pk)
+	if err != nil {
+		return nil, err
+	}
+	client, err := blindrsa.NewClient(blindrsa.SHA384PSSDeterministic, pk)
+	if err != nil {
+		return nil, err
+	}
+	return &BlindRSAClient{client, keyID}, nil
+}
+
+// BlindRSATokenState holds the state of a client between a request and the
+// reception of its response.
+type BlindRSATokenState struct {
+	client blindrsa.Client
+	token  *Token
+	state  blindrsa.State
+}
+
+// CreateTokenRequest requests a token for the encoded challenge, reading
+// randomness from rnd.
+func (c *BlindRSAClient) CreateTokenRequest(rnd io.Reader, challenge []byte) (
+	*TokenRequest, *BlindRSATokenState, error,
+) {
+	t, err := newToken(rnd, TokenTypeBlindRSA, challenge, c.keyID)
+	if err != nil {
+		return nil, nil, err
+	}
+	blinded, state, err := c.client.Blind(rnd, t.input())
+	if err != nil {
+		return nil, nil, err
+	}
+	return &TokenRequest{TokenTypeBlindRSA, truncate(c.keyID), blinded},
+		&BlindRSATokenState{c.client, t, state}, nil
+}
+
+// FinalizeToken returns the token obtained from the response of the issuer.
+func (s *BlindRSATokenState) FinalizeToken(resp *BlindRSATokenResponse) (*Token, error) {
+	if len(resp.BlindSig) != rsaModulusSize {
+		return nil, ErrInvalidMessage
+	}
+	sig, err := s.client.Finalize(s.state, resp.BlindSig)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	s.token.Authenticator = sig
+	return s.token, nil
+}
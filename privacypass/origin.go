@@ -0,0 +1,50 @@
+package privacypass
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"io"
+)
+
+// Origin challenges clients and redeems their tokens.
+type Origin struct {
+	// IssuerName is the name of the issuer trusted by the origin.
+	IssuerName string
+	// OriginInfo holds the names of the origins at which tokens can be
+	// redeemed, see TokenChallenge.
+	OriginInfo []string
+	// Verifier checks the tokens of the trusted issuer.
+	Verifier Verifier
+}
+
+// CreateTokenChallenge returns an encoded challenge for the token type of
+// the verifier. If perRequest is true, the challenge has a random
+// redemption context read from rnd, so that tokens are bound to it.
+func (o *Origin) CreateTokenChallenge(rnd io.Reader, perRequest bool) ([]byte, error) {
+	c := TokenChallenge{
+		TokenType:  o.Verifier.TokenType(),
+		IssuerName: o.IssuerName,
+		OriginInfo: o.OriginInfo,
+	}
+	if perRequest {
+		c.RedemptionContext = make([]byte, RedemptionContextSize)
+		if _, err := io.ReadFull(rnd, c.RedemptionContext); err != nil {
+			return nil, err
+		}
+	}
+	return c.MarshalBinary()
+}
+
+// VerifyToken checks that the token was issued by the trusted issuer for the
+// encoded challenge. The caller must check that the nonce of the token was
+// not redeemed before.
+func (o *Origin) VerifyToken(t *Token, challenge []byte) error {
+	if t.TokenType != o.Verifier.TokenType() {
+		return ErrInvalidTokenType
+	}
+	digest := sha256.Sum256(challenge)
+	if subtle.ConstantTimeCompare(t.ChallengeDigest, digest[:]) != 1 {
+		return ErrInvalidChallenge
+	}
+	return o.Verifier.Verify(t)
+}
@@ -0,0 +1,327 @@
+// Package privacypass implements the Privacy Pass issuance protocols.
+//
+// Privacy Pass lets an origin rate-limit clients without tracking them.
+// The origin sends a TokenChallenge to the client, which obtains a Token for
+// that challenge from an issuer and redeems it at the origin. Issuance is
+// blind, so the issuer cannot link the tokens it issues to their
+// redemption.
+//
+// Two token types are supported, see RFC 9578:
+//
+//   - TokenTypeVOPRF (0x0001) is privately verifiable: tokens are the
+//     output of a VOPRF on P-384 with SHA-384, and can only be verified by
+//     the holder of the issuer's private key. Tokens can be issued in
+//     batches, see draft-ietf-privacypass-batched-tokens.
+//
+//   - TokenTypeBlindRSA (0x0002) is publicly verifiable: tokens are
+//     RSASSA-PSS signatures with SHA-384 under a 2048-bit key, obtained
+//     with the RSABSSA-SHA384-PSS-Deterministic blind signature of RFC 9474.
+//
+// Messages flow between the three roles as follows:
+//
+//	Client                    Issuer                    Origin
+//	                                                 TokenChallenge
+//	<----------------------------------------------------------
+//	TokenRequest
+//	-------------------------->
+//	            TokenResponse
+//	<--------------------------
+//	Token
+//	---------------------------------------------------------->
+//
+// Origins are responsible for rejecting tokens that are redeemed twice.
+//
+// References:
+//   - RFC 9576: https://www.rfc-editor.org/rfc/rfc9576
+//   - RFC 9578: https://www.rfc-editor.org/rfc/rfc9578
+//   - draft-ietf-privacypass-batched-tokens: https://datatracker.ietf.org/doc/draft-ietf-privacypass-batched-tokens/
+package privacypass
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"io"
+	"strings"
+
+	"golang.org/x/crypto/cryptobyte"
+)
+
+// TokenType identifies an issuance protocol.
+type TokenType = uint16
+
+const (
+	// TokenTypeVOPRF is the privately verifiable token type based on the
+	// VOPRF(P-384, SHA-384) protocol.
+	TokenTypeVOPRF TokenType = 0x0001
+	// TokenTypeBlindRSA is the publicly verifiable token type based on
+	// blind RSA signatures with SHA-384.
+	TokenTypeBlindRSA TokenType = 0x0002
+)
+
+const (
+	// NonceSize is the size in bytes of the nonce of a token.
+	NonceSize = 32
+	// RedemptionContextSize is the size in bytes of a non-empty redemption
+	// context.
+	RedemptionContextSize = 32
+	// digestSize is the size of challenge digests and token key IDs.
+	digestSize = sha256.Size
+)
+
+var (
+	// ErrInvalidTokenType is returned when a message has an unexpected or
+	// unsupported token type.
+	ErrInvalidTokenType = errors.New("privacypass: invalid token type")
+	// ErrInvalidMessage is returned when a message is malformed.
+	ErrInvalidMessage = errors.New("privacypass: invalid message")
+	// ErrInvalidKeyID is returned when a message refers to another issuer key.
+	ErrInvalidKeyID = errors.New("privacypass: invalid token key ID")
+	// ErrInvalidKey is returned when a key cannot be used with a token type.
+	ErrInvalidKey = errors.New("privacypass: invalid key")
+	// ErrInvalidChallenge is returned when a token was not issued for the
+	// challenge it is redeemed with.
+	ErrInvalidChallenge = errors.New("privacypass: invalid challenge")
+	// ErrInvalidToken is returned when the authenticator of a token is not
+	// valid.
+	ErrInvalidToken = errors.New("privacypass: invalid token")
+)
+
+// authenticatorSize returns the size Nk of the authenticator of tokens of
+// the given type.
+func authenticatorSize(t TokenType) (int, error) {
+	switch t {
+	case TokenTypeVOPRF:
+		return voprfOutputSize, nil
+	case TokenTypeBlindRSA:
+		return rsaModulusSize, nil
+	default:
+		return 0, ErrInvalidTokenType
+	}
+}
+
+// TokenChallenge is sent by an origin to request a token from a client.
+type TokenChallenge struct {
+	TokenType  TokenType
+	IssuerName string
+	// RedemptionContext is either empty or RedemptionContextSize bytes
+	// long. A random context binds the token to a single challenge.
+	RedemptionContext []byte
+	// OriginInfo holds the names of the origins the token can be redeemed
+	// at. If empty, the token can be redeemed at any origin.
+	OriginInfo []string
+}
+
+// MarshalBinary encodes the challenge.
+func (c *TokenChallenge) MarshalBinary() ([]byte, error) {
+	if c.IssuerName == "" {
+		return nil, ErrInvalidMessage
+	}
+	if l := len(c.RedemptionContext); l != 0 && l != RedemptionContextSize {
+		return nil, ErrInvalidMessage
+	}
+	var b cryptobyte.Builder
+	b.AddUint16(c.TokenType)
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes([]byte(c.IssuerName))
+	})
+	b.AddUint8LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes(c.RedemptionContext)
+	})
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		b.AddBytes([]byte(strings.Join(c.OriginInfo, ",")))
+	})
+	return b.Bytes()
+}
+
+// UnmarshalBinary decodes a challenge.
+func (c *TokenChallenge) UnmarshalBinary(data []byte) error {
+	s := cryptobyte.String(data)
+	var issuer, context, origins cryptobyte.String
+	var tokenType uint16
+	if !s.ReadUint16(&tokenType) ||
+		!s.ReadUint16LengthPrefixed(&issuer) || len(issuer) == 0 ||
+		!s.ReadUint8LengthPrefixed(&context) ||
+		(len(context) != 0 && len(context) != RedemptionContextSize) ||
+		!s.ReadUint16LengthPrefixed(&origins) || !s.Empty() {
+		return ErrInvalidMessage
+	}
+	c.TokenType = tokenType
+	c.IssuerName = string(issuer)
+	c.RedemptionContext = append([]byte{}, context...)
+	c.OriginInfo = nil
+	if len(origins) != 0 {
+		c.OriginInfo = strings.Split(string(origins), ",")
+	}
+	return nil
+}
+
+// Token is redeemed by a client at an origin.
+type Token struct {
+	TokenType       TokenType
+	Nonce           []byte
+	ChallengeDigest []byte
+	TokenKeyID      []byte
+	Authenticator   []byte
+}
+
+// MarshalBinary encodes the token.
+func (t *Token) MarshalBinary() ([]byte, error) {
+	nk, err := authenticatorSize(t.TokenType)
+	if err != nil {
+		return nil, err
+	}
+	if len(t.Nonce) != NonceSize || len(t.ChallengeDigest) != digestSize ||
+		len(t.TokenKeyID) != digestSize || len(t.Authenticator) != nk {
+		return nil, ErrInvalidMessage
+	}
+	var b cryptobyte.Builder
+	b.AddBytes(t.input())
+	b.AddBytes(t.Authenticator)
+	return b.Bytes()
+}
+
+// UnmarshalBinary decodes a token.
+func (t *Token) UnmarshalBinary(data []byte) error {
+	s := cryptobyte.String(data)
+	var tokenType uint16
+	if !s.ReadUint16(&tokenType) {
+		return ErrInvalidMessage
+	}
+	nk, err := authenticatorSize(tokenType)
+	if err != nil {
+		return err
+	}
+	var nonce, digest, keyID, auth []byte
+	if !s.ReadBytes(&nonce, NonceSize) ||
+		!s.ReadBytes(&digest, digestSize) ||
+		!s.ReadBytes(&keyID, digestSize) ||
+		!s.ReadBytes(&auth, nk) || !s.Empty() {
+		return ErrInvalidMessage
+	}
+	t.TokenType = tokenType
+	t.Nonce = append([]byte{}, nonce...)
+	t.ChallengeDigest = append([]byte{}, digest...)
+	t.TokenKeyID = append([]byte{}, keyID...)
+	t.Authenticator = append([]byte{}, auth...)
+	return nil
+}
+
+// input returns the token_input, the message authenticated by the token.
+func (t *Token) input() []byte {
+	return bytes.Join([][]byte{
+		{byte(t.TokenType >> 8), byte(t.TokenType)},
+		t.Nonce, t.ChallengeDigest, t.TokenKeyID,
+	}, nil)
+}
+
+// newToken returns a token of the given type for the encoded challenge,
+// with a nonce read from rnd and no authenticator.
+func newToken(rnd io.Reader, tokenType TokenType, challenge, keyID []byte) (*Token, error) {
+	var c TokenChallenge
+	if err := c.UnmarshalBinary(challenge); err != nil {
+		return nil, err
+	}
+	if c.TokenType != tokenType {
+		return nil, ErrInvalidTokenType
+	}
+	nonce := make([]byte, NonceSize)
+	if _, err := io.ReadFull(rnd, nonce); err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256(challenge)
+	return &Token{
+		TokenType:       tokenType,
+		Nonce:           nonce,
+		ChallengeDigest: digest[:],
+		TokenKeyID:      append([]byte{}, keyID...),
+	}, nil
+}
+
+// tokenKeyID returns the key ID of a serialized public key.
+func tokenKeyID(publicKey []byte) []byte {
+	id := sha256.Sum256(publicKey)
+	return id[:]
+}
+
+// truncate returns the truncated key ID sent in token requests.
+func truncate(keyID []byte) uint8 { return keyID[len(keyID)-1] }
+
+// checkToken checks the type and key ID of a token.
+func checkToken(t *Token, tokenType TokenType, keyID []byte) error {
+	if t.TokenType != tokenType {
+		return ErrInvalidTokenType
+	}
+	if subtle.ConstantTimeCompare(t.TokenKeyID, keyID) != 1 {
+		return ErrInvalidKeyID
+	}
+	return nil
+}
+
+// TokenRequest is sent by a client to an issuer.
+type TokenRequest struct {
+	TokenType           TokenType
+	TruncatedTokenKeyID uint8
+	BlindedMsg          []byte
+}
+
+// blindedMsgSize returns the size of the blinded message of token requests
+// of the given type.
+func blindedMsgSize(t TokenType) (int, error) {
+	switch t {
+	case TokenTypeVOPRF:
+		return voprfElementSize, nil
+	case TokenTypeBlindRSA:
+		return rsaModulusSize, nil
+	default:
+		return 0, ErrInvalidTokenType
+	}
+}
+
+// MarshalBinary encodes the request.
+func (r *TokenRequest) MarshalBinary() ([]byte, error) {
+	size, err := blindedMsgSize(r.TokenType)
+	if err != nil {
+		return nil, err
+	}
+	if len(r.BlindedMsg) != size {
+		return nil, ErrInvalidMessage
+	}
+	var b cryptobyte.Builder
+	b.AddUint16(r.TokenType)
+	b.AddUint8(r.TruncatedTokenKeyID)
+	b.AddBytes(r.BlindedMsg)
+	return b.Bytes()
+}
+
+// UnmarshalBinary decodes a request.
+func (r *TokenRequest) UnmarshalBinary(data []byte) error {
+	s := cryptobyte.String(data)
+	var tokenType uint16
+	var keyID uint8
+	if !s.ReadUint16(&tokenType) || !s.ReadUint8(&keyID) {
+		return ErrInvalidMessage
+	}
+	size, err := blindedMsgSize(tokenType)
+	if err != nil {
+		return err
+	}
+	var blinded []byte
+	if !s.ReadBytes(&blinded, size) || !s.Empty() {
+		return ErrInvalidMessage
+	}
+	r.TokenType = tokenType
+	r.TruncatedTokenKeyID = keyID
+	r.BlindedMsg = append([]byte{}, blinded...)
+	return nil
+}
+
+// Verifier checks the authenticator of tokens.
+type Verifier interface {
+	// TokenType returns the type of the tokens checked by the verifier.
+	TokenType() TokenType
+	// Verify returns nil if the token was issued by the issuer of the
+	// verifier.
+	Verify(t *Token) error
+}
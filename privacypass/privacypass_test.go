@@ -0,0 +1,219 @@
+package privacypass
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"testing"
+
+	"github.com/cloudflare/circl/internal/test"
+	"github.com/cloudflare/circl/oprf"
+)
+
+type canMarshal interface {
+	MarshalBinary() ([]byte, error)
+	UnmarshalBinary(data []byte) error
+}
+
+// roundTrip serializes x and deserializes it into y.
+func roundTrip(t *testing.T, x, y canMarshal) {
+	t.Helper()
+	raw, err := x.MarshalBinary()
+	test.CheckNoErr(t, err, "marshal")
+	test.CheckNoErr(t, y.UnmarshalBinary(raw), "unmarshal")
+	got, err := y.MarshalBinary()
+	test.CheckNoErr(t, err, "marshal")
+	if !bytes.Equal(got, raw) {
+		test.ReportError(t, got, raw)
+	}
+	test.CheckIsErr(t, y.UnmarshalBinary(raw[:len(raw)-1]), "short message must fail")
+}
+
+// redeem checks that the token is accepted by the origin after a round
+// trip, and that tampered tokens are rejected.
+func redeem(t *testing.T, origin *Origin, token *Token, challenge []byte) {
+	t.Helper()
+	var token2 Token
+	roundTrip(t, token, &token2)
+	test.CheckNoErr(t, origin.VerifyToken(&token2, challenge), "verify token")
+
+	other, err := origin.CreateTokenChallenge(rand.Reader, true)
+	test.CheckNoErr(t, err, "challenge")
+	if err = origin.VerifyToken(&token2, other); err != ErrInvalidChallenge {
+		test.ReportError(t, err, ErrInvalidChallenge)
+	}
+	token2.Nonce[0] ^= 1
+	if err = origin.VerifyToken(&token2, challenge); err != ErrInvalidToken {
+		test.ReportError(t, err, ErrInvalidToken)
+	}
+}
+
+func TestVOPRF(t *testing.T) {
+	key, err := oprf.GenerateKey(oprf.SuiteP384, rand.Reader)
+	test.CheckNoErr(t, err, "key")
+	issuer, err := NewVOPRFIssuer(key)
+	test.CheckNoErr(t, err, "issuer")
+	client, err := NewVOPRFClient(issuer.PublicKey())
+	test.CheckNoErr(t, err, "client")
+	origin := &Origin{
+		IssuerName: "issuer.example",
+		OriginInfo: []string{"origin.example"},
+		Verifier:   issuer,
+	}
+
+	challenge, err := origin.CreateTokenChallenge(rand.Reader, false)
+	test.CheckNoErr(t, err, "challenge")
+	req, state, err := client.CreateTokenRequest(rand.Reader, challenge)
+	test.CheckNoErr(t, err, "request")
+	var req2 TokenRequest
+	roundTrip(t, req, &req2)
+	resp, err := issuer.Evaluate(&req2)
+	test.CheckNoErr(t, err, "evaluate")
+	var resp2 VOPRFTokenResponse
+	roundTrip(t, resp, &resp2)
+	token, err := state.FinalizeToken(&resp2)
+	test.CheckNoErr(t, err, "finalize")
+	redeem(t, origin, token, challenge)
+
+	// Batched issuance.
+	const n = 5
+	batchReq, state, err := client.CreateBatchTokenRequest(rand.Reader, challenge, n)
+	test.CheckNoErr(t, err, "batch request")
+	var batchReq2 BatchTokenRequest
+	roundTrip(t, batchReq, &batchReq2)
+	batchResp, err := issuer.EvaluateBatch(&batchReq2)
+	test.CheckNoErr(t, err, "batch evaluate")
+	var batchResp2 BatchTokenResponse
+	roundTrip(t, batchResp, &batchResp2)
+	tokens, err := state.FinalizeTokens(&batchResp2)
+	test.CheckNoErr(t, err, "batch finalize")
+	if len(tokens) != n {
+		test.ReportError(t, len(tokens), n)
+	}
+	for _, token := range tokens {
+		redeem(t, origin, token, challenge)
+	}
+
+	// A response from another key is rejected.
+	otherKey, err := oprf.GenerateKey(oprf.SuiteP384, rand.Reader)
+	test.CheckNoErr(t, err, "key")
+	otherIssuer, err := NewVOPRFIssuer(otherKey)
+	test.CheckNoErr(t, err, "issuer")
+	req, state, err = client.CreateTokenRequest(rand.Reader, challenge)
+	test.CheckNoErr(t, err, "request")
+	req.TruncatedTokenKeyID = truncate(otherIssuer.keyID)
+	resp, err = otherIssuer.Evaluate(req)
+	test.CheckNoErr(t, err, "evaluate")
+	_, err = state.FinalizeToken(resp)
+	if err != ErrInvalidToken {
+		test.ReportError(t, err, ErrInvalidToken)
+	}
+	_, err = issuer.Evaluate(req)
+	if err != ErrInvalidKeyID {
+		test.ReportError(t, err, ErrInvalidKeyID)
+	}
+
+	_, err = NewVOPRFIssuer(mustKey(t, oprf.SuiteP256))
+	test.CheckIsErr(t, err, "key of another suite must fail")
+}
+
+func mustKey(t *testing.T, s oprf.Suite) *oprf.PrivateKey {
+	key, err := oprf.GenerateKey(s, rand.Reader)
+	test.CheckNoErr(t, err, "key")
+	return key
+}
+
+func TestBlindRSA(t *testing.T) {
+	sk, err := rsa.GenerateKey(rand.Reader, 2048)
+	test.CheckNoErr(t, err, "key")
+	issuer, err := NewBlindRSAIssuer(sk)
+	test.CheckNoErr(t, err, "issuer")
+	rawKey, err := MarshalTokenKey(issuer.PublicKey())
+	test.CheckNoErr(t, err, "marshal key")
+	pk, err := ParseTokenKey(rawKey)
+	test.CheckNoErr(t, err, "parse key")
+	client, err := NewBlindRSAClient(pk)
+	test.CheckNoErr(t, err, "client")
+	verifier, err := NewBlindRSAVerifier(pk)
+	test.CheckNoErr(t, err, "verifier")
+	origin := &Origin{IssuerName: "issuer.example", Verifier: verifier}
+
+	challenge, err := origin.CreateTokenChallenge(rand.Reader, true)
+	test.CheckNoErr(t, err, "challenge")
+	req, state, err := client.CreateTokenRequest(rand.Reader, challenge)
+	test.CheckNoErr(t, err, "request")
+	var req2 TokenRequest
+	roundTrip(t, req, &req2)
+	resp, err := issuer.Evaluate(&req2)
+	test.CheckNoErr(t, err, "evaluate")
+	var resp2 BlindRSATokenResponse
+	roundTrip(t, resp, &resp2)
+	token, err := state.FinalizeToken(&resp2)
+	test.CheckNoErr(t, err, "finalize")
+	redeem(t, origin, token, challenge)
+	test.CheckNoErr(t, issuer.Verify(token), "issuer verify")
+
+	// Tokens of another type are rejected.
+	vOrigin := &Origin{IssuerName: "issuer.example"}
+	vOrigin.Verifier, err = NewVOPRFIssuer(mustKey(t, oprf.SuiteP384))
+	test.CheckNoErr(t, err, "issuer")
+	if err = vOrigin.VerifyToken(token, challenge); err != ErrInvalidTokenType {
+		test.ReportError(t, err, ErrInvalidTokenType)
+	}
+	_, _, err = client.CreateTokenRequest(rand.Reader, mustChallenge(t, TokenTypeVOPRF))
+	if err != ErrInvalidTokenType {
+		test.ReportError(t, err, ErrInvalidTokenType)
+	}
+
+	// The key is also accepted with the rsaEncryption identifier.
+	spki, err := x509.MarshalPKIXPublicKey(pk)
+	test.CheckNoErr(t, err, "marshal key")
+	pk2, err := ParseTokenKey(spki)
+	test.CheckNoErr(t, err, "parse key")
+	if !pk2.Equal(pk) {
+		test.ReportError(t, pk2, pk)
+	}
+
+	small, err := rsa.GenerateKey(rand.Reader, 1024)
+	test.CheckNoErr(t, err, "key")
+	_, err = NewBlindRSAIssuer(small)
+	if err != ErrInvalidKey {
+		test.ReportError(t, err, ErrInvalidKey)
+	}
+}
+
+func mustChallenge(t *testing.T, tokenType TokenType) []byte {
+	c := TokenChallenge{TokenType: tokenType, IssuerName: "issuer.example"}
+	raw, err := c.MarshalBinary()
+	test.CheckNoErr(t, err, "challenge")
+	return raw
+}
+
+func TestTokenChallenge(t *testing.T) {
+	c := &TokenChallenge{
+		TokenType:         TokenTypeBlindRSA,
+		IssuerName:        "issuer.example",
+		RedemptionContext: make([]byte, RedemptionContextSize),
+		OriginInfo:        []string{"a.example", "b.example"},
+	}
+	var c2 TokenChallenge
+	roundTrip(t, c, &c2)
+	if len(c2.OriginInfo) != 2 || c2.OriginInfo[1] != "b.example" {
+		test.ReportError(t, c2.OriginInfo, c.OriginInfo)
+	}
+
+	for _, bad := range []*TokenChallenge{
+		{TokenType: TokenTypeVOPRF},
+		{TokenType: TokenTypeVOPRF, IssuerName: "i", RedemptionContext: []byte{1}},
+	} {
+		_, err := bad.MarshalBinary()
+		test.CheckIsErr(t, err, "invalid challenge must fail")
+	}
+
+	var token Token
+	err := token.UnmarshalBinary(make([]byte, 2+3*32+48))
+	if err != ErrInvalidTokenType {
+		test.ReportError(t, err, ErrInvalidTokenType)
+	}
+}
@@ -0,0 +1,391 @@
+package privacypass
+
+import (
+	"io"
+
+	"github.com/cloudflare/circl/group"
+	"github.com/cloudflare/circl/oprf"
+	"github.com/cloudflare/circl/zk/dleq"
+	"golang.org/x/crypto/cryptobyte"
+)
+
+const (
+	// voprfElementSize is the size Ne of serialized P-384 elements.
+	voprfElementSize = 49
+	// voprfScalarSize is the size Ns of serialized P-384 scalars.
+	voprfScalarSize = 48
+	// voprfOutputSize is the size Nh of the VOPRF output.
+	voprfOutputSize = 48
+)
+
+var voprfSuite = oprf.SuiteP384
+
+// VOPRFTokenResponse is the answer of an issuer to a TokenRequest of type
+// TokenTypeVOPRF.
+type VOPRFTokenResponse struct {
+	EvaluateMsg   []byte
+	EvaluateProof []byte
+}
+
+// MarshalBinary encodes the response.
+func (r *VOPRFTokenResponse) MarshalBinary() ([]byte, error) {
+	if len(r.EvaluateMsg) != voprfElementSize || len(r.EvaluateProof) != 2*voprfScalarSize {
+		return nil, ErrInvalidMessage
+	}
+	return append(append([]byte{}, r.EvaluateMsg...), r.EvaluateProof...), nil
+}
+
+// UnmarshalBinary decodes a response.
+func (r *VOPRFTokenResponse) UnmarshalBinary(data []byte) error {
+	if len(data) != voprfElementSize+2*voprfScalarSize {
+		return ErrInvalidMessage
+	}
+	r.EvaluateMsg = append([]byte{}, data[:voprfElementSize]...)
+	r.EvaluateProof = append([]byte{}, data[voprfElementSize:]...)
+	return nil
+}
+
+// BatchTokenRequest asks an issuer for several tokens of type
+// TokenTypeVOPRF at once.
+type BatchTokenRequest struct {
+	TokenType           TokenType
+	TruncatedTokenKeyID uint8
+	BlindedElements     [][]byte
+}
+
+// MarshalBinary encodes the request.
+func (r *BatchTokenRequest) MarshalBinary() ([]byte, error) {
+	if r.TokenType != TokenTypeVOPRF {
+		return nil, ErrInvalidTokenType
+	}
+	var b cryptobyte.Builder
+	b.AddUint16(r.TokenType)
+	b.AddUint8(r.TruncatedTokenKeyID)
+	addElements(&b, r.BlindedElements)
+	return b.Bytes()
+}
+
+// UnmarshalBinary decodes a request.
+func (r *BatchTokenRequest) UnmarshalBinary(data []byte) error {
+	s := cryptobyte.String(data)
+	var tokenType uint16
+	var keyID uint8
+	if !s.ReadUint16(&tokenType) || !s.ReadUint8(&keyID) {
+		return ErrInvalidMessage
+	}
+	if tokenType != TokenTypeVOPRF {
+		return ErrInvalidTokenType
+	}
+	elements, ok := readElements(&s)
+	if !ok || !s.Empty() {
+		return ErrInvalidMessage
+	}
+	r.TokenType = tokenType
+	r.TruncatedTokenKeyID = keyID
+	r.BlindedElements = elements
+	return nil
+}
+
+// BatchTokenResponse is the answer of an issuer to a BatchTokenRequest. A
+// single proof covers all the evaluated elements.
+type BatchTokenResponse struct {
+	EvaluatedElements [][]byte
+	EvaluatedProof    []byte
+}
+
+// MarshalBinary encodes the response.
+func (r *BatchTokenResponse) MarshalBinary() ([]byte, error) {
+	if len(r.EvaluatedProof) != 2*voprfScalarSize {
+		return nil, ErrInvalidMessage
+	}
+	var b cryptobyte.Builder
+	addElements(&b, r.EvaluatedElements)
+	b.AddBytes(r.EvaluatedProof)
+	return b.Bytes()
+}
+
+// UnmarshalBinary decodes a response.
+func (r *BatchTokenResponse) UnmarshalBinary(data []byte) error {
+	s := cryptobyte.String(data)
+	elements, ok := readElements(&s)
+	var proof []byte
+	if !ok || !s.ReadBytes(&proof, 2*voprfScalarSize) || !s.Empty() {
+		return ErrInvalidMessage
+	}
+	r.EvaluatedElements = elements
+	r.EvaluatedProof = append([]byte{}, proof...)
+	return nil
+}
+
+// addElements adds a list of elements prefixed by its length in bytes.
+func addElements(b *cryptobyte.Builder, elements [][]byte) {
+	b.AddUint16LengthPrefixed(func(b *cryptobyte.Builder) {
+		for _, e := range elements {
+			if len(e) != voprfElementSize {
+				b.SetError(ErrInvalidMessage)
+				return
+			}
+			b.AddBytes(e)
+		}
+	})
+}
+
+// readElements reads a list of elements written by addElements.
+func readElements(s *cryptobyte.String) ([][]byte, bool) {
+	var list cryptobyte.String
+	if !s.ReadUint16LengthPrefixed(&list) || len(list)%voprfElementSize != 0 {
+		return nil, false
+	}
+	elements := make([][]byte, 0, len(list)/voprfElementSize)
+	for !list.Empty() {
+		var e []byte
+		list.ReadBytes(&e, voprfElementSize)
+		elements = append(elements, append([]byte{}, e...))
+	}
+	return elements, true
+}
+
+// deserializeElements decodes elements and rejects the identity.
+func deserializeElements(raw [][]byte) ([]group.Element, error) {
+	g := voprfSuite.Group()
+	elements := make([]group.Element, len(raw))
+	for i := range raw {
+		elements[i] = g.NewElement()
+		if err := elements[i].UnmarshalBinary(raw[i]); err != nil || elements[i].IsIdentity() {
+			return nil, ErrInvalidMessage
+		}
+	}
+	return elements, nil
+}
+
+// serializeElements encodes elements.
+func serializeElements(elements []group.Element) ([][]byte, error) {
+	raw := make([][]byte, len(elements))
+	for i := range elements {
+		var err error
+		if raw[i], err = elements[i].MarshalBinaryCompress(); err != nil {
+			return nil, err
+		}
+	}
+	return raw, nil
+}
+
+// voprfKeyID returns the token key ID of a public key.
+func voprfKeyID(pk *oprf.PublicKey) ([]byte, error) {
+	raw, err := pk.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) != voprfElementSize {
+		return nil, ErrInvalidKey
+	}
+	return tokenKeyID(raw), nil
+}
+
+// VOPRFIssuer issues and verifies tokens of type TokenTypeVOPRF.
+type VOPRFIssuer struct {
+	server oprf.VerifiableServer
+	keyID  []byte
+}
+
+// NewVOPRFIssuer returns an issuer using a private key of oprf.SuiteP384.
+func NewVOPRFIssuer(key *oprf.PrivateKey) (*VOPRFIssuer, error) {
+	keyID, err := voprfKeyID(key.Public())
+	if err != nil {
+		return nil, err
+	}
+	return &VOPRFIssuer{oprf.NewVerifiableServer(voprfSuite, key), keyID}, nil
+}
+
+// PublicKey returns the public key of the issuer, to be given to clients.
+func (i *VOPRFIssuer) PublicKey() *oprf.PublicKey { return i.server.PublicKey() }
+
+// TokenKeyID returns the key ID of the issuer.
+func (i *VOPRFIssuer) TokenKeyID() []byte { return append([]byte{}, i.keyID...) }
+
+// TokenType returns TokenTypeVOPRF.
+func (i *VOPRFIssuer) TokenType() TokenType { return TokenTypeVOPRF }
+
+// evaluate evaluates blinded elements after checking the token type and
+// key ID of their request.
+func (i *VOPRFIssuer) evaluate(tokenType TokenType, truncatedKeyID uint8, blinded [][]byte) (
+	[][]byte, []byte, error,
+) {
+	if tokenType != TokenTypeVOPRF {
+		return nil, nil, ErrInvalidTokenType
+	}
+	if truncatedKeyID != truncate(i.keyID) {
+		return nil, nil, ErrInvalidKeyID
+	}
+	elements, err := deserializeElements(blinded)
+	if err != nil {
+		return nil, nil, err
+	}
+	eval, err := i.server.Evaluate(&oprf.EvaluationRequest{Elements: elements})
+	if err != nil {
+		return nil, nil, err
+	}
+	evaluated, err := serializeElements(eval.Elements)
+	if err != nil {
+		return nil, nil, err
+	}
+	proof, err := eval.Proof.MarshalBinary()
+	if err != nil {
+		return nil, nil, err
+	}
+	return evaluated, proof, nil
+}
+
+// Evaluate answers a token request.
+func (i *VOPRFIssuer) Evaluate(req *TokenRequest) (*VOPRFTokenResponse, error) {
+	evaluated, proof, err := i.evaluate(req.TokenType, req.TruncatedTokenKeyID,
+		[][]byte{req.BlindedMsg})
+	if err != nil {
+		return nil, err
+	}
+	return &VOPRFTokenResponse{evaluated[0], proof}, nil
+}
+
+// EvaluateBatch answers a batched token request.
+func (i *VOPRFIssuer) EvaluateBatch(req *BatchTokenRequest) (*BatchTokenResponse, error) {
+	if len(req.BlindedElements) == 0 {
+		return nil, ErrInvalidMessage
+	}
+	evaluated, proof, err := i.evaluate(req.TokenType, req.TruncatedTokenKeyID,
+		req.BlindedElements)
+	if err != nil {
+		return nil, err
+	}
+	return &BatchTokenResponse{evaluated, proof}, nil
+}
+
+// Verify checks that the token was issued with the key of the issuer.
+func (i *VOPRFIssuer) Verify(t *Token) error {
+	if err := checkToken(t, TokenTypeVOPRF, i.keyID); err != nil {
+		return err
+	}
+	if !i.server.VerifyFinalize(t.input(), t.Authenticator) {
+		return ErrInvalidToken
+	}
+	return nil
+}
+
+// VOPRFClient requests tokens of type TokenTypeVOPRF.
+type VOPRFClient struct {
+	client oprf.VerifiableClient
+	keyID  []byte
+}
+
+// NewVOPRFClient returns a client for the issuer with the given public key
+// of oprf.SuiteP384.
+func NewVOPRFClient(pk *oprf.PublicKey) (*VOPRFClient, error) {
+	keyID, err := voprfKeyID(pk)
+	if err != nil {
+		return nil, err
+	}
+	return &VOPRFClient{oprf.NewVerifiableClient(voprfSuite, pk), keyID}, nil
+}
+
+// VOPRFTokenState holds the state of a client between a request and the
+// reception of its response.
+type VOPRFTokenState struct {
+	client  oprf.VerifiableClient
+	tokens  []*Token
+	finData *oprf.FinalizeData
+}
+
+// blind creates n tokens for the challenge and blinds their inputs,
+// reading randomness from rnd.
+func (c *VOPRFClient) blind(rnd io.Reader, challenge []byte, n int) (
+	[][]byte, *VOPRFTokenState, error,
+) {
+	g := voprfSuite.Group()
+	tokens := make([]*Token, n)
+	inputs := make([][]byte, n)
+	blinds := make([]oprf.Blind, n)
+	for i := range tokens {
+		t, err := newToken(rnd, TokenTypeVOPRF, challenge, c.keyID)
+		if err != nil {
+			return nil, nil, err
+		}
+		tokens[i] = t
+		inputs[i] = t.input()
+		blinds[i] = g.RandomNonZeroScalar(rnd)
+	}
+	finData, req, err := c.client.DeterministicBlind(inputs, blinds)
+	if err != nil {
+		return nil, nil, err
+	}
+	blinded, err := serializeElements(req.Elements)
+	if err != nil {
+		return nil, nil, err
+	}
+	return blinded, &VOPRFTokenState{c.client, tokens, finData}, nil
+}
+
+// CreateTokenRequest requests a token for the encoded challenge, reading
+// randomness from rnd.
+func (c *VOPRFClient) CreateTokenRequest(rnd io.Reader, challenge []byte) (
+	*TokenRequest, *VOPRFTokenState, error,
+) {
+	blinded, state, err := c.blind(rnd, challenge, 1)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &TokenRequest{TokenTypeVOPRF, truncate(c.keyID), blinded[0]}, state, nil
+}
+
+// CreateBatchTokenRequest requests n tokens for the encoded challenge,
+// reading randomness from rnd.
+func (c *VOPRFClient) CreateBatchTokenRequest(rnd io.Reader, challenge []byte, n int) (
+	*BatchTokenRequest, *VOPRFTokenState, error,
+) {
+	if n <= 0 || n*voprfElementSize > 0xFFFF {
+		return nil, nil, ErrInvalidMessage
+	}
+	blinded, state, err := c.blind(rnd, challenge, n)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &BatchTokenRequest{TokenTypeVOPRF, truncate(c.keyID), blinded}, state, nil
+}
+
+// finalize verifies the proof of the issuer and completes the tokens.
+func (s *VOPRFTokenState) finalize(evaluated [][]byte, rawProof []byte) ([]*Token, error) {
+	if len(evaluated) != len(s.tokens) {
+		return nil, ErrInvalidMessage
+	}
+	elements, err := deserializeElements(evaluated)
+	if err != nil {
+		return nil, err
+	}
+	proof := new(dleq.Proof)
+	if len(rawProof) != 2*voprfScalarSize ||
+		proof.UnmarshalBinary(voprfSuite.Group(), rawProof) != nil {
+		return nil, ErrInvalidMessage
+	}
+	outputs, err := s.client.Finalize(s.finData, &oprf.Evaluation{Elements: elements, Proof: proof})
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	for i, t := range s.tokens {
+		t.Authenticator = outputs[i]
+	}
+	return s.tokens, nil
+}
+
+// FinalizeToken returns the token obtained from the response of the issuer
+// to a request created by CreateTokenRequest.
+func (s *VOPRFTokenState) FinalizeToken(resp *VOPRFTokenResponse) (*Token, error) {
+	tokens, err := s.finalize([][]byte{resp.EvaluateMsg}, resp.EvaluateProof)
+	if err != nil {
+		return nil, err
+	}
+	return tokens[0], nil
+}
+
+// FinalizeTokens returns the tokens obtained from the response of the
+// issuer to a request created by CreateBatchTokenRequest.
+func (s *VOPRFTokenState) FinalizeTokens(resp *BatchTokenResponse) ([]*Token, error) {
+	return s.finalize(resp.EvaluatedElements, resp.EvaluatedProof)
+}
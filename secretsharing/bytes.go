@@ -0,0 +1,118 @@
+package secretsharing
+
+import (
+	"errors"
+	"io"
+)
+
+// ErrInvalidByteShares is returned when byte shares cannot be combined.
+var ErrInvalidByteShares = errors.New("secretsharing: invalid byte shares")
+
+// ByteShare represents a share of a byte string.
+type ByteShare struct {
+	// ID uniquely identifies a share in a secret sharing instance. ID is never zero.
+	ID byte
+	// Value has the length of the secret.
+	Value []byte
+}
+
+// MarshalBinary returns the ID followed by the value.
+func (s ByteShare) MarshalBinary() ([]byte, error) {
+	return append([]byte{s.ID}, s.Value...), nil
+}
+
+func (s *ByteShare) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 || data[0] == 0 {
+		return ErrInvalidByteShares
+	}
+	s.ID, s.Value = data[0], append([]byte{}, data[1:]...)
+	return nil
+}
+
+// SplitBytes splits the secret into n shares with IDs from 1 to n, such that
+// the secret is only recovered from any subset of at least t+1 shares. Each
+// byte of the secret is shared with Shamir secret sharing over GF(256), so
+// n must be below 256.
+func SplitBytes(rnd io.Reader, t, n uint, secret []byte) ([]ByteShare, error) {
+	if n > 255 || t >= n {
+		return nil, ErrInvalidByteShares
+	}
+	coeffs := make([]byte, int(t)*len(secret))
+	if _, err := io.ReadFull(rnd, coeffs); err != nil {
+		return nil, err
+	}
+
+	shares := make([]ByteShare, n)
+	for i := range shares {
+		x := byte(i + 1)
+		shares[i] = ByteShare{ID: x, Value: make([]byte, len(secret))}
+		for k := range secret {
+			// Horner's rule on the polynomial secret[k] + sum_j c_j x^j.
+			c := coeffs[k*int(t) : (k+1)*int(t)]
+			var y byte
+			for j := len(c) - 1; j >= 0; j-- {
+				y = gfMul(y, x) ^ c[j]
+			}
+			shares[i].Value[k] = gfMul(y, x) ^ secret[k]
+		}
+	}
+	return shares, nil
+}
+
+// RecoverBytes returns the secret provided more than t different shares are
+// given.
+func RecoverBytes(t uint, shares []ByteShare) ([]byte, error) {
+	if l := len(shares); l <= int(t) {
+		return nil, errThreshold(t, uint(l))
+	}
+	shares = shares[:t+1]
+	size := len(shares[0].Value)
+	for i := range shares {
+		if shares[i].ID == 0 || len(shares[i].Value) != size {
+			return nil, ErrInvalidByteShares
+		}
+		for j := 0; j < i; j++ {
+			if shares[i].ID == shares[j].ID {
+				return nil, ErrDuplicateID
+			}
+		}
+	}
+
+	secret := make([]byte, size)
+	for i := range shares {
+		// L_i(0) = prod_{j != i} x_j / (x_j - x_i), and subtraction is XOR.
+		l := byte(1)
+		for j := range shares {
+			if j != i {
+				l = gfMul(l, gfMul(shares[j].ID, gfInv(shares[j].ID^shares[i].ID)))
+			}
+		}
+		for k := range secret {
+			secret[k] ^= gfMul(l, shares[i].Value[k])
+		}
+	}
+	return secret, nil
+}
+
+// gfMul returns a*b in GF(256) with the AES polynomial x^8+x^4+x^3+x+1, in
+// constant time.
+func gfMul(a, b byte) byte {
+	var p byte
+	for i := 0; i < 8; i++ {
+		p ^= -(b & 1) & a
+		a = (a << 1) ^ (-(a >> 7) & 0x1b)
+		b >>= 1
+	}
+	return p
+}
+
+// gfInv returns a^-1 = a^254 in GF(256), and 0 for a = 0.
+func gfInv(a byte) byte {
+	// a^254 = a^2 * a^4 * ... * a^128
+	r := byte(1)
+	for i := 0; i < 7; i++ {
+		a = gfMul(a, a)
+		r = gfMul(r, a)
+	}
+	return r
+}
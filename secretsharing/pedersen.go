@@ -0,0 +1,88 @@
+package secretsharing
+
+import (
+	"io"
+
+	"github.com/cloudflare/circl/group"
+)
+
+// PedersenShare represents a share of a Pedersen secret sharing, made of a
+// share of the secret and a share of the blinding.
+type PedersenShare struct {
+	Share
+	// Blinding stores the share of the blinding polynomial.
+	Blinding group.Scalar
+}
+
+// PedersenSecretSharing provides a (t,n) Pedersen secret sharing. The
+// commitment a_i*G + b_i*H of each coefficient a_i of the polynomial is
+// hidden by a coefficient b_i of a random blinding polynomial, where
+// nobody knows the discrete logarithm of H to the base G.
+type PedersenSecretSharing struct {
+	secret, blinding SecretSharing
+	h                group.Element
+}
+
+// NewPedersen returns a PedersenSecretSharing providing a (t,n) Pedersen
+// secret sharing with the blinding base h.
+func NewPedersen(rnd io.Reader, t uint, secret group.Scalar, h group.Element) PedersenSecretSharing {
+	g := secret.Group()
+	return PedersenSecretSharing{
+		secret:   New(rnd, t, secret),
+		blinding: New(rnd, t, g.RandomScalar(rnd)),
+		h:        h.Copy(),
+	}
+}
+
+// Share creates n shares with an ID monotonically increasing from 1 to n.
+func (ss PedersenSecretSharing) Share(n uint) []PedersenShare {
+	shares := make([]PedersenShare, n)
+	id := ss.secret.g.NewScalar()
+	for i := range shares {
+		shares[i] = ss.ShareWithID(id.SetUint64(uint64(i + 1)))
+	}
+
+	return shares
+}
+
+// ShareWithID creates one share of the secret using the ID as identifier.
+// Panics, if the ID is zero.
+func (ss PedersenSecretSharing) ShareWithID(id group.Scalar) PedersenShare {
+	return PedersenShare{
+		Share:    ss.secret.ShareWithID(id),
+		Blinding: ss.blinding.ShareWithID(id).Value,
+	}
+}
+
+// CommitSecret creates a hiding commitment to the secret for further
+// verifying shares.
+func (ss PedersenSecretSharing) CommitSecret() SecretCommitment {
+	c := ss.secret.CommitSecret()
+	for i := range c {
+		c[i].Add(c[i], ss.secret.g.NewElement().Mul(ss.h, ss.blinding.poly.Coefficient(uint(i))))
+	}
+	return c
+}
+
+// CommitSecretFeldman creates a commitment to the secret without the
+// blinding, as the one of CommitSecret of SecretSharing. It reveals
+// secret*G, and lets the shares be verified with Verify.
+func (ss PedersenSecretSharing) CommitSecretFeldman() SecretCommitment {
+	return ss.secret.CommitSecret()
+}
+
+// VerifyPedersen returns true if the share s was produced by sharing a
+// secret with threshold t, blinding base h, and commitment of the secret c.
+func VerifyPedersen(t uint, h group.Element, s PedersenShare, c SecretCommitment) bool {
+	if len(c) != int(t+1) {
+		return false
+	}
+	if s.ID.IsZero() {
+		return false
+	}
+
+	g := s.ID.Group()
+	polI := g.NewElement().MulGen(s.Value)
+	polI.Add(polI, g.NewElement().Mul(h, s.Blinding))
+	return polI.IsEqual(evaluateCommitment(c, s.ID))
+}
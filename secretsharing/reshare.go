@@ -0,0 +1,133 @@
+package secretsharing
+
+import (
+	"io"
+
+	"github.com/cloudflare/circl/group"
+	"github.com/cloudflare/circl/math/polynomial"
+)
+
+// NewRefresh returns a SecretSharing of zero with threshold t. Shares of a
+// secret are refreshed by adding to them, with AddShares, the shares of the
+// same ID of one such sharing per party. The refreshed shares recover the
+// same secret, but cannot be combined with the old shares.
+func NewRefresh(rnd io.Reader, g group.Group, t uint) SecretSharing {
+	return New(rnd, t, g.NewScalar())
+}
+
+// AddShares returns the share of the sum of the secrets of a and b, which
+// must have the same ID.
+func AddShares(a, b Share) (Share, error) {
+	if !a.ID.IsEqual(b.ID) {
+		return Share{}, ErrMismatchedShares
+	}
+	return Share{ID: a.ID.Copy(), Value: a.ID.Group().NewScalar().Add(a.Value, b.Value)}, nil
+}
+
+// AddCommitments returns the commitment of the sum of the secrets of
+// commitments a and b with the same threshold.
+func AddCommitments(a, b SecretCommitment) (SecretCommitment, error) {
+	if len(a) != len(b) || len(a) == 0 {
+		return nil, ErrMismatchedShares
+	}
+	c := make(SecretCommitment, len(a))
+	for i := range c {
+		c[i] = a[i].Group().NewElement().Add(a[i], b[i])
+	}
+	return c, nil
+}
+
+// Reshare returns a SecretSharing of the value of the share with the new
+// threshold newT. To reshare a secret to new parties, at least t+1 holders
+// of shares deal the shares of a resharing of their share to the new
+// parties, which combine them with CombineReshares.
+func Reshare(rnd io.Reader, s Share, newT uint) SecretSharing {
+	return New(rnd, newT, s.Value)
+}
+
+// CombineReshares returns the share of the secret for a new party, given the
+// shares dealt to it by the holders of at least t+1 shares, where t is the
+// old threshold. The i-th share in subshares must have been dealt by the
+// holder of the share with the ID dealers[i].
+func CombineReshares(t uint, dealers []group.Scalar, subshares []Share) (Share, error) {
+	if len(dealers) != len(subshares) {
+		return Share{}, ErrMismatchedShares
+	}
+	if l := len(subshares); l <= int(t) {
+		return Share{}, errThreshold(t, uint(l))
+	}
+	dealers, subshares = dealers[:t+1], subshares[:t+1]
+	if !areAllDifferent(dealers) {
+		return Share{}, ErrDuplicateID
+	}
+
+	g := subshares[0].ID.Group()
+	id := subshares[0].ID
+	value := g.NewScalar()
+	zero := g.NewScalar()
+	for i := range subshares {
+		if !subshares[i].ID.IsEqual(id) {
+			return Share{}, ErrMismatchedShares
+		}
+		l := polynomial.LagrangeBase(uint(i), dealers, zero)
+		value.Add(value, l.Mul(l, subshares[i].Value))
+	}
+	return Share{ID: id.Copy(), Value: value}, nil
+}
+
+// CombineCommitments returns the commitment of the reshared secret, given
+// the commitments of the resharings dealt by the holders of the shares with
+// the IDs dealers, as in CombineReshares.
+func CombineCommitments(t uint, dealers []group.Scalar, commitments []SecretCommitment) (SecretCommitment, error) {
+	if len(dealers) != len(commitments) {
+		return nil, ErrMismatchedShares
+	}
+	if l := len(commitments); l <= int(t) {
+		return nil, errThreshold(t, uint(l))
+	}
+	dealers, commitments = dealers[:t+1], commitments[:t+1]
+	if !areAllDifferent(dealers) {
+		return nil, ErrDuplicateID
+	}
+
+	g := dealers[0].Group()
+	zero := g.NewScalar()
+	var c SecretCommitment
+	for i := range commitments {
+		if len(commitments[i]) == 0 || (c != nil && len(commitments[i]) != len(c)) {
+			return nil, ErrMismatchedShares
+		}
+		if c == nil {
+			c = make(SecretCommitment, len(commitments[i]))
+			for k := range c {
+				c[k] = g.Identity()
+			}
+		}
+		l := polynomial.LagrangeBase(uint(i), dealers, zero)
+		for k := range c {
+			c[k].Add(c[k], g.NewElement().Mul(commitments[i][k], l))
+		}
+	}
+	return c, nil
+}
+
+// VerifyReshare returns true if the resharing commitment dealerCommitment,
+// dealt by the holder of the share with the ID dealer, reshares that share
+// of the secret with commitment c and threshold t.
+func VerifyReshare(t uint, dealer group.Scalar, c, dealerCommitment SecretCommitment) bool {
+	if len(c) != int(t+1) || len(dealerCommitment) == 0 || dealer.IsZero() {
+		return false
+	}
+	return evaluateCommitment(c, dealer).IsEqual(dealerCommitment[0])
+}
+
+func areAllDifferent(x []group.Scalar) bool {
+	for i := range x {
+		for j := i + 1; j < len(x); j++ {
+			if x[i].IsEqual(x[j]) {
+				return false
+			}
+		}
+	}
+	return true
+}
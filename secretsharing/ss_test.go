@@ -0,0 +1,300 @@
+package secretsharing_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+
+	"github.com/cloudflare/circl/group"
+	"github.com/cloudflare/circl/internal/test"
+	"github.com/cloudflare/circl/secretsharing"
+	"github.com/cloudflare/circl/sign/ed25519"
+)
+
+func TestSecretSharing(tt *testing.T) {
+	g := group.P256
+	t := uint(2)
+	n := uint(5)
+
+	secret := g.RandomScalar(rand.Reader)
+	ss := secretsharing.New(rand.Reader, t, secret)
+	shares := ss.Share(n)
+	test.CheckOk(len(shares) == int(n), "bad num shares", tt)
+	coms := ss.CommitSecret()
+
+	tt.Run("subsetSize", func(ttt *testing.T) {
+		// Test any possible subset size.
+		for k := 0; k <= int(n); k++ {
+			got, err := secretsharing.Recover(t, shares[:k])
+			if !(int(t) < k && k <= int(n)) {
+				test.CheckIsErr(ttt, err, "should not recover secret")
+				test.CheckOk(got == nil, "not nil secret", ttt)
+			} else {
+				test.CheckNoErr(ttt, err, "should recover secret")
+				want := secret
+				if !got.IsEqual(want) {
+					test.ReportError(ttt, got, want, t, k, n)
+				}
+			}
+		}
+	})
+
+	tt.Run("verifyShares", func(ttt *testing.T) {
+		for i := range shares {
+			test.CheckOk(secretsharing.Verify(t, shares[i], coms) == true, "failed one share", ttt)
+		}
+	})
+
+	tt.Run("badShares", func(ttt *testing.T) {
+		badShares := make([]secretsharing.Share, len(shares))
+		for i := range shares {
+			badShares[i].ID = shares[i].ID.Copy()
+			badShares[i].Value = shares[i].Value.Copy()
+			badShares[i].Value.SetUint64(9)
+		}
+
+		for i := range badShares {
+			test.CheckOk(secretsharing.Verify(t, badShares[i], coms) == false, "verify must fail due to bad shares", ttt)
+		}
+	})
+
+	tt.Run("badCommitments", func(ttt *testing.T) {
+		badComs := make(secretsharing.SecretCommitment, len(coms))
+		for i := range coms {
+			badComs[i] = coms[i].Copy()
+			badComs[i].Dbl(badComs[i])
+		}
+
+		for i := range shares {
+			test.CheckOk(secretsharing.Verify(t, shares[i], badComs) == false, "verify must fail due to bad commitment", ttt)
+		}
+	})
+}
+
+func TestShareWithID(tt *testing.T) {
+	g := group.P256
+	t := uint(2)
+	n := uint(5)
+	secret := g.RandomScalar(rand.Reader)
+	ss := secretsharing.New(rand.Reader, t, secret)
+
+	tt.Run("recoverOk", func(ttt *testing.T) {
+		// SecretSharing can create shares at will, not exactly n many.
+		shares := []secretsharing.Share{
+			ss.ShareWithID(g.RandomScalar(rand.Reader)),
+			ss.ShareWithID(g.RandomScalar(rand.Reader)),
+			ss.ShareWithID(g.RandomScalar(rand.Reader)),
+		}
+		got, err := secretsharing.Recover(t, shares)
+		test.CheckNoErr(tt, err, "failed to recover the secret")
+		want := secret
+		if !got.IsEqual(want) {
+			test.ReportError(tt, got, want, t, n)
+		}
+	})
+
+	tt.Run("duplicatedFail", func(ttt *testing.T) {
+		// Panics if trying to recover duplicated shares.
+		share := ss.ShareWithID(g.RandomScalar(rand.Reader))
+		sameShares := []secretsharing.Share{share, share, share}
+		err := test.CheckPanic(func() {
+			got, err := secretsharing.Recover(t, sameShares)
+			test.CheckIsErr(tt, err, "must fail to recover the secret")
+			test.CheckOk(got == nil, "must not recover", tt)
+		})
+		test.CheckOk(err == nil, "must panic", tt)
+	})
+}
+
+func BenchmarkSecretSharing(b *testing.B) {
+	g := group.P256
+	t := uint(3)
+	n := uint(5)
+
+	secret := g.RandomScalar(rand.Reader)
+	ss := secretsharing.New(rand.Reader, t, secret)
+	shares := ss.Share(n)
+	coms := ss.CommitSecret()
+
+	b.Run("New", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			secretsharing.New(rand.Reader, t, secret)
+		}
+	})
+
+	b.Run("Share", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			ss.Share(n)
+		}
+	})
+
+	b.Run("Recover", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_, _ = secretsharing.Recover(t, shares)
+		}
+	})
+
+	b.Run("CommitSecret", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			ss.CommitSecret()
+		}
+	})
+
+	b.Run("Verify", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			secretsharing.Verify(t, shares[0], coms)
+		}
+	})
+}
+
+func TestPedersen(tt *testing.T) {
+	g := group.Ristretto255
+	t := uint(2)
+	n := uint(5)
+	h := g.HashToElement([]byte("H"), []byte("test"))
+
+	secret := g.RandomScalar(rand.Reader)
+	ss := secretsharing.NewPedersen(rand.Reader, t, secret, h)
+	shares := ss.Share(n)
+	coms := ss.CommitSecret()
+	for i := range shares {
+		test.CheckOk(secretsharing.VerifyPedersen(t, h, shares[i], coms), "failed one share", tt)
+		test.CheckOk(!secretsharing.Verify(t, shares[i].Share, coms), "commitment must be hiding", tt)
+	}
+
+	bad := shares[0]
+	bad.Blinding = g.RandomScalar(rand.Reader)
+	test.CheckOk(!secretsharing.VerifyPedersen(t, h, bad, coms), "verify must fail due to bad blinding", tt)
+	test.CheckOk(!secretsharing.VerifyPedersen(t+1, h, shares[0], coms), "verify must fail due to bad threshold", tt)
+
+	plain := make([]secretsharing.Share, n)
+	for i := range shares {
+		plain[i] = shares[i].Share
+	}
+	got, err := secretsharing.Recover(t, plain)
+	test.CheckNoErr(tt, err, "failed to recover the secret")
+	test.CheckOk(got.IsEqual(secret), "wrong secret", tt)
+}
+
+func TestRefresh(tt *testing.T) {
+	g := group.P256
+	t := uint(2)
+	n := uint(4)
+
+	secret := g.RandomScalar(rand.Reader)
+	ss := secretsharing.New(rand.Reader, t, secret)
+	shares := ss.Share(n)
+	coms := ss.CommitSecret()
+
+	// Every party deals a sharing of zero.
+	for p := uint(0); p < n; p++ {
+		zero := secretsharing.NewRefresh(rand.Reader, g, t)
+		zeroShares := zero.Share(n)
+		var err error
+		for i := range shares {
+			shares[i], err = secretsharing.AddShares(shares[i], zeroShares[i])
+			test.CheckNoErr(tt, err, "add shares")
+		}
+		coms, err = secretsharing.AddCommitments(coms, zero.CommitSecret())
+		test.CheckNoErr(tt, err, "add commitments")
+	}
+	for i := range shares {
+		test.CheckOk(secretsharing.Verify(t, shares[i], coms), "failed one refreshed share", tt)
+	}
+	got, err := secretsharing.Recover(t, shares[1:])
+	test.CheckNoErr(tt, err, "failed to recover the secret")
+	test.CheckOk(got.IsEqual(secret), "wrong secret", tt)
+
+	_, err = secretsharing.AddShares(shares[0], shares[1])
+	test.CheckIsErr(tt, err, "must fail to add shares with different IDs")
+}
+
+func TestReshare(tt *testing.T) {
+	g := group.P256
+	oldT, newT := uint(1), uint(3)
+	newN := uint(6)
+
+	secret := g.RandomScalar(rand.Reader)
+	ss := secretsharing.New(rand.Reader, oldT, secret)
+	shares := ss.Share(3)
+	coms := ss.CommitSecret()
+
+	// The holders of shares 3 and 1 reshare to the new parties.
+	dealers := []group.Scalar{shares[2].ID, shares[0].ID}
+	subshares := make([][]secretsharing.Share, len(dealers))
+	dealerComs := make([]secretsharing.SecretCommitment, len(dealers))
+	for i, s := range []secretsharing.Share{shares[2], shares[0]} {
+		reshare := secretsharing.Reshare(rand.Reader, s, newT)
+		subshares[i] = reshare.Share(newN)
+		dealerComs[i] = reshare.CommitSecret()
+		test.CheckOk(secretsharing.VerifyReshare(oldT, dealers[i], coms, dealerComs[i]), "failed to verify resharing", tt)
+	}
+	test.CheckOk(!secretsharing.VerifyReshare(oldT, dealers[1], coms, dealerComs[0]), "verify must fail due to wrong dealer", tt)
+
+	newComs, err := secretsharing.CombineCommitments(oldT, dealers, dealerComs)
+	test.CheckNoErr(tt, err, "combine commitments")
+	test.CheckOk(newComs[0].IsEqual(coms[0]), "the commitment to the secret must not change", tt)
+
+	newShares := make([]secretsharing.Share, newN)
+	for j := range newShares {
+		received := []secretsharing.Share{subshares[0][j], subshares[1][j]}
+		newShares[j], err = secretsharing.CombineReshares(oldT, dealers, received)
+		test.CheckNoErr(tt, err, "combine reshares")
+		test.CheckOk(secretsharing.Verify(newT, newShares[j], newComs), "failed one new share", tt)
+	}
+
+	_, err = secretsharing.Recover(newT, newShares[:newT])
+	test.CheckIsErr(tt, err, "must fail to recover with the old threshold")
+	got, err := secretsharing.Recover(newT, newShares[2:])
+	test.CheckNoErr(tt, err, "failed to recover the secret")
+	test.CheckOk(got.IsEqual(secret), "wrong secret", tt)
+
+	_, err = secretsharing.CombineReshares(oldT, dealers[:1], subshares[0][:1])
+	test.CheckIsErr(tt, err, "must fail below the threshold")
+	_, err = secretsharing.CombineReshares(oldT, []group.Scalar{dealers[0], dealers[0]}, []secretsharing.Share{subshares[0][0], subshares[1][0]})
+	test.CheckIsErr(tt, err, "must fail with duplicated dealers")
+	_, err = secretsharing.CombineReshares(oldT, dealers, []secretsharing.Share{subshares[0][0], subshares[1][1]})
+	test.CheckIsErr(tt, err, "must fail with mismatched IDs")
+}
+
+func TestBytes(tt *testing.T) {
+	_, sk, err := ed25519.GenerateKey(rand.Reader)
+	test.CheckNoErr(tt, err, "key generation")
+	secret, err := sk.MarshalBinary()
+	test.CheckNoErr(tt, err, "marshal private key")
+
+	t := uint(2)
+	n := uint(5)
+	shares, err := secretsharing.SplitBytes(rand.Reader, t, n, secret)
+	test.CheckNoErr(tt, err, "split")
+
+	for k := 0; k <= int(n); k++ {
+		got, err := secretsharing.RecoverBytes(t, shares[int(n)-k:])
+		if k <= int(t) {
+			test.CheckIsErr(tt, err, "should not recover secret")
+		} else {
+			test.CheckNoErr(tt, err, "should recover secret")
+			test.CheckOk(bytes.Equal(got, secret), "wrong secret", tt)
+		}
+	}
+
+	enc, err := shares[3].MarshalBinary()
+	test.CheckNoErr(tt, err, "marshal share")
+	var share secretsharing.ByteShare
+	test.CheckNoErr(tt, share.UnmarshalBinary(enc), "unmarshal share")
+	got, err := secretsharing.RecoverBytes(t, []secretsharing.ByteShare{shares[0], share, shares[4]})
+	test.CheckNoErr(tt, err, "should recover secret")
+	test.CheckOk(bytes.Equal(got, secret), "wrong secret", tt)
+
+	_, err = secretsharing.RecoverBytes(t, []secretsharing.ByteShare{shares[0], shares[0], shares[1]})
+	test.CheckIsErr(tt, err, "must fail with duplicated shares")
+	_, err = secretsharing.SplitBytes(rand.Reader, 1, 256, secret)
+	test.CheckIsErr(tt, err, "must fail with too many shares")
+	_, err = secretsharing.SplitBytes(rand.Reader, 3, 3, secret)
+	test.CheckIsErr(tt, err, "must fail with a threshold above the shares")
+
+	// A share of zero threshold is the secret itself.
+	shares, err = secretsharing.SplitBytes(rand.Reader, 0, 1, secret)
+	test.CheckNoErr(tt, err, "split")
+	test.CheckOk(bytes.Equal(shares[0].Value, secret), "wrong share", tt)
+}
@@ -0,0 +1,386 @@
+// Package bbs implements BBS signatures on the BLS12-381 curve.
+//
+// BBS is a multi-message signature scheme: a single signature is computed
+// over an ordered list of messages and a header. From a signature, its
+// holder can generate zero-knowledge proofs of knowledge of the signature
+// that reveal only a chosen subset of the messages. Proofs are bound to a
+// presentation header, which can carry a nonce chosen by the verifier, and
+// two proofs of the same signature cannot be linked.
+//
+// Public keys are elements of G2 and signatures are an element of G1 and a
+// scalar, 80 bytes in total. The message generators are derived with
+// hash-to-curve, so any number of messages can be signed with the same key.
+//
+// The SHA-256 and SHAKE-256 ciphersuites are supported, which use the
+// hash-to-scalar message mapping (the H2G_HM2S_ interface).
+//
+// References:
+//   - draft-irtf-cfrg-bbs-signatures: https://datatracker.ietf.org/doc/draft-irtf-cfrg-bbs-signatures/
+package bbs
+
+import (
+	"crypto"
+	_ "crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"io"
+	"sync"
+
+	bls "github.com/cloudflare/circl/ecc/bls12381"
+	"github.com/cloudflare/circl/expander"
+	"github.com/cloudflare/circl/xof"
+)
+
+const (
+	// PrivateKeySize is the size in bytes of an encoded private key.
+	PrivateKeySize = bls.ScalarSize
+	// PublicKeySize is the size in bytes of an encoded public key.
+	PublicKeySize = bls.G2SizeCompressed
+	// SignatureSize is the size in bytes of a signature.
+	SignatureSize = bls.G1SizeCompressed + bls.ScalarSize
+
+	// expandLen is the number of uniform bytes hashed to a scalar.
+	expandLen = 48
+	// minKeyMaterialSize is the minimum size of the key material for KeyGen.
+	minKeyMaterialSize = 32
+)
+
+var (
+	// ErrInvalidSuite is returned when a ciphersuite is not supported.
+	ErrInvalidSuite = errors.New("bbs: invalid suite")
+	// ErrInvalidKey is returned when a key cannot be decoded or generated.
+	ErrInvalidKey = errors.New("bbs: invalid key")
+	// ErrShortKeyMaterial is returned when the key material is shorter than
+	// 32 bytes.
+	ErrShortKeyMaterial = errors.New("bbs: key material shorter than 32 bytes")
+	// ErrInvalidSignature is returned when a signature is malformed or does
+	// not verify.
+	ErrInvalidSignature = errors.New("bbs: invalid signature")
+	// ErrInvalidProof is returned when a proof is malformed or does not
+	// verify.
+	ErrInvalidProof = errors.New("bbs: invalid proof")
+	// ErrInvalidIndexes is returned when the disclosed indexes are out of
+	// range or repeated.
+	ErrInvalidIndexes = errors.New("bbs: invalid disclosed indexes")
+)
+
+// Suite is a BBS ciphersuite.
+type Suite interface {
+	// Identifier returns the ciphersuite_id of the suite.
+	Identifier() string
+	cannotBeImplementedExternally()
+}
+
+var (
+	// SuiteBLS12381SHA256 is the BLS12-381-SHA-256 ciphersuite.
+	SuiteBLS12381SHA256 Suite = &params{
+		identifier: "BBS_BLS12381G1_XMD:SHA-256_SSWU_RO_",
+		hash:       crypto.SHA256,
+	}
+	// SuiteBLS12381SHAKE256 is the BLS12-381-SHAKE-256 ciphersuite.
+	SuiteBLS12381SHAKE256 Suite = &params{
+		identifier: "BBS_BLS12381G1_XOF:SHAKE-256_SSWU_RO_",
+		xof:        xof.SHAKE256,
+	}
+)
+
+type params struct {
+	identifier string
+	hash       crypto.Hash
+	xof        xof.ID
+
+	once sync.Once
+	p1   bls.G1
+}
+
+func (p *params) cannotBeImplementedExternally() {}
+
+func (p *params) String() string     { return p.identifier }
+func (p *params) Identifier() string { return p.identifier }
+
+func getParams(s Suite) *params {
+	p, ok := s.(*params)
+	if !ok {
+		panic(ErrInvalidSuite)
+	}
+	return p
+}
+
+// apiID returns the api_id of the hash-to-scalar interface of the suite.
+func (p *params) apiID() []byte { return []byte(p.identifier + "H2G_HM2S_") }
+
+// dst returns the api_id followed by the given suffix.
+func (p *params) dst(suffix string) []byte { return append(p.apiID(), suffix...) }
+
+func (p *params) newExpander(dst []byte) expander.Expander {
+	if p.xof != 0 {
+		return expander.NewExpanderXOF(p.xof, 128, dst)
+	}
+	return expander.NewExpanderMD(p.hash, dst)
+}
+
+func (p *params) expand(msg, dst []byte, n uint) []byte {
+	return p.newExpander(dst).Expand(msg, n)
+}
+
+func (p *params) hashToCurve(msg, dst []byte) *bls.G1 {
+	g := new(bls.G1)
+	g.HashWithExpander(p.newExpander(dst), msg)
+	return g
+}
+
+// hashToScalar is hash_to_scalar of the draft.
+func (p *params) hashToScalar(msg, dst []byte) *bls.Scalar {
+	s := new(bls.Scalar)
+	s.SetBytes(p.expand(msg, dst, expandLen))
+	return s
+}
+
+// createGenerators returns count generators derived from the seed.
+func (p *params) createGenerators(seed []byte, count int) []*bls.G1 {
+	seedDST := p.dst("SIG_GENERATOR_SEED_")
+	generatorDST := p.dst("SIG_GENERATOR_DST_")
+	v := p.expand(seed, seedDST, expandLen)
+	generators := make([]*bls.G1, count)
+	for i := range generators {
+		var ser serializer
+		ser.b = v
+		ser.uint64(uint64(i + 1))
+		v = p.expand(ser.b, seedDST, expandLen)
+		generators[i] = p.hashToCurve(v, generatorDST)
+	}
+	return generators
+}
+
+// generators returns the generators Q_1, H_1, ..., H_L for L messages.
+func (p *params) generators(l int) []*bls.G1 {
+	return p.createGenerators(p.dst("MESSAGE_GENERATOR_SEED"), l+1)
+}
+
+// basePoint returns the point P1 of the suite.
+func (p *params) basePoint() *bls.G1 {
+	p.once.Do(func() { p.p1 = *p.createGenerators(p.dst("BP_MESSAGE_GENERATOR_SEED"), 1)[0] })
+	p1 := p.p1
+	return &p1
+}
+
+// messagesToScalars maps the messages to scalars.
+func (p *params) messagesToScalars(messages [][]byte) []*bls.Scalar {
+	dst := p.dst("MAP_MSG_TO_SCALAR_AS_HASH_")
+	scalars := make([]*bls.Scalar, len(messages))
+	for i := range messages {
+		scalars[i] = p.hashToScalar(messages[i], dst)
+	}
+	return scalars
+}
+
+// calculateDomain binds the public key, the generators and the header.
+func (p *params) calculateDomain(pk *PublicKey, generators []*bls.G1, header []byte) *bls.Scalar {
+	var s serializer
+	s.b = append(s.b, pk.w.BytesCompressed()...)
+	s.uint64(uint64(len(generators) - 1))
+	s.points(generators...)
+	s.b = append(s.b, p.apiID()...)
+	s.uint64(uint64(len(header)))
+	s.b = append(s.b, header...)
+	return p.hashToScalar(s.b, p.dst("H2S_"))
+}
+
+// commitment returns B = P1 + Q_1*domain + H_1*msg_1 + ... + H_L*msg_L,
+// using only the generators and messages at the given indexes.
+func (p *params) commitment(domain *bls.Scalar, generators []*bls.G1, scalars []*bls.Scalar, indexes []int) *bls.G1 {
+	b := p.basePoint()
+	var t bls.G1
+	t.ScalarMult(domain, generators[0])
+	b.Add(b, &t)
+	for j, i := range indexes {
+		t.ScalarMult(scalars[j], generators[i+1])
+		b.Add(b, &t)
+	}
+	return b
+}
+
+// serializer builds the inputs of hash_to_scalar.
+type serializer struct{ b []byte }
+
+func (s *serializer) uint64(i uint64) {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], i)
+	s.b = append(s.b, b[:]...)
+}
+
+func (s *serializer) points(points ...*bls.G1) {
+	for _, p := range points {
+		s.b = append(s.b, p.BytesCompressed()...)
+	}
+}
+
+func (s *serializer) scalars(scalars ...*bls.Scalar) {
+	for _, k := range scalars {
+		b, _ := k.MarshalBinary()
+		s.b = append(s.b, b...)
+	}
+}
+
+// PrivateKey is a BBS private key.
+type PrivateKey struct {
+	sk  bls.Scalar
+	pub *PublicKey
+}
+
+// PublicKey is a BBS public key.
+type PublicKey struct{ w bls.G2 }
+
+// KeyGen deterministically derives a private key from at least 32 bytes of
+// secret key material, and an optional key info. If keyDST is nil, the
+// default domain separation tag of the suite is used.
+func KeyGen(s Suite, keyMaterial, keyInfo, keyDST []byte) (*PrivateKey, error) {
+	p := getParams(s)
+	if len(keyMaterial) < minKeyMaterialSize {
+		return nil, ErrShortKeyMaterial
+	}
+	if len(keyInfo) > 0xFFFF {
+		return nil, ErrInvalidKey
+	}
+	if keyDST == nil {
+		keyDST = p.dst("KEYGEN_DST_")
+	}
+	input := append([]byte{}, keyMaterial...)
+	input = append(input, byte(len(keyInfo)>>8), byte(len(keyInfo)))
+	input = append(input, keyInfo...)
+
+	sk := &PrivateKey{sk: *p.hashToScalar(input, keyDST)}
+	if sk.sk.IsZero() == 1 {
+		return nil, ErrInvalidKey
+	}
+	return sk, nil
+}
+
+// GenerateKey generates a private key from 32 bytes of key material read
+// from rnd.
+func GenerateKey(s Suite, rnd io.Reader) (*PrivateKey, error) {
+	keyMaterial := make([]byte, minKeyMaterialSize)
+	if _, err := io.ReadFull(rnd, keyMaterial); err != nil {
+		return nil, err
+	}
+	return KeyGen(s, keyMaterial, nil, nil)
+}
+
+// Public returns the public key of the private key.
+func (k *PrivateKey) Public() *PublicKey {
+	if k.pub == nil {
+		k.pub = new(PublicKey)
+		k.pub.w.ScalarMult(&k.sk, bls.G2Generator())
+	}
+	return k.pub
+}
+
+func (k *PrivateKey) MarshalBinary() ([]byte, error) { return k.sk.MarshalBinary() }
+func (k *PublicKey) MarshalBinary() ([]byte, error)  { return k.w.BytesCompressed(), nil }
+
+func (k *PrivateKey) UnmarshalBinary(data []byte) error {
+	var sk bls.Scalar
+	if len(data) != PrivateKeySize || sk.UnmarshalBinary(data) != nil || sk.IsZero() == 1 {
+		return ErrInvalidKey
+	}
+	k.sk, k.pub = sk, nil
+	return nil
+}
+
+func (k *PublicKey) UnmarshalBinary(data []byte) error {
+	var w bls.G2
+	if len(data) != PublicKeySize || w.SetBytes(data) != nil || !w.IsOnG2() || w.IsIdentity() {
+		return ErrInvalidKey
+	}
+	k.w = w
+	return nil
+}
+
+// Equal reports whether the public keys are equal.
+func (k *PublicKey) Equal(x *PublicKey) bool { return k.w.IsEqual(&x.w) }
+
+// allIndexes returns 0, 1, ..., n-1.
+func allIndexes(n int) []int {
+	indexes := make([]int, n)
+	for i := range indexes {
+		indexes[i] = i
+	}
+	return indexes
+}
+
+// Sign signs the header and the messages.
+func Sign(s Suite, sk *PrivateKey, header []byte, messages [][]byte) ([]byte, error) {
+	p := getParams(s)
+	scalars := p.messagesToScalars(messages)
+	generators := p.generators(len(messages))
+	domain := p.calculateDomain(sk.Public(), generators, header)
+
+	var ser serializer
+	ser.scalars(&sk.sk)
+	ser.scalars(scalars...)
+	ser.scalars(domain)
+	e := p.hashToScalar(ser.b, p.dst("H2S_"))
+
+	// A = B * (1 / (SK + e))
+	var inv bls.Scalar
+	inv.Add(&sk.sk, e)
+	if inv.IsZero() == 1 {
+		return nil, ErrInvalidKey
+	}
+	inv.Inv(&inv)
+	var a bls.G1
+	a.ScalarMult(&inv, p.commitment(domain, generators, scalars, allIndexes(len(messages))))
+
+	ser = serializer{}
+	ser.points(&a)
+	ser.scalars(e)
+	return ser.b, nil
+}
+
+// Verify checks that the signature is valid for the header and the
+// messages under the public key.
+func Verify(s Suite, pk *PublicKey, signature, header []byte, messages [][]byte) error {
+	p := getParams(s)
+	a, e, err := decodeSignature(signature)
+	if err != nil {
+		return err
+	}
+	scalars := p.messagesToScalars(messages)
+	generators := p.generators(len(messages))
+	domain := p.calculateDomain(pk, generators, header)
+	b := p.commitment(domain, generators, scalars, allIndexes(len(messages)))
+
+	// e(A, W + BP2*e) * e(B, -BP2) = 1
+	var we bls.G2
+	we.ScalarMult(e, bls.G2Generator())
+	we.Add(&we, &pk.w)
+	res := bls.ProdPairFrac([]*bls.G1{a, b}, []*bls.G2{&we, bls.G2Generator()}, []int{1, -1})
+	if !res.IsIdentity() {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+func decodeSignature(signature []byte) (*bls.G1, *bls.Scalar, error) {
+	if len(signature) != SignatureSize {
+		return nil, nil, ErrInvalidSignature
+	}
+	a, err := decodePoint(signature[:bls.G1SizeCompressed])
+	if err != nil {
+		return nil, nil, ErrInvalidSignature
+	}
+	e := new(bls.Scalar)
+	if e.UnmarshalBinary(signature[bls.G1SizeCompressed:]) != nil || e.IsZero() == 1 {
+		return nil, nil, ErrInvalidSignature
+	}
+	return a, e, nil
+}
+
+// decodePoint decodes a compressed element of G1 other than the identity.
+func decodePoint(data []byte) (*bls.G1, error) {
+	g := new(bls.G1)
+	if g.SetBytes(data) != nil || !g.IsOnG1() || g.IsIdentity() {
+		return nil, ErrInvalidSignature
+	}
+	return g, nil
+}
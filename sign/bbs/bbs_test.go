@@ -0,0 +1,222 @@
+package bbs
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/cloudflare/circl/internal/test"
+)
+
+type hexBytes []byte
+
+func (h *hexBytes) UnmarshalJSON(data []byte) (err error) {
+	var s string
+	if err = json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	*h, err = hex.DecodeString(s)
+	return err
+}
+
+type fixtures struct {
+	Ciphersuite string `json:"ciphersuite"`
+	KeyPair     struct {
+		KeyMaterial hexBytes `json:"keyMaterial"`
+		KeyInfo     hexBytes `json:"keyInfo"`
+		SecretKey   hexBytes `json:"secretKey"`
+		PublicKey   hexBytes `json:"publicKey"`
+	} `json:"keyPair"`
+	Generators struct {
+		P1            hexBytes   `json:"P1"`
+		Q1            hexBytes   `json:"Q1"`
+		MsgGenerators []hexBytes `json:"MsgGenerators"`
+	} `json:"generators"`
+	MockedRandomScalars struct {
+		Seed hexBytes `json:"seed"`
+		DST  string   `json:"dst"`
+	} `json:"mockedRandomScalars"`
+	Signatures []struct {
+		CaseName  string     `json:"caseName"`
+		Header    hexBytes   `json:"header"`
+		Messages  []hexBytes `json:"messages"`
+		Signature hexBytes   `json:"signature"`
+		Valid     bool       `json:"valid"`
+	} `json:"signatures"`
+	Proofs []struct {
+		CaseName           string     `json:"caseName"`
+		Header             hexBytes   `json:"header"`
+		PresentationHeader hexBytes   `json:"presentationHeader"`
+		Messages           []hexBytes `json:"messages"`
+		DisclosedIndexes   []uint     `json:"disclosedIndexes"`
+		Signature          hexBytes   `json:"signature"`
+		Proof              hexBytes   `json:"proof"`
+		Valid              bool       `json:"valid"`
+	} `json:"proofs"`
+}
+
+func toMessages(in []hexBytes) [][]byte {
+	out := make([][]byte, len(in))
+	for i := range in {
+		out[i] = in[i]
+	}
+	return out
+}
+
+func checkBytes(t *testing.T, got, want []byte, name string) {
+	t.Helper()
+	if !bytes.Equal(got, want) {
+		test.ReportError(t, hex.EncodeToString(got), hex.EncodeToString(want), name)
+	}
+}
+
+// TestFixtures checks the fixtures of draft-irtf-cfrg-bbs-signatures.
+func TestFixtures(t *testing.T) {
+	for _, v := range []struct {
+		suite Suite
+		file  string
+		// P1 and the secret key of the key pair as given in the draft.
+		p1, sk string
+	}{
+		{
+			SuiteBLS12381SHA256,
+			"testdata/bls12381_sha256.json",
+			"a8ce256102840821a3e94ea9025e4662b205762f9776b3a766c872b948f1fd225e7c59698588e70d11406d161b4e28c9",
+			"60e55110f76883a13d030b2f6bd11883422d5abde717569fc0731f51237169fc",
+		},
+		{
+			SuiteBLS12381SHAKE256,
+			"testdata/bls12381_shake256.json",
+			"8929dfbc7e6642c4ed9cba0856e493f8b9d7d5fcb0c31ef8fdcd34d50648a56c795e106e9eada6e0bda386b414150755",
+			"2eee0f60a8a3a8bec0ee942bfd46cbdae9a0738ee68f5a64e7238311cf09a079",
+		},
+	} {
+		t.Run(v.suite.Identifier(), func(t *testing.T) {
+			data, err := os.ReadFile(v.file)
+			test.CheckNoErr(t, err, "read fixtures")
+			var f fixtures
+			test.CheckNoErr(t, json.Unmarshal(data, &f), "parse fixtures")
+			if f.Ciphersuite != v.suite.Identifier() {
+				test.ReportError(t, f.Ciphersuite, v.suite.Identifier())
+			}
+			p := getParams(v.suite)
+
+			// Generators.
+			checkBytes(t, p.basePoint().BytesCompressed(), mustHex(v.p1), "P1")
+			checkBytes(t, f.Generators.P1, mustHex(v.p1), "P1")
+			generators := p.generators(len(f.Generators.MsgGenerators))
+			checkBytes(t, generators[0].BytesCompressed(), f.Generators.Q1, "Q1")
+			for i, h := range f.Generators.MsgGenerators {
+				checkBytes(t, generators[i+1].BytesCompressed(), h, "H_i")
+			}
+
+			// Key generation.
+			sk, err := KeyGen(v.suite, f.KeyPair.KeyMaterial, f.KeyPair.KeyInfo, nil)
+			test.CheckNoErr(t, err, "key generation")
+			raw, err := sk.MarshalBinary()
+			test.CheckNoErr(t, err, "marshal private key")
+			checkBytes(t, raw, f.KeyPair.SecretKey, "secret key")
+			checkBytes(t, raw, mustHex(v.sk), "secret key")
+			raw, err = sk.Public().MarshalBinary()
+			test.CheckNoErr(t, err, "marshal public key")
+			checkBytes(t, raw, f.KeyPair.PublicKey, "public key")
+			pk := new(PublicKey)
+			test.CheckNoErr(t, pk.UnmarshalBinary(f.KeyPair.PublicKey), "unmarshal public key")
+
+			for _, c := range f.Signatures {
+				messages := toMessages(c.Messages)
+				err := Verify(v.suite, pk, c.Signature, c.Header, messages)
+				if (err == nil) != c.Valid {
+					test.ReportError(t, err, c.Valid, c.CaseName)
+				}
+				if c.Valid {
+					sig, err := Sign(v.suite, sk, c.Header, messages)
+					test.CheckNoErr(t, err, "sign")
+					checkBytes(t, sig, c.Signature, c.CaseName)
+				}
+			}
+
+			mock := f.MockedRandomScalars
+			if string(p.dst("MOCK_RANDOM_SCALARS_DST_")) != mock.DST {
+				test.ReportError(t, mock.DST, string(p.dst("MOCK_RANDOM_SCALARS_DST_")))
+			}
+			for _, c := range f.Proofs {
+				messages := toMessages(c.Messages)
+				disclosed := make([][]byte, len(c.DisclosedIndexes))
+				for i, index := range c.DisclosedIndexes {
+					disclosed[i] = messages[index]
+				}
+				err := ProofVerify(v.suite, pk, c.Proof, c.Header, c.PresentationHeader, disclosed, c.DisclosedIndexes)
+				if (err == nil) != c.Valid {
+					test.ReportError(t, err, c.Valid, c.CaseName)
+				}
+				if c.Valid {
+					count := 5 + len(messages) - len(c.DisclosedIndexes)
+					rnd := bytes.NewReader(p.expand(mock.Seed, []byte(mock.DST), uint(count*expandLen)))
+					proof, err := ProofGen(v.suite, rnd, pk, c.Signature, c.Header, c.PresentationHeader, messages, c.DisclosedIndexes)
+					test.CheckNoErr(t, err, "proof generation")
+					checkBytes(t, proof, c.Proof, c.CaseName)
+				}
+			}
+		})
+	}
+}
+
+func mustHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+func TestSelectiveDisclosure(t *testing.T) {
+	for _, s := range []Suite{SuiteBLS12381SHA256, SuiteBLS12381SHAKE256} {
+		t.Run(s.Identifier(), func(t *testing.T) {
+			sk, err := GenerateKey(s, rand.Reader)
+			test.CheckNoErr(t, err, "key generation")
+			pk := sk.Public()
+			header, ph := []byte("header"), []byte("nonce")
+			messages := [][]byte{[]byte("name"), []byte("birth date"), []byte("address")}
+
+			sig, err := Sign(s, sk, header, messages)
+			test.CheckNoErr(t, err, "sign")
+			test.CheckNoErr(t, Verify(s, pk, sig, header, messages), "verify")
+
+			proof, err := ProofGen(s, rand.Reader, pk, sig, header, ph, messages, []uint{2, 0})
+			test.CheckNoErr(t, err, "proof generation")
+			if len(proof) != ProofSize(1) {
+				test.ReportError(t, len(proof), ProofSize(1))
+			}
+			disclosed := [][]byte{messages[2], messages[0]}
+			test.CheckNoErr(t, ProofVerify(s, pk, proof, header, ph, disclosed, []uint{2, 0}), "proof verify")
+
+			// Proofs of the same signature are not linkable.
+			proof2, err := ProofGen(s, rand.Reader, pk, sig, header, ph, messages, []uint{2, 0})
+			test.CheckNoErr(t, err, "proof generation")
+			if bytes.Equal(proof[:48], proof2[:48]) {
+				test.ReportError(t, proof2[:48], proof[:48])
+			}
+
+			other, err := GenerateKey(s, rand.Reader)
+			test.CheckNoErr(t, err, "key generation")
+			test.CheckIsErr(t, Verify(s, other.Public(), sig, header, messages), "other key must fail")
+			test.CheckIsErr(t, ProofVerify(s, other.Public(), proof, header, ph, disclosed, []uint{2, 0}), "other key must fail")
+			test.CheckIsErr(t, ProofVerify(s, pk, proof[1:], header, ph, disclosed, []uint{2, 0}), "short proof must fail")
+
+			for _, indexes := range [][]uint{{0, 0}, {3}, {0, 1, 2, 2}} {
+				_, err = ProofGen(s, rand.Reader, pk, sig, header, ph, messages, indexes)
+				if err != ErrInvalidIndexes {
+					test.ReportError(t, err, ErrInvalidIndexes, indexes)
+				}
+			}
+			_, err = KeyGen(s, make([]byte, 31), nil, nil)
+			if err != ErrShortKeyMaterial {
+				test.ReportError(t, err, ErrShortKeyMaterial)
+			}
+		})
+	}
+}
@@ -0,0 +1,246 @@
+package bbs
+
+import (
+	"io"
+	"sort"
+
+	bls "github.com/cloudflare/circl/ecc/bls12381"
+)
+
+// ProofSize returns the size in bytes of a proof that leaves undisclosed
+// messages hidden.
+func ProofSize(undisclosed int) int {
+	return 3*bls.G1SizeCompressed + (4+undisclosed)*bls.ScalarSize
+}
+
+// checkIndexes returns the disclosed indexes in increasing order, and the
+// undisclosed indexes out of l messages.
+func checkIndexes(disclosedIndexes []uint, l int) (disclosed, undisclosed []int, err error) {
+	if len(disclosedIndexes) > l {
+		return nil, nil, ErrInvalidIndexes
+	}
+	disclosed = make([]int, len(disclosedIndexes))
+	for i, index := range disclosedIndexes {
+		if index >= uint(l) {
+			return nil, nil, ErrInvalidIndexes
+		}
+		disclosed[i] = int(index)
+	}
+	sort.Ints(disclosed)
+
+	undisclosed = make([]int, 0, l-len(disclosed))
+	next := 0
+	for i := 0; i < l; i++ {
+		if next < len(disclosed) && disclosed[next] == i {
+			next++
+			if next < len(disclosed) && disclosed[next] == i {
+				return nil, nil, ErrInvalidIndexes
+			}
+			continue
+		}
+		undisclosed = append(undisclosed, i)
+	}
+	return disclosed, undisclosed, nil
+}
+
+// randomScalars reads count scalars from rnd, each reduced from expandLen
+// uniform bytes.
+func randomScalars(rnd io.Reader, count int) ([]*bls.Scalar, error) {
+	scalars := make([]*bls.Scalar, count)
+	buf := make([]byte, expandLen)
+	for i := range scalars {
+		if _, err := io.ReadFull(rnd, buf); err != nil {
+			return nil, err
+		}
+		scalars[i] = new(bls.Scalar)
+		scalars[i].SetBytes(buf)
+	}
+	return scalars, nil
+}
+
+// proofInit holds the values hashed into the challenge of a proof.
+type proofInit struct {
+	abar, bbar, d, t1, t2 *bls.G1
+	domain                *bls.Scalar
+}
+
+// challenge computes the challenge of the proof for the disclosed messages
+// and the presentation header.
+func (p *params) challenge(init *proofInit, disclosed []int, scalars []*bls.Scalar, ph []byte) *bls.Scalar {
+	var s serializer
+	s.uint64(uint64(len(disclosed)))
+	for j, i := range disclosed {
+		s.uint64(uint64(i))
+		s.scalars(scalars[j])
+	}
+	s.points(init.abar, init.bbar, init.d, init.t1, init.t2)
+	s.scalars(init.domain)
+	s.uint64(uint64(len(ph)))
+	s.b = append(s.b, ph...)
+	return p.hashToScalar(s.b, p.dst("H2S_"))
+}
+
+// ProofGen returns a zero-knowledge proof of knowledge of the signature
+// over the header and the messages, that discloses only the messages at the
+// given indexes. The proof is bound to the presentation header ph.
+// Randomness is read from rnd.
+func ProofGen(s Suite, rnd io.Reader, pk *PublicKey, signature, header, ph []byte, messages [][]byte, disclosedIndexes []uint) ([]byte, error) {
+	p := getParams(s)
+	a, e, err := decodeSignature(signature)
+	if err != nil {
+		return nil, err
+	}
+	disclosed, undisclosed, err := checkIndexes(disclosedIndexes, len(messages))
+	if err != nil {
+		return nil, err
+	}
+	scalars := p.messagesToScalars(messages)
+	generators := p.generators(len(messages))
+
+	random, err := randomScalars(rnd, 5+len(undisclosed))
+	if err != nil {
+		return nil, err
+	}
+	r1, r2, et, r1t, r3t, mt := random[0], random[1], random[2], random[3], random[4], random[5:]
+
+	init := &proofInit{domain: p.calculateDomain(pk, generators, header)}
+	b := p.commitment(init.domain, generators, scalars, allIndexes(len(messages)))
+
+	// D = B * r2, Abar = A * (r1 * r2), Bbar = D * r1 - Abar * e
+	var r1r2 bls.Scalar
+	r1r2.Mul(r1, r2)
+	var t bls.G1
+	init.d, init.abar, init.bbar = new(bls.G1), new(bls.G1), new(bls.G1)
+	init.d.ScalarMult(r2, b)
+	init.abar.ScalarMult(&r1r2, a)
+	init.bbar.ScalarMult(r1, init.d)
+	t.ScalarMult(e, init.abar)
+	t.Neg()
+	init.bbar.Add(init.bbar, &t)
+
+	// T1 = Abar * e~ + D * r1~
+	init.t1 = new(bls.G1)
+	init.t1.ScalarMult(et, init.abar)
+	t.ScalarMult(r1t, init.d)
+	init.t1.Add(init.t1, &t)
+
+	// T2 = D * r3~ + H_j1 * m~_j1 + ... + H_jU * m~_jU
+	init.t2 = new(bls.G1)
+	init.t2.ScalarMult(r3t, init.d)
+	for k, j := range undisclosed {
+		t.ScalarMult(mt[k], generators[j+1])
+		init.t2.Add(init.t2, &t)
+	}
+
+	disclosedScalars := make([]*bls.Scalar, len(disclosed))
+	for k, i := range disclosed {
+		disclosedScalars[k] = scalars[i]
+	}
+	c := p.challenge(init, disclosed, disclosedScalars, ph)
+
+	// e^ = e~ + e * c, r1^ = r1~ - r1 * c, r3^ = r3~ - r2^-1 * c,
+	// m^_j = m~_j + msg_j * c
+	var ser serializer
+	ser.points(init.abar, init.bbar, init.d)
+	var u, v bls.Scalar
+	v.Mul(e, c)
+	u.Add(et, &v)
+	ser.scalars(&u)
+	v.Mul(r1, c)
+	u.Sub(r1t, &v)
+	ser.scalars(&u)
+	v.Inv(r2)
+	v.Mul(&v, c)
+	u.Sub(r3t, &v)
+	ser.scalars(&u)
+	for k, j := range undisclosed {
+		v.Mul(scalars[j], c)
+		u.Add(mt[k], &v)
+		ser.scalars(&u)
+	}
+	ser.scalars(c)
+	return ser.b, nil
+}
+
+// ProofVerify checks that the proof was generated from a valid signature
+// under the public key over the header and a list of messages, such that
+// the message at each disclosed index is the corresponding disclosed
+// message.
+func ProofVerify(s Suite, pk *PublicKey, proof, header, ph []byte, disclosedMessages [][]byte, disclosedIndexes []uint) error {
+	p := getParams(s)
+	const pointsSize = 3 * bls.G1SizeCompressed
+	if len(proof) < ProofSize(0) || (len(proof)-pointsSize)%bls.ScalarSize != 0 ||
+		len(disclosedMessages) != len(disclosedIndexes) {
+		return ErrInvalidProof
+	}
+	u := (len(proof)-pointsSize)/bls.ScalarSize - 4
+	l := len(disclosedMessages) + u
+	disclosed, undisclosed, err := checkIndexes(disclosedIndexes, l)
+	if err != nil {
+		return err
+	}
+	byIndex := make(map[uint][]byte, len(disclosedIndexes))
+	for i, index := range disclosedIndexes {
+		byIndex[index] = disclosedMessages[i]
+	}
+	sortedMessages := make([][]byte, len(disclosed))
+	for k, i := range disclosed {
+		sortedMessages[k] = byIndex[uint(i)]
+	}
+
+	points := make([]*bls.G1, 3)
+	for i := range points {
+		if points[i], err = decodePoint(proof[i*bls.G1SizeCompressed : (i+1)*bls.G1SizeCompressed]); err != nil {
+			return ErrInvalidProof
+		}
+	}
+	proofScalars := make([]*bls.Scalar, u+4)
+	for i := range proofScalars {
+		start := pointsSize + i*bls.ScalarSize
+		proofScalars[i] = new(bls.Scalar)
+		if proofScalars[i].UnmarshalBinary(proof[start:start+bls.ScalarSize]) != nil {
+			return ErrInvalidProof
+		}
+	}
+	eh, r1h, r3h, mh, cp := proofScalars[0], proofScalars[1], proofScalars[2], proofScalars[3:3+u], proofScalars[3+u]
+
+	scalars := p.messagesToScalars(sortedMessages)
+	generators := p.generators(l)
+	init := &proofInit{abar: points[0], bbar: points[1], d: points[2]}
+	init.domain = p.calculateDomain(pk, generators, header)
+
+	// T1 = Bbar * cp + Abar * e^ + D * r1^
+	var t bls.G1
+	init.t1 = new(bls.G1)
+	init.t1.ScalarMult(cp, init.bbar)
+	t.ScalarMult(eh, init.abar)
+	init.t1.Add(init.t1, &t)
+	t.ScalarMult(r1h, init.d)
+	init.t1.Add(init.t1, &t)
+
+	// T2 = Bv * cp + D * r3^ + H_j1 * m^_j1 + ... + H_jU * m^_jU, where
+	// Bv = P1 + Q_1 * domain + H_i1 * msg_i1 + ... + H_iR * msg_iR
+	init.t2 = new(bls.G1)
+	init.t2.ScalarMult(cp, p.commitment(init.domain, generators, scalars, disclosed))
+	t.ScalarMult(r3h, init.d)
+	init.t2.Add(init.t2, &t)
+	for k, j := range undisclosed {
+		t.ScalarMult(mh[k], generators[j+1])
+		init.t2.Add(init.t2, &t)
+	}
+
+	if p.challenge(init, disclosed, scalars, ph).IsEqual(cp) != 1 {
+		return ErrInvalidProof
+	}
+
+	// e(Abar, W) * e(Bbar, -BP2) = 1
+	res := bls.ProdPairFrac(
+		[]*bls.G1{init.abar, init.bbar},
+		[]*bls.G2{&pk.w, bls.G2Generator()},
+		[]int{1, -1},
+	)
+	if !res.IsIdentity() {
+		return ErrInvalidProof
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+// Package bulletproofs provides range proofs on Pedersen commitments over
+// ristretto255.
+//
+// A range proof shows that committed values lie in [0, 2^n) without
+// revealing them. Proofs for m values are aggregated into a single proof of
+// size logarithmic in n*m, built from an inner-product argument [1].
+// Proofs are made non-interactive over a sigma.Transcript, which plays the
+// role of a Merlin transcript, and many proofs can be verified at once
+// with BatchVerify.
+//
+// References:
+//
+//	[1] Bünz, Bootle, Boneh, Poelstra, Wuille, Maxwell. Bulletproofs: Short
+//	    Proofs for Confidential Transactions and More. https://eprint.iacr.org/2017/1066
+package bulletproofs
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/cloudflare/circl/group"
+)
+
+var (
+	// ErrInvalidBitSize is returned when the bit size is not 8, 16, 32, or
+	// 64.
+	ErrInvalidBitSize = errors.New("bulletproofs: invalid bit size")
+	// ErrInvalidAggregation is returned when the number of values is not a
+	// power of two, or does not match the number of blindings.
+	ErrInvalidAggregation = errors.New("bulletproofs: invalid number of values")
+	// ErrInsufficientGenerators is returned when the generators are too few
+	// for the bit size and the number of values.
+	ErrInsufficientGenerators = errors.New("bulletproofs: insufficient generators")
+	// ErrValueOutOfRange is returned when a value does not fit in the bit
+	// size.
+	ErrValueOutOfRange = errors.New("bulletproofs: value out of range")
+	// ErrInvalidProof is returned when a proof cannot be decoded.
+	ErrInvalidProof = errors.New("bulletproofs: invalid proof")
+	// ErrVerification is returned when a proof does not verify.
+	ErrVerification = errors.New("bulletproofs: verification failed")
+)
+
+var g = group.Ristretto255
+
+const (
+	scalarSize  = 32
+	elementSize = 32
+)
+
+// Generators holds the bases of Pedersen commitments and the vector bases
+// of the range proofs.
+type Generators struct {
+	// b is the base of values and bBlinding the base of blindings.
+	b, bBlinding group.Element
+	g, h         []group.Element
+}
+
+// NewGenerators returns the generators for range proofs of up to capacity
+// bits in total, that is, n*m for m values of n bits.
+func NewGenerators(capacity int) *Generators {
+	if capacity <= 0 {
+		panic("bulletproofs: capacity must be positive")
+	}
+	dst := []byte("CIRCL-Bulletproofs-ristretto255-Generators")
+	gens := &Generators{
+		b:         g.Generator(),
+		bBlinding: g.HashToElement([]byte("blinding"), dst),
+		g:         make([]group.Element, capacity),
+		h:         make([]group.Element, capacity),
+	}
+	var label [5]byte
+	for i := range gens.g {
+		binary.BigEndian.PutUint32(label[1:], uint32(i))
+		label[0] = 'G'
+		gens.g[i] = g.HashToElement(label[:], dst)
+		label[0] = 'H'
+		gens.h[i] = g.HashToElement(label[:], dst)
+	}
+	return gens
+}
+
+// Capacity returns the number of bits the generators support.
+func (gens *Generators) Capacity() int { return len(gens.g) }
+
+// Commit returns the Pedersen commitment v*B + blinding*B' to the value.
+func (gens *Generators) Commit(v uint64, blinding group.Scalar) group.Element {
+	c := g.NewElement().Mul(gens.b, g.NewScalar().SetUint64(v))
+	return c.Add(c, g.NewElement().Mul(gens.bBlinding, blinding))
+}
+
+// RangeProof is an aggregated range proof.
+type RangeProof struct {
+	a, s, t1, t2   group.Element
+	tauX, mu, tHat group.Scalar
+	innerProduct   *innerProductProof
+}
+
+// MarshalBinary returns the encoding of the proof: the elements A, S, T1,
+// T2, and the k pairs (L, R) of the inner-product argument, followed by the
+// scalars tau_x, mu, t, a, and b. It has 32*(9+2k) bytes, where k is the
+// base-2 logarithm of the number of bits.
+func (p *RangeProof) MarshalBinary() ([]byte, error) {
+	b := appendElements(nil, p.a, p.s, p.t1, p.t2)
+	for i := range p.innerProduct.l {
+		b = appendElements(b, p.innerProduct.l[i], p.innerProduct.r[i])
+	}
+	return appendScalars(b, p.tauX, p.mu, p.tHat, p.innerProduct.a, p.innerProduct.b), nil
+}
+
+// UnmarshalBinary decodes a proof.
+func (p *RangeProof) UnmarshalBinary(data []byte) error {
+	if len(data) < 9*scalarSize || len(data)%(2*scalarSize) != scalarSize {
+		return ErrInvalidProof
+	}
+	k := (len(data)/scalarSize - 9) / 2
+	elements, err := readElements(data[:(4+2*k)*elementSize], 4+2*k)
+	if err != nil {
+		return err
+	}
+	scalars, err := readScalars(data[(4+2*k)*elementSize:], 5)
+	if err != nil {
+		return err
+	}
+	ipp := &innerProductProof{
+		l: make([]group.Element, k),
+		r: make([]group.Element, k),
+		a: scalars[3],
+		b: scalars[4],
+	}
+	for i := 0; i < k; i++ {
+		ipp.l[i], ipp.r[i] = elements[4+2*i], elements[5+2*i]
+	}
+	*p = RangeProof{
+		elements[0], elements[1], elements[2], elements[3],
+		scalars[0], scalars[1], scalars[2], ipp,
+	}
+	return nil
+}
+
+func readElements(data []byte, n int) ([]group.Element, error) {
+	if len(data) != n*elementSize {
+		return nil, ErrInvalidProof
+	}
+	elements := make([]group.Element, n)
+	for i := range elements {
+		elements[i] = g.NewElement()
+		if elements[i].UnmarshalBinary(data[i*elementSize:(i+1)*elementSize]) != nil {
+			return nil, ErrInvalidProof
+		}
+	}
+	return elements, nil
+}
+
+func readScalars(data []byte, n int) ([]group.Scalar, error) {
+	if len(data) != n*scalarSize {
+		return nil, ErrInvalidProof
+	}
+	scalars := make([]group.Scalar, n)
+	for i := range scalars {
+		scalars[i] = g.NewScalar()
+		if scalars[i].UnmarshalBinary(data[i*scalarSize:(i+1)*scalarSize]) != nil {
+			return nil, ErrInvalidProof
+		}
+	}
+	return scalars, nil
+}
+
+func appendElements(b []byte, elements ...group.Element) []byte {
+	for _, e := range elements {
+		enc, err := e.MarshalBinaryCompress()
+		if err != nil {
+			panic(err)
+		}
+		b = append(b, enc...)
+	}
+	return b
+}
+
+func appendScalars(b []byte, scalars ...group.Scalar) []byte {
+	for _, s := range scalars {
+		enc, err := s.MarshalBinary()
+		if err != nil {
+			panic(err)
+		}
+		b = append(b, enc...)
+	}
+	return b
+}
@@ -0,0 +1,127 @@
+package bulletproofs
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/cloudflare/circl/group"
+	"github.com/cloudflare/circl/internal/test"
+	"github.com/cloudflare/circl/zk/sigma"
+)
+
+func transcript() *sigma.Transcript {
+	return sigma.NewTranscript(group.Ristretto255, []byte("bulletproofs test"))
+}
+
+func prove(t testing.TB, gens *Generators, values []uint64, n uint) (*RangeProof, []group.Element) {
+	blindings := make([]group.Scalar, len(values))
+	for i := range blindings {
+		blindings[i] = g.RandomScalar(rand.Reader)
+	}
+	proof, commitments, err := Prove(transcript(), gens, values, blindings, n, rand.Reader)
+	test.CheckNoErr(t, err, "prove")
+	return proof, commitments
+}
+
+func TestRangeProof(t *testing.T) {
+	gens := NewGenerators(64 * 4)
+	for _, v := range []struct {
+		n      uint
+		values []uint64
+	}{
+		{8, []uint64{0}},
+		{8, []uint64{255}},
+		{32, []uint64{1 << 31, 7}},
+		{64, []uint64{^uint64(0)}},
+		{64, []uint64{1, 2, 3, 1 << 63}},
+	} {
+		proof, commitments := prove(t, gens, v.values, v.n)
+		err := Verify(transcript(), gens, commitments, v.n, proof)
+		test.CheckNoErr(t, err, "verify")
+
+		enc, err := proof.MarshalBinary()
+		test.CheckNoErr(t, err, "marshal")
+		nm := int(v.n) * len(v.values)
+		k := 0
+		for 1<<k < nm {
+			k++
+		}
+		if len(enc) != 32*(9+2*k) {
+			test.ReportError(t, len(enc), 32*(9+2*k))
+		}
+		got := new(RangeProof)
+		test.CheckNoErr(t, got.UnmarshalBinary(enc), "unmarshal")
+		err = Verify(transcript(), gens, commitments, v.n, got)
+		test.CheckNoErr(t, err, "verify decoded proof")
+
+		enc[len(enc)-1] ^= 1
+		if got.UnmarshalBinary(enc) == nil {
+			err = Verify(transcript(), gens, commitments, v.n, got)
+			test.CheckIsErr(t, err, "tampered proof must fail")
+		}
+
+		commitments[0] = g.NewElement().Add(commitments[0], gens.b)
+		err = Verify(transcript(), gens, commitments, v.n, proof)
+		test.CheckIsErr(t, err, "other commitment must fail")
+	}
+}
+
+func TestInvalidRange(t *testing.T) {
+	gens := NewGenerators(64)
+	blindings := []group.Scalar{g.RandomScalar(rand.Reader)}
+
+	_, _, err := Prove(transcript(), gens, []uint64{256}, blindings, 8, rand.Reader)
+	if err != ErrValueOutOfRange {
+		test.ReportError(t, err, ErrValueOutOfRange)
+	}
+	_, _, err = Prove(transcript(), gens, []uint64{1}, blindings, 12, rand.Reader)
+	if err != ErrInvalidBitSize {
+		test.ReportError(t, err, ErrInvalidBitSize)
+	}
+	_, _, err = Prove(transcript(), gens, []uint64{1, 2, 3}, blindings, 8, rand.Reader)
+	if err != ErrInvalidAggregation {
+		test.ReportError(t, err, ErrInvalidAggregation)
+	}
+	_, _, err = Prove(transcript(), gens, []uint64{1, 2}, blindings, 64, rand.Reader)
+	if err != ErrInsufficientGenerators {
+		test.ReportError(t, err, ErrInsufficientGenerators)
+	}
+
+	// A proof for 16 bits does not prove a range of 8 bits.
+	proof, commitments := prove(t, gens, []uint64{1000}, 16)
+	err = Verify(transcript(), gens, commitments, 8, proof)
+	test.CheckIsErr(t, err, "other bit size must fail")
+	err = Verify(sigma.NewTranscript(g, []byte("other")), gens, commitments, 16, proof)
+	test.CheckIsErr(t, err, "other transcript must fail")
+}
+
+func TestBatchVerify(t *testing.T) {
+	gens := NewGenerators(64 * 2)
+	var statements []Statement
+	for _, values := range [][]uint64{{5}, {6, 7}, {1 << 40}} {
+		proof, commitments := prove(t, gens, values, 64)
+		statements = append(statements, Statement{transcript(), commitments, 64, proof})
+	}
+	test.CheckNoErr(t, BatchVerify(gens, statements, rand.Reader), "batch verify")
+
+	for i := range statements {
+		statements[i].Transcript = transcript()
+	}
+	statements[1].Proof, statements[2].Proof = statements[2].Proof, statements[1].Proof
+	test.CheckIsErr(t, BatchVerify(gens, statements, rand.Reader), "swapped proofs must fail")
+}
+
+func BenchmarkRangeProof(b *testing.B) {
+	gens := NewGenerators(64)
+	proof, commitments := prove(b, gens, []uint64{42}, 64)
+	b.Run("Prove", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			prove(b, gens, []uint64{42}, 64)
+		}
+	})
+	b.Run("Verify", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_ = Verify(transcript(), gens, commitments, 64, proof)
+		}
+	})
+}
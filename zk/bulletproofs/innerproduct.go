@@ -0,0 +1,147 @@
+package bulletproofs
+
+import (
+	"encoding/binary"
+
+	"github.com/cloudflare/circl/group"
+	"github.com/cloudflare/circl/zk/sigma"
+)
+
+// innerProductProof shows knowledge of vectors a and b such that
+//
+//	P = <a, G> + <b, H> + <a, b>*Q
+//
+// with k rounds that halve the vectors, and the final scalars a and b.
+type innerProductProof struct {
+	l, r []group.Element
+	a, b group.Scalar
+}
+
+// proveInnerProduct returns a proof for the vectors a and b on the bases
+// gs, hs, and q. The length of the vectors must be a power of two.
+func proveInnerProduct(t *sigma.Transcript, q group.Element, gs, hs []group.Element, a, b []group.Scalar) *innerProductProof {
+	gs = append([]group.Element{}, gs...)
+	hs = append([]group.Element{}, hs...)
+	a = append([]group.Scalar{}, a...)
+	b = append([]group.Scalar{}, b...)
+	t.AppendMessage("dom-sep", []byte("ipp v1"))
+	t.AppendMessage("n", appendUint64(nil, uint64(len(a))))
+
+	proof := &innerProductProof{}
+	for n := len(a); n > 1; {
+		n /= 2
+		aLo, aHi, bLo, bHi := a[:n], a[n:], b[:n], b[n:]
+		gLo, gHi, hLo, hHi := gs[:n], gs[n:], hs[:n], hs[n:]
+
+		// L = <aLo, gHi> + <bHi, hLo> + <aLo, bHi>*Q
+		l := multiScalarMul(aLo, gHi)
+		l.Add(l, multiScalarMul(bHi, hLo))
+		l.Add(l, g.NewElement().Mul(q, innerProduct(aLo, bHi)))
+		// R = <aHi, gLo> + <bLo, hHi> + <aHi, bLo>*Q
+		r := multiScalarMul(aHi, gLo)
+		r.Add(r, multiScalarMul(bLo, hHi))
+		r.Add(r, g.NewElement().Mul(q, innerProduct(aHi, bLo)))
+		proof.l = append(proof.l, l)
+		proof.r = append(proof.r, r)
+
+		t.AppendElements("L", l)
+		t.AppendElements("R", r)
+		u := t.Challenge("u")
+		uInv := g.NewScalar().Inv(u)
+
+		for i := 0; i < n; i++ {
+			// a' = u*aLo + u^-1*aHi, b' = u^-1*bLo + u*bHi
+			aLo[i] = addMul(g.NewScalar().Mul(aLo[i], u), aHi[i], uInv)
+			bLo[i] = addMul(g.NewScalar().Mul(bLo[i], uInv), bHi[i], u)
+			// G' = u^-1*gLo + u*gHi, H' = u*hLo + u^-1*hHi
+			gLo[i] = multiScalarMul([]group.Scalar{uInv, u}, []group.Element{gLo[i], gHi[i]})
+			hLo[i] = multiScalarMul([]group.Scalar{u, uInv}, []group.Element{hLo[i], hHi[i]})
+		}
+		a, b, gs, hs = aLo, bLo, gLo, hLo
+	}
+	proof.a, proof.b = a[0], b[0]
+	return proof
+}
+
+// challenges returns the challenges u of the rounds of the proof, which
+// must all be non-zero.
+func (p *innerProductProof) challenges(t *sigma.Transcript, n int) ([]group.Scalar, bool) {
+	t.AppendMessage("dom-sep", []byte("ipp v1"))
+	t.AppendMessage("n", appendUint64(nil, uint64(n)))
+	u := make([]group.Scalar, len(p.l))
+	for i := range u {
+		t.AppendElements("L", p.l[i])
+		t.AppendElements("R", p.r[i])
+		u[i] = t.Challenge("u")
+		if u[i].IsZero() {
+			return nil, false
+		}
+	}
+	return u, true
+}
+
+// foldingScalars returns the scalars s such that the final bases of the
+// proof are <s, G> and <s^-1, H>, given the challenges u of the k rounds.
+// The scalar s_i is the product of u_j or u_j^-1, depending on whether the
+// bit k-1-j of i is set.
+func foldingScalars(u []group.Scalar) (s, sInv []group.Scalar) {
+	k := len(u)
+	n := 1 << k
+	s = make([]group.Scalar, n)
+	sInv = make([]group.Scalar, n)
+	s[0], sInv[0] = g.NewScalar().SetUint64(1), g.NewScalar().SetUint64(1)
+	uSq := make([]group.Scalar, k)
+	uInvSq := make([]group.Scalar, k)
+	for j := range u {
+		uInv := g.NewScalar().Inv(u[j])
+		s[0].Mul(s[0], uInv)
+		sInv[0].Mul(sInv[0], u[j])
+		uSq[j] = g.NewScalar().Mul(u[j], u[j])
+		uInvSq[j] = g.NewScalar().Mul(uInv, uInv)
+	}
+	for i, lg := 1, 0; i < n; i++ {
+		if i == 2<<lg {
+			lg++
+		}
+		s[i] = g.NewScalar().Mul(s[i-(1<<lg)], uSq[k-1-lg])
+		sInv[i] = g.NewScalar().Mul(sInv[i-(1<<lg)], uInvSq[k-1-lg])
+	}
+	return s, sInv
+}
+
+// addMul returns x + y*z.
+func addMul(x, y, z group.Scalar) group.Scalar {
+	return x.Add(x, g.NewScalar().Mul(y, z))
+}
+
+func innerProduct(a, b []group.Scalar) group.Scalar {
+	sum := g.NewScalar()
+	for i := range a {
+		addMul(sum, a[i], b[i])
+	}
+	return sum
+}
+
+func multiScalarMul(scalars []group.Scalar, elements []group.Element) group.Element {
+	sum := g.Identity()
+	for i := range scalars {
+		sum.Add(sum, g.NewElement().Mul(elements[i], scalars[i]))
+	}
+	return sum
+}
+
+// powers returns the vector (1, x, x^2, ..., x^(n-1)).
+func powers(x group.Scalar, n int) []group.Scalar {
+	p := make([]group.Scalar, n)
+	p[0] = g.NewScalar().SetUint64(1)
+	for i := 1; i < n; i++ {
+		p[i] = g.NewScalar().Mul(p[i-1], x)
+	}
+	return p
+}
+
+func appendUint64(b []byte, n uint64) []byte {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], n)
+	return append(b, buf[:]...)
+}
@@ -0,0 +1,329 @@
+package bulletproofs
+
+import (
+	"crypto/rand"
+	"io"
+
+	"github.com/cloudflare/circl/group"
+	"github.com/cloudflare/circl/zk/sigma"
+)
+
+func checkParams(gens *Generators, n uint, m int) error {
+	switch n {
+	case 8, 16, 32, 64:
+	default:
+		return ErrInvalidBitSize
+	}
+	if m <= 0 || m&(m-1) != 0 {
+		return ErrInvalidAggregation
+	}
+	if int(n)*m > gens.Capacity() {
+		return ErrInsufficientGenerators
+	}
+	return nil
+}
+
+func appendStatement(t *sigma.Transcript, n uint, commitments []group.Element) {
+	t.AppendMessage("dom-sep", []byte("rangeproof v1"))
+	t.AppendMessage("n", appendUint64(nil, uint64(n)))
+	t.AppendMessage("m", appendUint64(nil, uint64(len(commitments))))
+	t.AppendElements("V", commitments...)
+}
+
+// Prove returns a proof that each value is in [0, 2^n), and the commitments
+// to the values with the blindings. The number of values must be a power
+// of two, and n must be 8, 16, 32, or 64.
+func Prove(t *sigma.Transcript, gens *Generators, values []uint64, blindings []group.Scalar, n uint, rnd io.Reader) (*RangeProof, []group.Element, error) {
+	if rnd == nil {
+		return nil, nil, io.ErrNoProgress
+	}
+	m := len(values)
+	if err := checkParams(gens, n, m); err != nil {
+		return nil, nil, err
+	}
+	if len(blindings) != m {
+		return nil, nil, ErrInvalidAggregation
+	}
+	commitments := make([]group.Element, m)
+	for j, v := range values {
+		if n < 64 && v>>n != 0 {
+			return nil, nil, ErrValueOutOfRange
+		}
+		commitments[j] = gens.Commit(v, blindings[j])
+	}
+	appendStatement(t, n, commitments)
+
+	nm := int(n) * m
+	gs, hs := gens.g[:nm], gens.h[:nm]
+	one := g.NewScalar().SetUint64(1)
+
+	// a_L holds the bits of the values, and a_R = a_L - 1.
+	aL := make([]group.Scalar, nm)
+	aR := make([]group.Scalar, nm)
+	for j, v := range values {
+		for i := 0; i < int(n); i++ {
+			aL[j*int(n)+i] = g.NewScalar().SetUint64((v >> uint(i)) & 1)
+			aR[j*int(n)+i] = g.NewScalar().Sub(aL[j*int(n)+i], one)
+		}
+	}
+	alpha := g.RandomScalar(rnd)
+	a := g.NewElement().Mul(gens.bBlinding, alpha)
+	a.Add(a, multiScalarMul(aL, gs))
+	a.Add(a, multiScalarMul(aR, hs))
+
+	sL := make([]group.Scalar, nm)
+	sR := make([]group.Scalar, nm)
+	for i := range sL {
+		sL[i], sR[i] = g.RandomScalar(rnd), g.RandomScalar(rnd)
+	}
+	rho := g.RandomScalar(rnd)
+	s := g.NewElement().Mul(gens.bBlinding, rho)
+	s.Add(s, multiScalarMul(sL, gs))
+	s.Add(s, multiScalarMul(sR, hs))
+
+	t.AppendElements("A", a)
+	t.AppendElements("S", s)
+	y := t.Challenge("y")
+	z := t.Challenge("z")
+
+	// l(X) = l0 + l1*X and r(X) = r0 + r1*X, where
+	//  l0 = a_L - z, l1 = s_L,
+	//  r0 = y^nm o (a_R + z) + z^(2+j)*2^n for the value j, r1 = y^nm o s_R.
+	yPow := powers(y, nm)
+	twoPow := powers(g.NewScalar().SetUint64(2), int(n))
+	zPow := powers(z, m+3)
+	l0 := make([]group.Scalar, nm)
+	r0 := make([]group.Scalar, nm)
+	r1 := make([]group.Scalar, nm)
+	for i := range l0 {
+		l0[i] = g.NewScalar().Sub(aL[i], z)
+		r0[i] = g.NewScalar().Add(aR[i], z)
+		r0[i].Mul(r0[i], yPow[i])
+		addMul(r0[i], zPow[2+i/int(n)], twoPow[i%int(n)])
+		r1[i] = g.NewScalar().Mul(yPow[i], sR[i])
+	}
+
+	// t(X) = <l(X), r(X)> = t0 + t1*X + t2*X^2
+	t1 := innerProduct(l0, r1)
+	t1.Add(t1, innerProduct(sL, r0))
+	t2 := innerProduct(sL, r1)
+	tau1, tau2 := g.RandomScalar(rnd), g.RandomScalar(rnd)
+	bigT1 := multiScalarMul([]group.Scalar{t1, tau1}, []group.Element{gens.b, gens.bBlinding})
+	bigT2 := multiScalarMul([]group.Scalar{t2, tau2}, []group.Element{gens.b, gens.bBlinding})
+
+	t.AppendElements("T_1", bigT1)
+	t.AppendElements("T_2", bigT2)
+	x := t.Challenge("x")
+
+	// tau_x = tau2*x^2 + tau1*x + sum_j z^(2+j)*blinding_j
+	tauX := g.NewScalar().Mul(tau2, x)
+	tauX.Add(tauX, tau1)
+	tauX.Mul(tauX, x)
+	for j := range blindings {
+		addMul(tauX, zPow[2+j], blindings[j])
+	}
+	mu := addMul(alpha.Copy(), rho, x)
+	l := make([]group.Scalar, nm)
+	r := make([]group.Scalar, nm)
+	for i := range l {
+		l[i] = addMul(l0[i].Copy(), sL[i], x)
+		r[i] = addMul(r0[i].Copy(), r1[i], x)
+	}
+	tHat := innerProduct(l, r)
+
+	t.AppendMessage("t_x", appendScalars(nil, tHat))
+	t.AppendMessage("t_x_blinding", appendScalars(nil, tauX))
+	t.AppendMessage("e_blinding", appendScalars(nil, mu))
+	w := t.Challenge("w")
+
+	// The inner-product argument is on the bases G and H' = y^-i * H_i.
+	q := g.NewElement().Mul(gens.b, w)
+	yInvPow := powers(g.NewScalar().Inv(y), nm)
+	hPrime := make([]group.Element, nm)
+	for i := range hPrime {
+		hPrime[i] = g.NewElement().Mul(hs[i], yInvPow[i])
+	}
+
+	proof := &RangeProof{
+		a: a, s: s, t1: bigT1, t2: bigT2,
+		tauX: tauX, mu: mu, tHat: tHat,
+		innerProduct: proveInnerProduct(t, q, gs, hPrime, l, r),
+	}
+	return proof, commitments, nil
+}
+
+// Verify checks that the proof shows that the commitments are to values in
+// [0, 2^n). The transcript must hold the same messages as the transcript of
+// the prover before the proof.
+func Verify(t *sigma.Transcript, gens *Generators, commitments []group.Element, n uint, proof *RangeProof) error {
+	return BatchVerify(gens, []Statement{{t, commitments, n, proof}}, rand.Reader)
+}
+
+// Statement is a range proof with its public inputs.
+type Statement struct {
+	Transcript  *sigma.Transcript
+	Commitments []group.Element
+	Bits        uint
+	Proof       *RangeProof
+}
+
+// BatchVerify checks the statements at once, faster than verifying them
+// one by one. It fails if any proof is invalid, without telling which.
+func BatchVerify(gens *Generators, statements []Statement, rnd io.Reader) error {
+	if rnd == nil {
+		return io.ErrNoProgress
+	}
+	acc := newAccumulator(gens)
+	for _, s := range statements {
+		if err := acc.add(s, g.RandomNonZeroScalar(rnd), g.RandomNonZeroScalar(rnd)); err != nil {
+			return err
+		}
+	}
+	if !acc.isIdentity() {
+		return ErrVerification
+	}
+	return nil
+}
+
+// accumulator holds a multi-scalar multiplication, where the coefficients
+// of the generators are merged across the statements.
+type accumulator struct {
+	gens         *Generators
+	b, bBlinding group.Scalar
+	g, h         []group.Scalar
+	scalars      []group.Scalar
+	elements     []group.Element
+}
+
+func newAccumulator(gens *Generators) *accumulator {
+	acc := &accumulator{
+		gens:      gens,
+		b:         g.NewScalar(),
+		bBlinding: g.NewScalar(),
+		g:         make([]group.Scalar, gens.Capacity()),
+		h:         make([]group.Scalar, gens.Capacity()),
+	}
+	for i := range acc.g {
+		acc.g[i], acc.h[i] = g.NewScalar(), g.NewScalar()
+	}
+	return acc
+}
+
+func (acc *accumulator) addElement(s group.Scalar, e group.Element) {
+	acc.scalars = append(acc.scalars, s)
+	acc.elements = append(acc.elements, e)
+}
+
+// add accumulates the verification equations of the statement, weighted by
+// the random scalars:
+//
+//	weight*c*(t*B + tau_x*B' - sum_j z^(2+j)*V_j - delta(y, z)*B - x*T1 - x^2*T2)
+//	+ weight*(A + x*S - mu*B' + <-z - a*s, G> + <z + (z^(2+j)*2^i - b*s^-1)*y^-i, H>
+//	  + w*(t - a*b)*B + sum_j u_j^2*L_j + u_j^-2*R_j)
+//
+// which are the identity for valid proofs.
+func (acc *accumulator) add(s Statement, weight, c group.Scalar) error {
+	n, m, p := s.Bits, len(s.Commitments), s.Proof
+	if err := checkParams(acc.gens, n, m); err != nil {
+		return err
+	}
+	nm := int(n) * m
+	k := 0
+	for 1<<k < nm {
+		k++
+	}
+	if p == nil || p.innerProduct == nil || len(p.innerProduct.l) != k || len(p.innerProduct.r) != k {
+		return ErrVerification
+	}
+
+	t := s.Transcript
+	appendStatement(t, n, s.Commitments)
+	t.AppendElements("A", p.a)
+	t.AppendElements("S", p.s)
+	y := t.Challenge("y")
+	z := t.Challenge("z")
+	t.AppendElements("T_1", p.t1)
+	t.AppendElements("T_2", p.t2)
+	x := t.Challenge("x")
+	t.AppendMessage("t_x", appendScalars(nil, p.tHat))
+	t.AppendMessage("t_x_blinding", appendScalars(nil, p.tauX))
+	t.AppendMessage("e_blinding", appendScalars(nil, p.mu))
+	w := t.Challenge("w")
+	u, ok := p.innerProduct.challenges(t, nm)
+	if !ok || y.IsZero() {
+		return ErrVerification
+	}
+
+	yPow := powers(y, nm)
+	yInvPow := powers(g.NewScalar().Inv(y), nm)
+	twoPow := powers(g.NewScalar().SetUint64(2), int(n))
+	zPow := powers(z, m+3)
+	sVec, sInv := foldingScalars(u)
+	wc := g.NewScalar().Mul(weight, c)
+
+	// delta(y, z) = (z - z^2)*<1, y^nm> - sum_j z^(3+j)*<1, 2^n>
+	sumY, sumTwo, sumZ := g.NewScalar(), g.NewScalar(), g.NewScalar()
+	for i := range yPow {
+		sumY.Add(sumY, yPow[i])
+	}
+	for i := range twoPow {
+		sumTwo.Add(sumTwo, twoPow[i])
+	}
+	for j := 0; j < m; j++ {
+		sumZ.Add(sumZ, zPow[3+j])
+	}
+	delta := g.NewScalar().Sub(z, zPow[2])
+	delta.Mul(delta, sumY)
+	delta.Sub(delta, sumZ.Mul(sumZ, sumTwo))
+
+	// B: weight*w*(t - a*b) + weight*c*(t - delta)
+	ab := g.NewScalar().Mul(p.innerProduct.a, p.innerProduct.b)
+	coef := g.NewScalar().Sub(p.tHat, ab)
+	coef.Mul(coef, w)
+	coef.Mul(coef, weight)
+	acc.b.Add(acc.b, coef)
+	coef = g.NewScalar().Sub(p.tHat, delta)
+	acc.b.Add(acc.b, coef.Mul(coef, wc))
+
+	// B': weight*c*tau_x - weight*mu
+	coef = g.NewScalar().Mul(wc, p.tauX)
+	acc.bBlinding.Add(acc.bBlinding, coef.Sub(coef, g.NewScalar().Mul(weight, p.mu)))
+
+	for i := 0; i < nm; i++ {
+		// G_i: weight*(-z - a*s_i)
+		coef = g.NewScalar().Mul(p.innerProduct.a, sVec[i])
+		coef.Add(coef, z)
+		acc.g[i].Sub(acc.g[i], coef.Mul(coef, weight))
+		// H_i: weight*(z + (z^(2+j)*2^i - b*s_i^-1)*y^-i)
+		coef = g.NewScalar().Mul(zPow[2+i/int(n)], twoPow[i%int(n)])
+		coef.Sub(coef, g.NewScalar().Mul(p.innerProduct.b, sInv[i]))
+		coef.Mul(coef, yInvPow[i])
+		coef.Add(coef, z)
+		acc.h[i].Add(acc.h[i], coef.Mul(coef, weight))
+	}
+
+	acc.addElement(weight, p.a)
+	acc.addElement(g.NewScalar().Mul(weight, x), p.s)
+	for j := range s.Commitments {
+		acc.addElement(g.NewScalar().Neg(g.NewScalar().Mul(wc, zPow[2+j])), s.Commitments[j])
+	}
+	acc.addElement(g.NewScalar().Neg(g.NewScalar().Mul(wc, x)), p.t1)
+	acc.addElement(g.NewScalar().Neg(g.NewScalar().Mul(wc, g.NewScalar().Mul(x, x))), p.t2)
+	for j := range u {
+		uSq := g.NewScalar().Mul(u[j], u[j])
+		acc.addElement(g.NewScalar().Mul(weight, uSq), p.innerProduct.l[j])
+		acc.addElement(g.NewScalar().Mul(weight, uSq.Inv(uSq)), p.innerProduct.r[j])
+	}
+	return nil
+}
+
+func (acc *accumulator) isIdentity() bool {
+	sum := multiScalarMul(acc.scalars, acc.elements)
+	sum.Add(sum, multiScalarMul(acc.g, acc.gens.g))
+	sum.Add(sum, multiScalarMul(acc.h, acc.gens.h))
+	sum.Add(sum, multiScalarMul(
+		[]group.Scalar{acc.b, acc.bBlinding},
+		[]group.Element{acc.gens.b, acc.gens.bBlinding},
+	))
+	return sum.IsIdentity()
+}
@@ -15,6 +15,7 @@ import (
 	"io"
 
 	"github.com/cloudflare/circl/group"
+	"github.com/cloudflare/circl/xof"
 )
 
 const (
@@ -28,6 +29,32 @@ type Params struct {
 	G   group.Group
 	H   crypto.Hash
 	DST []byte
+	// X is an extendable-output function used instead of H when set. Its
+	// output is truncated to 64 bytes.
+	X xof.ID
+}
+
+// xofDigestSize is the output size of X.
+const xofDigestSize = 64
+
+// digest returns the hash of the concatenation of the inputs.
+func (p Params) digest(inputs ...[]byte) []byte {
+	if p.X != 0 {
+		x := p.X.New()
+		for _, in := range inputs {
+			mustWrite(x, in)
+		}
+		out := make([]byte, xofDigestSize)
+		if _, err := io.ReadFull(x, out); err != nil {
+			panic(err)
+		}
+		return out
+	}
+	h := p.H.New()
+	for _, in := range inputs {
+		mustWrite(h, in)
+	}
+	return h.Sum(nil)
 }
 
 type Proof struct {
@@ -106,18 +133,11 @@ func (p Params) computeComposites(
 	}
 
 	lenBuf := []byte{0, 0}
-	H := p.H.New()
-
 	binary.BigEndian.PutUint16(lenBuf, uint16(len(kAm)))
-	mustWrite(H, lenBuf)
-	mustWrite(H, kAm)
-
 	seedDST := append(append([]byte{}, labelSeed...), p.DST...)
-	binary.BigEndian.PutUint16(lenBuf, uint16(len(seedDST)))
-	mustWrite(H, lenBuf)
-	mustWrite(H, seedDST)
-
-	seed := H.Sum(nil)
+	seedLen := []byte{0, 0}
+	binary.BigEndian.PutUint16(seedLen, uint16(len(seedDST)))
+	seed := p.digest(lenBuf, kAm, seedLen, seedDST)
 
 	m = p.G.Identity()
 	z = p.G.Identity()
@@ -9,6 +9,7 @@ import (
 
 	"github.com/cloudflare/circl/group"
 	"github.com/cloudflare/circl/internal/test"
+	"github.com/cloudflare/circl/xof"
 	"github.com/cloudflare/circl/zk/dleq"
 )
 
@@ -18,9 +19,13 @@ func TestDLEQ(t *testing.T) {
 		group.P384,
 		group.P521,
 		group.Ristretto255,
+		group.Decaf448,
 	} {
 		t.Run(g.(fmt.Stringer).String(), func(t *testing.T) {
-			params := dleq.Params{g, crypto.SHA256, []byte("domain_sep_string")}
+			params := dleq.Params{G: g, H: crypto.SHA256, DST: []byte("domain_sep_string")}
+			if g == group.Decaf448 {
+				params.X = xof.SHAKE256
+			}
 			Peggy := dleq.Prover{params}
 			Victor := dleq.Verifier{params}
 
@@ -112,7 +117,7 @@ func testErrors(
 
 func BenchmarkDLEQ(b *testing.B) {
 	g := group.P256
-	params := dleq.Params{g, crypto.SHA256, []byte("domain_sep_string")}
+	params := dleq.Params{G: g, H: crypto.SHA256, DST: []byte("domain_sep_string")}
 	Peggy := dleq.Prover{params}
 	Victor := dleq.Verifier{params}
 
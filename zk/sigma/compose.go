@@ -0,0 +1,249 @@
+package sigma
+
+import (
+	"io"
+
+	"github.com/cloudflare/circl/group"
+)
+
+// AndWitness is the witness of a statement returned by And, made of the
+// witnesses of its statements in order.
+type AndWitness []Witness
+
+// OrWitness is the witness of a statement returned by Or, made of the
+// witness of the statement at Index.
+type OrWitness struct {
+	Index   int
+	Witness Witness
+}
+
+type and struct {
+	g          group.Group
+	statements []Statement
+}
+
+type or struct {
+	g          group.Group
+	statements []Statement
+}
+
+// And returns the statement that holds when all the statements hold. It
+// panics if there are no statements or if their groups differ.
+func And(statements ...Statement) Statement {
+	return &and{checkStatements(statements), append([]Statement{}, statements...)}
+}
+
+// Or returns the statement that holds when any of the statements holds.
+// Proofs do not reveal which statement the prover knows a witness for. It
+// panics if there are no statements or if their groups differ.
+func Or(statements ...Statement) Statement {
+	return &or{checkStatements(statements), append([]Statement{}, statements...)}
+}
+
+func checkStatements(statements []Statement) group.Group {
+	if len(statements) == 0 {
+		panic("sigma: no statements")
+	}
+	g := statements[0].Group()
+	for _, s := range statements[1:] {
+		if s.Group() != g {
+			panic("sigma: statements over different groups")
+		}
+	}
+	return g
+}
+
+func appendComposition(b []byte, tag byte, statements []Statement) []byte {
+	b = append(b, tag)
+	b = appendUint32(b, uint32(len(statements)))
+	for _, s := range statements {
+		b = s.appendStatement(b)
+	}
+	return b
+}
+
+func (a *and) Group() group.Group { return a.g }
+
+func (a *and) numCommitments() (n int) {
+	for _, s := range a.statements {
+		n += s.numCommitments()
+	}
+	return n
+}
+
+func (a *and) numResponses() (n int) {
+	for _, s := range a.statements {
+		n += s.numResponses()
+	}
+	return n
+}
+
+func (a *and) appendStatement(b []byte) []byte { return appendComposition(b, 'A', a.statements) }
+
+func (a *and) commit(w Witness, rnd io.Reader) ([]group.Element, proverState, error) {
+	witness, ok := w.(AndWitness)
+	if !ok || len(witness) != len(a.statements) {
+		return nil, nil, ErrInvalidWitness
+	}
+	var commitments []group.Element
+	states := make([]proverState, len(a.statements))
+	for i, s := range a.statements {
+		t, st, err := s.commit(witness[i], rnd)
+		if err != nil {
+			return nil, nil, err
+		}
+		commitments = append(commitments, t...)
+		states[i] = st
+	}
+	return commitments, states, nil
+}
+
+func (a *and) respond(st proverState, c group.Scalar) []group.Scalar {
+	states := st.([]proverState)
+	var responses []group.Scalar
+	for i, s := range a.statements {
+		responses = append(responses, s.respond(states[i], c)...)
+	}
+	return responses
+}
+
+func (a *and) simulate(c group.Scalar, rnd io.Reader) ([]group.Element, []group.Scalar) {
+	var commitments []group.Element
+	var responses []group.Scalar
+	for _, s := range a.statements {
+		t, r := s.simulate(c, rnd)
+		commitments = append(commitments, t...)
+		responses = append(responses, r...)
+	}
+	return commitments, responses
+}
+
+func (a *and) recompute(c group.Scalar, responses []group.Scalar) []group.Element {
+	var commitments []group.Element
+	for _, s := range a.statements {
+		n := s.numResponses()
+		commitments = append(commitments, s.recompute(c, responses[:n])...)
+		responses = responses[n:]
+	}
+	return commitments
+}
+
+// The responses of an Or statement of n statements are the challenges of
+// the first n-1 statements, followed by the responses of each statement.
+// The challenge of the last statement is the challenge minus the sum of the
+// other challenges.
+
+func (o *or) Group() group.Group { return o.g }
+
+func (o *or) numCommitments() (n int) {
+	for _, s := range o.statements {
+		n += s.numCommitments()
+	}
+	return n
+}
+
+func (o *or) numResponses() int {
+	n := len(o.statements) - 1
+	for _, s := range o.statements {
+		n += s.numResponses()
+	}
+	return n
+}
+
+func (o *or) appendStatement(b []byte) []byte { return appendComposition(b, 'O', o.statements) }
+
+type orState struct {
+	index      int
+	st         proverState
+	challenges []group.Scalar
+	responses  [][]group.Scalar
+}
+
+func (o *or) commit(w Witness, rnd io.Reader) ([]group.Element, proverState, error) {
+	witness, ok := w.(OrWitness)
+	if !ok || witness.Index < 0 || witness.Index >= len(o.statements) {
+		return nil, nil, ErrInvalidWitness
+	}
+	n := len(o.statements)
+	state := orState{
+		index:      witness.Index,
+		challenges: make([]group.Scalar, n),
+		responses:  make([][]group.Scalar, n),
+	}
+	commitments := make([][]group.Element, n)
+	for i, s := range o.statements {
+		if i == witness.Index {
+			t, st, err := s.commit(witness.Witness, rnd)
+			if err != nil {
+				return nil, nil, err
+			}
+			commitments[i], state.st = t, st
+		} else {
+			state.challenges[i] = o.g.RandomScalar(rnd)
+			commitments[i], state.responses[i] = s.simulate(state.challenges[i], rnd)
+		}
+	}
+
+	var all []group.Element
+	for _, t := range commitments {
+		all = append(all, t...)
+	}
+	return all, state, nil
+}
+
+func (o *or) respond(st proverState, c group.Scalar) []group.Scalar {
+	state := st.(orState)
+	ci := c.Copy()
+	for i, cj := range state.challenges {
+		if i != state.index {
+			ci.Sub(ci, cj)
+		}
+	}
+	state.challenges[state.index] = ci
+	state.responses[state.index] = o.statements[state.index].respond(state.st, ci)
+	return o.responses(state.challenges, state.responses)
+}
+
+func (o *or) responses(challenges []group.Scalar, responses [][]group.Scalar) []group.Scalar {
+	all := append([]group.Scalar{}, challenges[:len(challenges)-1]...)
+	for _, r := range responses {
+		all = append(all, r...)
+	}
+	return all
+}
+
+func (o *or) simulate(c group.Scalar, rnd io.Reader) ([]group.Element, []group.Scalar) {
+	n := len(o.statements)
+	challenges := make([]group.Scalar, n)
+	challenges[n-1] = c.Copy()
+	for i := 0; i < n-1; i++ {
+		challenges[i] = o.g.RandomScalar(rnd)
+		challenges[n-1].Sub(challenges[n-1], challenges[i])
+	}
+	var commitments []group.Element
+	responses := make([][]group.Scalar, n)
+	for i, s := range o.statements {
+		var t []group.Element
+		t, responses[i] = s.simulate(challenges[i], rnd)
+		commitments = append(commitments, t...)
+	}
+	return commitments, o.responses(challenges, responses)
+}
+
+func (o *or) recompute(c group.Scalar, responses []group.Scalar) []group.Element {
+	n := len(o.statements)
+	last := c.Copy()
+	for _, cj := range responses[:n-1] {
+		last.Sub(last, cj)
+	}
+	challenges := append(append([]group.Scalar{}, responses[:n-1]...), last)
+	responses = responses[n-1:]
+
+	var commitments []group.Element
+	for i, s := range o.statements {
+		m := s.numResponses()
+		commitments = append(commitments, s.recompute(challenges[i], responses[:m])...)
+		responses = responses[m:]
+	}
+	return commitments
+}
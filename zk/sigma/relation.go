@@ -0,0 +1,151 @@
+package sigma
+
+import (
+	"encoding/binary"
+	"io"
+
+	"github.com/cloudflare/circl/group"
+)
+
+// Term is the product of the scalar of the witness at index Scalar and the
+// public element Element.
+type Term struct {
+	Scalar  int
+	Element group.Element
+}
+
+type equation struct {
+	lhs   group.Element
+	terms []Term
+}
+
+// Relation is a system of linear equations between public elements and the
+// scalars of a witness. Its witness is a []group.Scalar of the number of
+// scalars of the relation.
+type Relation struct {
+	g        group.Group
+	nScalars int
+	eqs      []equation
+}
+
+// NewRelation returns a relation without equations over nScalars secret
+// scalars.
+func NewRelation(g group.Group, nScalars int) *Relation {
+	if nScalars <= 0 {
+		panic("sigma: relation must have scalars")
+	}
+	return &Relation{g: g, nScalars: nScalars}
+}
+
+// Append adds the equation lhs = sum of the terms to the relation. It
+// panics if a term refers to a scalar out of range.
+func (r *Relation) Append(lhs group.Element, terms ...Term) {
+	for _, t := range terms {
+		if t.Scalar < 0 || t.Scalar >= r.nScalars {
+			panic("sigma: scalar index out of range")
+		}
+	}
+	r.eqs = append(r.eqs, equation{lhs.Copy(), append([]Term{}, terms...)})
+}
+
+// Image returns the left-hand sides of the equations evaluated at the
+// scalars.
+func (r *Relation) Image(scalars []group.Scalar) []group.Element {
+	image := make([]group.Element, len(r.eqs))
+	for i, eq := range r.eqs {
+		image[i] = r.combine(eq.terms, scalars)
+	}
+	return image
+}
+
+func (r *Relation) combine(terms []Term, scalars []group.Scalar) group.Element {
+	sum := r.g.Identity()
+	for _, t := range terms {
+		sum.Add(sum, r.g.NewElement().Mul(t.Element, scalars[t.Scalar]))
+	}
+	return sum
+}
+
+func (r *Relation) Group() group.Group  { return r.g }
+func (r *Relation) numCommitments() int { return len(r.eqs) }
+func (r *Relation) numResponses() int   { return r.nScalars }
+
+func (r *Relation) appendStatement(b []byte) []byte {
+	b = append(b, 'R')
+	b = appendUint32(b, uint32(r.nScalars))
+	b = appendUint32(b, uint32(len(r.eqs)))
+	for _, eq := range r.eqs {
+		enc, err := eq.lhs.MarshalBinaryCompress()
+		if err != nil {
+			panic(err)
+		}
+		b = appendLengthPrefixed(b, enc)
+		b = appendUint32(b, uint32(len(eq.terms)))
+		for _, t := range eq.terms {
+			b = appendUint32(b, uint32(t.Scalar))
+			enc, err := t.Element.MarshalBinaryCompress()
+			if err != nil {
+				panic(err)
+			}
+			b = appendLengthPrefixed(b, enc)
+		}
+	}
+	return b
+}
+
+type relationState struct {
+	nonces, witness []group.Scalar
+}
+
+func (r *Relation) commit(w Witness, rnd io.Reader) ([]group.Element, proverState, error) {
+	witness, ok := w.([]group.Scalar)
+	if !ok || len(witness) != r.nScalars {
+		return nil, nil, ErrInvalidWitness
+	}
+	for i, x := range r.Image(witness) {
+		if !x.IsEqual(r.eqs[i].lhs) {
+			return nil, nil, ErrInvalidWitness
+		}
+	}
+
+	nonces := make([]group.Scalar, r.nScalars)
+	for i := range nonces {
+		nonces[i] = r.g.RandomScalar(rnd)
+	}
+	return r.Image(nonces), relationState{nonces, witness}, nil
+}
+
+// respond returns s = k + c*w for the nonces k and the witness w.
+func (r *Relation) respond(st proverState, c group.Scalar) []group.Scalar {
+	s := st.(relationState)
+	responses := make([]group.Scalar, r.nScalars)
+	for i := range responses {
+		responses[i] = r.g.NewScalar().Mul(c, s.witness[i])
+		responses[i].Add(responses[i], s.nonces[i])
+	}
+	return responses
+}
+
+func (r *Relation) simulate(c group.Scalar, rnd io.Reader) ([]group.Element, []group.Scalar) {
+	responses := make([]group.Scalar, r.nScalars)
+	for i := range responses {
+		responses[i] = r.g.RandomScalar(rnd)
+	}
+	return r.recompute(c, responses), responses
+}
+
+// recompute returns T_i = sum_j s_{k_ij} * B_ij - c * X_i.
+func (r *Relation) recompute(c group.Scalar, responses []group.Scalar) []group.Element {
+	commitments := r.Image(responses)
+	for i, eq := range r.eqs {
+		cx := r.g.NewElement().Mul(eq.lhs, c)
+		commitments[i].Add(commitments[i], cx.Neg(cx))
+	}
+	return commitments
+}
+
+func appendUint32(b []byte, n uint32) []byte {
+	var buf [4]byte
+	binary.BigEndian.PutUint32(buf[:], n)
+	return append(b, buf[:]...)
+}
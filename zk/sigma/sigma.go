@@ -0,0 +1,229 @@
+// Package sigma provides zero-knowledge proofs of knowledge for linear
+// relations over prime-order groups.
+//
+// A Relation is a system of equations
+//
+//	X_i = sum_j w_{k_ij} * B_ij
+//
+// between public elements X_i and B_ij, and secret scalars w_k. Relations
+// capture proofs of discrete logarithm, of discrete-logarithm equality, of
+// the opening of Pedersen commitments, or of the correct issuance of an
+// algebraic MAC. Statements are composed with And, where the prover knows
+// the witnesses of all the statements, and with Or, where the prover knows
+// the witness of one of them [2].
+//
+// Proofs are made non-interactive with the Fiat-Shamir transform over a
+// Transcript, which binds the statement, the commitments of the prover, and
+// any message the application appends to it. Proofs have two encodings:
+// the compact encoding holds the challenge and the responses, and the
+// batchable encoding holds the commitments and the responses, so a verifier
+// checks the equations directly, as needed to batch the verification of many
+// proofs.
+//
+// References:
+//
+//	[1] draft-irtf-cfrg-sigma-protocols: https://datatracker.ietf.org/doc/draft-irtf-cfrg-sigma-protocols/
+//	[2] Cramer, Damgård, Schoenmakers. Proofs of partial knowledge. CRYPTO 1994.
+package sigma
+
+import (
+	"errors"
+	"io"
+
+	"github.com/cloudflare/circl/group"
+)
+
+var (
+	// ErrInvalidWitness is returned when a witness does not satisfy its
+	// statement.
+	ErrInvalidWitness = errors.New("sigma: invalid witness")
+	// ErrInvalidProof is returned when a proof cannot be decoded.
+	ErrInvalidProof = errors.New("sigma: invalid proof")
+)
+
+// Witness is the secret input of a prover: a []group.Scalar for a
+// Relation, an AndWitness for a statement returned by And, and an OrWitness
+// for a statement returned by Or.
+type Witness interface{}
+
+// Statement is a public statement, whose knowledge of a witness is proven.
+// It is either a *Relation, or a composition of statements returned by And
+// or Or.
+type Statement interface {
+	// Group returns the group of the statement.
+	Group() group.Group
+	// numCommitments returns the number of elements of a commitment.
+	numCommitments() int
+	// numResponses returns the number of scalars of a response.
+	numResponses() int
+	// appendStatement appends an encoding of the statement to b.
+	appendStatement(b []byte) []byte
+	// commit returns the commitments of the prover and its state.
+	commit(w Witness, rnd io.Reader) ([]group.Element, proverState, error)
+	// respond returns the responses of the prover to the challenge.
+	respond(st proverState, c group.Scalar) []group.Scalar
+	// simulate returns a transcript for the challenge without a witness.
+	simulate(c group.Scalar, rnd io.Reader) ([]group.Element, []group.Scalar)
+	// recompute returns the only commitments that are accepted with the
+	// challenge and the responses.
+	recompute(c group.Scalar, responses []group.Scalar) []group.Element
+}
+
+type proverState interface{}
+
+// Proof is a non-interactive proof of knowledge of a witness of a
+// statement.
+type Proof struct {
+	commitments []group.Element
+	c           group.Scalar
+	responses   []group.Scalar
+}
+
+// Prove returns a proof of knowledge of the witness of the statement. The
+// statement and the commitments are appended to the transcript, from which
+// the challenge is derived.
+func Prove(t *Transcript, s Statement, w Witness, rnd io.Reader) (*Proof, error) {
+	if rnd == nil {
+		return nil, io.ErrNoProgress
+	}
+	commitments, st, err := s.commit(w, rnd)
+	if err != nil {
+		return nil, err
+	}
+	c := challenge(t, s, commitments)
+	return &Proof{commitments, c, s.respond(st, c)}, nil
+}
+
+// Verify checks the proof for the statement. The transcript must hold the
+// same messages as the transcript of the prover before the proof, and the
+// statement and the commitments are appended to it.
+func Verify(t *Transcript, s Statement, p *Proof) bool {
+	if p == nil || len(p.responses) != s.numResponses() {
+		return false
+	}
+
+	if p.commitments != nil {
+		// Batchable proof: check the commitments.
+		if len(p.commitments) != s.numCommitments() {
+			return false
+		}
+		c := challenge(t, s, p.commitments)
+		commitments := s.recompute(c, p.responses)
+		for i := range commitments {
+			if !commitments[i].IsEqual(p.commitments[i]) {
+				return false
+			}
+		}
+		return true
+	}
+
+	// Compact proof: check the challenge.
+	if p.c == nil {
+		return false
+	}
+	return challenge(t, s, s.recompute(p.c, p.responses)).IsEqual(p.c)
+}
+
+func challenge(t *Transcript, s Statement, commitments []group.Element) group.Scalar {
+	t.AppendMessage("statement", s.appendStatement(nil))
+	t.AppendElements("commitments", commitments...)
+	return t.Challenge("challenge")
+}
+
+// MarshalCompact returns the compact encoding of the proof: the challenge
+// followed by the responses.
+func (p *Proof) MarshalCompact() ([]byte, error) {
+	if p.c == nil {
+		return nil, ErrInvalidProof
+	}
+	return appendScalars(nil, append([]group.Scalar{p.c}, p.responses...)...)
+}
+
+// MarshalBatchable returns the batchable encoding of the proof: the
+// commitments followed by the responses.
+func (p *Proof) MarshalBatchable() ([]byte, error) {
+	if p.commitments == nil {
+		return nil, ErrInvalidProof
+	}
+	b, err := appendElements(nil, p.commitments...)
+	if err != nil {
+		return nil, err
+	}
+	return appendScalars(b, p.responses...)
+}
+
+// UnmarshalCompact decodes a compact proof of the statement.
+func (p *Proof) UnmarshalCompact(s Statement, data []byte) error {
+	g := s.Group()
+	scalars, err := readScalars(g, data, 1+s.numResponses())
+	if err != nil {
+		return err
+	}
+	*p = Proof{nil, scalars[0], scalars[1:]}
+	return nil
+}
+
+// UnmarshalBatchable decodes a batchable proof of the statement.
+func (p *Proof) UnmarshalBatchable(s Statement, data []byte) error {
+	g := s.Group()
+	n := s.numCommitments()
+	l := int(g.Params().CompressedElementLength)
+	size := n * l
+	if len(data) < size {
+		return ErrInvalidProof
+	}
+	commitments := make([]group.Element, n)
+	for i := range commitments {
+		commitments[i] = g.NewElement()
+		if commitments[i].UnmarshalBinary(data[i*l:(i+1)*l]) != nil {
+			return ErrInvalidProof
+		}
+	}
+	responses, err := readScalars(g, data[size:], s.numResponses())
+	if err != nil {
+		return err
+	}
+	*p = Proof{commitments, nil, responses}
+	return nil
+}
+
+func readScalars(g group.Group, data []byte, n int) ([]group.Scalar, error) {
+	size := int(g.Params().ScalarLength)
+	if len(data) != n*size {
+		return nil, ErrInvalidProof
+	}
+	scalars := make([]group.Scalar, n)
+	for i := range scalars {
+		scalars[i] = g.NewScalar()
+		if scalars[i].UnmarshalBinary(data[i*size:(i+1)*size]) != nil {
+			return nil, ErrInvalidProof
+		}
+	}
+	return scalars, nil
+}
+
+func appendScalars(b []byte, scalars ...group.Scalar) ([]byte, error) {
+	for _, s := range scalars {
+		enc, err := s.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		b = append(b, enc...)
+	}
+	return b, nil
+}
+
+func appendElements(b []byte, elements ...group.Element) ([]byte, error) {
+	for _, e := range elements {
+		enc, err := e.MarshalBinaryCompress()
+		if err != nil {
+			return nil, err
+		}
+		// Some groups encode the identity with fewer bytes.
+		if len(enc) != int(e.Group().Params().CompressedElementLength) {
+			return nil, ErrInvalidProof
+		}
+		b = append(b, enc...)
+	}
+	return b, nil
+}
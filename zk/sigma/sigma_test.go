@@ -0,0 +1,124 @@
+package sigma_test
+
+import (
+	"crypto/rand"
+	"fmt"
+	"testing"
+
+	"github.com/cloudflare/circl/group"
+	"github.com/cloudflare/circl/internal/test"
+	"github.com/cloudflare/circl/zk/sigma"
+)
+
+// pedersen returns the relation C = m*G + r*H, and its witness.
+func pedersen(g group.Group, h group.Element) (*sigma.Relation, []group.Scalar) {
+	m, r := g.RandomScalar(rand.Reader), g.RandomScalar(rand.Reader)
+	c := g.NewElement().MulGen(m)
+	c.Add(c, g.NewElement().Mul(h, r))
+	rel := sigma.NewRelation(g, 2)
+	rel.Append(c, sigma.Term{Scalar: 0, Element: g.Generator()}, sigma.Term{Scalar: 1, Element: h})
+	return rel, []group.Scalar{m, r}
+}
+
+// dleq returns the relation X = x*G and Y = x*H, and its witness.
+func dleq(g group.Group, h group.Element) (*sigma.Relation, []group.Scalar) {
+	x := g.RandomScalar(rand.Reader)
+	rel := sigma.NewRelation(g, 1)
+	rel.Append(g.NewElement().MulGen(x), sigma.Term{Scalar: 0, Element: g.Generator()})
+	rel.Append(g.NewElement().Mul(h, x), sigma.Term{Scalar: 0, Element: h})
+	return rel, []group.Scalar{x}
+}
+
+func checkProof(t *testing.T, s sigma.Statement, w sigma.Witness) {
+	t.Helper()
+	domain := []byte("test")
+	proof, err := sigma.Prove(sigma.NewTranscript(s.Group(), domain), s, w, rand.Reader)
+	test.CheckNoErr(t, err, "prove")
+	test.CheckOk(sigma.Verify(sigma.NewTranscript(s.Group(), domain), s, proof), "proof must verify", t)
+	test.CheckOk(!sigma.Verify(sigma.NewTranscript(s.Group(), []byte("other")), s, proof), "other transcript must fail", t)
+
+	compact, err := proof.MarshalCompact()
+	test.CheckNoErr(t, err, "marshal compact")
+	batchable, err := proof.MarshalBatchable()
+	test.CheckNoErr(t, err, "marshal batchable")
+
+	for _, v := range []struct {
+		name      string
+		data      []byte
+		unmarshal func(*sigma.Proof, sigma.Statement, []byte) error
+	}{
+		{"compact", compact, (*sigma.Proof).UnmarshalCompact},
+		{"batchable", batchable, (*sigma.Proof).UnmarshalBatchable},
+	} {
+		got := new(sigma.Proof)
+		test.CheckNoErr(t, v.unmarshal(got, s, v.data), "unmarshal "+v.name)
+		test.CheckOk(sigma.Verify(sigma.NewTranscript(s.Group(), domain), s, got), v.name+" proof must verify", t)
+
+		v.data[len(v.data)-1] ^= 1
+		if v.unmarshal(got, s, v.data) == nil {
+			test.CheckOk(!sigma.Verify(sigma.NewTranscript(s.Group(), domain), s, got), "tampered "+v.name+" proof must fail", t)
+		}
+		err := v.unmarshal(got, s, v.data[1:])
+		test.CheckIsErr(t, err, "short "+v.name+" proof must fail")
+	}
+}
+
+func TestSigma(t *testing.T) {
+	for _, g := range []group.Group{group.P256, group.Ristretto255, group.Decaf448} {
+		t.Run(g.(fmt.Stringer).String(), func(t *testing.T) {
+			h := g.HashToElement([]byte("H"), []byte("sigma test"))
+			ped, pedWitness := pedersen(g, h)
+			eq, eqWitness := dleq(g, h)
+
+			t.Run("Relation", func(t *testing.T) {
+				checkProof(t, ped, pedWitness)
+				checkProof(t, eq, eqWitness)
+			})
+			t.Run("And", func(t *testing.T) {
+				checkProof(t, sigma.And(ped, eq), sigma.AndWitness{pedWitness, eqWitness})
+			})
+			t.Run("Or", func(t *testing.T) {
+				other, _ := dleq(g, h)
+				s := sigma.Or(other, ped, eq)
+				checkProof(t, s, sigma.OrWitness{Index: 1, Witness: pedWitness})
+				checkProof(t, s, sigma.OrWitness{Index: 2, Witness: eqWitness})
+			})
+			t.Run("Nested", func(t *testing.T) {
+				other, _ := dleq(g, h)
+				s := sigma.And(ped, sigma.Or(sigma.And(other, ped), eq))
+				w := sigma.AndWitness{pedWitness, sigma.OrWitness{Index: 1, Witness: eqWitness}}
+				checkProof(t, s, w)
+			})
+			t.Run("InvalidWitness", func(t *testing.T) {
+				tr := sigma.NewTranscript(g, nil)
+				for _, v := range []struct {
+					s sigma.Statement
+					w sigma.Witness
+				}{
+					{ped, eqWitness},
+					{ped, []group.Scalar{pedWitness[1], pedWitness[0]}},
+					{sigma.And(ped, eq), sigma.AndWitness{pedWitness}},
+					{sigma.Or(ped, eq), sigma.OrWitness{Index: 0, Witness: eqWitness}},
+					{sigma.Or(ped, eq), sigma.OrWitness{Index: 2, Witness: eqWitness}},
+				} {
+					_, err := sigma.Prove(tr, v.s, v.w, rand.Reader)
+					if err != sigma.ErrInvalidWitness {
+						test.ReportError(t, err, sigma.ErrInvalidWitness)
+					}
+				}
+			})
+		})
+	}
+}
+
+func TestTranscript(t *testing.T) {
+	g := group.Ristretto255
+	a, b := sigma.NewTranscript(g, []byte("test")), sigma.NewTranscript(g, []byte("test"))
+	a.AppendMessage("msg", []byte("ab"))
+	b.AppendMessage("msga", []byte("b"))
+	test.CheckOk(!a.Challenge("c").IsEqual(b.Challenge("c")), "labels must be separated", t)
+
+	c := a.Clone()
+	test.CheckOk(a.Challenge("c").IsEqual(c.Challenge("c")), "clones must agree", t)
+	test.CheckOk(!a.Challenge("c").IsEqual(a.Challenge("c")), "challenges must differ", t)
+}
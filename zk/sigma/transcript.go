@@ -0,0 +1,67 @@
+package sigma
+
+import (
+	"encoding/binary"
+
+	"github.com/cloudflare/circl/group"
+)
+
+// Transcript accumulates the messages of a protocol, and derives
+// challenges from them with the Fiat-Shamir transform. Each challenge is
+// also appended to the transcript, so that successive challenges differ.
+type Transcript struct {
+	g     group.Group
+	dst   []byte
+	state []byte
+}
+
+// NewTranscript returns a transcript in the group separated by the domain,
+// which should identify the application and the protocol.
+func NewTranscript(g group.Group, domain []byte) *Transcript {
+	t := &Transcript{g: g, dst: append([]byte("SigmaTranscript-"), domain...)}
+	t.AppendMessage("domain", domain)
+	return t
+}
+
+// AppendMessage appends a labeled message to the transcript.
+func (t *Transcript) AppendMessage(label string, msg []byte) {
+	t.state = appendLengthPrefixed(t.state, []byte(label))
+	t.state = appendLengthPrefixed(t.state, msg)
+}
+
+// AppendElements appends labeled elements to the transcript.
+func (t *Transcript) AppendElements(label string, elements ...group.Element) {
+	var b []byte
+	for _, e := range elements {
+		enc, err := e.MarshalBinaryCompress()
+		if err != nil {
+			panic(err)
+		}
+		b = appendLengthPrefixed(b, enc)
+	}
+	t.AppendMessage(label, b)
+}
+
+// Challenge returns a labeled challenge derived from the transcript, and
+// appends it to the transcript.
+func (t *Transcript) Challenge(label string) group.Scalar {
+	t.state = appendLengthPrefixed(t.state, []byte(label))
+	c := t.g.HashToScalar(t.state, t.dst)
+	enc, err := c.MarshalBinary()
+	if err != nil {
+		panic(err)
+	}
+	t.state = appendLengthPrefixed(t.state, enc)
+	return c
+}
+
+// Clone returns a copy of the transcript.
+func (t *Transcript) Clone() *Transcript {
+	return &Transcript{t.g, t.dst, append([]byte{}, t.state...)}
+}
+
+func appendLengthPrefixed(b, data []byte) []byte {
+	var length [8]byte
+	binary.BigEndian.PutUint64(length[:], uint64(len(data)))
+	return append(append(b, length[:]...), data...)
+}